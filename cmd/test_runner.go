@@ -37,12 +37,28 @@ type PullRequest struct {
 }
 
 type SignInRequest struct {
-	Username string `json:"username"`
-	Password string `json:"password"`
+	Token string `json:"token"`
+}
+
+// Capabilities is the part of /api/v1/capabilities the runner adapts to
+type Capabilities struct {
+	Version      string          `json:"version"`
+	DefaultModel string          `json:"default_model"`
+	Models       []string        `json:"models"`
+	Features     map[string]bool `json:"features"`
+	Auth         struct {
+		Mode string `json:"mode"`
+	} `json:"auth"`
 }
 
 const baseURL = "http://localhost:8080"
 
+// fallbackModel is used when the server does not report a default model
+const fallbackModel = "llama3.2:1b"
+
+// capabilities of the server under test, discovered at startup
+var capabilities Capabilities
+
 func main() {
 	fmt.Println("🚀 Starting Ollama Cloud Integration Tests")
 	fmt.Println("==========================================")
@@ -58,25 +74,40 @@ func main() {
 		fmt.Print(".")
 	}
 
-	// Run all tests
+	// Adapt to what this server build offers instead of assuming every feature
+	if err := discoverCapabilities(); err != nil {
+		fmt.Printf("⚠️  Could not discover capabilities, assuming defaults: %v\n", err)
+	} else {
+		fmt.Printf("🔎 Server %s, default model %q, %d models available\n", capabilities.Version, capabilities.DefaultModel, len(capabilities.Models))
+	}
+
+	// Run all tests. Tests that require a feature are skipped when the server has it disabled.
 	tests := []struct {
-		name string
-		fn   func() bool
+		name     string
+		requires string
+		fn       func() bool
 	}{
-		{"Server Health", testServerHealth},
-		{"List Models", testListModels},
-		{"Chat Completion", testChatCompletion},
-		{"Text Completion", testTextCompletion},
-		{"Embedding Generation", testEmbedding},
-		{"Cloud Sign In", testCloudSignIn},
-		{"List Cloud Models", testListCloudModels},
-		{"Streaming Chat", testStreamingChat},
+		{"Server Health", "", testServerHealth},
+		{"Capabilities", "", testCapabilities},
+		{"List Models", "", testListModels},
+		{"Chat Completion", "", testChatCompletion},
+		{"Text Completion", "", testTextCompletion},
+		{"Embedding Generation", "", testEmbedding},
+		{"Cloud Sign In", "cloud", testCloudSignIn},
+		{"List Cloud Models", "cloud", testListCloudModels},
+		{"Streaming Chat", "", testStreamingChat},
 	}
 
 	passed := 0
-	total := len(tests)
+	total := 0
 
 	for _, test := range tests {
+		if test.requires != "" && !featureEnabled(test.requires) {
+			fmt.Printf("\n⏭️  Skipping: %s (%s is disabled on this server)\n", test.name, test.requires)
+			continue
+		}
+
+		total++
 		fmt.Printf("\n🧪 Testing: %s\n", test.name)
 		if test.fn() {
 			fmt.Printf("✅ %s: PASSED\n", test.name)
@@ -103,6 +134,50 @@ func testServerHealth() bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// discoverCapabilities loads the server's capabilities into capabilities
+func discoverCapabilities() error {
+	resp, err := http.Get(baseURL + "/api/v1/capabilities")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(&capabilities)
+}
+
+// featureEnabled reports whether the server has a feature enabled. Features the server did not
+// report, for example because discovery failed, are assumed to be enabled.
+func featureEnabled(feature string) bool {
+	enabled, ok := capabilities.Features[feature]
+	return !ok || enabled
+}
+
+// chatModel is the model used for generation tests, the server's default when it reports one
+func chatModel() string {
+	if capabilities.DefaultModel != "" {
+		return capabilities.DefaultModel
+	}
+	return fallbackModel
+}
+
+func testCapabilities() bool {
+	resp, err := http.Get(baseURL + "/api/v1/capabilities")
+	if err != nil {
+		fmt.Printf("   Error: %v\n", err)
+		return false
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("   Status: %d\n", resp.StatusCode)
+	fmt.Printf("   Response: %s\n", string(body)[:min(200, len(body))])
+
+	return resp.StatusCode == http.StatusOK
+}
+
 func testListModels() bool {
 	resp, err := http.Get(baseURL + "/api/v1/llama/models")
 	if err != nil {
@@ -123,7 +198,7 @@ func testListModels() bool {
 
 func testChatCompletion() bool {
 	chatReq := ChatRequest{
-		Model: "llama3.2:1b",
+		Model: chatModel(),
 		Messages: []Message{
 			{Role: "user", Content: "Hello! Say 'test successful' if you can read this."},
 		},
@@ -148,7 +223,7 @@ func testChatCompletion() bool {
 
 func testTextCompletion() bool {
 	completionReq := CompletionRequest{
-		Model:  "llama3.2:1b",
+		Model:  chatModel(),
 		Prompt: "The future of AI is",
 		Stream: false,
 	}
@@ -193,8 +268,7 @@ func testEmbedding() bool {
 
 func testCloudSignIn() bool {
 	signInReq := SignInRequest{
-		Username: "test@example.com",
-		Password: "testpassword",
+		Token: "test-api-key",
 	}
 
 	jsonData, _ := json.Marshal(signInReq)
@@ -231,7 +305,7 @@ func testListCloudModels() bool {
 
 func testStreamingChat() bool {
 	chatReq := ChatRequest{
-		Model: "llama3.2:1b",
+		Model: chatModel(),
 		Messages: []Message{
 			{Role: "user", Content: "Tell me a very short story in one sentence."},
 		},
@@ -3,30 +3,210 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
+	"sync"
 )
 
 type Config struct {
-	Server   ServerConfig
-	Llama    LlamaConfig
-	Database DatabaseConfig
+	Server        ServerConfig
+	Llama         LlamaConfig
+	Providers     ProvidersConfig
+	Hooks         HooksConfig
+	CORS          CORSConfig
+	AdminCORS     CORSConfig // Applied instead of CORS to admin and login routes
+	RateLimit     RateLimitConfig
+	Conversations ConversationConfig
+	Usage         UsageConfig
+	Audit         AuditConfig
+	Limits        LimitsConfig
+	Log           LogConfig
+	Auth          AuthConfig
+	TLS           TLSConfig
+	Database      DatabaseConfig
 }
 
 type ServerConfig struct {
-	Port         string
-	Host         string
-	ReadTimeout  int
-	WriteTimeout int
+	Port              string
+	Host              string
+	ReadTimeout       int
+	WriteTimeout      int
+	H2C               bool     // Serve HTTP/2 over cleartext alongside HTTP/1.1
+	StreamCompression bool     // Allow proxies to compress streaming responses
+	StreamSchema      string   // Event schema version of streaming chat when the client does not ask for one
+	AccessLogFormat   string   // "gin" for the default gin logger, "combined" or "json"
+	AccessLogFile     string   // Access log destination, stdout when empty
+	TrustedProxies    []string // Proxies whose X-Forwarded-For is trusted for the client IP
+	IPAllowList       []string // CIDR ranges allowed to call the API, empty for everyone
+	IPDenyList        []string // CIDR ranges rejected before the allow list is checked
+	AdminToken        string   // Bearer token for /api/v1/admin, which is disabled when empty
+	ShutdownTimeout   int      // Seconds in-flight requests and background work get to finish on shutdown
+	MaxRequestTimeout int      // Longest budget in seconds clients may set with X-Request-Timeout
+	ReadinessTimeout  int      // Seconds each dependency check of the readiness probe may take
+	PersistenceCheck  int      // Seconds between checks of the stores the server can run without
+	GRPCPort          string   // Port of the gRPC API, disabled when empty
 }
 
 type LlamaConfig struct {
-	BaseURL      string
-	APIKey       string
-	DefaultModel string
-	Timeout      int
-	CloudEnabled bool
-	CloudAPIURL  string
-	CloudAPIKey  string
-	SignedIn     bool
+	BaseURL               string
+	APIKey                string
+	DefaultModel          string
+	Timeout               int // Total generation budget in seconds
+	CloudTimeout          int // Total generation budget for cloud models in seconds, 0 to use Timeout
+	ConnectTimeout        int // Seconds allowed to establish the upstream connection
+	HeaderTimeout         int // Seconds allowed to wait for upstream response headers
+	ModelTimeouts         []ModelTimeout
+	CompareWorkers        int // Maximum models queried in parallel by the compare endpoint
+	BatchWorkers          int // Maximum requests of a chat batch run in parallel
+	BatchItemTimeout      int // Seconds each request of a chat batch may take, 0 for the generation budget only
+	ModelAliases          map[string]string
+	PreloadModels         []string // Models loaded into memory at startup
+	PreloadKeepAlive      string   // How long preloaded models stay loaded, in Ollama keep_alive format
+	SwapTimeout           int      // Seconds a model swap may take to pull and warm the new model
+	MaxConcurrent         int      // Server-wide generations in flight, 0 for unlimited
+	MaxConcurrentPerModel int      // Generations in flight per model, 0 for unlimited
+	MaxQueued             int      // Requests allowed to wait for a slot before rejecting with 429
+	QueueTimeout          int      // Seconds a request may wait for a slot before failing with 503
+	CloudEnabled          bool
+	CloudAPIURL           string
+	CloudAPIKey           string
+	CloudTokenFile        string // Where a signed-in API key is persisted, empty to keep it in memory only
+	SignedIn              bool
+	FailoverToCloud       bool                // Retry failed local generations against Ollama Cloud
+	RetryMaxAttempts      int                 // Attempts per upstream request, 1 disables retries
+	RetryBackoff          int                 // Milliseconds before the first retry, doubled for each further retry
+	RetryStatusCodes      []int               // Upstream statuses that are retried
+	BreakerThreshold      int                 // Consecutive failures that open an upstream's circuit, 0 disables the breaker
+	BreakerCooldown       int                 // Seconds an open circuit waits before letting a trial request through
+	ContextTrimming       bool                // Drop the oldest chat messages that do not fit the model's context window
+	ContextReserve        int                 // Tokens kept free for the reply when max_tokens is not set
+	FallbackChains        map[string][]string // Models tried in order when a generation fails, per endpoint
+	ShadowModel           string              // Candidate model that sampled chat requests are mirrored to
+	ShadowPercent         int                 // Share of chat requests mirrored to the shadow model, 0 to 100
+	ShadowResults         int                 // Shadow results kept in memory for comparison
+	SchemaRepairAttempts  int                 // Times an answer that does not match the requested format is sent back to the model
+	CoalesceRequests      bool                // Share one generation between identical chat requests in flight at the same time
+	SemanticCacheModel    string              // Embedding model of the semantic cache, empty to disable it
+	SemanticSimilarity    int                 // Minimum cosine similarity, in percent, for a cached answer to be reused
+	SemanticCacheSize     int                 // Answers kept in the semantic cache
+	SemanticCacheTTL      int                 // Seconds a cached answer may be reused
+	SemanticCacheFile     string              // Where the semantic cache is persisted across restarts, empty to keep it in memory only
+	SemanticCacheSnapshot int                 // Seconds between semantic cache snapshots
+}
+
+// ProvidersConfig enables remote providers, which serve models named with the provider's prefix,
+// such as "openai:gpt-4o-mini" or "anthropic:claude-3-5-haiku-latest". A provider is enabled when
+// its API key is set.
+type ProvidersConfig struct {
+	OpenAIBaseURL    string // Any OpenAI-compatible API
+	OpenAIAPIKey     string
+	AnthropicBaseURL string
+	AnthropicAPIKey  string
+}
+
+// ModelTimeout overrides the total generation budget for models whose name contains Pattern
+type ModelTimeout struct {
+	Pattern string
+	Seconds int
+}
+
+// HooksConfig configures the built-in chat hooks. Each hook applies to the listed endpoints,
+// or to every endpoint when its list is empty.
+type HooksConfig struct {
+	SystemPrompt           string
+	SystemPromptEndpoints  []string
+	Disclaimer             string
+	DisclaimerEndpoints    []string
+	StripMarkdown          bool
+	StripMarkdownEndpoints []string
+	ModerationPolicyFile   string // YAML moderation policy, see services.ModerationPolicy
+	ModerationEndpoints    []string
+}
+
+// CORSConfig is a cross-origin policy.
+// A "*" origin allows any origin and cannot be combined with credentials; no origins
+// rejects every cross-origin request.
+type CORSConfig struct {
+	AllowOrigins     []string
+	AllowMethods     []string
+	AllowHeaders     []string
+	AllowCredentials bool
+	MaxAge           int // Seconds browsers may cache preflight responses
+}
+
+// RateLimitConfig limits how many requests each client may make per window.
+// A Requests value of 0 disables rate limiting.
+type RateLimitConfig struct {
+	Requests    int
+	Window      int    // Seconds
+	Backend     string // "memory" for a per-replica budget, "redis" to share it across replicas
+	RedisURL    string
+	DailyTokens int // Tokens each client may consume per UTC day, 0 disables the quota
+}
+
+type ConversationConfig struct {
+	Store        string // "memory" for a per-replica store, "redis" to share conversations across replicas
+	TTL          int    // Minutes a conversation is kept after its last message
+	RedisURL     string
+	TokenBudget  int // Estimated tokens above which older turns are summarized, 0 disables summarization
+	KeepMessages int // Most recent messages kept verbatim when summarizing
+}
+
+// UsageConfig selects where per-request usage records are kept and for how long
+type UsageConfig struct {
+	Store         string // "memory" for a per-replica store, "redis" to share records across replicas
+	RetentionDays int    // Days of records kept, counting today
+	RedisURL      string
+}
+
+// AuditConfig selects where the audit log of administrative actions is kept
+type AuditConfig struct {
+	File string // Append-only JSON lines file, or empty to keep entries in memory until restart
+}
+
+// LimitsConfig caps what a single generation request may ask for, 0 meaning unlimited. The
+// overrides are keyed by role ("admin", "user", "readonly") or by client key ("user:alice",
+// "ip:10.0.0.1"); a client key wins over its role, which wins over the default.
+type LimitsConfig struct {
+	MaxTokens                      int // Longest answer a request may ask for
+	MaxMessages                    int // Most messages a chat request may send
+	MaxPromptChars                 int // Longest prompt a request may send, counting all chat messages
+	MaxEmbeddingChars              int // Longest input an embedding request may send
+	MaxBodyBytes                   int // Largest request body accepted, of any route
+	StreamTokensPerSecond          int // Streamed output rate of a client, shared by its streams
+	MaxTokensOverrides             map[string]int
+	MaxMessagesOverrides           map[string]int
+	StreamTokensPerSecondOverrides map[string]int
+}
+
+// LogConfig selects the level and format of application logs
+type LogConfig struct {
+	Level         string // "debug", "info", "warn" or "error"
+	Format        string // "text" or "json"
+	DebugSampling int    // Keep one in this many debug records, 1 keeps them all
+}
+
+// AuthConfig enables access tokens when JWTSecret is set. Users log in with a password checked
+// against its bcrypt hash and receive a token carrying their role.
+type AuthConfig struct {
+	JWTSecret string
+	TokenTTL  int // Minutes an access token is valid
+	Users     map[string]AuthUser
+}
+
+// AuthUser is an account that may log in
+type AuthUser struct {
+	Role         string // "admin", "user" or "readonly"
+	PasswordHash string // bcrypt
+}
+
+// TLSConfig serves HTTPS, and HTTP/2 over it, from a certificate and key pair, or from
+// certificates obtained from Let's Encrypt for AutocertDomains. HTTP is served when neither is set.
+type TLSConfig struct {
+	CertFile         string
+	KeyFile          string
+	AutocertDomains  []string // Domains certificates are requested for
+	AutocertCacheDir string   // Where obtained certificates are kept across restarts
+	AutocertEmail    string   // Contact for expiry notices, optional
 }
 
 type DatabaseConfig struct {
@@ -39,22 +219,167 @@ type DatabaseConfig struct {
 }
 
 func Load() *Config {
+	return loadFrom(os.Getenv)
+}
+
+var (
+	loadMu sync.Mutex
+	getenv = os.Getenv // Where the getEnv functions read variables, set by loadFrom
+)
+
+// loadFrom builds the configuration from the variables env returns
+func loadFrom(env func(string) string) *Config {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+	getenv = env
+	defer func() { getenv = os.Getenv }()
+
 	return &Config{
 		Server: ServerConfig{
-			Port:         getEnv("PORT", "8080"),
-			Host:         getEnv("HOST", "0.0.0.0"),
-			ReadTimeout:  getEnvAsInt("READ_TIMEOUT", 30),
-			WriteTimeout: getEnvAsInt("WRITE_TIMEOUT", 30),
+			Port:              getEnv("PORT", "8080"),
+			Host:              getEnv("HOST", "0.0.0.0"),
+			ReadTimeout:       getEnvAsInt("READ_TIMEOUT", 30),
+			WriteTimeout:      getEnvAsInt("WRITE_TIMEOUT", 30),
+			H2C:               getEnv("SERVER_H2C", "true") == "true",
+			StreamCompression: getEnv("STREAM_COMPRESSION", "false") == "true",
+			StreamSchema:      getEnv("STREAM_SCHEMA_VERSION", "v1"),
+			AccessLogFormat:   getEnv("ACCESS_LOG_FORMAT", "gin"),
+			AccessLogFile:     getEnv("ACCESS_LOG_FILE", ""),
+			TrustedProxies:    getEnvAsSlice("TRUSTED_PROXIES"),
+			IPAllowList:       getEnvAsSlice("IP_ALLOW_LIST"),
+			IPDenyList:        getEnvAsSlice("IP_DENY_LIST"),
+			AdminToken:        getEnv("ADMIN_TOKEN", ""),
+			ShutdownTimeout:   getEnvAsInt("SHUTDOWN_TIMEOUT", 30),
+			MaxRequestTimeout: getEnvAsInt("MAX_REQUEST_TIMEOUT", 600),
+			ReadinessTimeout:  getEnvAsInt("READINESS_TIMEOUT", 2),
+			PersistenceCheck:  getEnvAsInt("PERSISTENCE_CHECK_INTERVAL", 10),
+			GRPCPort:          getEnv("GRPC_PORT", ""),
 		},
 		Llama: LlamaConfig{
-			BaseURL:      getEnv("LLAMA_BASE_URL", "http://localhost:11434"),
-			APIKey:       getEnv("LLAMA_API_KEY", ""),
-			DefaultModel: getEnv("LLAMA_DEFAULT_MODEL", "llama2"),
-			Timeout:      getEnvAsInt("LLAMA_TIMEOUT", 60),
-			CloudEnabled: getEnv("LLAMA_CLOUD_ENABLED", "false") == "true",
-			CloudAPIURL:  getEnv("LLAMA_CLOUD_API_URL", "https://api.ollama.com"),
-			CloudAPIKey:  getEnv("LLAMA_CLOUD_API_KEY", ""),
-			SignedIn:     getEnv("LLAMA_SIGNED_IN", "false") == "true",
+			BaseURL:               getEnv("LLAMA_BASE_URL", "http://localhost:11434"),
+			APIKey:                getEnv("LLAMA_API_KEY", ""),
+			DefaultModel:          getEnv("LLAMA_DEFAULT_MODEL", "llama2"),
+			Timeout:               getEnvAsInt("LLAMA_TIMEOUT", 60),
+			CloudTimeout:          getEnvAsInt("LLAMA_CLOUD_TIMEOUT", 0),
+			ConnectTimeout:        getEnvAsInt("LLAMA_CONNECT_TIMEOUT", 10),
+			HeaderTimeout:         getEnvAsInt("LLAMA_HEADER_TIMEOUT", 60),
+			ModelTimeouts:         getEnvAsModelTimeouts("LLAMA_MODEL_TIMEOUTS"),
+			CompareWorkers:        getEnvAsInt("LLAMA_COMPARE_WORKERS", 2),
+			BatchWorkers:          getEnvAsInt("LLAMA_BATCH_WORKERS", 4),
+			BatchItemTimeout:      getEnvAsInt("LLAMA_BATCH_ITEM_TIMEOUT", 0),
+			ModelAliases:          getEnvAsMap("LLAMA_MODEL_ALIASES"),
+			PreloadModels:         getEnvAsSlice("LLAMA_PRELOAD_MODELS"),
+			PreloadKeepAlive:      getEnv("LLAMA_PRELOAD_KEEP_ALIVE", "30m"),
+			SwapTimeout:           getEnvAsInt("LLAMA_SWAP_TIMEOUT", 3600),
+			MaxConcurrent:         getEnvAsInt("LLAMA_MAX_CONCURRENT", 0),
+			MaxConcurrentPerModel: getEnvAsInt("LLAMA_MAX_CONCURRENT_PER_MODEL", 0),
+			MaxQueued:             getEnvAsInt("LLAMA_MAX_QUEUED", 16),
+			QueueTimeout:          getEnvAsInt("LLAMA_QUEUE_TIMEOUT", 30),
+			CloudEnabled:          getEnv("LLAMA_CLOUD_ENABLED", "false") == "true",
+			CloudAPIURL:           getEnv("LLAMA_CLOUD_API_URL", "https://api.ollama.com"),
+			CloudAPIKey:           getEnv("LLAMA_CLOUD_API_KEY", ""),
+			CloudTokenFile:        getEnv("LLAMA_CLOUD_TOKEN_FILE", ""),
+			SignedIn:              getEnv("LLAMA_SIGNED_IN", "false") == "true",
+			FailoverToCloud:       getEnv("FAILOVER_TO_CLOUD", "false") == "true",
+			RetryMaxAttempts:      getEnvAsInt("LLAMA_RETRY_MAX_ATTEMPTS", 3),
+			RetryBackoff:          getEnvAsInt("LLAMA_RETRY_BACKOFF_MS", 250),
+			RetryStatusCodes:      getEnvAsIntSlice("LLAMA_RETRY_STATUS_CODES", []int{502, 503, 504}),
+			BreakerThreshold:      getEnvAsInt("LLAMA_BREAKER_THRESHOLD", 5),
+			BreakerCooldown:       getEnvAsInt("LLAMA_BREAKER_COOLDOWN", 30),
+			ContextTrimming:       getEnv("LLAMA_CONTEXT_TRIMMING", "true") == "true",
+			ContextReserve:        getEnvAsInt("LLAMA_CONTEXT_RESERVE", 512),
+			FallbackChains:        getEnvAsChains("LLAMA_FALLBACK_CHAINS"),
+			ShadowModel:           getEnv("LLAMA_SHADOW_MODEL", ""),
+			ShadowPercent:         getEnvAsInt("LLAMA_SHADOW_PERCENT", 0),
+			ShadowResults:         getEnvAsInt("LLAMA_SHADOW_RESULTS", 100),
+			SchemaRepairAttempts:  getEnvAsInt("LLAMA_SCHEMA_REPAIR_ATTEMPTS", 2),
+			CoalesceRequests:      getEnv("LLAMA_COALESCE_REQUESTS", "true") == "true",
+			SemanticCacheModel:    getEnv("LLAMA_SEMANTIC_CACHE_MODEL", ""),
+			SemanticSimilarity:    getEnvAsInt("LLAMA_SEMANTIC_CACHE_SIMILARITY", 95),
+			SemanticCacheSize:     getEnvAsInt("LLAMA_SEMANTIC_CACHE_SIZE", 1000),
+			SemanticCacheTTL:      getEnvAsInt("LLAMA_SEMANTIC_CACHE_TTL", 3600),
+			SemanticCacheFile:     getEnv("LLAMA_SEMANTIC_CACHE_FILE", ""),
+			SemanticCacheSnapshot: getEnvAsInt("LLAMA_SEMANTIC_CACHE_SNAPSHOT_INTERVAL", 60),
+		},
+		Providers: ProvidersConfig{
+			OpenAIBaseURL:    getEnv("OPENAI_BASE_URL", "https://api.openai.com/v1"),
+			OpenAIAPIKey:     getEnv("OPENAI_API_KEY", ""),
+			AnthropicBaseURL: getEnv("ANTHROPIC_BASE_URL", "https://api.anthropic.com"),
+			AnthropicAPIKey:  getEnv("ANTHROPIC_API_KEY", ""),
+		},
+		Hooks: HooksConfig{
+			SystemPrompt:           getEnv("HOOK_SYSTEM_PROMPT", ""),
+			SystemPromptEndpoints:  getEnvAsSlice("HOOK_SYSTEM_PROMPT_ENDPOINTS"),
+			Disclaimer:             getEnv("HOOK_DISCLAIMER", ""),
+			DisclaimerEndpoints:    getEnvAsSlice("HOOK_DISCLAIMER_ENDPOINTS"),
+			StripMarkdown:          getEnv("HOOK_STRIP_MARKDOWN", "false") == "true",
+			StripMarkdownEndpoints: getEnvAsSlice("HOOK_STRIP_MARKDOWN_ENDPOINTS"),
+			ModerationPolicyFile:   getEnv("HOOK_MODERATION_POLICY_FILE", ""),
+			ModerationEndpoints:    getEnvAsSlice("HOOK_MODERATION_ENDPOINTS"),
+		},
+		CORS: CORSConfig{
+			AllowOrigins:     getEnvAsSliceOr("CORS_ALLOW_ORIGINS", []string{"*"}),
+			AllowMethods:     getEnvAsSliceOr("CORS_ALLOW_METHODS", []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}),
+			AllowHeaders:     getEnvAsSliceOr("CORS_ALLOW_HEADERS", []string{"Origin", "Content-Type", "Accept", "Authorization"}),
+			AllowCredentials: getEnv("CORS_ALLOW_CREDENTIALS", "false") == "true",
+			MaxAge:           getEnvAsInt("CORS_MAX_AGE", 600),
+		},
+		AdminCORS: CORSConfig{
+			AllowOrigins:     getEnvAsSlice("CORS_ADMIN_ALLOW_ORIGINS"),
+			AllowMethods:     getEnvAsSliceOr("CORS_ADMIN_ALLOW_METHODS", []string{"GET", "POST", "PUT", "DELETE"}),
+			AllowHeaders:     getEnvAsSliceOr("CORS_ADMIN_ALLOW_HEADERS", []string{"Content-Type", "Authorization"}),
+			AllowCredentials: getEnv("CORS_ADMIN_ALLOW_CREDENTIALS", "false") == "true",
+			MaxAge:           getEnvAsInt("CORS_ADMIN_MAX_AGE", 600),
+		},
+		RateLimit: RateLimitConfig{
+			Requests:    getEnvAsInt("RATE_LIMIT_REQUESTS", 100),
+			Window:      getEnvAsInt("RATE_LIMIT_WINDOW", 60),
+			Backend:     getEnv("RATE_LIMIT_BACKEND", "memory"),
+			RedisURL:    getEnv("REDIS_URL", "redis://localhost:6379/0"),
+			DailyTokens: getEnvAsInt("QUOTA_DAILY_TOKENS", 0),
+		},
+		Conversations: ConversationConfig{
+			Store:        getEnv("CONVERSATION_STORE", "memory"),
+			TTL:          getEnvAsInt("CONVERSATION_TTL", 1440),
+			RedisURL:     getEnv("REDIS_URL", "redis://localhost:6379/0"),
+			TokenBudget:  getEnvAsInt("CONVERSATION_TOKEN_BUDGET", 4000),
+			KeepMessages: getEnvAsInt("CONVERSATION_KEEP_MESSAGES", 6),
+		},
+		Usage: UsageConfig{
+			Store:         getEnv("USAGE_STORE", "memory"),
+			RetentionDays: getEnvAsInt("USAGE_RETENTION_DAYS", 30),
+			RedisURL:      getEnv("REDIS_URL", "redis://localhost:6379/0"),
+		},
+		Audit: AuditConfig{
+			File: getEnv("AUDIT_LOG_FILE", ""),
+		},
+		Limits: LimitsConfig{
+			MaxTokens:                      getEnvAsInt("LIMIT_MAX_TOKENS", 0),
+			MaxMessages:                    getEnvAsInt("LIMIT_MAX_MESSAGES", 0),
+			MaxPromptChars:                 getEnvAsInt("LIMIT_MAX_PROMPT_CHARS", 0),
+			MaxEmbeddingChars:              getEnvAsInt("LIMIT_MAX_EMBEDDING_CHARS", 0),
+			MaxBodyBytes:                   getEnvAsInt("LIMIT_MAX_BODY_BYTES", 1<<20),
+			StreamTokensPerSecond:          getEnvAsInt("LIMIT_STREAM_TOKENS_PER_SECOND", 0),
+			MaxTokensOverrides:             getEnvAsIntMap("LIMIT_MAX_TOKENS_OVERRIDES"),
+			MaxMessagesOverrides:           getEnvAsIntMap("LIMIT_MAX_MESSAGES_OVERRIDES"),
+			StreamTokensPerSecondOverrides: getEnvAsIntMap("LIMIT_STREAM_TOKENS_PER_SECOND_OVERRIDES"),
+		},
+		Log: LogConfig{
+			Level:         getEnv("LOG_LEVEL", "info"),
+			Format:        getEnv("LOG_FORMAT", "text"),
+			DebugSampling: getEnvAsInt("LOG_DEBUG_SAMPLING", 1),
+		},
+		Auth: AuthConfig{
+			JWTSecret: getEnv("JWT_SECRET", ""),
+			TokenTTL:  getEnvAsInt("JWT_TTL", 60),
+			Users:     getEnvAsUsers("AUTH_USERS"),
+		},
+		TLS: TLSConfig{
+			CertFile:         getEnv("TLS_CERT_FILE", ""),
+			KeyFile:          getEnv("TLS_KEY_FILE", ""),
+			AutocertDomains:  getEnvAsSlice("TLS_AUTOCERT_DOMAINS"),
+			AutocertCacheDir: getEnv("TLS_AUTOCERT_CACHE_DIR", "certs"),
+			AutocertEmail:    getEnv("TLS_AUTOCERT_EMAIL", ""),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -68,17 +393,137 @@ func Load() *Config {
 }
 
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	register(key, settingKind(defaultValue), defaultValue)
+	if value := getenv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	register(key, kindInt, strconv.Itoa(defaultValue))
+	if value := getenv(key); value != "" {
 		if intValue, err := strconv.Atoi(value); err == nil {
 			return intValue
 		}
 	}
 	return defaultValue
 }
+
+// getEnvAsSlice parses a comma-separated list, dropping empty entries
+func getEnvAsSlice(key string) []string {
+	register(key, kindList, "")
+	var values []string
+	for _, value := range strings.Split(getenv(key), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// getEnvAsSliceOr parses a comma-separated list, falling back to defaultValue when it is empty
+func getEnvAsSliceOr(key string, defaultValue []string) []string {
+	defer register(key, kindList, strings.Join(defaultValue, ","))
+	if values := getEnvAsSlice(key); len(values) > 0 {
+		return values
+	}
+	return defaultValue
+}
+
+// getEnvAsIntSlice parses a comma-separated list of integers, falling back to defaultValue
+// when the variable is unset or contains no valid entries
+func getEnvAsIntSlice(key string, defaultValue []int) []int {
+	defaults := make([]string, len(defaultValue))
+	for i, value := range defaultValue {
+		defaults[i] = strconv.Itoa(value)
+	}
+	defer register(key, kindIntList, strings.Join(defaults, ","))
+	var values []int
+	for _, entry := range getEnvAsSlice(key) {
+		if value, err := strconv.Atoi(entry); err == nil {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultValue
+	}
+	return values
+}
+
+// getEnvAsMap parses a list such as "fast=phi3:mini,smart=llama3.1:70b" into a map.
+// Malformed entries are skipped.
+func getEnvAsMap(key string) map[string]string {
+	values := map[string]string{}
+	for _, entry := range getEnvAsSlice(key) {
+		name, value, found := strings.Cut(entry, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if found && name != "" && value != "" {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+// getEnvAsIntMap parses a list such as "user=2048,readonly=256" into numbers by name. Malformed
+// and negative entries are skipped.
+func getEnvAsIntMap(key string) map[string]int {
+	values := map[string]int{}
+	for name, value := range getEnvAsMap(key) {
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			values[name] = n
+		}
+	}
+	return values
+}
+
+// getEnvAsChains parses a list such as "chat=llama3.1:8b>phi3:mini>gpt-oss:120b-cloud,rewrite=phi3:mini"
+// into an ordered model chain per endpoint. Malformed entries are skipped.
+func getEnvAsChains(key string) map[string][]string {
+	chains := map[string][]string{}
+	for endpoint, value := range getEnvAsMap(key) {
+		var chain []string
+		for _, model := range strings.Split(value, ">") {
+			if model = strings.TrimSpace(model); model != "" {
+				chain = append(chain, model)
+			}
+		}
+		if len(chain) > 0 {
+			chains[endpoint] = chain
+		}
+	}
+	return chains
+}
+
+// getEnvAsUsers parses a list such as "alice=admin:<bcrypt hash>,bob=user:<bcrypt hash>" into
+// accounts by name. Malformed entries are skipped.
+func getEnvAsUsers(key string) map[string]AuthUser {
+	users := map[string]AuthUser{}
+	for name, value := range getEnvAsMap(key) {
+		role, hash, found := strings.Cut(value, ":")
+		if found && role != "" && hash != "" {
+			users[name] = AuthUser{Role: role, PasswordHash: hash}
+		}
+	}
+	return users
+}
+
+// getEnvAsModelTimeouts parses a list such as "70b=600,-cloud=300" into ordered model timeouts.
+// Malformed entries are skipped.
+func getEnvAsModelTimeouts(key string) []ModelTimeout {
+	register(key, kindList, "")
+	var timeouts []ModelTimeout
+	for _, entry := range strings.Split(getenv(key), ",") {
+		pattern, value, found := strings.Cut(strings.TrimSpace(entry), "=")
+		if !found || pattern == "" {
+			continue
+		}
+		if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil && seconds > 0 {
+			timeouts = append(timeouts, ModelTimeout{
+				Pattern: strings.ToLower(strings.TrimSpace(pattern)),
+				Seconds: seconds,
+			})
+		}
+	}
+	return timeouts
+}
@@ -18,12 +18,74 @@ func TestLoad_Defaults(t *testing.T) {
 	assert.Equal(t, "0.0.0.0", config.Server.Host)
 	assert.Equal(t, 30, config.Server.ReadTimeout)
 	assert.Equal(t, 30, config.Server.WriteTimeout)
+	assert.Equal(t, 30, config.Server.ShutdownTimeout)
+	assert.Equal(t, 600, config.Server.MaxRequestTimeout)
+	assert.Equal(t, 2, config.Server.ReadinessTimeout)
+	assert.Equal(t, 10, config.Server.PersistenceCheck)
+	assert.Empty(t, config.TLS.CertFile)
+	assert.Empty(t, config.TLS.AutocertDomains)
+	assert.Equal(t, "certs", config.TLS.AutocertCacheDir)
+	assert.Equal(t, "v1", config.Server.StreamSchema)
+	assert.Zero(t, config.Limits.MaxTokens)
+	assert.Equal(t, "info", config.Log.Level)
+	assert.Equal(t, "text", config.Log.Format)
+	assert.Equal(t, 1, config.Log.DebugSampling)
+	assert.Zero(t, config.Limits.MaxMessages)
+	assert.Equal(t, 1<<20, config.Limits.MaxBodyBytes)
+	assert.True(t, config.Server.H2C)
+	assert.False(t, config.Server.StreamCompression)
+	assert.Equal(t, "gin", config.Server.AccessLogFormat)
 
 	assert.Equal(t, "http://localhost:11434", config.Llama.BaseURL)
 	assert.Equal(t, "llama2", config.Llama.DefaultModel)
 	assert.Equal(t, 60, config.Llama.Timeout)
+	assert.Equal(t, 10, config.Llama.ConnectTimeout)
+	assert.Equal(t, 60, config.Llama.HeaderTimeout)
+	assert.Empty(t, config.Llama.ModelTimeouts)
+	assert.Equal(t, 2, config.Llama.CompareWorkers)
+	assert.Equal(t, 4, config.Llama.BatchWorkers)
+	assert.Empty(t, config.Llama.ModelAliases)
+	assert.Empty(t, config.Llama.FallbackChains)
+	assert.Empty(t, config.Llama.ShadowModel)
+	assert.Equal(t, 0, config.Llama.ShadowPercent)
+	assert.Equal(t, 100, config.Llama.ShadowResults)
+	assert.Equal(t, 2, config.Llama.SchemaRepairAttempts)
+	assert.True(t, config.Llama.CoalesceRequests)
+	assert.Empty(t, config.Llama.SemanticCacheModel)
+	assert.Equal(t, 95, config.Llama.SemanticSimilarity)
+	assert.Empty(t, config.Llama.PreloadModels)
+	assert.Equal(t, "30m", config.Llama.PreloadKeepAlive)
+	assert.Equal(t, 3600, config.Llama.SwapTimeout)
+	assert.Equal(t, 0, config.Llama.MaxConcurrent)
+	assert.Equal(t, 0, config.Llama.MaxConcurrentPerModel)
+	assert.Equal(t, 16, config.Llama.MaxQueued)
+	assert.Equal(t, 30, config.Llama.QueueTimeout)
 	assert.False(t, config.Llama.CloudEnabled)
 	assert.Equal(t, "https://api.ollama.com", config.Llama.CloudAPIURL)
+	assert.Equal(t, 3, config.Llama.RetryMaxAttempts)
+	assert.Equal(t, 250, config.Llama.RetryBackoff)
+	assert.Equal(t, []int{502, 503, 504}, config.Llama.RetryStatusCodes)
+	assert.Equal(t, 5, config.Llama.BreakerThreshold)
+	assert.Equal(t, 30, config.Llama.BreakerCooldown)
+	assert.True(t, config.Llama.ContextTrimming)
+	assert.Equal(t, 512, config.Llama.ContextReserve)
+
+	assert.Equal(t, []string{"*"}, config.CORS.AllowOrigins)
+	assert.False(t, config.CORS.AllowCredentials)
+	assert.Equal(t, 600, config.CORS.MaxAge)
+	assert.Empty(t, config.AdminCORS.AllowOrigins)
+	assert.Equal(t, []string{"Content-Type", "Authorization"}, config.AdminCORS.AllowHeaders)
+
+	assert.Equal(t, 100, config.RateLimit.Requests)
+	assert.Equal(t, 60, config.RateLimit.Window)
+	assert.Equal(t, "memory", config.RateLimit.Backend)
+
+	assert.Equal(t, "memory", config.Conversations.Store)
+	assert.Equal(t, 1440, config.Conversations.TTL)
+	assert.Equal(t, 4000, config.Conversations.TokenBudget)
+	assert.Equal(t, 6, config.Conversations.KeepMessages)
+	assert.Equal(t, "memory", config.Usage.Store)
+	assert.Equal(t, 30, config.Usage.RetentionDays)
 }
 
 func TestLoad_WithEnvironmentVariables(t *testing.T) {
@@ -80,6 +142,20 @@ func TestLoad_DatabaseConfig(t *testing.T) {
 	assert.Equal(t, "require", config.Database.SSLMode)
 }
 
+func TestLoad_TLSConfig(t *testing.T) {
+	os.Setenv("TLS_CERT_FILE", "/etc/tls/tls.crt")
+	os.Setenv("TLS_KEY_FILE", "/etc/tls/tls.key")
+	os.Setenv("TLS_AUTOCERT_DOMAINS", "api.example.com, llama.example.com")
+
+	defer os.Clearenv()
+
+	config := Load()
+
+	assert.Equal(t, "/etc/tls/tls.crt", config.TLS.CertFile)
+	assert.Equal(t, "/etc/tls/tls.key", config.TLS.KeyFile)
+	assert.Equal(t, []string{"api.example.com", "llama.example.com"}, config.TLS.AutocertDomains)
+}
+
 func TestGetEnv(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -161,6 +237,75 @@ func TestGetEnvAsInt(t *testing.T) {
 	}
 }
 
+func TestGetEnvAsSlice(t *testing.T) {
+	os.Setenv("TEST_SLICE", "chat, chat_stream,,rewrite ")
+	defer os.Unsetenv("TEST_SLICE")
+
+	assert.Equal(t, []string{"chat", "chat_stream", "rewrite"}, getEnvAsSlice("TEST_SLICE"))
+	assert.Empty(t, getEnvAsSlice("UNSET_SLICE"))
+}
+
+func TestGetEnvAsIntSlice(t *testing.T) {
+	os.Setenv("TEST_INT_SLICE", "500, 503,abc")
+	defer os.Unsetenv("TEST_INT_SLICE")
+
+	assert.Equal(t, []int{500, 503}, getEnvAsIntSlice("TEST_INT_SLICE", []int{502}))
+	assert.Equal(t, []int{502}, getEnvAsIntSlice("UNSET_INT_SLICE", []int{502}))
+}
+
+func TestGetEnvAsMap(t *testing.T) {
+	os.Setenv("TEST_MAP", "fast=phi3:mini, smart = llama3.1:70b,broken,=x,y=")
+	defer os.Unsetenv("TEST_MAP")
+
+	assert.Equal(t, map[string]string{
+		"fast":  "phi3:mini",
+		"smart": "llama3.1:70b",
+	}, getEnvAsMap("TEST_MAP"))
+}
+
+func TestGetEnvAsIntMap(t *testing.T) {
+	os.Setenv("TEST_INT_MAP", "user=2048, user:alice = 0,readonly=-1,broken=x")
+	defer os.Unsetenv("TEST_INT_MAP")
+
+	assert.Equal(t, map[string]int{
+		"user":       2048,
+		"user:alice": 0,
+	}, getEnvAsIntMap("TEST_INT_MAP"))
+}
+
+func TestGetEnvAsChains(t *testing.T) {
+	os.Setenv("TEST_CHAINS", "chat=llama3.1:8b > phi3:mini>gpt-oss:120b-cloud,rewrite=phi3:mini,empty=>")
+	defer os.Unsetenv("TEST_CHAINS")
+
+	assert.Equal(t, map[string][]string{
+		"chat":    {"llama3.1:8b", "phi3:mini", "gpt-oss:120b-cloud"},
+		"rewrite": {"phi3:mini"},
+	}, getEnvAsChains("TEST_CHAINS"))
+}
+
+func TestGetEnvAsUsers(t *testing.T) {
+	os.Setenv("TEST_USERS", "alice=admin:$2a$10$abc,bob=readonly:$2a$10$def,broken=user,=user:$2a$10$ghi")
+	defer os.Unsetenv("TEST_USERS")
+
+	assert.Equal(t, map[string]AuthUser{
+		"alice": {Role: "admin", PasswordHash: "$2a$10$abc"},
+		"bob":   {Role: "readonly", PasswordHash: "$2a$10$def"},
+	}, getEnvAsUsers("TEST_USERS"))
+}
+
+func TestGetEnvAsModelTimeouts(t *testing.T) {
+	os.Setenv("TEST_MODEL_TIMEOUTS", "70B=600, -cloud=300,broken,zero=0,=5")
+	defer os.Unsetenv("TEST_MODEL_TIMEOUTS")
+
+	result := getEnvAsModelTimeouts("TEST_MODEL_TIMEOUTS")
+
+	assert.Equal(t, []ModelTimeout{
+		{Pattern: "70b", Seconds: 600},
+		{Pattern: "-cloud", Seconds: 300},
+	}, result)
+	assert.Empty(t, getEnvAsModelTimeouts("UNSET_MODEL_TIMEOUTS"))
+}
+
 func TestConfig_Structs(t *testing.T) {
 	// Test that all structs are properly defined
 	config := &Config{
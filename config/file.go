@@ -0,0 +1,190 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// sectionAliases are alternative names of configuration file sections
+var sectionAliases = map[string]string{
+	"ollama": "llama",
+}
+
+var (
+	fileMu       sync.Mutex
+	loadedFile   string          // Configuration file applied by LoadFile
+	fileSettings map[string]bool // Variables LoadFile set from it
+)
+
+// ConfigFile is the YAML configuration file read at startup. Its settings apply where neither the
+// process environment, the profile file nor .env sets a value.
+func ConfigFile() string {
+	return getEnv("CONFIG_FILE", "config.yaml")
+}
+
+// LoadFile reads the configuration file at path and sets each of its settings that is not already
+// set in the environment. Nothing is applied if any entry is invalid; the error lists every problem.
+func LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read configuration file: %w", err)
+	}
+
+	values, problems, err := ParseFile(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration file %s:\n  %s", path, strings.Join(problems, "\n  "))
+	}
+
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	loadedFile, fileSettings = path, map[string]bool{}
+	for name, value := range values {
+		if _, set := os.LookupEnv(name); !set {
+			os.Setenv(name, value)
+			fileSettings[name] = true
+		}
+	}
+	return nil
+}
+
+// LoadedFile returns the configuration file applied by LoadFile, or "" if none was
+func LoadedFile() string {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	return loadedFile
+}
+
+// ParseFile reads a YAML configuration file into environment variable values. Sections nest
+// settings: a key names the variable formed by joining its path with underscores in upper case,
+// and the top-level section may be left out of the name, so server.port sets PORT and
+// rate_limit.requests sets RATE_LIMIT_REQUESTS. Lists are joined with commas and mappings of a
+// setting with name=value entries. The returned problems list each invalid entry with its line,
+// in file order.
+func ParseFile(data []byte) (map[string]string, []string, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, nil, fmt.Errorf("invalid configuration file: %w", err)
+	}
+
+	values := map[string]string{}
+	if len(document.Content) == 0 {
+		return values, nil, nil
+	}
+	root := document.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, fmt.Errorf("invalid configuration file: line %d: expected a mapping of sections", root.Line)
+	}
+
+	known := map[string]Setting{}
+	for _, setting := range Settings() {
+		known[setting.Name] = setting
+	}
+
+	p := &fileParser{known: known, values: values, lines: map[string]int{}}
+	p.mapping(nil, root)
+	return values, p.problems, nil
+}
+
+type fileParser struct {
+	known    map[string]Setting
+	values   map[string]string
+	lines    map[string]int // Line each setting was set on, to report duplicates
+	problems []string
+}
+
+func (p *fileParser) mapping(path []string, node *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		keyPath := append(append([]string(nil), path...), strings.ToLower(key.Value))
+
+		name, ok := p.settingName(keyPath)
+		switch {
+		case ok:
+			p.setting(name, keyPath, value)
+		case value.Kind == yaml.MappingNode:
+			p.mapping(keyPath, value)
+		default:
+			p.problem(key.Line, keyPath, "unknown setting")
+		}
+	}
+}
+
+// settingName returns the variable a key path names, trying the full path, then the path without
+// its section, then the path with its section alias
+func (p *fileParser) settingName(path []string) (string, bool) {
+	candidates := []string{strings.Join(path, "_")}
+	if len(path) > 1 {
+		candidates = append(candidates, strings.Join(path[1:], "_"))
+		if alias, ok := sectionAliases[path[0]]; ok {
+			candidates = append(candidates, alias+"_"+strings.Join(path[1:], "_"))
+		}
+	}
+	for _, candidate := range candidates {
+		name := strings.ToUpper(candidate)
+		if _, ok := p.known[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (p *fileParser) setting(name string, path []string, node *yaml.Node) {
+	if line, ok := p.lines[name]; ok {
+		p.problem(node.Line, path, fmt.Sprintf("%s is already set on line %d", name, line))
+		return
+	}
+
+	value, err := fileValue(node)
+	if err == nil {
+		err = validateSetting(p.known[name], value)
+	}
+	if err != nil {
+		p.problem(node.Line, path, fmt.Sprintf("%v (%s)", err, name))
+		return
+	}
+	p.values[name] = value
+	p.lines[name] = node.Line
+}
+
+func (p *fileParser) problem(line int, path []string, message string) {
+	p.problems = append(p.problems, fmt.Sprintf("line %d: %s: %s", line, strings.Join(path, "."), message))
+}
+
+// fileValue renders a node as an environment variable value
+func fileValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!!null" {
+			return "", nil
+		}
+		return node.Value, nil
+	case yaml.SequenceNode:
+		entries := make([]string, len(node.Content))
+		for i, entry := range node.Content {
+			if entry.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("list entries must be plain values")
+			}
+			entries[i] = entry.Value
+		}
+		return strings.Join(entries, ","), nil
+	case yaml.MappingNode:
+		entries := make([]string, 0, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if value.Kind != yaml.ScalarNode {
+				return "", fmt.Errorf("mapping entries must be plain values")
+			}
+			entries = append(entries, key.Value+"="+value.Value)
+		}
+		return strings.Join(entries, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value")
+	}
+}
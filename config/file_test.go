@@ -0,0 +1,97 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFile(t *testing.T) {
+	values, problems, err := ParseFile([]byte(`
+server:
+  port: 9090
+  h2c: false
+ollama:
+  base_url: http://ollama:11434
+  preload_models: [llama3.2, phi3:mini]
+  model_aliases:
+    fast: phi3:mini
+    smart: llama3.1:70b
+  semantic_cache:
+    size: 500
+rate_limit:
+  requests: 20
+  redis_url: redis://redis:6379/0
+auth:
+  jwt_secret: s3cret
+LOG_LEVEL: debug
+`))
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, map[string]string{
+		"PORT":                      "9090",
+		"SERVER_H2C":                "false",
+		"LLAMA_BASE_URL":            "http://ollama:11434",
+		"LLAMA_PRELOAD_MODELS":      "llama3.2,phi3:mini",
+		"LLAMA_MODEL_ALIASES":       "fast=phi3:mini,smart=llama3.1:70b",
+		"LLAMA_SEMANTIC_CACHE_SIZE": "500",
+		"RATE_LIMIT_REQUESTS":       "20",
+		"REDIS_URL":                 "redis://redis:6379/0",
+		"JWT_SECRET":                "s3cret",
+		"LOG_LEVEL":                 "debug",
+	}, values)
+}
+
+func TestParseFile_Problems(t *testing.T) {
+	_, problems, err := ParseFile([]byte(`
+server:
+  port: 9090
+  colour: blue
+llama:
+  timeout: soon
+  retry_status_codes: [502, busy]
+PORT: 8080
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"line 4: server.colour: unknown setting",
+		"line 6: llama.timeout: must be an integer (LLAMA_TIMEOUT)",
+		"line 7: llama.retry_status_codes: must be a list of integers (LLAMA_RETRY_STATUS_CODES)",
+		"line 8: port: PORT is already set on line 3",
+	}, problems)
+
+	_, _, err = ParseFile([]byte(`- not a mapping`))
+	assert.Error(t, err)
+}
+
+func TestLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("llama:\n  max_queued: 4\n  compare_workers: 3\n"), 0o600))
+
+	// The environment overrides the file
+	os.Setenv("LLAMA_COMPARE_WORKERS", "8")
+	defer os.Unsetenv("LLAMA_COMPARE_WORKERS")
+	defer os.Unsetenv("LLAMA_MAX_QUEUED")
+
+	assert.NoError(t, LoadFile(path))
+	cfg := Load()
+	assert.Equal(t, 4, cfg.Llama.MaxQueued)
+	assert.Equal(t, 8, cfg.Llama.CompareWorkers)
+	assert.Equal(t, path, LoadedFile())
+
+	sources := map[string]string{}
+	for _, setting := range Effective() {
+		sources[setting.Name] = setting.Source
+	}
+	assert.Equal(t, SourceFile, sources["LLAMA_MAX_QUEUED"])
+	assert.Equal(t, SourceEnvironment, sources["LLAMA_COMPARE_WORKERS"])
+	assert.Equal(t, SourceDefault, sources["LLAMA_TIMEOUT"])
+
+	assert.NoError(t, os.WriteFile(path, []byte("llama:\n  max_queued: many\n"), 0o600))
+	err := LoadFile(path)
+	assert.ErrorContains(t, err, "line 2: llama.max_queued: must be an integer (LLAMA_MAX_QUEUED)")
+
+	assert.ErrorIs(t, LoadFile(filepath.Join(t.TempDir(), "missing.yaml")), os.ErrNotExist)
+}
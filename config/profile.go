@@ -0,0 +1,291 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// Redacted replaces secret values in exported profiles. Importing it keeps the current value.
+const Redacted = "[REDACTED]"
+
+// Setting kinds, used to validate imported values
+const (
+	kindString  = "string"
+	kindBool    = "bool"
+	kindInt     = "int"
+	kindList    = "list"
+	kindIntList = "int_list"
+)
+
+// Setting is an environment variable read by Load
+type Setting struct {
+	Name    string
+	Kind    string
+	Default string
+}
+
+var (
+	settingsMu sync.Mutex
+	settings   = map[string]Setting{}
+)
+
+// register records a variable read by Load so profiles can be exported and validated
+func register(key, kind, defaultValue string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings[key] = Setting{Name: key, Kind: kind, Default: defaultValue}
+}
+
+func settingKind(defaultValue string) string {
+	if defaultValue == "true" || defaultValue == "false" {
+		return kindBool
+	}
+	return kindString
+}
+
+// Settings returns the variables read by Load, sorted by name
+func Settings() []Setting {
+	Load()
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	list := make([]Setting, 0, len(settings))
+	for _, setting := range settings {
+		list = append(list, setting)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list
+}
+
+// ProfileFile is the env file an imported profile is written to. It is loaded at startup,
+// before .env, so its values take precedence over .env but not over the process environment.
+func ProfileFile() string {
+	return getEnv("CONFIG_PROFILE_FILE", ".env.profile")
+}
+
+// ExportProfile renders the effective configuration as YAML, keyed by environment variable.
+// Secrets are replaced by Redacted and passwords are removed from URLs.
+func ExportProfile() ([]byte, error) {
+	profile := map[string]string{}
+	for _, setting := range Settings() {
+		value := setting.Default
+		if current := os.Getenv(setting.Name); current != "" {
+			value = current
+		}
+		profile[setting.Name] = redact(setting.Name, value)
+	}
+	return yaml.Marshal(profile)
+}
+
+// Sources of an effective setting
+const (
+	SourceEnvironment = "environment" // The process environment, .env or the profile file
+	SourceFile        = "config_file"
+	SourceDefault     = "default"
+)
+
+// EffectiveSetting is the value a setting has in the running server and where it came from
+type EffectiveSetting struct {
+	Setting
+	Value  string
+	Source string
+}
+
+// Effective returns every setting with its effective value and source, sorted by name. Secrets
+// are replaced by Redacted and passwords are removed from URLs, in values and defaults alike.
+func Effective() []EffectiveSetting {
+	fileMu.Lock()
+	fromFile := fileSettings
+	fileMu.Unlock()
+
+	var effective []EffectiveSetting
+	for _, setting := range Settings() {
+		value, source := os.Getenv(setting.Name), SourceEnvironment
+		switch {
+		case value == "":
+			value, source = setting.Default, SourceDefault
+		case fromFile[setting.Name]:
+			source = SourceFile
+		}
+		setting.Default = redact(setting.Name, setting.Default)
+		effective = append(effective, EffectiveSetting{
+			Setting: setting,
+			Value:   redact(setting.Name, value),
+			Source:  source,
+		})
+	}
+	return effective
+}
+
+// ParseProfile reads a YAML profile and checks every entry against the known settings.
+// Scalars are kept as written and lists are joined with commas. Redacted entries are left out,
+// and URLs exported with their password masked get the current URL back if nothing else changed.
+// The returned problems list each invalid entry; the profile is only valid when there are none.
+func ParseProfile(data []byte) (map[string]string, []string, error) {
+	var raw map[string]interface{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, nil, fmt.Errorf("invalid profile: %w", err)
+	}
+
+	known := map[string]Setting{}
+	for _, setting := range Settings() {
+		known[setting.Name] = setting
+	}
+
+	profile := map[string]string{}
+	var problems []string
+	for name, rawValue := range raw {
+		setting, ok := known[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown setting", name))
+			continue
+		}
+
+		value, err := profileValue(rawValue)
+		if err == nil && maskedURL(name, value) {
+			value, err = unmaskURL(name, value)
+		}
+		if err == nil {
+			err = validateSetting(setting, value)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if value != Redacted {
+			profile[name] = value
+		}
+	}
+	sort.Strings(problems)
+	return profile, problems, nil
+}
+
+// ValidateProfile checks the configuration the server would run with once profile is applied:
+// the current environment with the profile's values taking precedence. Secrets left out of
+// profile because they were exported redacted keep their current value.
+func ValidateProfile(profile map[string]string) []string {
+	merged := loadFrom(func(key string) string {
+		if value, ok := profile[key]; ok {
+			return value
+		}
+		return os.Getenv(key)
+	})
+	return merged.Validate()
+}
+
+// WriteProfile replaces the profile file with profile. Secrets missing from profile because they
+// were exported redacted keep the value they have in the current profile file.
+func WriteProfile(path string, profile map[string]string) error {
+	if current, err := godotenv.Read(path); err == nil {
+		for name, value := range current {
+			if _, ok := profile[name]; !ok && isSecret(name) {
+				profile[name] = value
+			}
+		}
+	}
+	if err := godotenv.Write(profile, path); err != nil {
+		return fmt.Errorf("failed to write profile: %w", err)
+	}
+	return os.Chmod(path, 0o600)
+}
+
+func profileValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case []interface{}:
+		entries := make([]string, len(v))
+		for i, entry := range v {
+			entries[i] = fmt.Sprint(entry)
+		}
+		return strings.Join(entries, ","), nil
+	case map[string]interface{}:
+		return "", fmt.Errorf("nested values are not supported")
+	default:
+		return fmt.Sprint(v), nil
+	}
+}
+
+func validateSetting(setting Setting, value string) error {
+	if value == "" || value == Redacted {
+		return nil
+	}
+
+	switch setting.Kind {
+	case kindBool:
+		if value != "true" && value != "false" {
+			return fmt.Errorf("must be true or false")
+		}
+	case kindInt:
+		if _, err := strconv.Atoi(value); err != nil {
+			return fmt.Errorf("must be an integer")
+		}
+	case kindIntList:
+		for _, entry := range strings.Split(value, ",") {
+			if _, err := strconv.Atoi(strings.TrimSpace(entry)); err != nil {
+				return fmt.Errorf("must be a list of integers")
+			}
+		}
+	}
+	return nil
+}
+
+// isSecret reports whether a setting holds a credential, including lists of user accounts
+func isSecret(name string) bool {
+	return strings.HasSuffix(name, "_TOKEN") ||
+		strings.HasSuffix(name, "_USERS") ||
+		strings.HasSuffix(name, "_API_KEY") ||
+		strings.HasSuffix(name, "_PASSWORD") ||
+		strings.HasSuffix(name, "_SECRET")
+}
+
+// maskedPassword is the password url.Redacted puts in exported URLs
+const maskedPassword = "xxxxx"
+
+// maskedURL reports whether value is a URL setting exported with its password masked
+func maskedURL(name, value string) bool {
+	if !strings.HasSuffix(name, "_URL") {
+		return false
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.User == nil {
+		return false
+	}
+	password, ok := u.User.Password()
+	return ok && password == maskedPassword
+}
+
+// unmaskURL returns the current value of the URL setting name, which masked must be an export of
+func unmaskURL(name, masked string) (string, error) {
+	current := os.Getenv(name)
+	if current == "" || redact(name, current) != masked {
+		return "", fmt.Errorf("the password was masked on export, keep the exported URL as is or give the password")
+	}
+	return current, nil
+}
+
+func redact(name, value string) string {
+	if value == "" {
+		return value
+	}
+	if isSecret(name) {
+		return Redacted
+	}
+	if strings.HasSuffix(name, "_URL") {
+		if u, err := url.Parse(value); err == nil && u.User != nil {
+			return u.Redacted()
+		}
+	}
+	return value
+}
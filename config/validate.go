@@ -0,0 +1,122 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+)
+
+// stores are the backends of rate limits, quotas, conversations and usage records
+var stores = []string{"memory", "redis"}
+
+// fallbackEndpoints are the endpoints LLAMA_FALLBACK_CHAINS can configure
+var fallbackEndpoints = []string{"chat", "chat_stream", "rewrite", "compare", "prompt", "glossary", "completion"}
+
+// namedValue is a setting's value with the variable it was read from
+type namedValue[T any] struct {
+	name  string
+	value T
+}
+
+// Validate checks the configuration for values Load accepts but the server cannot sensibly run
+// with: malformed URLs, negative or zero timeouts, unknown backends and options that contradict
+// each other. It returns every problem found, naming the variables involved.
+func (c *Config) Validate() []string {
+	var problems []string
+	problem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	urls := []namedValue[string]{
+		{"LLAMA_BASE_URL", c.Llama.BaseURL},
+		{"LLAMA_CLOUD_API_URL", c.Llama.CloudAPIURL},
+		{"OPENAI_BASE_URL", c.Providers.OpenAIBaseURL},
+		{"ANTHROPIC_BASE_URL", c.Providers.AnthropicBaseURL},
+	}
+	for _, setting := range urls {
+		if u, err := url.Parse(setting.value); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			problem("%s %q must be an http or https URL with a host", setting.name, setting.value)
+		}
+	}
+
+	positive := []namedValue[int]{
+		{"LLAMA_TIMEOUT", c.Llama.Timeout},
+		{"LLAMA_CONNECT_TIMEOUT", c.Llama.ConnectTimeout},
+		{"LLAMA_HEADER_TIMEOUT", c.Llama.HeaderTimeout},
+		{"LLAMA_SWAP_TIMEOUT", c.Llama.SwapTimeout},
+		{"READINESS_TIMEOUT", c.Server.ReadinessTimeout},
+		{"PERSISTENCE_CHECK_INTERVAL", c.Server.PersistenceCheck},
+		{"JWT_TTL", c.Auth.TokenTTL},
+	}
+	for _, setting := range positive {
+		if setting.value <= 0 {
+			problem("%s must be a positive number of seconds, got %d", setting.name, setting.value)
+		}
+	}
+
+	nonNegative := []namedValue[int]{
+		{"READ_TIMEOUT", c.Server.ReadTimeout},
+		{"WRITE_TIMEOUT", c.Server.WriteTimeout},
+		{"SHUTDOWN_TIMEOUT", c.Server.ShutdownTimeout},
+		{"MAX_REQUEST_TIMEOUT", c.Server.MaxRequestTimeout},
+		{"LLAMA_CLOUD_TIMEOUT", c.Llama.CloudTimeout},
+		{"LLAMA_QUEUE_TIMEOUT", c.Llama.QueueTimeout},
+		{"LLAMA_BATCH_ITEM_TIMEOUT", c.Llama.BatchItemTimeout},
+		{"LIMIT_MAX_BODY_BYTES", c.Limits.MaxBodyBytes},
+	}
+	for _, setting := range nonNegative {
+		if setting.value < 0 {
+			problem("%s must not be negative, got %d", setting.name, setting.value)
+		}
+	}
+
+	if c.Llama.Timeout > 0 && c.Llama.ConnectTimeout > c.Llama.Timeout {
+		problem("LLAMA_CONNECT_TIMEOUT (%ds) is longer than the whole generation budget LLAMA_TIMEOUT (%ds)", c.Llama.ConnectTimeout, c.Llama.Timeout)
+	}
+	if c.Llama.Timeout > 0 && c.Llama.HeaderTimeout > c.Llama.Timeout {
+		problem("LLAMA_HEADER_TIMEOUT (%ds) is longer than the whole generation budget LLAMA_TIMEOUT (%ds)", c.Llama.HeaderTimeout, c.Llama.Timeout)
+	}
+	if c.Llama.ShadowPercent < 0 || c.Llama.ShadowPercent > 100 {
+		problem("LLAMA_SHADOW_PERCENT must be between 0 and 100, got %d", c.Llama.ShadowPercent)
+	}
+
+	backends := []namedValue[string]{
+		{"RATE_LIMIT_BACKEND", c.RateLimit.Backend},
+		{"CONVERSATION_STORE", c.Conversations.Store},
+		{"USAGE_STORE", c.Usage.Store},
+	}
+	for _, setting := range backends {
+		if !slices.Contains(stores, setting.value) {
+			problem("%s %q must be one of %v", setting.name, setting.value, stores)
+		}
+	}
+
+	var chains []string
+	for endpoint := range c.Llama.FallbackChains {
+		chains = append(chains, endpoint)
+	}
+	slices.Sort(chains)
+	for _, endpoint := range chains {
+		if !slices.Contains(fallbackEndpoints, endpoint) {
+			problem("LLAMA_FALLBACK_CHAINS has a chain for unknown endpoint %q, expected one of %v", endpoint, fallbackEndpoints)
+		}
+	}
+
+	if len(c.TLS.AutocertDomains) > 0 && (c.TLS.CertFile != "" || c.TLS.KeyFile != "") {
+		problem("TLS_AUTOCERT_DOMAINS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		problem("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if c.Llama.FailoverToCloud && !c.Llama.CloudEnabled {
+		problem("FAILOVER_TO_CLOUD needs LLAMA_CLOUD_ENABLED=true")
+	}
+	if c.Server.GRPCPort != "" && c.Server.GRPCPort == c.Server.Port {
+		problem("GRPC_PORT must differ from PORT, both are %s", c.Server.Port)
+	}
+	if c.Auth.JWTSecret != "" && c.Server.AdminToken != "" {
+		problem("ADMIN_TOKEN is ignored when JWT_SECRET is set, admin routes require the admin role instead; unset one of them")
+	}
+
+	return problems
+}
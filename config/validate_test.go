@@ -0,0 +1,47 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate_Defaults(t *testing.T) {
+	assert.Empty(t, Load().Validate())
+}
+
+func TestValidate(t *testing.T) {
+	env := map[string]string{
+		"LLAMA_BASE_URL":        "localhost:11434",
+		"LLAMA_TIMEOUT":         "30",
+		"LLAMA_CONNECT_TIMEOUT": "45",
+		"READINESS_TIMEOUT":     "0",
+		"SHUTDOWN_TIMEOUT":      "-5",
+		"RATE_LIMIT_BACKEND":    "memcached",
+		"TLS_CERT_FILE":         "server.crt",
+		"FAILOVER_TO_CLOUD":     "true",
+		"JWT_SECRET":            "jwt-secret",
+		"ADMIN_TOKEN":           "admin-secret",
+		"GRPC_PORT":             "8080",
+		"LLAMA_FALLBACK_CHAINS": "chat=llama3.2>phi3,complete=llama3.2>phi3",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+
+	assert.Equal(t, []string{
+		`LLAMA_BASE_URL "localhost:11434" must be an http or https URL with a host`,
+		"READINESS_TIMEOUT must be a positive number of seconds, got 0",
+		"SHUTDOWN_TIMEOUT must not be negative, got -5",
+		"LLAMA_CONNECT_TIMEOUT (45s) is longer than the whole generation budget LLAMA_TIMEOUT (30s)",
+		"LLAMA_HEADER_TIMEOUT (60s) is longer than the whole generation budget LLAMA_TIMEOUT (30s)",
+		`RATE_LIMIT_BACKEND "memcached" must be one of [memory redis]`,
+		`LLAMA_FALLBACK_CHAINS has a chain for unknown endpoint "complete", expected one of [chat chat_stream rewrite compare prompt glossary completion]`,
+		"TLS_CERT_FILE and TLS_KEY_FILE must be set together",
+		"FAILOVER_TO_CLOUD needs LLAMA_CLOUD_ENABLED=true",
+		"GRPC_PORT must differ from PORT, both are 8080",
+		"ADMIN_TOKEN is ignored when JWT_SECRET is set, admin routes require the admin role instead; unset one of them",
+	}, Load().Validate())
+}
@@ -10,6 +10,39 @@ class LlamaAPIClient {
     constructor(baseURL = 'http://localhost:8080') {
         this.baseURL = baseURL.replace(/\/$/, '');
         this.apiBase = `${this.baseURL}/api/v1`;
+        this._capabilities = null;
+    }
+
+    /**
+     * Discover the server's features, models, streaming formats and auth mode
+     */
+    async capabilities(refresh = false) {
+        if (this._capabilities && !refresh) {
+            return this._capabilities;
+        }
+        try {
+            const response = await axios.get(`${this.apiBase}/capabilities`);
+            this._capabilities = response.data;
+            return this._capabilities;
+        } catch (error) {
+            throw new Error(`Capability discovery failed: ${error.message}`);
+        }
+    }
+
+    /**
+     * Check whether the server has a feature such as "cloud" enabled
+     */
+    async supports(feature) {
+        const capabilities = await this.capabilities();
+        return Boolean(capabilities.features && capabilities.features[feature]);
+    }
+
+    /**
+     * Model to use when none is given, as configured on the server
+     */
+    async defaultModel() {
+        const capabilities = await this.capabilities();
+        return capabilities.default_model || 'llama2';
     }
 
     /**
@@ -39,11 +72,11 @@ class LlamaAPIClient {
     /**
      * Send chat completion request
      */
-    async chatCompletion(messages, model = 'llama2', temperature = 0.7, maxTokens = 100) {
+    async chatCompletion(messages, model = null, temperature = 0.7, maxTokens = 100) {
         try {
             const payload = {
                 messages,
-                model,
+                model: model || await this.defaultModel(),
                 temperature,
                 max_tokens: maxTokens
             };
@@ -60,11 +93,11 @@ class LlamaAPIClient {
     /**
      * Send text completion request
      */
-    async textCompletion(prompt, model = 'llama2', temperature = 0.8, maxTokens = 50) {
+    async textCompletion(prompt, model = null, temperature = 0.8, maxTokens = 50) {
         try {
             const payload = {
                 prompt,
-                model,
+                model: model || await this.defaultModel(),
                 temperature,
                 max_tokens: maxTokens
             };
@@ -81,11 +114,11 @@ class LlamaAPIClient {
     /**
      * Generate text embedding
      */
-    async generateEmbedding(text, model = 'llama2') {
+    async generateEmbedding(text, model = null) {
         try {
             const payload = {
                 input: text,
-                model
+                model: model || await this.defaultModel()
             };
 
             const response = await axios.post(`${this.apiBase}/llama/embedding`, payload, {
@@ -100,11 +133,11 @@ class LlamaAPIClient {
     /**
      * Stream chat responses
      */
-    async streamChat(messages, model = 'llama2', temperature = 0.7) {
+    async streamChat(messages, model = null, temperature = 0.7) {
         try {
             const payload = {
                 messages,
-                model,
+                model: model || await this.defaultModel(),
                 temperature,
                 stream: true
             };
@@ -138,6 +171,15 @@ async function main() {
         console.log(`   Status: ${health.status}`);
         console.log(`   Message: ${health.message}`);
 
+        // Discover what this server offers instead of assuming a build
+        const capabilities = await client.capabilities();
+        const enabled = Object.keys(capabilities.features || {}).filter(name => capabilities.features[name]);
+        console.log(`   Auth mode: ${capabilities.auth.mode}`);
+        console.log(`   Features: ${enabled.sort().join(', ') || 'none'}`);
+        if (!(await client.supports('cloud'))) {
+            console.log('   Cloud models are disabled on this server');
+        }
+
         // List models
         console.log('\n2. Available Models:');
         const models = await client.listModels();
@@ -154,20 +196,20 @@ async function main() {
         const messages = [
             { role: 'user', content: 'Hello! Can you tell me a short joke?' }
         ];
-        const chatResponse = await client.chatCompletion(messages, 'llama2', 0.7);
+        const chatResponse = await client.chatCompletion(messages, null, 0.7);
         console.log(`   Response: ${chatResponse.choices[0].message.content}`);
 
         // Text completion
         console.log('\n4. Text Completion:');
         const prompt = 'The future of artificial intelligence is';
-        const completionResponse = await client.textCompletion(prompt, 'llama2', 0.8);
+        const completionResponse = await client.textCompletion(prompt, null, 0.8);
         console.log(`   Prompt: ${prompt}`);
         console.log(`   Completion: ${completionResponse.choices[0].message.content}`);
 
         // Embedding
         console.log('\n5. Text Embedding:');
         const text = 'This is a sample text for embedding generation';
-        const embeddingResponse = await client.generateEmbedding(text, 'llama2');
+        const embeddingResponse = await client.generateEmbedding(text);
         const embeddingVector = embeddingResponse.data[0].embedding;
         console.log(`   Text: ${text}`);
         console.log(`   Embedding dimensions: ${embeddingVector.length}`);
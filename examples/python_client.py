@@ -7,13 +7,30 @@ Demonstrates how to interact with the API endpoints
 import requests
 import json
 import time
-from typing import List, Dict, Any
+from typing import List, Dict, Any, Optional
 
 class LlamaAPIClient:
     def __init__(self, base_url: str = "http://localhost:8080"):
         self.base_url = base_url.rstrip('/')
         self.api_base = f"{self.base_url}/api/v1"
+        self._capabilities: Optional[Dict[str, Any]] = None
         
+    def capabilities(self, refresh: bool = False) -> Dict[str, Any]:
+        """Discover the server's features, models, streaming formats and auth mode"""
+        if self._capabilities is None or refresh:
+            response = requests.get(f"{self.api_base}/capabilities")
+            response.raise_for_status()
+            self._capabilities = response.json()
+        return self._capabilities
+    
+    def supports(self, feature: str) -> bool:
+        """Check whether the server has a feature such as "cloud" enabled"""
+        return self.capabilities().get("features", {}).get(feature, False)
+    
+    def default_model(self) -> str:
+        """Model to use when none is given, as configured on the server"""
+        return self.capabilities().get("default_model") or "llama2"
+    
     def health_check(self) -> Dict[str, Any]:
         """Check API health status"""
         response = requests.get(f"{self.api_base}/health")
@@ -27,13 +44,13 @@ class LlamaAPIClient:
         return response.json()
     
     def chat_completion(self, messages: List[Dict[str, str]], 
-                       model: str = "llama2", 
+                       model: Optional[str] = None, 
                        temperature: float = 0.7,
                        max_tokens: int = 100) -> Dict[str, Any]:
         """Send chat completion request"""
         payload = {
             "messages": messages,
-            "model": model,
+            "model": model or self.default_model(),
             "temperature": temperature,
             "max_tokens": max_tokens
         }
@@ -47,13 +64,13 @@ class LlamaAPIClient:
         return response.json()
     
     def text_completion(self, prompt: str, 
-                       model: str = "llama2",
+                       model: Optional[str] = None,
                        temperature: float = 0.8,
                        max_tokens: int = 50) -> Dict[str, Any]:
         """Send text completion request"""
         payload = {
             "prompt": prompt,
-            "model": model,
+            "model": model or self.default_model(),
             "temperature": temperature,
             "max_tokens": max_tokens
         }
@@ -66,11 +83,11 @@ class LlamaAPIClient:
         response.raise_for_status()
         return response.json()
     
-    def generate_embedding(self, text: str, model: str = "llama2") -> Dict[str, Any]:
+    def generate_embedding(self, text: str, model: Optional[str] = None) -> Dict[str, Any]:
         """Generate text embedding"""
         payload = {
             "input": text,
-            "model": model
+            "model": model or self.default_model()
         }
         
         response = requests.post(
@@ -96,6 +113,14 @@ def main():
         print(f"   Status: {health['status']}")
         print(f"   Message: {health['message']}")
         
+        # Discover what this server offers instead of assuming a build
+        capabilities = client.capabilities()
+        enabled = [name for name, on in capabilities.get('features', {}).items() if on]
+        print(f"   Auth mode: {capabilities['auth']['mode']}")
+        print(f"   Features: {', '.join(sorted(enabled)) or 'none'}")
+        if not client.supports("cloud"):
+            print("   Cloud models are disabled on this server")
+        
         # List models
         print("\n2. Available Models:")
         models = client.list_models()
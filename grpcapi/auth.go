@@ -0,0 +1,154 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// Protections are the checks the HTTP API applies to generation requests. Every RPC generates,
+// so every call goes through them.
+type Protections struct {
+	Secret      string                      // Calls must carry an access token when set
+	IPPolicy    *middleware.IPPolicy        // nil admits every address
+	Maintenance *middleware.MaintenanceMode // nil never refuses
+	RateLimiter middleware.RateLimiter      // nil disables rate limiting
+	Quota       middleware.TokenQuota       // nil disables the daily token quota
+	DailyTokens int
+	Limits      config.LimitsConfig
+}
+
+// admit applies protections to a call and returns ctx with the caller usage is recorded against,
+// its token recorder and its generation limits. Callers are identified as on the HTTP API:
+// "user:<subject>" with an access token, "ip:<address>" otherwise.
+func admit(ctx context.Context, protections Protections) (context.Context, error) {
+	host := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		host = p.Addr.String()
+		if address, _, err := net.SplitHostPort(host); err == nil {
+			host = address
+		}
+	}
+	if protections.IPPolicy != nil && !protections.IPPolicy.Admits(host) {
+		return nil, status.Errorf(codes.PermissionDenied, "client IP %s is not allowed", host)
+	}
+
+	caller, role, err := authenticate(ctx, protections.Secret, host)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []middleware.AdmissionCheck
+	if protections.Maintenance != nil {
+		checks = append(checks, protections.Maintenance.Check)
+	}
+	if protections.RateLimiter != nil {
+		checks = append(checks, middleware.RateCheck(protections.RateLimiter, caller))
+	}
+	if protections.Quota != nil && protections.DailyTokens > 0 {
+		checks = append(checks, middleware.QuotaCheck(protections.Quota, protections.DailyTokens, caller))
+	}
+	for _, check := range checks {
+		if err := check(ctx); err != nil {
+			return nil, admissionStatus(err)
+		}
+	}
+
+	ctx = services.WithCaller(ctx, caller)
+	if quota := protections.Quota; quota != nil && protections.DailyTokens > 0 {
+		storeCtx := context.WithoutCancel(ctx)
+		ctx = services.WithTokenRecorder(ctx, func(tokens int) {
+			if err := quota.Add(storeCtx, caller, tokens); err != nil {
+				slog.Error("Failed to record tokens", "tokens", tokens, "client", caller, "error", err)
+			}
+		})
+	}
+	return context.WithValue(ctx, limitsKey{}, middleware.LimitsFor(protections.Limits, caller, role)), nil
+}
+
+// authenticate checks the access token in the call's authorization metadata when secret is set
+// and returns the caller and its role. Read-only tokens are refused.
+func authenticate(ctx context.Context, secret, host string) (string, string, error) {
+	if secret == "" {
+		return "ip:" + host, "", nil
+	}
+
+	var token string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		token, _ = strings.CutPrefix(values[0], "Bearer ")
+	}
+	if token == "" {
+		return "", "", status.Error(codes.Unauthenticated, "access token required")
+	}
+
+	subject, role, err := middleware.VerifyToken(secret, token)
+	if err != nil {
+		return "", "", status.Error(codes.Unauthenticated, "invalid access token: "+err.Error())
+	}
+	if role == middleware.RoleReadOnly {
+		return "", "", status.Error(codes.PermissionDenied, "read-only access")
+	}
+	return "user:" + subject, role, nil
+}
+
+// admissionStatus maps a refusal by an admission check to the gRPC status closest to its HTTP status
+func admissionStatus(err error) error {
+	var refusal *middleware.AdmissionError
+	if errors.As(err, &refusal) && refusal.Status == http.StatusServiceUnavailable {
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.ResourceExhausted, err.Error())
+}
+
+// limitsKey is the context key of the caller's generation limits, set by admit
+type limitsKey struct{}
+
+// generationLimits returns the limits of the caller of ctx, or none
+func generationLimits(ctx context.Context) models.GenerationLimits {
+	limits, _ := ctx.Value(limitsKey{}).(models.GenerationLimits)
+	return limits
+}
+
+func unaryAdmit(protections Protections) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := admit(ctx, protections)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func streamAdmit(protections Protections) grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := admit(stream.Context(), protections)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &admittedStream{ServerStream: stream, ctx: ctx})
+	}
+}
+
+// admittedStream carries the context added by admit to the stream's handler
+type admittedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *admittedStream) Context() context.Context {
+	return s.ctx
+}
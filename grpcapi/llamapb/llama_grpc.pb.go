@@ -0,0 +1,255 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: grpcapi/llamapb/llama.proto
+
+package llamapb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Llama_Chat_FullMethodName       = "/llama.v1.Llama/Chat"
+	Llama_StreamChat_FullMethodName = "/llama.v1.Llama/StreamChat"
+	Llama_Completion_FullMethodName = "/llama.v1.Llama/Completion"
+	Llama_Embedding_FullMethodName  = "/llama.v1.Llama/Embedding"
+)
+
+// LlamaClient is the client API for Llama service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Llama serves chat, completion and embeddings to internal services, through the same service as
+// the HTTP API. Send an access token as "authorization: Bearer <token>" metadata when JWT_SECRET
+// is set.
+type LlamaClient interface {
+	// Chat returns the assistant's reply to a conversation
+	Chat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (*ChatResponse, error)
+	// StreamChat sends the reply as it is generated
+	StreamChat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatChunk], error)
+	// Completion continues a prompt
+	Completion(ctx context.Context, in *CompletionRequest, opts ...grpc.CallOption) (*CompletionResponse, error)
+	// Embedding returns the embedding vector of a text
+	Embedding(ctx context.Context, in *EmbeddingRequest, opts ...grpc.CallOption) (*EmbeddingResponse, error)
+}
+
+type llamaClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLlamaClient(cc grpc.ClientConnInterface) LlamaClient {
+	return &llamaClient{cc}
+}
+
+func (c *llamaClient) Chat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (*ChatResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ChatResponse)
+	err := c.cc.Invoke(ctx, Llama_Chat_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *llamaClient) StreamChat(ctx context.Context, in *ChatRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ChatChunk], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Llama_ServiceDesc.Streams[0], Llama_StreamChat_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ChatRequest, ChatChunk]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Llama_StreamChatClient = grpc.ServerStreamingClient[ChatChunk]
+
+func (c *llamaClient) Completion(ctx context.Context, in *CompletionRequest, opts ...grpc.CallOption) (*CompletionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CompletionResponse)
+	err := c.cc.Invoke(ctx, Llama_Completion_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *llamaClient) Embedding(ctx context.Context, in *EmbeddingRequest, opts ...grpc.CallOption) (*EmbeddingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EmbeddingResponse)
+	err := c.cc.Invoke(ctx, Llama_Embedding_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LlamaServer is the server API for Llama service.
+// All implementations must embed UnimplementedLlamaServer
+// for forward compatibility.
+//
+// Llama serves chat, completion and embeddings to internal services, through the same service as
+// the HTTP API. Send an access token as "authorization: Bearer <token>" metadata when JWT_SECRET
+// is set.
+type LlamaServer interface {
+	// Chat returns the assistant's reply to a conversation
+	Chat(context.Context, *ChatRequest) (*ChatResponse, error)
+	// StreamChat sends the reply as it is generated
+	StreamChat(*ChatRequest, grpc.ServerStreamingServer[ChatChunk]) error
+	// Completion continues a prompt
+	Completion(context.Context, *CompletionRequest) (*CompletionResponse, error)
+	// Embedding returns the embedding vector of a text
+	Embedding(context.Context, *EmbeddingRequest) (*EmbeddingResponse, error)
+	mustEmbedUnimplementedLlamaServer()
+}
+
+// UnimplementedLlamaServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLlamaServer struct{}
+
+func (UnimplementedLlamaServer) Chat(context.Context, *ChatRequest) (*ChatResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Chat not implemented")
+}
+func (UnimplementedLlamaServer) StreamChat(*ChatRequest, grpc.ServerStreamingServer[ChatChunk]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamChat not implemented")
+}
+func (UnimplementedLlamaServer) Completion(context.Context, *CompletionRequest) (*CompletionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Completion not implemented")
+}
+func (UnimplementedLlamaServer) Embedding(context.Context, *EmbeddingRequest) (*EmbeddingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Embedding not implemented")
+}
+func (UnimplementedLlamaServer) mustEmbedUnimplementedLlamaServer() {}
+func (UnimplementedLlamaServer) testEmbeddedByValue()               {}
+
+// UnsafeLlamaServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LlamaServer will
+// result in compilation errors.
+type UnsafeLlamaServer interface {
+	mustEmbedUnimplementedLlamaServer()
+}
+
+func RegisterLlamaServer(s grpc.ServiceRegistrar, srv LlamaServer) {
+	// If the following call pancis, it indicates UnimplementedLlamaServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Llama_ServiceDesc, srv)
+}
+
+func _Llama_Chat_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ChatRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LlamaServer).Chat(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Llama_Chat_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LlamaServer).Chat(ctx, req.(*ChatRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Llama_StreamChat_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ChatRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LlamaServer).StreamChat(m, &grpc.GenericServerStream[ChatRequest, ChatChunk]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Llama_StreamChatServer = grpc.ServerStreamingServer[ChatChunk]
+
+func _Llama_Completion_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CompletionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LlamaServer).Completion(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Llama_Completion_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LlamaServer).Completion(ctx, req.(*CompletionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Llama_Embedding_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmbeddingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LlamaServer).Embedding(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Llama_Embedding_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LlamaServer).Embedding(ctx, req.(*EmbeddingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Llama_ServiceDesc is the grpc.ServiceDesc for Llama service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Llama_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "llama.v1.Llama",
+	HandlerType: (*LlamaServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Chat",
+			Handler:    _Llama_Chat_Handler,
+		},
+		{
+			MethodName: "Completion",
+			Handler:    _Llama_Completion_Handler,
+		},
+		{
+			MethodName: "Embedding",
+			Handler:    _Llama_Embedding_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamChat",
+			Handler:       _Llama_StreamChat_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "grpcapi/llamapb/llama.proto",
+}
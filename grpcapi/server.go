@@ -0,0 +1,293 @@
+// Package grpcapi serves chat, completion and embeddings over gRPC for internal services, through
+// the same LlamaService as the HTTP API. The service is defined in llamapb/llama.proto.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/grpcapi/llamapb"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements the Llama gRPC service
+type Server struct {
+	llamapb.UnimplementedLlamaServer
+	llamaService services.LlamaServiceInterface
+}
+
+// NewServer creates the Llama gRPC service backed by llamaService
+func NewServer(llamaService services.LlamaServiceInterface) *Server {
+	return &Server{llamaService: llamaService}
+}
+
+// NewGRPCServer creates a gRPC server with the Llama service registered. Every call goes
+// through protections; options such as TLS credentials are passed to grpc.NewServer.
+func NewGRPCServer(llamaService services.LlamaServiceInterface, protections Protections, options ...grpc.ServerOption) *grpc.Server {
+	server := grpc.NewServer(append(options,
+		grpc.ChainUnaryInterceptor(unaryAdmit(protections)),
+		grpc.ChainStreamInterceptor(streamAdmit(protections)),
+	)...)
+	llamapb.RegisterLlamaServer(server, NewServer(llamaService))
+	return server
+}
+
+// Chat returns the assistant's reply to a conversation
+func (s *Server) Chat(ctx context.Context, request *llamapb.ChatRequest) (*llamapb.ChatResponse, error) {
+	chatRequest, err := chatRequest(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.llamaService.Chat(ctx, chatRequest)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	reply := &llamapb.ChatResponse{
+		Id:      response.ID,
+		Created: response.Created,
+		Model:   response.Model,
+		Usage:   usage(response.Usage),
+		Backend: response.Backend,
+	}
+	if len(response.Choices) > 0 {
+		reply.Message = &llamapb.Message{Role: response.Choices[0].Message.Role, Content: response.Choices[0].Message.Content}
+	}
+	return reply, nil
+}
+
+// StreamChat sends the reply as it is generated. A failure after the first chunk ends the stream
+// with an error status.
+func (s *Server) StreamChat(request *llamapb.ChatRequest, stream grpc.ServerStreamingServer[llamapb.ChatChunk]) error {
+	chatRequest, err := chatRequest(stream.Context(), request)
+	if err != nil {
+		return err
+	}
+
+	responseChan := make(chan string)
+	services.Go("grpc_stream_chat", func(context.Context) {
+		s.llamaService.StreamChat(stream.Context(), chatRequest, responseChan)
+	})
+
+	var streamErr error
+	for text := range responseChan {
+		if streamErr != nil {
+			// Drain the channel so the service can finish
+			continue
+		}
+		if message, ok := strings.CutPrefix(text, "Error: "); ok {
+			streamErr = status.Error(codes.Unavailable, message)
+			continue
+		}
+		streamErr = stream.Send(&llamapb.ChatChunk{Content: text})
+	}
+	return streamErr
+}
+
+// Completion continues a prompt
+func (s *Server) Completion(ctx context.Context, request *llamapb.CompletionRequest) (*llamapb.CompletionResponse, error) {
+	if request.GetPrompt() == "" {
+		return nil, status.Error(codes.InvalidArgument, "prompt is required")
+	}
+
+	completionRequest := models.CompletionRequest{
+		Prompt:      request.GetPrompt(),
+		Model:       request.GetModel(),
+		Temperature: request.GetTemperature(),
+		MaxTokens:   int(request.GetMaxTokens()),
+		Stop:        request.GetStop(),
+		Options:     options(request.GetOptions()),
+	}
+	limits := generationLimits(ctx)
+	if err := limitError(middleware.PromptLimitError(limits, completionRequest.Prompt), maxTokensError(completionRequest.MaxTokens)); err != nil {
+		return nil, err
+	}
+	middleware.ClampMaxTokens(limits, &completionRequest.MaxTokens, completionRequest.Options)
+
+	response, err := s.llamaService.Completion(ctx, completionRequest)
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	reply := &llamapb.CompletionResponse{
+		Id:      response.ID,
+		Created: response.Created,
+		Model:   response.Model,
+		Usage:   usage(response.Usage),
+		Backend: response.Backend,
+	}
+	if len(response.Choices) > 0 {
+		reply.Text = response.Choices[0].Message.Content
+	}
+	return reply, nil
+}
+
+// Embedding returns the embedding vector of a text
+func (s *Server) Embedding(ctx context.Context, request *llamapb.EmbeddingRequest) (*llamapb.EmbeddingResponse, error) {
+	if request.GetInput() == "" {
+		return nil, status.Error(codes.InvalidArgument, "input is required")
+	}
+	if err := limitError(middleware.EmbeddingLimitError(generationLimits(ctx), request.GetInput())); err != nil {
+		return nil, err
+	}
+
+	response, err := s.llamaService.Embedding(ctx, models.EmbeddingRequest{
+		Input:      request.GetInput(),
+		Model:      request.GetModel(),
+		Dimensions: int(request.GetDimensions()),
+		Normalize:  request.GetNormalize(),
+	})
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	reply := &llamapb.EmbeddingResponse{Model: response.Model, Usage: usage(response.Usage)}
+	if len(response.Data) > 0 {
+		reply.Embedding = response.Data[0].Embedding
+	}
+	return reply, nil
+}
+
+// chatRequest converts a gRPC chat request, rejecting one without messages or breaking the
+// caller's limits, and lowers its max_tokens to the caller's cap
+func chatRequest(ctx context.Context, request *llamapb.ChatRequest) (models.ChatRequest, error) {
+	if len(request.GetMessages()) == 0 {
+		return models.ChatRequest{}, status.Error(codes.InvalidArgument, "at least one message is required")
+	}
+
+	chatRequest := models.ChatRequest{
+		Model:       request.GetModel(),
+		Temperature: request.GetTemperature(),
+		MaxTokens:   int(request.GetMaxTokens()),
+		Options:     options(request.GetOptions()),
+		Preset:      request.GetPreset(),
+	}
+	var contents []string
+	for _, message := range request.GetMessages() {
+		chatRequest.Messages = append(chatRequest.Messages, models.Message{Role: message.GetRole(), Content: message.GetContent()})
+		contents = append(contents, message.GetContent())
+	}
+
+	limits := generationLimits(ctx)
+	err := limitError(
+		middleware.MessageLimitError(limits, len(chatRequest.Messages)),
+		middleware.PromptLimitError(limits, contents...),
+		maxTokensError(chatRequest.MaxTokens),
+	)
+	if err != nil {
+		return models.ChatRequest{}, err
+	}
+	middleware.ClampMaxTokens(limits, &chatRequest.MaxTokens, chatRequest.Options)
+
+	// The HTTP API takes the format as JSON, either the string "json" or a schema
+	if format := request.GetFormat(); format != "" {
+		if format == "json" {
+			chatRequest.Format = json.RawMessage(`"json"`)
+		} else if json.Valid([]byte(format)) {
+			chatRequest.Format = json.RawMessage(format)
+		} else {
+			return models.ChatRequest{}, status.Error(codes.InvalidArgument, `format must be "json" or a JSON schema`)
+		}
+	}
+	return chatRequest, nil
+}
+
+// limitError reports the first of errs, errors of requests breaking the caller's limits, as InvalidArgument
+func limitError(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return status.Error(codes.InvalidArgument, "request exceeds limits: "+err.Error())
+		}
+	}
+	return nil
+}
+
+// maxTokensError returns an error if a request asks for a negative answer length
+func maxTokensError(maxTokens int) error {
+	if maxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative, got %d", maxTokens)
+	}
+	return nil
+}
+
+func options(o *llamapb.Options) *models.Options {
+	if o == nil {
+		return nil
+	}
+	return &models.Options{
+		Temperature:      o.Temperature,
+		TopP:             o.TopP,
+		TopK:             optionalInt(o.TopK),
+		MinP:             o.MinP,
+		Seed:             optionalInt(o.Seed),
+		Stop:             o.Stop,
+		RepeatPenalty:    o.RepeatPenalty,
+		RepeatLastN:      optionalInt(o.RepeatLastN),
+		PresencePenalty:  o.PresencePenalty,
+		FrequencyPenalty: o.FrequencyPenalty,
+		NumCtx:           optionalInt(o.NumCtx),
+		NumPredict:       optionalInt(o.NumPredict),
+	}
+}
+
+func optionalInt(value *int32) *int {
+	if value == nil {
+		return nil
+	}
+	converted := int(*value)
+	return &converted
+}
+
+func usage(u models.Usage) *llamapb.Usage {
+	return &llamapb.Usage{
+		PromptTokens:     int32(u.PromptTokens),
+		CompletionTokens: int32(u.CompletionTokens),
+		TotalTokens:      int32(u.TotalTokens),
+	}
+}
+
+// statusError maps a service error to the gRPC status closest to the HTTP API's response
+func statusError(err error) error {
+	var (
+		timeoutErr    *services.GenerationTimeoutError
+		budgetErr     *services.RequestBudgetError
+		validationErr *services.SchemaValidationError
+		upstreamErr   *services.UpstreamError
+		queueErr      *services.QueueError
+		moderationErr *services.ModerationError
+	)
+	switch {
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.As(err, &timeoutErr), errors.As(err, &budgetErr), errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, services.ErrPresetNotFound), errors.Is(err, services.ErrInvalidFormat), errors.As(err, &moderationErr):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.As(err, &validationErr):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.As(err, &queueErr):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.As(err, &upstreamErr):
+		switch upstreamErr.StatusCode {
+		case http.StatusNotFound:
+			return status.Error(codes.NotFound, err.Error())
+		case http.StatusBadRequest:
+			return status.Error(codes.InvalidArgument, err.Error())
+		case http.StatusTooManyRequests:
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		return status.Error(codes.Unavailable, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
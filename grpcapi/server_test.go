@@ -0,0 +1,218 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/grpcapi/llamapb"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// fakeService answers chat, streaming chat and embeddings; other methods are not called
+type fakeService struct {
+	services.LlamaServiceInterface
+	chatRequest models.ChatRequest
+	chatErr     error
+	chunks      []string
+}
+
+func (f *fakeService) Chat(ctx context.Context, request models.ChatRequest) (*models.ChatResponse, error) {
+	f.chatRequest = request
+	if f.chatErr != nil {
+		return nil, f.chatErr
+	}
+	return &models.ChatResponse{
+		ID:      "chatcmpl-1",
+		Model:   "llama2",
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Hello"}}},
+		Usage:   models.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4},
+		Backend: services.BackendLocal,
+	}, nil
+}
+
+func (f *fakeService) StreamChat(ctx context.Context, request models.ChatRequest, responseChan chan<- string) {
+	defer close(responseChan)
+	for _, chunk := range f.chunks {
+		responseChan <- chunk
+	}
+}
+
+func (f *fakeService) Embedding(ctx context.Context, request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
+	return &models.EmbeddingResponse{Model: "nomic-embed-text", Data: []models.Embedding{{Embedding: []float64{0.5, 0.5}}}}, nil
+}
+
+// dial starts a gRPC server over an in-memory listener and returns a client connected to it
+func dial(t *testing.T, service services.LlamaServiceInterface, protections Protections) llamapb.LlamaClient {
+	listener := bufconn.Listen(1 << 20)
+	server := NewGRPCServer(service, protections)
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return llamapb.NewLlamaClient(conn)
+}
+
+func TestChat(t *testing.T) {
+	service := &fakeService{}
+	client := dial(t, service, Protections{})
+
+	temperature := 0.2
+	response, err := client.Chat(context.Background(), &llamapb.ChatRequest{
+		Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}},
+		Options:  &llamapb.Options{Temperature: &temperature},
+		Format:   "json",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", response.GetMessage().GetContent())
+	assert.Equal(t, int32(4), response.GetUsage().GetTotalTokens())
+	assert.Equal(t, "local", response.GetBackend())
+	assert.Equal(t, []models.Message{{Role: "user", Content: "Hi"}}, service.chatRequest.Messages)
+	assert.Equal(t, &temperature, service.chatRequest.Options.Temperature)
+	assert.Equal(t, `"json"`, string(service.chatRequest.Format))
+}
+
+func TestChat_Errors(t *testing.T) {
+	service := &fakeService{chatErr: &services.UpstreamError{Backend: services.BackendLocal, StatusCode: 404}}
+	client := dial(t, service, Protections{})
+
+	_, err := client.Chat(context.Background(), &llamapb.ChatRequest{})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.Chat(context.Background(), &llamapb.ChatRequest{Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}}})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestStreamChat(t *testing.T) {
+	client := dial(t, &fakeService{chunks: []string{"Hel", "lo", "Error: connection reset"}}, Protections{})
+
+	stream, err := client.StreamChat(context.Background(), &llamapb.ChatRequest{Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}}})
+	assert.NoError(t, err)
+
+	var content string
+	for {
+		chunk, err := stream.Recv()
+		if err != nil {
+			assert.NotEqual(t, io.EOF, err)
+			assert.Equal(t, codes.Unavailable, status.Code(err))
+			break
+		}
+		content += chunk.GetContent()
+	}
+	assert.Equal(t, "Hello", content)
+}
+
+func TestEmbedding(t *testing.T) {
+	client := dial(t, &fakeService{}, Protections{})
+
+	response, err := client.Embedding(context.Background(), &llamapb.EmbeddingRequest{Input: "text"})
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{0.5, 0.5}, response.GetEmbedding())
+}
+
+func TestAuthentication(t *testing.T) {
+	client := dial(t, &fakeService{}, Protections{Secret: "secret"})
+	request := &llamapb.ChatRequest{Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}}}
+
+	_, err := client.Chat(context.Background(), request)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	readOnly, _ := middleware.IssueToken("secret", "bob", middleware.RoleReadOnly, time.Hour)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+readOnly)
+	_, err = client.Chat(ctx, request)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	token, _ := middleware.IssueToken("secret", "alice", middleware.RoleUser, time.Hour)
+	ctx = metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer "+token)
+	_, err = client.Chat(ctx, request)
+	assert.NoError(t, err)
+
+	stream, err := client.StreamChat(context.Background(), request)
+	assert.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
+
+func TestLimits(t *testing.T) {
+	service := &fakeService{}
+	client := dial(t, service, Protections{Limits: config.LimitsConfig{MaxTokens: 100, MaxMessages: 2, MaxEmbeddingChars: 4}})
+
+	// A request without max_tokens gets the cap, as do its options
+	numPredict := int32(500)
+	_, err := client.Chat(context.Background(), &llamapb.ChatRequest{
+		Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}},
+		Options:  &llamapb.Options{NumPredict: &numPredict},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 100, service.chatRequest.MaxTokens)
+	assert.Equal(t, 100, *service.chatRequest.Options.NumPredict)
+
+	_, err = client.Chat(context.Background(), &llamapb.ChatRequest{
+		Messages: []*llamapb.Message{{Role: "user", Content: "1"}, {Role: "assistant", Content: "2"}, {Role: "user", Content: "3"}},
+	})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Contains(t, err.Error(), "the request has 3 messages, the limit is 2")
+
+	_, err = client.Embedding(context.Background(), &llamapb.EmbeddingRequest{Input: "too long"})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestAdmission(t *testing.T) {
+	request := &llamapb.ChatRequest{Messages: []*llamapb.Message{{Role: "user", Content: "Hi"}}}
+
+	maintenance := middleware.NewMaintenanceMode()
+	client := dial(t, &fakeService{}, Protections{Maintenance: maintenance})
+	maintenance.Enable("Upgrading models", nil)
+	_, err := client.Chat(context.Background(), request)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	maintenance.Disable()
+	_, err = client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+
+	client = dial(t, &fakeService{}, Protections{RateLimiter: middleware.NewTokenBucketLimiter(1, time.Hour)})
+	_, err = client.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	_, err = client.Chat(context.Background(), request)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestAdmit_CallerKey(t *testing.T) {
+	quota := middleware.NewMemoryQuota()
+	quota.Add(context.Background(), "ip:10.0.0.1", 100)
+	policy, _ := middleware.NewIPPolicy(nil, []string{"10.0.0.2"})
+	protections := Protections{Quota: quota, DailyTokens: 100, IPPolicy: policy}
+	from := func(ip string, port int) context.Context {
+		return peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(ip), Port: port}})
+	}
+
+	// Every connection of a client counts against the same budget, as on the HTTP API
+	for _, port := range []int{5000, 6000} {
+		_, err := admit(from("10.0.0.1", port), protections)
+		assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	}
+	_, err := admit(from("10.0.0.3", 5000), protections)
+	assert.NoError(t, err)
+
+	_, err = admit(from("10.0.0.2", 5000), protections)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
@@ -0,0 +1,239 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxProfileSize bounds the configuration profiles accepted by ImportConfig
+const maxProfileSize = 1 << 20
+
+type AdminHandler struct {
+	maintenance  *middleware.MaintenanceMode
+	llamaService services.LlamaServiceInterface
+}
+
+func NewAdminHandler(maintenance *middleware.MaintenanceMode, llamaService services.LlamaServiceInterface) *AdminHandler {
+	return &AdminHandler{
+		maintenance:  maintenance,
+		llamaService: llamaService,
+	}
+}
+
+// GetMaintenance returns the current maintenance window
+func (h *AdminHandler) GetMaintenance(c *gin.Context) {
+	c.JSON(http.StatusOK, h.maintenance.Status())
+}
+
+// EnableMaintenance starts rejecting generation requests with 503
+func (h *AdminHandler) EnableMaintenance(c *gin.Context) {
+	var request models.MaintenanceRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	var until *time.Time
+	if request.DurationMinutes > 0 {
+		end := time.Now().Add(time.Duration(request.DurationMinutes) * time.Minute)
+		until = &end
+	}
+
+	message := request.Message
+	if message == "" {
+		message = "The service is temporarily unavailable for maintenance"
+	}
+
+	h.maintenance.Enable(message, until)
+	c.JSON(http.StatusOK, h.maintenance.Status())
+}
+
+// DisableMaintenance resumes serving generation requests
+func (h *AdminHandler) DisableMaintenance(c *gin.Context) {
+	h.maintenance.Disable()
+	c.JSON(http.StatusOK, h.maintenance.Status())
+}
+
+// SwapModel moves an alias or the default model to a new model version without downtime
+func (h *AdminHandler) SwapModel(c *gin.Context) {
+	var request models.SwapModelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	response, err := h.llamaService.SwapModel(c.Request.Context(), request)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrSwapInProgress) {
+			status = http.StatusConflict
+		}
+		respondError(c, status, "Failed to swap model", err.Error())
+		return
+	}
+
+	// The previous model is still serving, report the failed step alongside the error status
+	if !response.Success {
+		c.JSON(http.StatusBadGateway, response)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetUpstreams reports the circuit state and recent health of each Ollama backend
+func (h *AdminHandler) GetUpstreams(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"upstreams": h.llamaService.Upstreams(),
+	})
+}
+
+// GetGoroutines reports the managed background goroutines by name, including recovered panics
+func (h *AdminHandler) GetGoroutines(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"goroutines": services.GoroutineStats(),
+	})
+}
+
+// defaultUsageLimit is the number of usage records returned when the query sets no limit
+const defaultUsageLimit = 100
+
+// GetUsage reports per-request usage records, filtered by day, model and caller key, with totals
+// overall, per model and per caller
+func (h *AdminHandler) GetUsage(c *gin.Context) {
+	var query models.UsageQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid usage query", err.Error())
+		return
+	}
+	if query.Day != "" {
+		if _, err := time.Parse(time.DateOnly, query.Day); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid day", "day must be a UTC date formatted as YYYY-MM-DD")
+			return
+		}
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultUsageLimit
+	}
+
+	list, err := h.llamaService.UsageRecords(c.Request.Context(), query)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPersistenceUnavailable) {
+			status = http.StatusServiceUnavailable
+		}
+		respondError(c, status, "Failed to load usage records", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
+
+// GetShadow reports shadow mode and the most recent production and shadow answers
+func (h *AdminHandler) GetShadow(c *gin.Context) {
+	c.JSON(http.StatusOK, h.llamaService.ShadowReport())
+}
+
+// ResetUpstream closes a backend's circuit so requests are sent to it again
+func (h *AdminHandler) ResetUpstream(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.llamaService.ResetUpstream(name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrUpstreamNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to reset upstream", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Circuit closed",
+		"name":    name,
+	})
+}
+
+// ExportConfig returns the effective configuration as a YAML profile with secrets redacted. With
+// ?format=json it lists each setting with its default and where its value came from instead.
+func (h *AdminHandler) ExportConfig(c *gin.Context) {
+	if c.Query("format") == "json" {
+		effective := models.EffectiveConfig{
+			Object:      "config",
+			ConfigFile:  config.LoadedFile(),
+			ProfileFile: config.ProfileFile(),
+			Settings:    []models.ConfigSetting{},
+		}
+		for _, setting := range config.Effective() {
+			effective.Settings = append(effective.Settings, models.ConfigSetting{
+				Name:    setting.Name,
+				Value:   setting.Value,
+				Default: setting.Default,
+				Source:  setting.Source,
+			})
+		}
+		c.JSON(http.StatusOK, effective)
+		return
+	}
+
+	profile, err := config.ExportProfile()
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to export configuration", err.Error())
+		return
+	}
+
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", profile)
+}
+
+// ImportConfig validates a YAML profile, and the configuration it makes merged with the current
+// settings, and saves it to the profile file, which is applied on the next restart.
+// With ?dry_run=true the profile is only validated.
+func (h *AdminHandler) ImportConfig(c *gin.Context) {
+	data, err := io.ReadAll(io.LimitReader(c.Request.Body, maxProfileSize))
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to read profile", err.Error())
+		return
+	}
+
+	profile, problems, err := config.ParseProfile(data)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid configuration profile", err.Error())
+		return
+	}
+	// Valid entries may still make an invalid configuration together with the current settings
+	if len(problems) == 0 {
+		problems = config.ValidateProfile(profile)
+	}
+	if len(problems) > 0 {
+		middleware.AbortWithError(c, http.StatusBadRequest, "Invalid configuration profile", "", gin.H{"problems": problems})
+		return
+	}
+
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{
+			"valid":    true,
+			"settings": len(profile),
+		})
+		return
+	}
+
+	file := config.ProfileFile()
+	if err := config.WriteProfile(file, profile); err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to save configuration profile", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":          "Profile saved, restart the server to apply it",
+		"file":             file,
+		"settings":         len(profile),
+		"restart_required": true,
+	})
+}
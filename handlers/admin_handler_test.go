@@ -0,0 +1,318 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupAdminRouter(handler *AdminHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	admin := router.Group("/api/v1/admin")
+	{
+		admin.GET("/maintenance", handler.GetMaintenance)
+		admin.PUT("/maintenance", handler.EnableMaintenance)
+		admin.DELETE("/maintenance", handler.DisableMaintenance)
+		admin.POST("/models/swap", handler.SwapModel)
+		admin.GET("/upstreams", handler.GetUpstreams)
+		admin.GET("/shadow", handler.GetShadow)
+		admin.GET("/goroutines", handler.GetGoroutines)
+		admin.GET("/config", handler.ExportConfig)
+		admin.PUT("/config", handler.ImportConfig)
+		admin.POST("/upstreams/:name/reset", handler.ResetUpstream)
+	}
+	router.GET("/api/v1/usage", handler.GetUsage)
+
+	return router
+}
+
+func TestMaintenance_Toggle(t *testing.T) {
+	maintenance := middleware.NewMaintenanceMode()
+	router := setupAdminRouter(NewAdminHandler(maintenance, new(MockLlamaService)))
+
+	body, _ := json.Marshal(map[string]interface{}{"message": "Upgrading models", "duration_minutes": 15})
+	req, _ := http.NewRequest("PUT", "/api/v1/admin/maintenance", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	status := maintenance.Status()
+	assert.True(t, status.Enabled)
+	assert.Equal(t, "Upgrading models", status.Message)
+	assert.NotNil(t, status.Until)
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/admin/maintenance", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.False(t, maintenance.Status().Enabled)
+}
+
+func TestMaintenance_InvalidDuration(t *testing.T) {
+	maintenance := middleware.NewMaintenanceMode()
+	router := setupAdminRouter(NewAdminHandler(maintenance, new(MockLlamaService)))
+
+	body, _ := json.Marshal(map[string]interface{}{"duration_minutes": -5})
+	req, _ := http.NewRequest("PUT", "/api/v1/admin/maintenance", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.False(t, maintenance.Status().Enabled)
+}
+
+func TestSwapModel_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	request := models.SwapModelRequest{Alias: "chat", Model: "llama3.3"}
+	mockService.On("SwapModel", request).Return(&models.SwapModelResponse{
+		Success:  true,
+		Alias:    "chat",
+		Previous: "llama3.2",
+		Current:  "llama3.3",
+		Steps:    []models.SwapStep{{Name: "pull", Status: "ok"}, {Name: "warm", Status: "ok"}},
+	}, nil)
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest("POST", "/api/v1/admin/models/swap", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.SwapModelResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "llama3.2", response.Previous)
+	mockService.AssertExpectations(t)
+}
+
+func TestSwapModel_RolledBack(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	mockService.On("SwapModel", mock.Anything).Return(&models.SwapModelResponse{
+		Success:    false,
+		RolledBack: true,
+		Previous:   "llama3.2",
+		Current:    "llama3.3",
+	}, nil)
+
+	body, _ := json.Marshal(map[string]string{"model": "llama3.3"})
+	req, _ := http.NewRequest("POST", "/api/v1/admin/models/swap", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadGateway, w.Code)
+	assert.Contains(t, w.Body.String(), `"rolled_back":true`)
+}
+
+func TestSwapModel_InProgress(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	mockService.On("SwapModel", mock.Anything).Return(nil, services.ErrSwapInProgress)
+
+	body, _ := json.Marshal(map[string]string{"model": "llama3.3"})
+	req, _ := http.NewRequest("POST", "/api/v1/admin/models/swap", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestUpstreams(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	mockService.On("Upstreams").Return([]models.UpstreamStatus{
+		{Name: "local", URL: "http://localhost:11434", State: "open", ConsecutiveFailures: 5, LastFailure: "status 503"},
+	})
+	mockService.On("ResetUpstream", "local").Return(nil)
+	mockService.On("ResetUpstream", "remote").Return(services.ErrUpstreamNotFound)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/upstreams", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"state":"open"`)
+
+	req, _ = http.NewRequest("POST", "/api/v1/admin/upstreams/local/reset", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("POST", "/api/v1/admin/upstreams/remote/reset", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestShadow(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	mockService.On("ShadowReport").Return(models.ShadowReport{
+		Model:    "phi3:mini",
+		Percent:  10,
+		Mirrored: 1,
+		Results: []models.ShadowResult{
+			{Model: "llama3.1:8b", Output: "Hello", ShadowModel: "phi3:mini", ShadowOutput: "Hi"},
+		},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/shadow", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"shadow_output":"Hi"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestGoroutines(t *testing.T) {
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), new(MockLlamaService)))
+
+	done := make(chan struct{})
+	services.Go("admin_test", func(context.Context) {
+		defer close(done)
+		panic("boom")
+	})
+	<-done
+
+	assert.Eventually(t, func() bool {
+		req, _ := http.NewRequest("GET", "/api/v1/admin/goroutines", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var response struct {
+			Goroutines map[string]models.GoroutineStats `json:"goroutines"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return w.Code == http.StatusOK && response.Goroutines["admin_test"] == models.GoroutineStats{Started: 1, Panics: 1}
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUsage(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), mockService))
+
+	list := &models.UsageRecordList{
+		Object: "list",
+		Totals: models.UsageTotals{Requests: 1, TotalTokens: 15},
+		Data:   []models.UsageRecord{{Caller: "user:alice", Model: "llama3.2", TotalTokens: 15}},
+	}
+	mockService.On("UsageRecords", models.UsageQuery{Day: "2025-03-10", Model: "llama3.2", Caller: "user:alice", Limit: 100}).Return(list, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/usage?day=2025-03-10&model=llama3.2&key=user:alice", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.UsageRecordList
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(15), response.Totals.TotalTokens)
+	assert.Equal(t, "user:alice", response.Data[0].Caller)
+
+	for _, query := range []string{"day=10-03-2025", "limit=5000"} {
+		req, _ = http.NewRequest("GET", "/api/v1/usage?"+query, nil)
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestConfigProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env.profile")
+	os.Setenv("CONFIG_PROFILE_FILE", path)
+	defer os.Unsetenv("CONFIG_PROFILE_FILE")
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), new(MockLlamaService)))
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/config", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "application/yaml")
+	assert.Contains(t, w.Body.String(), "LLAMA_MAX_QUEUED:")
+
+	req, _ = http.NewRequest("PUT", "/api/v1/admin/config", bytes.NewBufferString("LLAMA_TIMEOUT: soon\n"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "LLAMA_TIMEOUT: must be an integer")
+
+	// Entries that make an invalid configuration together are rejected, dry run or not
+	req, _ = http.NewRequest("PUT", "/api/v1/admin/config?dry_run=true", bytes.NewBufferString("LLAMA_TIMEOUT: 5\nLLAMA_CONNECT_TIMEOUT: 10\n"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "LLAMA_CONNECT_TIMEOUT (10s) is longer than the whole generation budget LLAMA_TIMEOUT (5s)")
+
+	req, _ = http.NewRequest("PUT", "/api/v1/admin/config", bytes.NewBufferString("LLAMA_BASE_URL: ollama\n"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.NoFileExists(t, path)
+
+	req, _ = http.NewRequest("PUT", "/api/v1/admin/config?dry_run=true", bytes.NewBufferString("LLAMA_TIMEOUT: 120\n"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoFileExists(t, path)
+
+	req, _ = http.NewRequest("PUT", "/api/v1/admin/config", bytes.NewBufferString("LLAMA_TIMEOUT: 120\n"))
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"restart_required":true`)
+	assert.FileExists(t, path)
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	os.Setenv("LLAMA_MAX_QUEUED", "32")
+	os.Setenv("ADMIN_TOKEN", "s3cret")
+	defer os.Unsetenv("LLAMA_MAX_QUEUED")
+	defer os.Unsetenv("ADMIN_TOKEN")
+	router := setupAdminRouter(NewAdminHandler(middleware.NewMaintenanceMode(), new(MockLlamaService)))
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/config?format=json", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var effective models.EffectiveConfig
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &effective))
+	assert.Equal(t, "config", effective.Object)
+	settings := map[string]models.ConfigSetting{}
+	for _, setting := range effective.Settings {
+		settings[setting.Name] = setting
+	}
+	assert.Equal(t, models.ConfigSetting{Name: "LLAMA_MAX_QUEUED", Value: "32", Default: "16", Source: "environment"}, settings["LLAMA_MAX_QUEUED"])
+	assert.Equal(t, models.ConfigSetting{Name: "PORT", Value: "8080", Default: "8080", Source: "default"}, settings["PORT"])
+	assert.Equal(t, config.Redacted, settings["ADMIN_TOKEN"].Value)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultAuditLimit is the number of entries returned when a query sets no limit
+const defaultAuditLimit = 100
+
+// AuditHandler serves the audit log of administrative actions
+type AuditHandler struct {
+	log services.AuditLog
+}
+
+// NewAuditHandler creates a handler querying log
+func NewAuditHandler(log services.AuditLog) *AuditHandler {
+	return &AuditHandler{log: log}
+}
+
+// GetAuditLog returns the recorded actions matching the query, newest first
+func (h *AuditHandler) GetAuditLog(c *gin.Context) {
+	var query models.AuditQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid audit query", err.Error())
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultAuditLimit
+	}
+
+	list, err := services.QueryAuditLog(c.Request.Context(), h.log, query)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to load audit log", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, list)
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAuditLog(t *testing.T) {
+	auditLog := services.NewMemoryAuditLog()
+	auditLog.Append(context.Background(), models.AuditEntry{Time: 100, Actor: "user:alice", Action: "model.pull"})
+	auditLog.Append(context.Background(), models.AuditEntry{Time: 200, Actor: "user:bob", Action: "cloud.signin"})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/admin/audit", NewAuditHandler(auditLog).GetAuditLog)
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/audit?actor=user:alice", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var list models.AuditEntryList
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+	assert.Equal(t, []models.AuditEntry{{Time: 100, Actor: "user:alice", Action: "model.pull"}}, list.Data)
+
+	req, _ = http.NewRequest("GET", "/api/v1/admin/audit?limit=5000", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// unknownUserHash is compared against for unknown users, so a login takes as long whether or not
+// the user exists
+var unknownUserHash, _ = bcrypt.GenerateFromPassword([]byte("unknown user"), bcrypt.DefaultCost)
+
+type AuthHandler struct {
+	config config.AuthConfig
+}
+
+func NewAuthHandler(cfg config.AuthConfig) *AuthHandler {
+	return &AuthHandler{config: cfg}
+}
+
+// Login exchanges a username and password for an access token carrying the user's role
+func (h *AuthHandler) Login(c *gin.Context) {
+	var request models.LoginRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	user, found := h.config.Users[request.Username]
+	hash := unknownUserHash
+	if found {
+		hash = []byte(user.PasswordHash)
+	}
+	if err := bcrypt.CompareHashAndPassword(hash, []byte(request.Password)); err != nil || !found {
+		respondError(c, http.StatusUnauthorized, "Invalid username or password", "")
+		return
+	}
+
+	ttl := time.Duration(h.config.TokenTTL) * time.Minute
+	token, err := middleware.IssueToken(h.config.JWTSecret, request.Username, user.Role, ttl)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to issue access token", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, models.LoginResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl.Seconds()),
+		Role:        user.Role,
+	})
+}
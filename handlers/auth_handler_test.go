@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func setupAuthRouter(t *testing.T) *gin.Engine {
+	hash, err := bcrypt.GenerateFromPassword([]byte("s3cret"), bcrypt.MinCost)
+	assert.NoError(t, err)
+
+	handler := NewAuthHandler(config.AuthConfig{
+		JWTSecret: "test-secret",
+		TokenTTL:  15,
+		Users: map[string]config.AuthUser{
+			"alice": {Role: middleware.RoleAdmin, PasswordHash: string(hash)},
+		},
+	})
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/api/v1/auth/login", handler.Login)
+	router.GET("/api/v1/admin/ping", middleware.JWTAuth("test-secret"), middleware.RequireRole(middleware.RoleAdmin), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func login(router *gin.Engine, username, password string) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(models.LoginRequest{Username: username, Password: password})
+	req, _ := http.NewRequest("POST", "/api/v1/auth/login", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLogin_Success(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	w := login(router, "alice", "s3cret")
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.LoginResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Bearer", response.TokenType)
+	assert.Equal(t, 900, response.ExpiresIn)
+	assert.Equal(t, middleware.RoleAdmin, response.Role)
+
+	// The token opens admin routes
+	req, _ := http.NewRequest("GET", "/api/v1/admin/ping", nil)
+	req.Header.Set("Authorization", "Bearer "+response.AccessToken)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestLogin_InvalidCredentials(t *testing.T) {
+	router := setupAuthRouter(t)
+
+	assert.Equal(t, http.StatusUnauthorized, login(router, "alice", "wrong").Code)
+	assert.Equal(t, http.StatusUnauthorized, login(router, "mallory", "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, login(router, "alice", "").Code)
+}
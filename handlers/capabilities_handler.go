@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+	"agent-ollama-gin/version"
+
+	"github.com/gin-gonic/gin"
+)
+
+// CapabilitiesHandler reports the features, models and formats the server offers
+type CapabilitiesHandler struct {
+	cfg          *config.Config
+	llamaService services.LlamaServiceInterface
+}
+
+// NewCapabilitiesHandler creates a handler describing the server configured by cfg
+func NewCapabilitiesHandler(cfg *config.Config, llamaService services.LlamaServiceInterface) *CapabilitiesHandler {
+	return &CapabilitiesHandler{
+		cfg:          cfg,
+		llamaService: llamaService,
+	}
+}
+
+// GetCapabilities describes the server. It succeeds when Ollama is unreachable, with an empty model
+// list and the reason in models_error, so clients can still discover everything else.
+func (h *CapabilitiesHandler) GetCapabilities(c *gin.Context) {
+	cfg := h.cfg
+
+	capabilities := models.Capabilities{
+		Version:      version.Version,
+		DefaultModel: h.llamaService.DefaultModel(),
+		Models:       []string{},
+		Features: map[string]bool{
+			"cloud":              cfg.Llama.CloudEnabled,
+			"cloud_failover":     cfg.Llama.FailoverToCloud,
+			"semantic_cache":     cfg.Llama.SemanticCacheModel != "",
+			"coalescing":         cfg.Llama.CoalesceRequests,
+			"fallback_chains":    len(cfg.Llama.FallbackChains) > 0,
+			"context_trimming":   cfg.Llama.ContextTrimming,
+			"conversations":      true,
+			"messages_api":       true,
+			"admin":              cfg.Server.AdminToken != "" || cfg.Auth.JWTSecret != "",
+			"rate_limit":         cfg.RateLimit.Requests > 0,
+			"token_quota":        cfg.RateLimit.DailyTokens > 0,
+			"grpc":               cfg.Server.GRPCPort != "",
+			"openai_provider":    cfg.Providers.OpenAIAPIKey != "",
+			"anthropic_provider": cfg.Providers.AnthropicAPIKey != "",
+			"moderation":         cfg.Hooks.ModerationPolicyFile != "",
+		},
+		Streaming: models.StreamingCapabilities{
+			Schemas:       models.StreamSchemas,
+			DefaultSchema: cfg.Server.StreamSchema,
+			Compression:   cfg.Server.StreamCompression,
+		},
+		Auth: models.AuthCapabilities{Mode: "none", Admin: "disabled"},
+		Limits: models.LimitCapabilities{
+			MaxTokens:                cfg.Limits.MaxTokens,
+			MaxMessages:              cfg.Limits.MaxMessages,
+			MaxPromptChars:           cfg.Limits.MaxPromptChars,
+			MaxEmbeddingChars:        cfg.Limits.MaxEmbeddingChars,
+			MaxBodyBytes:             cfg.Limits.MaxBodyBytes,
+			MaxRequestTimeoutSeconds: cfg.Server.MaxRequestTimeout,
+		},
+	}
+
+	switch {
+	case cfg.Auth.JWTSecret != "":
+		capabilities.Auth = models.AuthCapabilities{Mode: "jwt", Admin: "role"}
+	case cfg.Server.AdminToken != "":
+		capabilities.Auth.Admin = "token"
+	}
+
+	available, err := h.llamaService.ListModels()
+	if err != nil {
+		capabilities.ModelsError = err.Error()
+	}
+	for _, model := range available {
+		capabilities.Models = append(capabilities.Models, model.ID)
+	}
+
+	c.JSON(http.StatusOK, capabilities)
+}
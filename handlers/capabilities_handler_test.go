@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func getCapabilities(t *testing.T, cfg *config.Config, mockService *MockLlamaService) models.Capabilities {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/capabilities", NewCapabilitiesHandler(cfg, mockService).GetCapabilities)
+
+	req, _ := http.NewRequest("GET", "/api/v1/capabilities", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var capabilities models.Capabilities
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &capabilities))
+	return capabilities
+}
+
+func TestCapabilities(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Llama.DefaultModel = "llama3.2"
+	cfg.Llama.CloudEnabled = true
+	cfg.Server.StreamSchema = models.StreamSchemaV2
+	cfg.Server.AdminToken = "admin-secret"
+	cfg.Limits.MaxTokens = 2048
+
+	// The default model was swapped since startup
+	mockService := new(MockLlamaService)
+	mockService.On("ListModels").Return([]models.Model{{ID: "llama3.2"}, {ID: "mistral"}}, nil)
+	mockService.On("DefaultModel").Return("mistral")
+
+	capabilities := getCapabilities(t, cfg, mockService)
+	assert.Equal(t, "mistral", capabilities.DefaultModel)
+	assert.Equal(t, []string{"llama3.2", "mistral"}, capabilities.Models)
+	assert.Empty(t, capabilities.ModelsError)
+	assert.True(t, capabilities.Features["cloud"])
+	assert.True(t, capabilities.Features["admin"])
+	assert.False(t, capabilities.Features["rate_limit"])
+	assert.Equal(t, models.StreamSchemas, capabilities.Streaming.Schemas)
+	assert.Equal(t, models.StreamSchemaV2, capabilities.Streaming.DefaultSchema)
+	assert.Equal(t, models.AuthCapabilities{Mode: "none", Admin: "token"}, capabilities.Auth)
+	assert.Equal(t, 2048, capabilities.Limits.MaxTokens)
+}
+
+func TestCapabilities_OllamaUnavailable(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Auth.JWTSecret = "jwt-secret"
+
+	mockService := new(MockLlamaService)
+	mockService.On("ListModels").Return(nil, errors.New("connection refused"))
+	mockService.On("DefaultModel").Return("llama3.2")
+
+	capabilities := getCapabilities(t, cfg, mockService)
+	assert.Equal(t, []string{}, capabilities.Models)
+	assert.Equal(t, "connection refused", capabilities.ModelsError)
+	assert.False(t, capabilities.Features["cloud"])
+	assert.Equal(t, models.AuthCapabilities{Mode: "jwt", Admin: "role"}, capabilities.Auth)
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// chatSocketReadLimit caps the size of a message sent by a WebSocket chat client
+	chatSocketReadLimit = 1 << 20
+	// chatSocketWriteTimeout bounds each write, so a client that stops reading cannot hold a reply open
+	chatSocketWriteTimeout = 10 * time.Second
+)
+
+// chatSocketUpgrader accepts WebSocket chats from pages on the API's own origin, and from clients
+// that are not browsers
+var chatSocketUpgrader = websocket.Upgrader{}
+
+// ChatSocket holds a chat over a WebSocket. The client sends "message" messages, each answered
+// with "delta" events as the reply is generated and a "done" event with the whole reply. Sending
+// "stop" cancels the reply being generated, which SSE streams cannot do. The conversation is kept
+// for the life of the connection, stopped replies included as far as they got.
+func (h *LlamaHandler) ChatSocket(c *gin.Context) {
+	conn, err := chatSocketUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already answered with an error status
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(chatSocketReadLimit)
+
+	session := &chatSocket{handler: h, conn: conn}
+	defer session.stop()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			// The client closed the connection or it broke
+			return
+		}
+
+		var request models.ChatSocketRequest
+		if err := json.Unmarshal(data, &request); err != nil {
+			session.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: "invalid message: " + err.Error()})
+			continue
+		}
+
+		switch request.Type {
+		case models.ChatSocketMessage:
+			session.reply(c, request)
+		case models.ChatSocketStop:
+			session.stop()
+		default:
+			session.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: `type must be "message" or "stop"`})
+		}
+	}
+}
+
+// chatSocket is the state of one WebSocket chat: the conversation so far and the reply being
+// generated, if any. The reply's goroutines use it, so it keeps no gin.Context.
+type chatSocket struct {
+	handler *LlamaHandler
+	conn    *websocket.Conn
+
+	writeMu sync.Mutex // gorilla/websocket allows one writer at a time
+
+	mu      sync.Mutex
+	history []models.Message
+	cancel  context.CancelFunc // Cancels the reply being generated, nil when idle
+	stopped bool               // The client stopped the reply being generated
+	done    chan struct{}      // Closed when the reply being generated ends
+}
+
+// reply generates the answer to a user message, unless another reply is still being generated.
+// Everything needed from c is read before the reply's goroutines start.
+func (s *chatSocket) reply(c *gin.Context, request models.ChatSocketRequest) {
+	if request.Content == "" {
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: "content is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: "a reply is already being generated; send stop to cancel it"})
+		return
+	}
+
+	// The rate limit, token quota and maintenance mode apply to each message, not just the upgrade
+	if err := middleware.Readmit(c); err != nil {
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: err.Error()})
+		return
+	}
+
+	messages := append(append([]models.Message(nil), s.history...), models.Message{Role: "user", Content: request.Content})
+	if err := chatLimitError(c, messages, request.MaxTokens); err != nil {
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: "request exceeds limits: " + err.Error()})
+		return
+	}
+	chatRequest := models.ChatRequest{
+		Messages:  messages,
+		Model:     defaultTo(request.Model, requestPreferences(c).Model),
+		MaxTokens: request.MaxTokens,
+		Options:   request.Options,
+	}
+	clampMaxTokens(c, &chatRequest.MaxTokens, chatRequest.Options)
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	done := make(chan struct{})
+	s.cancel, s.stopped, s.done = cancel, false, done
+
+	responseChan := make(chan string)
+	services.Go("chat_socket", func(context.Context) {
+		s.handler.llamaService.StreamChat(ctx, chatRequest, responseChan)
+	})
+	services.Go("chat_socket_send", func(context.Context) {
+		defer close(done)
+		defer cancel()
+		s.relay(messages, responseChan)
+	})
+}
+
+// relay sends the reply's chunks to the client as they arrive and adds the exchange to the
+// conversation once the reply ends. A failed reply is left out so the client can send it again.
+func (s *chatSocket) relay(messages []models.Message, responseChan <-chan string) {
+	var reply strings.Builder
+	var failure string
+	for text := range responseChan {
+		if failure != "" {
+			// Drain the channel so the service can finish
+			continue
+		}
+		if message, ok := strings.CutPrefix(text, "Error: "); ok {
+			failure = message
+			continue
+		}
+		reply.WriteString(text)
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketDelta, Content: text})
+	}
+
+	s.mu.Lock()
+	stopped := s.stopped
+	s.cancel = nil
+	if failure == "" || stopped {
+		s.history = append(messages, models.Message{Role: "assistant", Content: reply.String()})
+	}
+	s.mu.Unlock()
+
+	switch {
+	case stopped:
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketDone, Content: reply.String(), FinishReason: "cancelled"})
+	case failure != "":
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketError, Error: failure})
+	default:
+		s.send(models.ChatSocketEvent{Type: models.ChatSocketDone, Content: reply.String(), FinishReason: "stop"})
+	}
+}
+
+// stop cancels the reply being generated, if any, and waits for it to end
+func (s *chatSocket) stop() {
+	s.mu.Lock()
+	if s.cancel == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.stopped = true
+	s.cancel()
+	done := s.done
+	s.mu.Unlock()
+
+	<-done
+}
+
+func (s *chatSocket) send(event models.ChatSocketEvent) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	// A failed write means the client is gone, which the read loop notices
+	s.conn.SetWriteDeadline(time.Now().Add(chatSocketWriteTimeout))
+	s.conn.WriteJSON(event)
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+// socketService streams a fixed reply, then holds the stream open until it is cancelled when hold
+// is set
+type socketService struct {
+	services.LlamaServiceInterface
+	chunks []string
+	hold   bool
+
+	mu       sync.Mutex
+	requests []models.ChatRequest
+}
+
+func (s *socketService) StreamChat(ctx context.Context, request models.ChatRequest, responseChan chan<- string) {
+	defer close(responseChan)
+	s.mu.Lock()
+	s.requests = append(s.requests, request)
+	hold := s.hold
+	s.mu.Unlock()
+
+	for _, chunk := range s.chunks {
+		responseChan <- chunk
+	}
+	if hold {
+		<-ctx.Done()
+		responseChan <- "Error: " + ctx.Err().Error()
+	}
+}
+
+func (s *socketService) lastRequest() models.ChatRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests[len(s.requests)-1]
+}
+
+func dialChatSocket(t *testing.T, service services.LlamaServiceInterface, guards ...gin.HandlerFunc) *websocket.Conn {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/chat/ws", append(guards, NewLlamaHandler(service).ChatSocket)...)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/chat/ws", nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// readUntil returns the events received up to and including the first one of type eventType
+func readUntil(t *testing.T, conn *websocket.Conn, eventType string) []models.ChatSocketEvent {
+	var events []models.ChatSocketEvent
+	for {
+		var event models.ChatSocketEvent
+		if err := conn.ReadJSON(&event); err != nil {
+			t.Fatalf("reading event: %v", err)
+		}
+		events = append(events, event)
+		if event.Type == eventType {
+			return events
+		}
+	}
+}
+
+func TestChatSocket_FollowUp(t *testing.T) {
+	service := &socketService{chunks: []string{"Hel", "lo"}}
+	conn := dialChatSocket(t, service)
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Hi", Model: "llama2"})
+	events := readUntil(t, conn, models.ChatSocketDone)
+	assert.Equal(t, []models.ChatSocketEvent{
+		{Type: models.ChatSocketDelta, Content: "Hel"},
+		{Type: models.ChatSocketDelta, Content: "lo"},
+		{Type: models.ChatSocketDone, Content: "Hello", FinishReason: "stop"},
+	}, events)
+	assert.Equal(t, "llama2", service.lastRequest().Model)
+
+	// The follow-up carries the conversation so far
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "And again"})
+	readUntil(t, conn, models.ChatSocketDone)
+	assert.Equal(t, []models.Message{
+		{Role: "user", Content: "Hi"},
+		{Role: "assistant", Content: "Hello"},
+		{Role: "user", Content: "And again"},
+	}, service.lastRequest().Messages)
+}
+
+func TestChatSocket_Stop(t *testing.T) {
+	service := &socketService{chunks: []string{"Once upon"}, hold: true}
+	conn := dialChatSocket(t, service)
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Tell a story"})
+	readUntil(t, conn, models.ChatSocketDelta)
+
+	// A second message is refused while the reply is being generated
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Hurry"})
+	events := readUntil(t, conn, models.ChatSocketError)
+	assert.Contains(t, events[0].Error, "already being generated")
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketStop})
+	events = readUntil(t, conn, models.ChatSocketDone)
+	assert.Equal(t, models.ChatSocketEvent{Type: models.ChatSocketDone, Content: "Once upon", FinishReason: "cancelled"}, events[0])
+
+	// The stopped reply stays in the conversation
+	service.mu.Lock()
+	service.hold = false
+	service.mu.Unlock()
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Go on"})
+	readUntil(t, conn, models.ChatSocketDone)
+	assert.Equal(t, models.Message{Role: "assistant", Content: "Once upon"}, service.lastRequest().Messages[1])
+}
+
+func TestChatSocket_Errors(t *testing.T) {
+	service := &socketService{chunks: []string{"Error: connection refused"}}
+	conn := dialChatSocket(t, service)
+
+	conn.WriteMessage(websocket.TextMessage, []byte("not json"))
+	events := readUntil(t, conn, models.ChatSocketError)
+	assert.Contains(t, events[0].Error, "invalid message")
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: "shout"})
+	events = readUntil(t, conn, models.ChatSocketError)
+	assert.Equal(t, `type must be "message" or "stop"`, events[0].Error)
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage})
+	events = readUntil(t, conn, models.ChatSocketError)
+	assert.Equal(t, "content is required", events[0].Error)
+
+	// A failed reply is reported and left out of the conversation
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Hi"})
+	events = readUntil(t, conn, models.ChatSocketError)
+	assert.Equal(t, "connection refused", events[0].Error)
+
+	conn.WriteJSON(models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Hi again"})
+	readUntil(t, conn, models.ChatSocketError)
+	assert.Equal(t, []models.Message{{Role: "user", Content: "Hi again"}}, service.lastRequest().Messages)
+}
+
+func TestChatSocket_ChecksEachMessage(t *testing.T) {
+	clientKey := func(c *gin.Context) string { return "alice" }
+	message := models.ChatSocketRequest{Type: models.ChatSocketMessage, Content: "Hi"}
+
+	// The daily token budget is spent while the socket is open
+	service := &socketService{chunks: []string{"Hello"}}
+	quota := middleware.NewMemoryQuota()
+	conn := dialChatSocket(t, service, middleware.Quota(quota, 100, clientKey, services.WithTokenRecorder))
+	conn.WriteJSON(message)
+	readUntil(t, conn, models.ChatSocketDone)
+
+	quota.Add(context.Background(), "alice", 100)
+	conn.WriteJSON(message)
+	events := readUntil(t, conn, models.ChatSocketError)
+	assert.Contains(t, events[0].Error, "Token quota exceeded")
+	assert.Len(t, service.requests, 1, "refused messages are not generated")
+
+	// Maintenance is enabled while the socket is open
+	maintenance := middleware.NewMaintenanceMode()
+	conn = dialChatSocket(t, service, maintenance.Guard())
+	maintenance.Enable("Upgrading models", nil)
+	conn.WriteJSON(message)
+	events = readUntil(t, conn, models.ChatSocketError)
+	assert.Equal(t, "Service under maintenance: Upgrading models", events[0].Error)
+
+	// Each message counts against the rate limit, after the upgrade request
+	conn = dialChatSocket(t, service, middleware.RateLimitBy(middleware.NewTokenBucketLimiter(2, time.Hour), clientKey))
+	conn.WriteJSON(message)
+	readUntil(t, conn, models.ChatSocketDone)
+	conn.WriteJSON(message)
+	events = readUntil(t, conn, models.ChatSocketError)
+	assert.Contains(t, events[0].Error, "Rate limit exceeded")
+	assert.Len(t, service.requests, 2)
+}
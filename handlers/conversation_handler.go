@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ConversationHandler struct {
+	conversations *services.ConversationService
+}
+
+func NewConversationHandler(conversations *services.ConversationService) *ConversationHandler {
+	return &ConversationHandler{
+		conversations: conversations,
+	}
+}
+
+// CreateConversation starts a server-side conversation
+func (h *ConversationHandler) CreateConversation(c *gin.Context) {
+	var request models.CreateConversationRequest
+	// The body is optional: an empty request uses the default model without a system prompt
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+			return
+		}
+	}
+
+	request.Model = defaultTo(request.Model, requestPreferences(c).Model)
+
+	conversation, err := h.conversations.Create(c.Request.Context(), request)
+	if err != nil {
+		respondConversationError(c, "Failed to create conversation", err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, conversation)
+}
+
+// GetConversation returns a conversation with its history
+func (h *ConversationHandler) GetConversation(c *gin.Context) {
+	conversation, err := h.conversations.Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		respondConversationError(c, "Failed to load conversation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, conversation)
+}
+
+// SendMessage adds a user message to a conversation and returns the model's answer
+func (h *ConversationHandler) SendMessage(c *gin.Context) {
+	var request models.ConversationMessageRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+	if respondLimitError(c, promptLimitError(c, request.Content)) || respondLimitError(c, maxTokensError(request.MaxTokens)) {
+		return
+	}
+
+	clamped := clampMaxTokens(c, &request.MaxTokens, request.Options)
+
+	reply, err := h.conversations.SendMessage(c.Request.Context(), c.Param("id"), request)
+	if err != nil {
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) {
+			return
+		}
+		respondConversationError(c, "Failed to process message", err)
+		return
+	}
+
+	reply.Clamped = clamped
+	c.JSON(http.StatusOK, reply)
+}
+
+// DeleteConversation removes a conversation and its history
+func (h *ConversationHandler) DeleteConversation(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.conversations.Delete(c.Request.Context(), id); err != nil {
+		respondConversationError(c, "Failed to delete conversation", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Conversation deleted successfully",
+		"id":      id,
+	})
+}
+
+func respondConversationError(c *gin.Context, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, services.ErrConversationNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrPersistenceUnavailable):
+		status = http.StatusServiceUnavailable
+	}
+	respondError(c, status, message, err.Error())
+}
@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupConversationRouter(handler *ConversationHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	conversations := router.Group("/api/v1/conversations")
+	{
+		conversations.POST("", handler.CreateConversation)
+		conversations.GET("/:id", handler.GetConversation)
+		conversations.POST("/:id/messages", handler.SendMessage)
+		conversations.DELETE("/:id", handler.DeleteConversation)
+	}
+
+	return router
+}
+
+func TestConversation_Lifecycle(t *testing.T) {
+	mockService := new(MockLlamaService)
+	conversations := services.NewConversationService(services.NewMemoryConversationStore(time.Hour, time.Now), mockService, config.ConversationConfig{})
+	router := setupConversationRouter(NewConversationHandler(conversations))
+
+	// Create
+	body, _ := json.Marshal(models.CreateConversationRequest{Model: "llama3.2"})
+	req, _ := http.NewRequest("POST", "/api/v1/conversations", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var conversation models.Conversation
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &conversation))
+	assert.NotEmpty(t, conversation.ID)
+
+	// Send a message
+	mockService.On("Chat", models.ChatRequest{
+		Model:    "llama3.2",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	}).Return(&models.ChatResponse{
+		Model:   "llama3.2",
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Hi there!"}}},
+	}, nil)
+
+	body, _ = json.Marshal(models.ConversationMessageRequest{Content: "Hello"})
+	req, _ = http.NewRequest("POST", "/api/v1/conversations/"+conversation.ID+"/messages", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var reply models.ConversationReply
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &reply))
+	assert.Equal(t, "Hi there!", reply.Message.Content)
+
+	// History
+	req, _ = http.NewRequest("GET", "/api/v1/conversations/"+conversation.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &conversation))
+	assert.Len(t, conversation.Messages, 2)
+
+	// Delete
+	req, _ = http.NewRequest("DELETE", "/api/v1/conversations/"+conversation.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/conversations/"+conversation.ID, nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestConversation_SendMessageNotFound(t *testing.T) {
+	conversations := services.NewConversationService(services.NewMemoryConversationStore(time.Hour, time.Now), new(MockLlamaService), config.ConversationConfig{})
+	router := setupConversationRouter(NewConversationHandler(conversations))
+
+	body, _ := json.Marshal(models.ConversationMessageRequest{Content: "Hello"})
+	req, _ := http.NewRequest("POST", "/api/v1/conversations/conv_missing/messages", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestConversation_SendMessageRequiresContent(t *testing.T) {
+	conversations := services.NewConversationService(services.NewMemoryConversationStore(time.Hour, time.Now), new(MockLlamaService), config.ConversationConfig{})
+	router := setupConversationRouter(NewConversationHandler(conversations))
+
+	req, _ := http.NewRequest("POST", "/api/v1/conversations/conv_1/messages", bytes.NewBufferString(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestConversation_StoreUnavailable(t *testing.T) {
+	persistence := services.NewPersistence(time.Minute, time.Second)
+	persistence.Add("conversation_store", func(context.Context) error { return errors.New("connection refused") })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go persistence.Run(ctx)
+	assert.Eventually(t, func() bool { return !persistence.Available("conversation_store") }, time.Second, 5*time.Millisecond)
+
+	store := persistence.GuardConversationStore("conversation_store", services.NewMemoryConversationStore(time.Hour, time.Now))
+	conversations := services.NewConversationService(store, new(MockLlamaService), config.ConversationConfig{})
+	router := setupConversationRouter(NewConversationHandler(conversations))
+
+	req, _ := http.NewRequest("POST", "/api/v1/conversations", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Contains(t, w.Body.String(), services.ErrPersistenceUnavailable.Error())
+}
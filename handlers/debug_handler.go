@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DebugHandler serves runtime statistics and pprof profiles for diagnosing performance problems
+type DebugHandler struct {
+	connections *middleware.ConnCounter
+}
+
+// NewDebugHandler creates a handler reporting the connections counted by connections
+func NewDebugHandler(connections *middleware.ConnCounter) *DebugHandler {
+	return &DebugHandler{connections: connections}
+}
+
+// GetStats reports goroutines, heap, garbage collection and open connections
+func (h *DebugHandler) GetStats(c *gin.Context) {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+
+	stats := models.DebugStats{
+		Goroutines:        runtime.NumGoroutine(),
+		ManagedGoroutines: services.GoroutineStats(),
+		Heap: models.HeapStats{
+			AllocBytes:    memStats.HeapAlloc,
+			InuseBytes:    memStats.HeapInuse,
+			IdleBytes:     memStats.HeapIdle,
+			ReleasedBytes: memStats.HeapReleased,
+			SysBytes:      memStats.Sys,
+			Objects:       memStats.HeapObjects,
+		},
+		GC: models.GCStats{
+			Cycles:       memStats.NumGC,
+			PauseTotalMs: float64(memStats.PauseTotalNs) / float64(time.Millisecond),
+			NextGCBytes:  memStats.NextGC,
+			CPUFraction:  memStats.GCCPUFraction,
+			ForcedCycles: memStats.NumForcedGC,
+		},
+		Connections: h.connections.Stats(),
+	}
+	if memStats.NumGC > 0 {
+		// PauseNs and PauseEnd are circular buffers, the latest cycle is at (NumGC+255)%256
+		latest := (memStats.NumGC + 255) % 256
+		lastGC := time.Unix(0, int64(memStats.PauseEnd[latest])).UTC()
+		stats.GC.LastGC = &lastGC
+		stats.GC.LastPauseMs = float64(memStats.PauseNs[latest]) / float64(time.Millisecond)
+	}
+
+	c.JSON(http.StatusOK, stats)
+}
+
+// Pprof serves the net/http/pprof profiles. It must be mounted at /debug/pprof/*profile, the path
+// the pprof index and tools expect.
+func (h *DebugHandler) Pprof(c *gin.Context) {
+	switch c.Param("profile") {
+	case "/cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "/profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "/symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "/trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		// The index, and named profiles such as heap, goroutine and allocs
+		pprof.Index(c.Writer, c.Request)
+	}
+}
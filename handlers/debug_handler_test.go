@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupDebugRouter(handler *DebugHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/api/v1/admin/debug/stats", handler.GetStats)
+	router.GET("/debug/pprof/*profile", handler.Pprof)
+	return router
+}
+
+func TestDebugStats(t *testing.T) {
+	router := setupDebugRouter(NewDebugHandler(middleware.NewConnCounter()))
+	runtime.GC()
+
+	req, _ := http.NewRequest("GET", "/api/v1/admin/debug/stats", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var stats models.DebugStats
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.Heap.AllocBytes)
+	assert.Positive(t, stats.GC.Cycles)
+	assert.NotNil(t, stats.GC.LastGC)
+	assert.Equal(t, models.ConnectionStats{}, stats.Connections)
+}
+
+func TestPprof(t *testing.T) {
+	router := setupDebugRouter(NewDebugHandler(middleware.NewConnCounter()))
+
+	req, _ := http.NewRequest("GET", "/debug/pprof/", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "goroutine")
+
+	req, _ = http.NewRequest("GET", "/debug/pprof/goroutine?debug=1", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.True(t, strings.HasPrefix(w.Body.String(), "goroutine profile:"))
+}
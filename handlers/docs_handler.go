@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+// openAPISpec is the OpenAPI 3.0 document of every route. Update it alongside route and model changes.
+//
+//go:embed openapi.yaml
+var openAPISpec []byte
+
+// swaggerUI renders the OpenAPI document with Swagger UI, loaded from a CDN
+const swaggerUI = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Llama API Documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
+
+// DocsHandler serves the OpenAPI document and Swagger UI, for exploring the API and generating clients
+type DocsHandler struct{}
+
+// NewDocsHandler creates a handler serving the embedded OpenAPI document
+func NewDocsHandler() *DocsHandler {
+	return &DocsHandler{}
+}
+
+// GetSpecYAML returns the OpenAPI document as written
+func (h *DocsHandler) GetSpecYAML(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", openAPISpec)
+}
+
+// GetSpecJSON returns the OpenAPI document converted to JSON, the format most client generators read
+func (h *DocsHandler) GetSpecJSON(c *gin.Context) {
+	var spec map[string]interface{}
+	if err := yaml.Unmarshal(openAPISpec, &spec); err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to read API specification", err.Error())
+		return
+	}
+	data, err := json.Marshal(spec)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to encode API specification", err.Error())
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", data)
+}
+
+// SwaggerUI serves a page exploring the OpenAPI document
+func (h *DocsHandler) SwaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUI))
+}
@@ -0,0 +1,85 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func docsRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewDocsHandler()
+	router.GET("/openapi.yaml", handler.GetSpecYAML)
+	router.GET("/openapi.json", handler.GetSpecJSON)
+	router.GET("/docs", handler.SwaggerUI)
+	return router
+}
+
+// collectRefs appends every $ref in a decoded document to refs
+func collectRefs(node interface{}, refs *[]string) {
+	switch value := node.(type) {
+	case map[string]interface{}:
+		for key, child := range value {
+			if ref, ok := child.(string); ok && key == "$ref" {
+				*refs = append(*refs, ref)
+			}
+			collectRefs(child, refs)
+		}
+	case []interface{}:
+		for _, child := range value {
+			collectRefs(child, refs)
+		}
+	}
+}
+
+func TestDocs_SpecJSON(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	w := httptest.NewRecorder()
+	docsRouter().ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var spec map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &spec))
+	assert.Equal(t, "3.0.3", spec["openapi"])
+
+	paths := spec["paths"].(map[string]interface{})
+	for _, path := range []string{"/api/v1/llama/chat", "/api/v1/conversations/{id}/messages", "/api/v1/admin/audit", "/v1/messages"} {
+		assert.Contains(t, paths, path)
+	}
+
+	// Every reference resolves to a component
+	var refs []string
+	collectRefs(spec, &refs)
+	assert.NotEmpty(t, refs)
+	components := spec["components"].(map[string]interface{})
+	for _, ref := range refs {
+		parts := strings.Split(strings.TrimPrefix(ref, "#/components/"), "/")
+		if assert.Len(t, parts, 2, ref) {
+			section, _ := components[parts[0]].(map[string]interface{})
+			assert.Contains(t, section, parts[1], ref)
+		}
+	}
+}
+
+func TestDocs_SpecYAMLAndUI(t *testing.T) {
+	router := docsRouter()
+
+	req, _ := http.NewRequest("GET", "/openapi.yaml", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.True(t, strings.HasPrefix(w.Body.String(), "openapi: 3.0.3"))
+
+	req, _ = http.NewRequest("GET", "/docs", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `url: "/openapi.json"`)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"agent-ollama-gin/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondError writes the standard error envelope, with a code derived from status and the
+// request ID
+func respondError(c *gin.Context, status int, message, details string) {
+	c.JSON(status, middleware.NewErrorResponse(c, status, message, details))
+}
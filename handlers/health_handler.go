@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// HealthHandler answers the liveness and readiness probes of orchestrators such as Kubernetes
+type HealthHandler struct {
+	readiness *services.Readiness
+}
+
+// NewHealthHandler creates a handler whose readiness probe runs the checks of readiness
+func NewHealthHandler(readiness *services.Readiness) *HealthHandler {
+	return &HealthHandler{readiness: readiness}
+}
+
+// Liveness reports that the process is serving requests. It checks no dependencies, so an Ollama
+// outage does not get the server restarted.
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// Readiness checks every dependency and answers 503 when a required one fails, so traffic is
+// routed away until it recovers. A server that is only degraded stays in rotation.
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.readiness.Check(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == models.StatusNotReady {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupHealthRouter(readiness *services.Readiness) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	handler := NewHealthHandler(readiness)
+	router.GET("/healthz", handler.Liveness)
+	router.GET("/readyz", handler.Readiness)
+	return router
+}
+
+func TestProbes(t *testing.T) {
+	var ollamaErr error
+	readiness := services.NewReadiness(time.Second)
+	readiness.Add("ollama", func(context.Context) error { return ollamaErr })
+	router := setupHealthRouter(readiness)
+
+	req, _ := http.NewRequest("GET", "/readyz", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// A failed dependency takes the server out of rotation but does not fail liveness
+	ollamaErr = errors.New("connection refused")
+
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var report models.ReadinessReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+	assert.Equal(t, models.StatusNotReady, report.Status)
+	assert.Equal(t, "connection refused", report.Checks["ollama"].Error)
+
+	// Optional dependencies only degrade the server
+	ollamaErr = nil
+	readiness.AddOptional("usage_store", func(context.Context) error { return errors.New("connection refused") })
+
+	req, _ = http.NewRequest("GET", "/readyz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"degraded"`)
+
+	req, _ = http.NewRequest("GET", "/healthz", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
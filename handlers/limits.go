@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestLimits returns the generation limits set by middleware.Limits, or none
+func requestLimits(c *gin.Context) models.GenerationLimits {
+	limits, _ := c.Get(middleware.LimitsKey)
+	l, _ := limits.(models.GenerationLimits)
+	return l
+}
+
+// streamPacer returns the pacer set by middleware.StreamRate, or nil when the stream is not paced
+func streamPacer(c *gin.Context) *middleware.StreamPacer {
+	pacer, _ := c.Get(middleware.StreamPacerKey)
+	p, _ := pacer.(*middleware.StreamPacer)
+	return p
+}
+
+// clampMaxTokens lowers the max_tokens of a request, and its num_predict option when set, to the
+// client's limit. It returns the values that were clamped, to report in the response.
+func clampMaxTokens(c *gin.Context, maxTokens *int, options *models.Options) []models.ClampedLimit {
+	return middleware.ClampMaxTokens(requestLimits(c), maxTokens, options)
+}
+
+// messageLimitError returns an error if a chat request has more messages than the client may send
+func messageLimitError(c *gin.Context, messages int) error {
+	return middleware.MessageLimitError(requestLimits(c), messages)
+}
+
+// promptLimitError returns an error if a prompt is longer than the client may send
+func promptLimitError(c *gin.Context, prompt ...string) error {
+	return middleware.PromptLimitError(requestLimits(c), prompt...)
+}
+
+// maxTokensError returns an error if a request asks for a negative answer length
+func maxTokensError(maxTokens int) error {
+	if maxTokens < 0 {
+		return fmt.Errorf("max_tokens must not be negative, got %d", maxTokens)
+	}
+	return nil
+}
+
+// chatLimitError returns the first limit of the client a chat request breaks, or nil
+func chatLimitError(c *gin.Context, messages []models.Message, maxTokens int) error {
+	if err := messageLimitError(c, len(messages)); err != nil {
+		return err
+	}
+	if err := promptLimitError(c, messageContents(messages)...); err != nil {
+		return err
+	}
+	return maxTokensError(maxTokens)
+}
+
+// respondLimitError writes a 422 if err reports a request breaking a limit of the client
+func respondLimitError(c *gin.Context, err error) bool {
+	if err == nil {
+		return false
+	}
+
+	respondError(c, http.StatusUnprocessableEntity, "Request exceeds limits", err.Error())
+	return true
+}
+
+// messageContents returns the content of each message, in order
+func messageContents(messages []models.Message) []string {
+	contents := make([]string, len(messages))
+	for i, message := range messages {
+		contents[i] = message.Content
+	}
+	return contents
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupLimitsRouter(handler *LlamaHandler, limits models.GenerationLimits) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(middleware.LimitsKey, limits) })
+	r.POST("/chat", handler.Chat)
+	r.POST("/v1/messages", handler.Messages)
+	return r
+}
+
+func TestChat_ClampsMaxTokens(t *testing.T) {
+	messages := []models.Message{{Role: "user", Content: "Hi"}}
+	limit := 256
+	unlimited := -1
+
+	tests := []struct {
+		name     string
+		request  models.ChatRequest
+		expected models.ChatRequest
+		clamped  []models.ClampedLimit
+	}{
+		{
+			name:     "within the limit",
+			request:  models.ChatRequest{Messages: messages, MaxTokens: 100},
+			expected: models.ChatRequest{Messages: messages, MaxTokens: 100},
+		},
+		{
+			name:     "above the limit",
+			request:  models.ChatRequest{Messages: messages, MaxTokens: 1000},
+			expected: models.ChatRequest{Messages: messages, MaxTokens: limit},
+			clamped:  []models.ClampedLimit{{Name: "max_tokens", Requested: 1000, Limit: limit}},
+		},
+		{
+			name:     "unset",
+			request:  models.ChatRequest{Messages: messages},
+			expected: models.ChatRequest{Messages: messages, MaxTokens: limit},
+			clamped:  []models.ClampedLimit{{Name: "max_tokens", Limit: limit}},
+		},
+		{
+			name:     "unlimited num_predict",
+			request:  models.ChatRequest{Messages: messages, MaxTokens: 100, Options: &models.Options{NumPredict: &unlimited}},
+			expected: models.ChatRequest{Messages: messages, MaxTokens: limit, Options: &models.Options{NumPredict: &limit}},
+			clamped:  []models.ClampedLimit{{Name: "max_tokens", Limit: limit}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			router := setupLimitsRouter(NewLlamaHandler(mockService), models.GenerationLimits{MaxTokens: limit})
+			mockService.On("Chat", tt.expected).Return(&models.ChatResponse{Object: "chat.completion"}, nil)
+
+			body, _ := json.Marshal(tt.request)
+			req, _ := http.NewRequest("POST", "/chat", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusOK, w.Code)
+			var response models.ChatResponse
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.clamped, response.Clamped)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestChat_TooManyMessages(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupLimitsRouter(NewLlamaHandler(mockService), models.GenerationLimits{MaxMessages: 2})
+
+	body, _ := json.Marshal(models.ChatRequest{Messages: []models.Message{
+		{Role: "user", Content: "One"},
+		{Role: "assistant", Content: "Two"},
+		{Role: "user", Content: "Three"},
+	}})
+	req, _ := http.NewRequest("POST", "/chat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "the request has 3 messages, the limit is 2")
+	mockService.AssertNotCalled(t, "Chat")
+}
+
+func TestChat_RejectsRequestsOverLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.ChatRequest
+		details string
+	}{
+		{
+			name:    "prompt too long",
+			request: models.ChatRequest{Messages: []models.Message{{Role: "system", Content: "Be brief"}, {Role: "user", Content: "Hello"}}},
+			details: "the prompt has 13 characters, the limit is 10",
+		},
+		{
+			name:    "negative max_tokens",
+			request: models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}, MaxTokens: -5},
+			details: "max_tokens must not be negative, got -5",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			router := setupLimitsRouter(NewLlamaHandler(mockService), models.GenerationLimits{MaxPromptChars: 10})
+
+			body, _ := json.Marshal(tt.request)
+			req, _ := http.NewRequest("POST", "/chat", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			assert.JSONEq(t, `{"code": "unprocessable_entity", "message": "Request exceeds limits", "error": "Request exceeds limits", "details": "`+tt.details+`"}`, w.Body.String())
+			mockService.AssertNotCalled(t, "Chat")
+		})
+	}
+}
+
+func TestEmbedding_InputTooLong(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupLimitsRouter(handler, models.GenerationLimits{MaxEmbeddingChars: 4})
+	router.POST("/embedding", handler.Embedding)
+
+	req, _ := http.NewRequest("POST", "/embedding", bytes.NewBufferString(`{"input": "héllo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "the input has 5 characters, the limit is 4")
+	mockService.AssertNotCalled(t, "Embedding")
+}
+
+func TestMessages_PromptTooLong(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupLimitsRouter(NewLlamaHandler(mockService), models.GenerationLimits{MaxPromptChars: 10})
+
+	body := `{"model": "llama3.2", "max_tokens": 64, "system": "Be brief", "messages": [{"role": "user", "content": "Hello"}]}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"type":"invalid_request_error"`)
+	assert.Contains(t, w.Body.String(), "the prompt has 13 characters, the limit is 10")
+	mockService.AssertNotCalled(t, "Chat")
+}
+
+func TestMessages_ClampsMaxTokens(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupLimitsRouter(NewLlamaHandler(mockService), models.GenerationLimits{MaxTokens: 32})
+
+	mockService.On("Chat", models.ChatRequest{
+		Model:     "llama3.2",
+		Messages:  []models.Message{{Role: "user", Content: "Hi"}},
+		MaxTokens: 32,
+	}).Return(&models.ChatResponse{
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Hello"}}},
+		Usage:   models.Usage{CompletionTokens: 32},
+	}, nil)
+
+	body := `{"model": "llama3.2", "max_tokens": 1024, "messages": [{"role": "user", "content": "Hi"}]}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.MessagesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "max_tokens", *response.StopReason)
+	mockService.AssertExpectations(t)
+}
+
+func TestStreamChat_ReportsOutputRate(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupLimitsRouter(handler, models.GenerationLimits{StreamTokensPerSecond: 100})
+	router.POST("/chat/stream", middleware.StreamRate(middleware.NewOutputRate(), func(*gin.Context) string { return "user:alice" }), handler.StreamChat)
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Hel"
+		responseChan <- "lo"
+		close(responseChan)
+	})
+
+	body, _ := json.Marshal(models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+	req, _ := http.NewRequest("POST", "/chat/stream", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-Stream-Rate-Limit"))
+	assert.Contains(t, w.Body.String(), "event:stream.metadata")
+	assert.Contains(t, w.Body.String(), `"limit_tokens_per_second":100,"tokens":2`)
+}
@@ -1,8 +1,15 @@
 package handlers
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
+	"agent-ollama-gin/middleware"
 	"agent-ollama-gin/models"
 
 	"github.com/gin-gonic/gin"
@@ -24,61 +31,324 @@ func NewLlamaHandler(llamaService services.LlamaServiceInterface) *LlamaHandler
 func (h *LlamaHandler) Chat(c *gin.Context) {
 	var request models.ChatRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
 		return
 	}
 
 	// Validate request
 	if len(request.Messages) == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "At least one message is required",
-		})
+		respondError(c, http.StatusBadRequest, "At least one message is required", "")
 		return
 	}
 
-	response, err := h.llamaService.Chat(request)
+	if respondLimitError(c, chatLimitError(c, request.Messages, request.MaxTokens)) {
+		return
+	}
+	clamped := clampMaxTokens(c, &request.MaxTokens, request.Options)
+
+	request.Model = defaultTo(request.Model, requestPreferences(c).Model)
+
+	response, err := h.llamaService.Chat(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process chat request",
-			"details": err.Error(),
-		})
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrPresetNotFound) {
+			respondError(c, http.StatusBadRequest, "Unknown preset", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrInvalidFormat) {
+			respondError(c, http.StatusBadRequest, "Invalid output format", err.Error())
+			return
+		}
+		if respondSchemaValidationError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process chat request", err.Error())
 		return
 	}
 
+	response.Clamped = clamped
 	c.JSON(http.StatusOK, response)
 }
 
+// setStreamHeaders prepares a server-sent events response that proxies pass through unbuffered
+func setStreamHeaders(c *gin.Context) {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache, no-transform")
+	c.Header("X-Accel-Buffering", "no")
+	// Connection-specific headers are not allowed in HTTP/2
+	if c.Request.ProtoMajor == 1 {
+		c.Header("Connection", "keep-alive")
+	}
+}
+
+// StatusClientClosedRequest is the nginx convention for a request the client abandoned before
+// the response was ready. Nobody reads it, but access logs then do not count it as a server error.
+const StatusClientClosedRequest = 499
+
+// respondClientCancelled writes a 499 if err means the client cancelled the request
+func respondClientCancelled(c *gin.Context, err error) bool {
+	if !errors.Is(err, context.Canceled) {
+		return false
+	}
+
+	respondError(c, StatusClientClosedRequest, "Client closed request", err.Error())
+	return true
+}
+
+// failure is the error response a request failing with a typed error is answered with
+type failure struct {
+	status  int
+	message string
+	details string
+	fields  gin.H // Added to the envelope, such as the model and the partial output
+}
+
+// errorMapping maps a typed error to the failure a request failing with it is answered with. ok
+// is false when err is not of its type.
+type errorMapping func(err error) (f failure, ok bool)
+
+// respondMapped writes the response mapping gives err, if any
+func respondMapped(c *gin.Context, err error, mapping errorMapping) bool {
+	f, ok := mapping(err)
+	if ok {
+		middleware.AbortWithError(c, f.status, f.message, f.details, f.fields)
+	}
+	return ok
+}
+
+// generationFailures are the mappings of the typed errors a generation fails with
+var generationFailures = []errorMapping{
+	budgetExceededFailure,
+	generationTimeoutFailure,
+	queueFailure,
+	upstreamFailure,
+	moderationFailure,
+	schemaValidationFailure,
+}
+
+// generationFailure maps err as the generation endpoints answer it, and other errors to a 500
+func generationFailure(err error) failure {
+	for _, mapping := range generationFailures {
+		if f, ok := mapping(err); ok {
+			return f
+		}
+	}
+	return failure{status: http.StatusInternalServerError, message: "Generation failed", details: err.Error()}
+}
+
+// respondGenerationTimeout writes a 504 with the partial output if err is a generation timeout
+func respondGenerationTimeout(c *gin.Context, err error) bool {
+	return respondMapped(c, err, generationTimeoutFailure)
+}
+
+func generationTimeoutFailure(err error) (failure, bool) {
+	var timeoutErr *services.GenerationTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return failure{}, false
+	}
+
+	return failure{
+		status:  http.StatusGatewayTimeout,
+		message: "Generation timed out",
+		details: timeoutErr.Error(),
+		fields: gin.H{
+			"model":           timeoutErr.Model,
+			"timeout_seconds": int(timeoutErr.Timeout.Seconds()),
+			"partial_output":  timeoutErr.Partial,
+		},
+	}, true
+}
+
+// respondBudgetExceeded writes a 504 naming the stage that used up the budget if err means the
+// request ran out of the time budget its client set
+func respondBudgetExceeded(c *gin.Context, err error) bool {
+	return respondMapped(c, err, budgetExceededFailure)
+}
+
+func budgetExceededFailure(err error) (failure, bool) {
+	var budgetErr *services.RequestBudgetError
+	if !errors.As(err, &budgetErr) {
+		return failure{}, false
+	}
+
+	spent := make(map[string]int64, len(budgetErr.Spent))
+	for stage, d := range budgetErr.Spent {
+		spent[stage] = d.Milliseconds()
+	}
+	return failure{
+		status:  http.StatusGatewayTimeout,
+		message: "Request budget exceeded",
+		details: budgetErr.Error(),
+		fields: gin.H{
+			"model":          budgetErr.Model,
+			"budget_ms":      budgetErr.Budget.Milliseconds(),
+			"stage":          budgetErr.Stage,
+			"stage_ms":       spent,
+			"partial_output": budgetErr.Partial,
+		},
+	}, true
+}
+
+// respondSchemaValidationError writes a 422 with the validation errors and the last answer if the
+// model could not produce output matching the requested format
+func respondSchemaValidationError(c *gin.Context, err error) bool {
+	return respondMapped(c, err, schemaValidationFailure)
+}
+
+func schemaValidationFailure(err error) (failure, bool) {
+	var validationErr *services.SchemaValidationError
+	if !errors.As(err, &validationErr) {
+		return failure{}, false
+	}
+
+	return failure{
+		status:  http.StatusUnprocessableEntity,
+		message: "Model output does not match the requested format",
+		details: validationErr.Error(),
+		fields: gin.H{
+			"model":             validationErr.Model,
+			"validation_errors": validationErr.Errors,
+			"output":            validationErr.Output,
+			"attempts":          validationErr.Attempts,
+		},
+	}, true
+}
+
+// respondUpstreamError maps an upstream failure to a status that tells the client which backend failed.
+// Missing models, bad requests and rate limits keep their status; other failures are 503 for the
+// local daemon and 502 for Ollama Cloud and remote providers.
+func respondUpstreamError(c *gin.Context, err error) bool {
+	return respondMapped(c, err, upstreamFailure)
+}
+
+func upstreamFailure(err error) (failure, bool) {
+	var upstreamErr *services.UpstreamError
+	if !errors.As(err, &upstreamErr) {
+		return failure{}, false
+	}
+
+	upstream, keyMessage := "Ollama", "Ollama Cloud rejected the API key, sign in again"
+	status, message := http.StatusServiceUnavailable, "Local Ollama is unavailable"
+	switch {
+	case upstreamErr.Backend == services.BackendCloud:
+		status, message = http.StatusBadGateway, "Ollama Cloud request failed"
+	case upstreamErr.Backend != services.BackendLocal:
+		// A remote provider such as openai
+		upstream = fmt.Sprintf("The %s provider", upstreamErr.Backend)
+		keyMessage = upstream + " rejected the API key"
+		status, message = http.StatusBadGateway, upstream+" request failed"
+	}
+	switch upstreamErr.StatusCode {
+	case http.StatusNotFound:
+		status, message = http.StatusNotFound, "Model not found"
+	case http.StatusBadRequest:
+		status, message = http.StatusBadRequest, upstream+" rejected the request"
+	case http.StatusTooManyRequests:
+		status, message = http.StatusTooManyRequests, upstream+" rate limit exceeded"
+	case http.StatusUnauthorized, http.StatusForbidden:
+		message = keyMessage
+	}
+
+	return failure{
+		status:  status,
+		message: message,
+		details: upstreamErr.Error(),
+		fields:  gin.H{"backend": upstreamErr.Backend},
+	}, true
+}
+
+// respondModerationError writes a 400 naming the matched categories if err means the moderation
+// policy blocked the prompt or the reply
+func respondModerationError(c *gin.Context, err error) bool {
+	return respondMapped(c, err, moderationFailure)
+}
+
+func moderationFailure(err error) (failure, bool) {
+	var moderationErr *services.ModerationError
+	if !errors.As(err, &moderationErr) {
+		return failure{}, false
+	}
+
+	return failure{
+		status:  http.StatusBadRequest,
+		message: "Content blocked by moderation policy",
+		details: moderationErr.Error(),
+		fields: gin.H{
+			"stage":      moderationErr.Stage,
+			"categories": moderationErr.Categories,
+		},
+	}, true
+}
+
+// respondQueueError writes a 429 or 503 with Retry-After if err means no generation slot was available
+func respondQueueError(c *gin.Context, err error) bool {
+	f, ok := queueFailure(err)
+	if !ok {
+		return false
+	}
+
+	c.Header("Retry-After", strconv.Itoa(f.fields["retry_after_seconds"].(int)))
+	middleware.AbortWithError(c, f.status, f.message, f.details, f.fields)
+	return true
+}
+
+func queueFailure(err error) (failure, bool) {
+	var queueErr *services.QueueError
+	if !errors.As(err, &queueErr) {
+		return failure{}, false
+	}
+
+	status := http.StatusServiceUnavailable
+	if errors.Is(err, services.ErrQueueFull) {
+		status = http.StatusTooManyRequests
+	}
+
+	return failure{
+		status:  status,
+		message: "Server is busy",
+		details: queueErr.Error(),
+		fields: gin.H{
+			"retry_after_seconds": int(math.Ceil(queueErr.RetryAfter.Seconds())),
+		},
+	}, true
+}
+
 // Completion handles text completion requests
 func (h *LlamaHandler) Completion(c *gin.Context) {
 	var request models.CompletionRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
 		return
 	}
 
 	// Validate request
 	if request.Prompt == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Prompt is required",
-		})
+		respondError(c, http.StatusBadRequest, "Prompt is required", "")
+		return
+	}
+	if respondLimitError(c, promptLimitError(c, request.Prompt)) || respondLimitError(c, maxTokensError(request.MaxTokens)) {
 		return
 	}
 
-	response, err := h.llamaService.Completion(request)
+	clamped := clampMaxTokens(c, &request.MaxTokens, request.Options)
+	request.Model = defaultTo(request.Model, requestPreferences(c).Model)
+
+	response, err := h.llamaService.Completion(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process completion request",
-			"details": err.Error(),
-		})
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrChatOnly) {
+			respondError(c, http.StatusBadRequest, "Model does not support completion", err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process completion request", err.Error())
 		return
 	}
 
+	response.Clamped = clamped
 	c.JSON(http.StatusOK, response)
 }
 
@@ -86,41 +356,228 @@ func (h *LlamaHandler) Completion(c *gin.Context) {
 func (h *LlamaHandler) Embedding(c *gin.Context) {
 	var request models.EmbeddingRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
 		return
 	}
 
 	// Validate request
 	if request.Input == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Input text is required",
-		})
+		respondError(c, http.StatusBadRequest, "Input text is required", "")
+		return
+	}
+	if request.Dimensions < 0 {
+		respondError(c, http.StatusBadRequest, "Dimensions must be a positive number", "")
+		return
+	}
+	if respondLimitError(c, middleware.EmbeddingLimitError(requestLimits(c), request.Input)) {
+		return
+	}
+
+	response, err := h.llamaService.Embedding(c.Request.Context(), request)
+	if err != nil {
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondQueueError(c, err) {
+			return
+		}
+		if errors.Is(err, services.ErrInvalidDimensions) {
+			respondError(c, http.StatusBadRequest, "Invalid dimensions", err.Error())
+			return
+		}
+		if errors.Is(err, services.ErrChatOnly) {
+			respondError(c, http.StatusBadRequest, "Model does not support embeddings", err.Error())
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process embedding request", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Rewrite handles style transfer requests for existing text
+func (h *LlamaHandler) Rewrite(c *gin.Context) {
+	var request models.RewriteRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	// Validate request
+	if strings.TrimSpace(request.Text) == "" {
+		respondError(c, http.StatusBadRequest, "Text is required", "")
+		return
+	}
+
+	preferences := requestPreferences(c)
+	request.Model = defaultTo(request.Model, preferences.Model)
+	request.Style = defaultTo(request.Style, preferences.Style)
+	request.Tone = defaultTo(request.Tone, preferences.Tone)
+	request.Language = defaultTo(request.Language, preferences.Language)
+
+	response, err := h.llamaService.Rewrite(c.Request.Context(), request)
+	if err != nil {
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process rewrite request", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Glossary handles extracting the key terms of a text with their definitions
+func (h *LlamaHandler) Glossary(c *gin.Context) {
+	var request models.GlossaryRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	// Validate request
+	if strings.TrimSpace(request.Text) == "" {
+		respondError(c, http.StatusBadRequest, "Text is required", "")
+		return
+	}
+
+	preferences := requestPreferences(c)
+	request.Model = defaultTo(request.Model, preferences.Model)
+	request.Language = defaultTo(request.Language, preferences.Language)
+
+	response, err := h.llamaService.Glossary(c.Request.Context(), request)
+	if err != nil {
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) || respondSchemaValidationError(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process glossary request", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// maxCompareModels caps how many models a single comparison may include
+const maxCompareModels = 8
+
+// Compare handles running one prompt against several models side-by-side
+func (h *LlamaHandler) Compare(c *gin.Context) {
+	var request models.CompareRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	// Validate request
+	if strings.TrimSpace(request.Prompt) == "" {
+		respondError(c, http.StatusBadRequest, "Prompt is required", "")
+		return
+	}
+
+	request.Models = uniqueModels(request.Models)
+	if len(request.Models) < 2 || len(request.Models) > maxCompareModels {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Between 2 and %d distinct models are required", maxCompareModels), "")
+		return
+	}
+	if respondLimitError(c, promptLimitError(c, request.Prompt)) || respondLimitError(c, maxTokensError(request.MaxTokens)) {
+		return
+	}
+
+	clamped := clampMaxTokens(c, &request.MaxTokens, request.Options)
+
+	response, err := h.llamaService.Compare(c.Request.Context(), request)
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to process compare request", err.Error())
+		return
+	}
+
+	// Each failed model reports the error its own request would have been answered with
+	for i, result := range response.Results {
+		if result.Err == nil {
+			continue
+		}
+		f := generationFailure(result.Err)
+		response.Results[i].Status = f.status
+		response.Results[i].Failure = middleware.NewErrorResponse(c, f.status, f.message, f.details).WithFields(f.fields)
+	}
+
+	response.Clamped = clamped
+	c.JSON(http.StatusOK, response)
+}
+
+// maxBatchRequests caps how many chat requests a single batch may include
+const maxBatchRequests = 100
+
+// ChatBatch handles running several chat requests with bounded parallelism
+func (h *LlamaHandler) ChatBatch(c *gin.Context) {
+	var request models.BatchChatRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	// Validate request
+	if len(request.Requests) == 0 || len(request.Requests) > maxBatchRequests {
+		respondError(c, http.StatusBadRequest, fmt.Sprintf("Between 1 and %d requests are required", maxBatchRequests), "")
+		return
+	}
+	if request.ItemTimeoutSeconds < 0 {
+		respondError(c, http.StatusBadRequest, "Item timeout must not be negative", "")
 		return
 	}
 
-	response, err := h.llamaService.Embedding(request)
+	model := requestPreferences(c).Model
+	clamped := make([][]models.ClampedLimit, len(request.Requests))
+	for i := range request.Requests {
+		item := &request.Requests[i]
+		if len(item.Messages) == 0 {
+			respondError(c, http.StatusBadRequest, fmt.Sprintf("Request %d has no messages", i), "")
+			return
+		}
+		if err := chatLimitError(c, item.Messages, item.MaxTokens); err != nil {
+			respondLimitError(c, fmt.Errorf("request %d: %w", i, err))
+			return
+		}
+		clamped[i] = clampMaxTokens(c, &item.MaxTokens, item.Options)
+		item.Model = defaultTo(item.Model, model)
+		item.Stream = false
+	}
+
+	response, err := h.llamaService.ChatBatch(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to process embedding request",
-			"details": err.Error(),
-		})
+		if respondClientCancelled(c, err) {
+			return
+		}
+		respondError(c, http.StatusInternalServerError, "Failed to process chat batch", err.Error())
 		return
 	}
 
+	for i, result := range response.Results {
+		if result.Response != nil {
+			result.Response.Clamped = clamped[i]
+		}
+	}
 	c.JSON(http.StatusOK, response)
 }
 
+// uniqueModels drops empty and duplicate model names while keeping their order
+func uniqueModels(names []string) []string {
+	seen := make(map[string]bool, len(names))
+	unique := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		unique = append(unique, name)
+	}
+	return unique
+}
+
 // ListModels returns available Llama models
 func (h *LlamaHandler) ListModels(c *gin.Context) {
 	models, err := h.llamaService.ListModels()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to retrieve models",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "Failed to retrieve models", err.Error())
 		return
 	}
 
@@ -133,58 +590,60 @@ func (h *LlamaHandler) ListModels(c *gin.Context) {
 func (h *LlamaHandler) StreamChat(c *gin.Context) {
 	var request models.ChatRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
 		return
 	}
+	if respondLimitError(c, chatLimitError(c, request.Messages, request.MaxTokens)) {
+		return
+	}
+	// A stream has no response body to report clamping in
+	clampMaxTokens(c, &request.MaxTokens, request.Options)
+	request.Model = defaultTo(request.Model, requestPreferences(c).Model)
 
-	// Set headers for streaming
-	c.Header("Content-Type", "text/event-stream")
-	c.Header("Cache-Control", "no-cache")
-	c.Header("Connection", "keep-alive")
-	c.Header("Access-Control-Allow-Origin", "*")
+	setStreamHeaders(c)
 
 	// Create a channel for streaming responses
 	responseChan := make(chan string)
 
-	go func() {
-		h.llamaService.StreamChat(request, responseChan)
-	}()
+	services.Go("stream_chat", func(context.Context) {
+		h.llamaService.StreamChat(c.Request.Context(), request, responseChan)
+	})
 
-	// Stream responses
+	// Stream responses in the event schema the client asked for, at the client's output rate
+	events := newStreamEncoder(c, request.Model, h.llamaService.GenerateID(), h.llamaService.Now())
+	pacer := streamPacer(c)
 	for response := range responseChan {
-		c.SSEvent("message", response)
-		c.Writer.Flush()
+		if err := pacer.Wait(c.Request.Context(), 1); err != nil {
+			// The client is gone; drain the channel so the service can finish
+			for range responseChan {
+			}
+			return
+		}
+		events.chunk(c, response)
+	}
+	if pacer != nil {
+		c.SSEvent("stream.metadata", gin.H{"rate": pacer.Report()})
 	}
+	events.finish(c)
 }
 
 // SignIn handles Ollama cloud authentication
 func (h *LlamaHandler) SignIn(c *gin.Context) {
 	var request models.AuthRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Invalid request format",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
 		return
 	}
 
 	// Validate request
-	if request.Username == "" || request.Password == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Username and password are required",
-		})
+	if request.Token == "" {
+		respondError(c, http.StatusBadRequest, "API key token is required", "Ollama Cloud signs in with an API key; create one at https://ollama.com/settings/keys")
 		return
 	}
 
-	response, err := h.llamaService.SignIn(request.Username, request.Password)
+	response, err := h.llamaService.SignIn(c.Request.Context(), request)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to sign in",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "Failed to sign in", err.Error())
 		return
 	}
 
@@ -200,10 +659,7 @@ func (h *LlamaHandler) SignIn(c *gin.Context) {
 func (h *LlamaHandler) SignOut(c *gin.Context) {
 	err := h.llamaService.SignOut()
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to sign out",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "Failed to sign out", err.Error())
 		return
 	}
 
@@ -216,18 +672,13 @@ func (h *LlamaHandler) SignOut(c *gin.Context) {
 func (h *LlamaHandler) PullModel(c *gin.Context) {
 	modelName := c.Param("model")
 	if modelName == "" {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"error": "Model name is required",
-		})
+		respondError(c, http.StatusBadRequest, "Model name is required", "")
 		return
 	}
 
 	err := h.llamaService.PullModel(modelName)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to pull model",
-			"details": err.Error(),
-		})
+		respondError(c, http.StatusInternalServerError, "Failed to pull model", err.Error())
 		return
 	}
 
@@ -237,9 +688,301 @@ func (h *LlamaHandler) PullModel(c *gin.Context) {
 	})
 }
 
+// DeleteModel handles removing a local model
+func (h *LlamaHandler) DeleteModel(c *gin.Context) {
+	modelName := c.Param("model")
+	if modelName == "" {
+		respondError(c, http.StatusBadRequest, "Model name is required", "")
+		return
+	}
+
+	err := h.llamaService.DeleteModel(modelName)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrDefaultModelProtected):
+			status = http.StatusConflict
+		case errors.Is(err, services.ErrModelNotFound):
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to delete model", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Model deleted successfully",
+		"model":   modelName,
+	})
+}
+
+// CopyModel handles copying a model to a new name
+func (h *LlamaHandler) CopyModel(c *gin.Context) {
+	var request models.CopyModelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	source := c.Param("model")
+	err := h.llamaService.CopyModel(source, request.Destination)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrModelNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to copy model", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":     "Model copied successfully",
+		"source":      source,
+		"destination": request.Destination,
+	})
+}
+
+// CreateModel handles building a model from a Modelfile, streaming build progress
+func (h *LlamaHandler) CreateModel(c *gin.Context) {
+	var request models.CreateModelRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	// Validate the Modelfile before opening the stream
+	if _, err := services.ParseModelfile(request.Modelfile); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid Modelfile", err.Error())
+		return
+	}
+
+	setStreamHeaders(c)
+
+	// gin.Context is not safe for concurrent use, so the build gets plain values
+	ctx, modelName := c.Request.Context(), c.Param("model")
+	progressChan := make(chan string)
+
+	services.Go("create_model", func(context.Context) {
+		h.llamaService.CreateModel(ctx, modelName, request.Modelfile, progressChan)
+	})
+
+	// Stream build progress
+	for progress := range progressChan {
+		c.SSEvent("progress", progress)
+		c.Writer.Flush()
+	}
+}
+
+// CloudUsage reports the tokens consumed through this server, split by local and cloud backend
+func (h *LlamaHandler) CloudUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, h.llamaService.Usage())
+}
+
+// ListAliases returns the server-side model alias table
+func (h *LlamaHandler) ListAliases(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"aliases": h.llamaService.ListAliases(),
+	})
+}
+
+// SetAlias handles creating or updating a model alias
+func (h *LlamaHandler) SetAlias(c *gin.Context) {
+	var request models.AliasRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	alias := c.Param("alias")
+	if err := h.llamaService.SetAlias(alias, request.Model); err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to set alias", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"alias": alias,
+		"model": request.Model,
+	})
+}
+
+// DeleteAlias handles removing a model alias
+func (h *LlamaHandler) DeleteAlias(c *gin.Context) {
+	alias := c.Param("alias")
+	if err := h.llamaService.DeleteAlias(alias); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrAliasNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to delete alias", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alias deleted successfully",
+		"alias":   alias,
+	})
+}
+
 // ListCloudModels returns available cloud models
 func (h *LlamaHandler) ListCloudModels(c *gin.Context) {
+	cloudModels, err := h.llamaService.ListCloudModels(c.Request.Context())
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, "Failed to list cloud models", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"models": cloudModels,
+	})
+}
+
+// ListPresets returns the system prompt presets
+func (h *LlamaHandler) ListPresets(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"presets": h.llamaService.ListPresets(),
+	})
+}
+
+// GetPreset returns a single system prompt preset
+func (h *LlamaHandler) GetPreset(c *gin.Context) {
+	preset, err := h.llamaService.GetPreset(c.Param("name"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Failed to get preset", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, preset)
+}
+
+// SetPreset creates or replaces a system prompt preset
+func (h *LlamaHandler) SetPreset(c *gin.Context) {
+	var request models.PresetRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	preset := models.Preset{
+		Name:         c.Param("name"),
+		Description:  request.Description,
+		SystemPrompt: request.SystemPrompt,
+	}
+	if err := h.llamaService.SetPreset(preset); err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to set preset", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, preset)
+}
+
+// DeletePreset removes a system prompt preset
+func (h *LlamaHandler) DeletePreset(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.llamaService.DeletePreset(name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPresetNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to delete preset", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Preset deleted successfully",
+		"name":    name,
+	})
+}
+
+// ListPrompts returns the prompt templates
+func (h *LlamaHandler) ListPrompts(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"models": services.CloudModels,
+		"prompts": h.llamaService.ListPrompts(),
 	})
 }
+
+// GetPrompt returns a single prompt template
+func (h *LlamaHandler) GetPrompt(c *gin.Context) {
+	prompt, err := h.llamaService.GetPrompt(c.Param("name"))
+	if err != nil {
+		respondError(c, http.StatusNotFound, "Failed to get prompt template", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// SetPrompt registers or replaces a prompt template
+func (h *LlamaHandler) SetPrompt(c *gin.Context) {
+	var request models.PromptTemplateRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	prompt := models.PromptTemplate{
+		Name:         c.Param("name"),
+		Description:  request.Description,
+		Template:     request.Template,
+		SystemPrompt: request.SystemPrompt,
+		Model:        request.Model,
+	}
+	if err := h.llamaService.SetPrompt(prompt); err != nil {
+		respondError(c, http.StatusBadRequest, "Failed to set prompt template", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, prompt)
+}
+
+// DeletePrompt removes a prompt template
+func (h *LlamaHandler) DeletePrompt(c *gin.Context) {
+	name := c.Param("name")
+	if err := h.llamaService.DeletePrompt(name); err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrPromptNotFound) {
+			status = http.StatusNotFound
+		}
+		respondError(c, status, "Failed to delete prompt template", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Prompt template deleted successfully",
+		"name":    name,
+	})
+}
+
+// RunPrompt renders a prompt template with the given variables and returns the model's answer
+func (h *LlamaHandler) RunPrompt(c *gin.Context) {
+	var request models.RunPromptRequest
+	// The body is optional: a template without variables can be run with an empty request
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&request); err != nil {
+			respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+			return
+		}
+	}
+	if respondLimitError(c, maxTokensError(request.MaxTokens)) {
+		return
+	}
+
+	clamped := clampMaxTokens(c, &request.MaxTokens, request.Options)
+
+	response, err := h.llamaService.RunPrompt(c.Request.Context(), c.Param("name"), request)
+	if err != nil {
+		if respondClientCancelled(c, err) || respondBudgetExceeded(c, err) || respondGenerationTimeout(c, err) || respondQueueError(c, err) || respondUpstreamError(c, err) || respondModerationError(c, err) {
+			return
+		}
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrPromptNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrPromptRender):
+			status = http.StatusBadRequest
+		}
+		respondError(c, status, "Failed to run prompt template", err.Error())
+		return
+	}
+
+	response.Clamped = clamped
+	c.JSON(http.StatusOK, response)
+}
@@ -2,13 +2,17 @@ package handlers
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
+	"agent-ollama-gin/middleware"
 	"agent-ollama-gin/models"
 	"agent-ollama-gin/services"
 
@@ -25,7 +29,7 @@ type MockLlamaService struct {
 // Ensure MockLlamaService implements the interface
 var _ services.LlamaServiceInterface = (*MockLlamaService)(nil)
 
-func (m *MockLlamaService) Chat(request models.ChatRequest) (*models.ChatResponse, error) {
+func (m *MockLlamaService) Chat(ctx context.Context, request models.ChatRequest) (*models.ChatResponse, error) {
 	args := m.Called(request)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -33,7 +37,7 @@ func (m *MockLlamaService) Chat(request models.ChatRequest) (*models.ChatRespons
 	return args.Get(0).(*models.ChatResponse), args.Error(1)
 }
 
-func (m *MockLlamaService) Completion(request models.CompletionRequest) (*models.CompletionResponse, error) {
+func (m *MockLlamaService) Completion(ctx context.Context, request models.CompletionRequest) (*models.CompletionResponse, error) {
 	args := m.Called(request)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -41,7 +45,7 @@ func (m *MockLlamaService) Completion(request models.CompletionRequest) (*models
 	return args.Get(0).(*models.CompletionResponse), args.Error(1)
 }
 
-func (m *MockLlamaService) Embedding(request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
+func (m *MockLlamaService) Embedding(ctx context.Context, request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
 	args := m.Called(request)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
@@ -57,14 +61,50 @@ func (m *MockLlamaService) ListModels() ([]models.Model, error) {
 	return args.Get(0).([]models.Model), args.Error(1)
 }
 
-func (m *MockLlamaService) SignIn(username, password string) (*models.AuthResponse, error) {
-	args := m.Called(username, password)
+func (m *MockLlamaService) SignIn(ctx context.Context, request models.AuthRequest) (*models.AuthResponse, error) {
+	args := m.Called(request)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
 	return args.Get(0).(*models.AuthResponse), args.Error(1)
 }
 
+func (m *MockLlamaService) Usage() *models.UsageReport {
+	args := m.Called()
+	return args.Get(0).(*models.UsageReport)
+}
+
+func (m *MockLlamaService) UsageRecords(ctx context.Context, query models.UsageQuery) (*models.UsageRecordList, error) {
+	args := m.Called(query)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.UsageRecordList), args.Error(1)
+}
+
+func (m *MockLlamaService) ShadowReport() models.ShadowReport {
+	args := m.Called()
+	return args.Get(0).(models.ShadowReport)
+}
+
+func (m *MockLlamaService) Upstreams() []models.UpstreamStatus {
+	args := m.Called()
+	return args.Get(0).([]models.UpstreamStatus)
+}
+
+func (m *MockLlamaService) ResetUpstream(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) ListCloudModels(ctx context.Context) ([]models.CloudModel, error) {
+	args := m.Called()
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CloudModel), args.Error(1)
+}
+
 func (m *MockLlamaService) SignOut() error {
 	args := m.Called()
 	return args.Error(0)
@@ -75,10 +115,144 @@ func (m *MockLlamaService) PullModel(modelName string) error {
 	return args.Error(0)
 }
 
-func (m *MockLlamaService) StreamChat(request models.ChatRequest, responseChan chan<- string) {
+func (m *MockLlamaService) DeleteModel(modelName string) error {
+	args := m.Called(modelName)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) CopyModel(source, destination string) error {
+	args := m.Called(source, destination)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) CreateModel(ctx context.Context, modelName, modelfile string, progressChan chan<- string) {
+	m.Called(modelName, modelfile, progressChan)
+}
+
+func (m *MockLlamaService) DefaultModel() string {
+	args := m.Called()
+	return args.String(0)
+}
+
+// mockNow is the time on the mock's clock. Now and GenerateID are not expectations, as every
+// streamed response reads them.
+var mockNow = time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func (m *MockLlamaService) Now() time.Time {
+	return mockNow
+}
+
+func (m *MockLlamaService) GenerateID() string {
+	return fmt.Sprintf("chatcmpl-%d", mockNow.UnixNano())
+}
+
+func (m *MockLlamaService) ListAliases() map[string]string {
+	args := m.Called()
+	return args.Get(0).(map[string]string)
+}
+
+func (m *MockLlamaService) SetAlias(alias, model string) error {
+	args := m.Called(alias, model)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) DeleteAlias(alias string) error {
+	args := m.Called(alias)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) ListPresets() []models.Preset {
+	args := m.Called()
+	return args.Get(0).([]models.Preset)
+}
+
+func (m *MockLlamaService) GetPreset(name string) (models.Preset, error) {
+	args := m.Called(name)
+	return args.Get(0).(models.Preset), args.Error(1)
+}
+
+func (m *MockLlamaService) SetPreset(preset models.Preset) error {
+	args := m.Called(preset)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) DeletePreset(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) ListPrompts() []models.PromptTemplate {
+	args := m.Called()
+	return args.Get(0).([]models.PromptTemplate)
+}
+
+func (m *MockLlamaService) GetPrompt(name string) (models.PromptTemplate, error) {
+	args := m.Called(name)
+	return args.Get(0).(models.PromptTemplate), args.Error(1)
+}
+
+func (m *MockLlamaService) SetPrompt(prompt models.PromptTemplate) error {
+	args := m.Called(prompt)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) DeletePrompt(name string) error {
+	args := m.Called(name)
+	return args.Error(0)
+}
+
+func (m *MockLlamaService) RunPrompt(ctx context.Context, name string, request models.RunPromptRequest) (*models.RunPromptResponse, error) {
+	args := m.Called(name, request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RunPromptResponse), args.Error(1)
+}
+
+func (m *MockLlamaService) SwapModel(ctx context.Context, request models.SwapModelRequest) (*models.SwapModelResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.SwapModelResponse), args.Error(1)
+}
+
+func (m *MockLlamaService) StreamChat(ctx context.Context, request models.ChatRequest, responseChan chan<- string) {
 	m.Called(request, responseChan)
 }
 
+func (m *MockLlamaService) Rewrite(ctx context.Context, request models.RewriteRequest) (*models.RewriteResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RewriteResponse), args.Error(1)
+}
+
+func (m *MockLlamaService) Glossary(ctx context.Context, request models.GlossaryRequest) (*models.GlossaryResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.GlossaryResponse), args.Error(1)
+}
+
+func (m *MockLlamaService) Compare(ctx context.Context, request models.CompareRequest) (*models.CompareResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CompareResponse), args.Error(1)
+}
+
+func (m *MockLlamaService) ChatBatch(ctx context.Context, request models.BatchChatRequest) (*models.BatchChatResponse, error) {
+	args := m.Called(request)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.BatchChatResponse), args.Error(1)
+}
+
 func setupRouter(handler *LlamaHandler) *gin.Engine {
 	gin.SetMode(gin.TestMode)
 	router := gin.Default()
@@ -88,12 +262,31 @@ func setupRouter(handler *LlamaHandler) *gin.Engine {
 		api.POST("/chat", handler.Chat)
 		api.POST("/completion", handler.Completion)
 		api.POST("/embedding", handler.Embedding)
+		api.POST("/rewrite", handler.Rewrite)
+		api.POST("/glossary", handler.Glossary)
+		api.POST("/compare", handler.Compare)
+		api.POST("/chat/batch", handler.ChatBatch)
 		api.GET("/models", handler.ListModels)
 		api.POST("/chat/stream", handler.StreamChat)
 		api.POST("/cloud/signin", handler.SignIn)
 		api.POST("/cloud/signout", handler.SignOut)
 		api.POST("/models/:model/pull", handler.PullModel)
+		api.DELETE("/models/:model", handler.DeleteModel)
+		api.POST("/models/:model/copy", handler.CopyModel)
+		api.POST("/models/:model/create", handler.CreateModel)
+		api.GET("/aliases", handler.ListAliases)
+		api.PUT("/aliases/:alias", handler.SetAlias)
+		api.DELETE("/aliases/:alias", handler.DeleteAlias)
+		api.GET("/presets", handler.ListPresets)
+		api.GET("/presets/:name", handler.GetPreset)
+		api.PUT("/presets/:name", handler.SetPreset)
+		api.DELETE("/presets/:name", handler.DeletePreset)
+		api.GET("/prompts", handler.ListPrompts)
+		api.PUT("/prompts/:name", handler.SetPrompt)
+		api.DELETE("/prompts/:name", handler.DeletePrompt)
+		api.POST("/prompts/:name/run", handler.RunPrompt)
 		api.GET("/cloud/models", handler.ListCloudModels)
+		api.GET("/cloud/usage", handler.CloudUsage)
 	}
 
 	return router
@@ -174,6 +367,27 @@ func TestChat_EmptyMessages(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestChat_TooManyTopLogprobs(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	chatRequest := models.ChatRequest{
+		Messages:    []models.Message{{Role: "user", Content: "Hello"}},
+		TopLogprobs: 21,
+	}
+
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "Chat")
+}
+
 func TestChat_ServiceError(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
@@ -198,187 +412,752 @@ func TestChat_ServiceError(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
-func TestCompletion_Success(t *testing.T) {
+func TestChat_SchemaValidationError(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	expectedResponse := &models.CompletionResponse{
-		ID:      "test-id",
-		Object:  "text_completion",
-		Created: time.Now().Unix(),
-		Model:   "llama2",
-		Choices: []models.Choice{
-			{
-				Index: 0,
-				Message: models.Message{
-					Role:    "assistant",
-					Content: "The future of AI is bright",
-				},
-			},
-		},
-	}
-
-	completionRequest := models.CompletionRequest{
-		Prompt: "The future of AI is",
-		Model:  "llama2",
+	chatRequest := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Describe a cat as JSON"}},
 	}
 
-	mockService.On("Completion", completionRequest).Return(expectedResponse, nil)
+	mockService.On("Chat", chatRequest).Return(nil, &services.SchemaValidationError{
+		Model:    "llama3.2",
+		Errors:   []string{"at /: missing property 'name'"},
+		Output:   `{"age": 3}`,
+		Attempts: 3,
+	})
 
-	body, _ := json.Marshal(completionRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/llama/completion", bytes.NewBuffer(body))
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, []interface{}{"at /: missing property 'name'"}, response["validation_errors"])
+	assert.Equal(t, float64(3), response["attempts"])
 	mockService.AssertExpectations(t)
 }
 
-func TestCompletion_EmptyPrompt(t *testing.T) {
+func TestChat_ModerationBlocked(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	completionRequest := models.CompletionRequest{
-		Prompt: "",
-		Model:  "llama2",
+	chatRequest := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Well, darn it"}},
 	}
 
-	body, _ := json.Marshal(completionRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/llama/completion", bytes.NewBuffer(body))
+	mockService.On("Chat", chatRequest).Return(nil, fmt.Errorf("chat request rejected: %w", &services.ModerationError{
+		Stage:      services.ModerationPrompt,
+		Categories: []string{"profanity"},
+	}))
+
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "prompt", response["stage"])
+	assert.Equal(t, []interface{}{"profanity"}, response["categories"])
+	mockService.AssertExpectations(t)
 }
 
-func TestEmbedding_Success(t *testing.T) {
+func TestCompletion_ModerationBlocked(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	expectedResponse := &models.EmbeddingResponse{
-		Object: "list",
-		Data: []models.Embedding{
-			{
-				Object:    "embedding",
-				Embedding: []float64{0.1, 0.2, 0.3},
-				Index:     0,
-			},
-		},
-		Model: "llama2",
-	}
-
-	embeddingRequest := models.EmbeddingRequest{
-		Input: "Test input",
-		Model: "llama2",
-	}
+	completionRequest := models.CompletionRequest{Prompt: "Well, darn it"}
 
-	mockService.On("Embedding", embeddingRequest).Return(expectedResponse, nil)
+	mockService.On("Completion", completionRequest).Return(nil, fmt.Errorf("completion request rejected: %w", &services.ModerationError{
+		Stage:      services.ModerationPrompt,
+		Categories: []string{"profanity"},
+	}))
 
-	body, _ := json.Marshal(embeddingRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/llama/embedding", bytes.NewBuffer(body))
+	body, _ := json.Marshal(completionRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/completion", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "prompt", response["stage"])
+	assert.Equal(t, []interface{}{"profanity"}, response["categories"])
 	mockService.AssertExpectations(t)
 }
 
-func TestListModels_Success(t *testing.T) {
+func TestChat_ClientCancelled(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	expectedModels := []models.Model{
-		{
-			ID:      "llama2",
-			Object:  "model",
-			Created: time.Now().Unix(),
-			OwnedBy: "ollama",
+	chatRequest := models.ChatRequest{
+		Messages: []models.Message{
+			{Role: "user", Content: "Hello"},
 		},
+		Model: "llama2",
 	}
 
-	mockService.On("ListModels").Return(expectedModels, nil)
+	mockService.On("Chat", chatRequest).Return(nil, &services.CancelledError{Model: "llama2"})
+
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
 
-	req, _ := http.NewRequest("GET", "/api/v1/llama/models", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	mockService.AssertExpectations(t)
+	assert.Equal(t, StatusClientClosedRequest, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Client closed request", response["error"])
 }
 
-func TestSignIn_Success(t *testing.T) {
+func TestChat_GenerationTimeout(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	expectedResponse := &models.AuthResponse{
-		Success: true,
-		Token:   "test-token",
-		Message: "Successfully signed in",
+	chatRequest := models.ChatRequest{
+		Messages: []models.Message{
+			{Role: "user", Content: "Write a long essay"},
+		},
+		Model: "llama3:70b",
 	}
 
-	authRequest := models.AuthRequest{
-		Username: "test@example.com",
-		Password: "password123",
+	timeoutErr := &services.GenerationTimeoutError{
+		Model:   "llama3:70b",
+		Timeout: 60 * time.Second,
+		Partial: "Once upon a time",
 	}
+	mockService.On("Chat", chatRequest).Return(nil, timeoutErr)
 
-	mockService.On("SignIn", "test@example.com", "password123").Return(expectedResponse, nil)
-
-	body, _ := json.Marshal(authRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signin", bytes.NewBuffer(body))
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Once upon a time", response["partial_output"])
+	assert.Equal(t, float64(60), response["timeout_seconds"])
 	mockService.AssertExpectations(t)
 }
 
-func TestSignIn_MissingCredentials(t *testing.T) {
+func TestChat_BudgetExceeded(t *testing.T) {
 	mockService := new(MockLlamaService)
 	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
-	authRequest := models.AuthRequest{
-		Username: "",
-		Password: "",
+	chatRequest := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+		Model:    "llama3",
 	}
 
-	body, _ := json.Marshal(authRequest)
-	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signin", bytes.NewBuffer(body))
+	budgetErr := &services.RequestBudgetError{
+		Model:  "llama3",
+		Budget: 2 * time.Second,
+		Stage:  services.StageQueue,
+		Spent:  map[string]time.Duration{services.StageSemanticCache: 300 * time.Millisecond, services.StageQueue: 1700 * time.Millisecond},
+	}
+	mockService.On("Chat", chatRequest).Return(nil, budgetErr)
+
+	body, _ := json.Marshal(chatRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
 	req.Header.Set("Content-Type", "application/json")
 
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "queue", response["stage"])
+	assert.Equal(t, float64(2000), response["budget_ms"])
+	assert.Equal(t, map[string]interface{}{"semantic_cache": float64(300), "queue": float64(1700)}, response["stage_ms"])
+	mockService.AssertExpectations(t)
 }
 
-func TestSignOut_Success(t *testing.T) {
-	mockService := new(MockLlamaService)
-	handler := NewLlamaHandler(mockService)
-	router := setupRouter(handler)
+func TestChat_QueueErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		reason       error
+		expectedCode int
+	}{
+		{name: "Queue full", reason: services.ErrQueueFull, expectedCode: http.StatusTooManyRequests},
+		{name: "Queue timeout", reason: services.ErrQueueTimeout, expectedCode: http.StatusServiceUnavailable},
+	}
 
-	mockService.On("SignOut").Return(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			handler := NewLlamaHandler(mockService)
+			router := setupRouter(handler)
 
-	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signout", nil)
-	w := httptest.NewRecorder()
-	router.ServeHTTP(w, req)
+			chatRequest := models.ChatRequest{
+				Messages: []models.Message{{Role: "user", Content: "Hello"}},
+			}
+			mockService.On("Chat", chatRequest).Return(nil, &services.QueueError{Reason: tt.reason, RetryAfter: 30 * time.Second})
 
-	assert.Equal(t, http.StatusOK, w.Code)
-	mockService.AssertExpectations(t)
-}
+			body, _ := json.Marshal(chatRequest)
+			req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, "30", w.Header().Get("Retry-After"))
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCompletion_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedResponse := &models.CompletionResponse{
+		ID:      "test-id",
+		Object:  "text_completion",
+		Created: time.Now().Unix(),
+		Model:   "llama2",
+		Choices: []models.Choice{
+			{
+				Index: 0,
+				Message: models.Message{
+					Role:    "assistant",
+					Content: "The future of AI is bright",
+				},
+			},
+		},
+	}
+
+	completionRequest := models.CompletionRequest{
+		Prompt: "The future of AI is",
+		Model:  "llama2",
+	}
+
+	mockService.On("Completion", completionRequest).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(completionRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/completion", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCompletion_EmptyPrompt(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	completionRequest := models.CompletionRequest{
+		Prompt: "",
+		Model:  "llama2",
+	}
+
+	body, _ := json.Marshal(completionRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/completion", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestEmbedding_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedResponse := &models.EmbeddingResponse{
+		Object: "list",
+		Data: []models.Embedding{
+			{
+				Object:    "embedding",
+				Embedding: []float64{0.1, 0.2, 0.3},
+				Index:     0,
+			},
+		},
+		Model: "llama2",
+	}
+
+	embeddingRequest := models.EmbeddingRequest{
+		Input: "Test input",
+		Model: "llama2",
+	}
+
+	mockService.On("Embedding", embeddingRequest).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(embeddingRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/embedding", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRewrite_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedResponse := &models.RewriteResponse{
+		ID:     "test-id",
+		Object: "text.rewrite",
+		Model:  "llama2",
+		Text:   "Paris is the capital of France [1].",
+	}
+
+	rewriteRequest := models.RewriteRequest{
+		Text:              "paris is france's capital [1]",
+		Style:             "encyclopedic",
+		PreserveCitations: true,
+	}
+
+	mockService.On("Rewrite", rewriteRequest).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(rewriteRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/rewrite", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestRewrite_EmptyText(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(map[string]string{"text": "   ", "style": "casual"})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/rewrite", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestGlossary_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	glossaryRequest := models.GlossaryRequest{
+		Text:     "Photosynthesis turns light into chemical energy in the chloroplast.",
+		MaxTerms: 5,
+	}
+	mockService.On("Glossary", glossaryRequest).Return(&models.GlossaryResponse{
+		Object: "text.glossary",
+		Model:  "llama2",
+		Entries: []models.GlossaryEntry{
+			{Term: "chloroplast", Definition: "Where photosynthesis happens.", Excerpt: glossaryRequest.Text},
+		},
+	}, nil)
+
+	body, _ := json.Marshal(glossaryRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/glossary", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"term":"chloroplast"`)
+	mockService.AssertExpectations(t)
+}
+
+func TestGlossary_TooManyTerms(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(map[string]interface{}{"text": "Photosynthesis", "max_terms": 500})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/glossary", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	mockService.AssertNotCalled(t, "Glossary", mock.Anything)
+}
+
+func TestCompare_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedResponse := &models.CompareResponse{
+		Object: "model.comparison",
+		Prompt: "What is Go?",
+		Results: []models.CompareResult{
+			{Model: "llama2", Output: "A language", LatencyMs: 120},
+			{Model: "phi3:mini", Output: "A game", LatencyMs: 80},
+		},
+	}
+
+	// Duplicates and blanks are removed before reaching the service
+	mockService.On("Compare", models.CompareRequest{
+		Prompt: "What is Go?",
+		Models: []string{"llama2", "phi3:mini"},
+	}).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(models.CompareRequest{
+		Prompt: "What is Go?",
+		Models: []string{"llama2", "phi3:mini", "llama2", " "},
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/compare", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCompare_FailedModels(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	missing := &services.UpstreamError{Backend: services.BackendLocal, StatusCode: http.StatusNotFound, Message: "model not found"}
+	busy := &services.QueueError{Reason: services.ErrQueueFull, RetryAfter: 30 * time.Second}
+	mockService.On("Compare", mock.Anything).Return(&models.CompareResponse{
+		Object: "model.comparison",
+		Results: []models.CompareResult{
+			{Model: "llama2", Output: "A language"},
+			{Model: "mistral", Error: missing.Error(), Err: missing},
+			{Model: "phi3:mini", Error: busy.Error(), Err: busy},
+		},
+	}, nil)
+
+	body, _ := json.Marshal(models.CompareRequest{Prompt: "What is Go?", Models: []string{"llama2", "mistral", "phi3:mini"}})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/compare", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Results []struct {
+			Model   string                 `json:"model"`
+			Error   string                 `json:"error"`
+			Status  int                    `json:"status"`
+			Failure map[string]interface{} `json:"failure"`
+		} `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+
+	assert.Zero(t, response.Results[0].Status)
+	assert.Nil(t, response.Results[0].Failure)
+
+	assert.Equal(t, http.StatusNotFound, response.Results[1].Status)
+	assert.Equal(t, "not_found", response.Results[1].Failure["code"])
+	assert.Equal(t, "Model not found", response.Results[1].Failure["error"])
+	assert.Equal(t, services.BackendLocal, response.Results[1].Failure["backend"])
+	assert.Equal(t, missing.Error(), response.Results[1].Error)
+
+	assert.Equal(t, http.StatusTooManyRequests, response.Results[2].Status)
+	assert.Equal(t, "Server is busy", response.Results[2].Failure["error"])
+	assert.Equal(t, 30.0, response.Results[2].Failure["retry_after_seconds"])
+}
+
+func TestCompare_RequiresTwoModels(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(models.CompareRequest{
+		Prompt: "What is Go?",
+		Models: []string{"llama2", "llama2"},
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/compare", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestChatBatch_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	messages := []models.Message{{Role: "user", Content: "Label: I love it"}}
+	expectedResponse := &models.BatchChatResponse{
+		Object: "chat.batch",
+		Results: []models.BatchChatResult{
+			{Response: &models.ChatResponse{Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "positive"}}}}},
+			{Error: "llama2 not found"},
+		},
+		Succeeded: 1,
+		Failed:    1,
+	}
+
+	// Items are run as plain chat requests
+	mockService.On("ChatBatch", models.BatchChatRequest{
+		Requests:           []models.ChatRequest{{Messages: messages}, {Messages: messages, Model: "llama2"}},
+		ItemTimeoutSeconds: 30,
+	}).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(models.BatchChatRequest{
+		Requests:           []models.ChatRequest{{Messages: messages, Stream: true}, {Messages: messages, Model: "llama2"}},
+		ItemTimeoutSeconds: 30,
+	})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat/batch", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response models.BatchChatResponse
+	json.Unmarshal(w.Body.Bytes(), &response)
+	assert.Equal(t, "positive", response.Results[0].Response.Choices[0].Message.Content)
+	assert.Equal(t, "llama2 not found", response.Results[1].Error)
+	mockService.AssertExpectations(t)
+}
+
+func TestChatBatch_InvalidRequests(t *testing.T) {
+	tests := []struct {
+		name    string
+		request models.BatchChatRequest
+	}{
+		{"empty batch", models.BatchChatRequest{Requests: []models.ChatRequest{}}},
+		{"too many requests", models.BatchChatRequest{Requests: make([]models.ChatRequest, maxBatchRequests+1)}},
+		{"request without messages", models.BatchChatRequest{Requests: []models.ChatRequest{
+			{Messages: []models.Message{{Role: "user", Content: "Hi"}}},
+			{},
+		}}},
+		{"negative timeout", models.BatchChatRequest{
+			Requests:           []models.ChatRequest{{Messages: []models.Message{{Role: "user", Content: "Hi"}}}},
+			ItemTimeoutSeconds: -1,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			router := setupRouter(NewLlamaHandler(mockService))
+
+			body, _ := json.Marshal(tt.request)
+			req, _ := http.NewRequest("POST", "/api/v1/llama/chat/batch", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertNotCalled(t, "ChatBatch", mock.Anything)
+		})
+	}
+}
+
+func TestEmbedding_QueueFull(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	embeddingRequest := models.EmbeddingRequest{Input: "Test input"}
+	mockService.On("Embedding", embeddingRequest).Return(nil, &services.QueueError{Reason: services.ErrQueueFull, RetryAfter: 30 * time.Second})
+
+	body, _ := json.Marshal(embeddingRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/embedding", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "30", w.Header().Get("Retry-After"))
+	mockService.AssertExpectations(t)
+}
+
+func TestEmbedding_InvalidDimensions(t *testing.T) {
+	tests := []struct {
+		name       string
+		dimensions int
+		serviceErr error
+	}{
+		{name: "Negative dimensions", dimensions: -1},
+		{name: "Dimensions larger than vector", dimensions: 4096, serviceErr: services.ErrInvalidDimensions},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			handler := NewLlamaHandler(mockService)
+			router := setupRouter(handler)
+
+			embeddingRequest := models.EmbeddingRequest{
+				Input:      "Test input",
+				Dimensions: tt.dimensions,
+			}
+			if tt.serviceErr != nil {
+				mockService.On("Embedding", embeddingRequest).Return(nil, tt.serviceErr)
+			}
+
+			body, _ := json.Marshal(embeddingRequest)
+			req, _ := http.NewRequest("POST", "/api/v1/llama/embedding", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListModels_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedModels := []models.Model{
+		{
+			ID:      "llama2",
+			Object:  "model",
+			Created: time.Now().Unix(),
+			OwnedBy: "ollama",
+		},
+	}
+
+	mockService.On("ListModels").Return(expectedModels, nil)
+
+	req, _ := http.NewRequest("GET", "/api/v1/llama/models", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSignIn_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	expectedResponse := &models.AuthResponse{
+		Success: true,
+		Message: "Successfully signed in",
+	}
+
+	authRequest := models.AuthRequest{
+		Token: "test-api-key",
+	}
+
+	mockService.On("SignIn", authRequest).Return(expectedResponse, nil)
+
+	body, _ := json.Marshal(authRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSignIn_InvalidKey(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	authRequest := models.AuthRequest{Token: "revoked-key"}
+	mockService.On("SignIn", authRequest).Return(&models.AuthResponse{
+		Success: false,
+		Message: "Invalid credentials",
+	}, nil)
+
+	body, _ := json.Marshal(authRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestSignIn_MissingCredentials(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	authRequest := models.AuthRequest{
+		Username: "",
+		Password: "",
+	}
+
+	body, _ := json.Marshal(authRequest)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signin", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestSignOut_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("SignOut").Return(nil)
+
+	req, _ := http.NewRequest("POST", "/api/v1/llama/cloud/signout", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
 
 func TestPullModel_Success(t *testing.T) {
 	mockService := new(MockLlamaService)
@@ -395,10 +1174,339 @@ func TestPullModel_Success(t *testing.T) {
 	mockService.AssertExpectations(t)
 }
 
+func TestDeleteModel(t *testing.T) {
+	tests := []struct {
+		name         string
+		serviceErr   error
+		expectedCode int
+	}{
+		{
+			name:         "Success",
+			serviceErr:   nil,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Default model protected",
+			serviceErr:   services.ErrDefaultModelProtected,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "Model not found",
+			serviceErr:   fmt.Errorf("%w: llama2", services.ErrModelNotFound),
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "Upstream failure",
+			serviceErr:   errors.New("connection refused"),
+			expectedCode: http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			handler := NewLlamaHandler(mockService)
+			router := setupRouter(handler)
+
+			mockService.On("DeleteModel", "llama2").Return(tt.serviceErr)
+
+			req, _ := http.NewRequest("DELETE", "/api/v1/llama/models/llama2", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+			mockService.AssertExpectations(t)
+		})
+	}
+}
+
+func TestCopyModel_Success(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("CopyModel", "llama2", "llama2-backup").Return(nil)
+
+	body, _ := json.Marshal(models.CopyModelRequest{Destination: "llama2-backup"})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/models/llama2/copy", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	mockService.AssertExpectations(t)
+}
+
+func TestCreateModel_StreamsProgress(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	modelfile := "FROM llama3.2\nSYSTEM You are an encyclopedia editor."
+	mockService.On("CreateModel", "editor", modelfile, mock.Anything).Run(func(args mock.Arguments) {
+		progressChan := args.Get(2).(chan<- string)
+		progressChan <- "reading model metadata"
+		progressChan <- "success"
+		close(progressChan)
+	})
+
+	body, _ := json.Marshal(models.CreateModelRequest{Modelfile: modelfile})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/models/editor/create", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "event:progress\ndata:reading model metadata")
+	assert.Contains(t, w.Body.String(), "data:success")
+	mockService.AssertExpectations(t)
+}
+
+func TestChat_UpstreamErrors(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          *services.UpstreamError
+		expectedCode int
+	}{
+		{"local unreachable", &services.UpstreamError{Backend: services.BackendLocal}, http.StatusServiceUnavailable},
+		{"cloud unreachable", &services.UpstreamError{Backend: services.BackendCloud}, http.StatusBadGateway},
+		{"cloud key rejected", &services.UpstreamError{Backend: services.BackendCloud, StatusCode: http.StatusUnauthorized}, http.StatusBadGateway},
+		{"model missing", &services.UpstreamError{Backend: services.BackendLocal, StatusCode: http.StatusNotFound}, http.StatusNotFound},
+		{"cloud quota", &services.UpstreamError{Backend: services.BackendCloud, StatusCode: http.StatusTooManyRequests}, http.StatusTooManyRequests},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := new(MockLlamaService)
+			handler := NewLlamaHandler(mockService)
+			router := setupRouter(handler)
+
+			mockService.On("Chat", mock.Anything).Return(nil, fmt.Errorf("failed to make chat request: %w", tt.err))
+
+			body, _ := json.Marshal(models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+			req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+			req.Header.Set("Content-Type", "application/json")
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+
+			var response map[string]interface{}
+			json.Unmarshal(w.Body.Bytes(), &response)
+			assert.Equal(t, tt.err.Backend, response["backend"])
+		})
+	}
+}
+
+func TestStreamChat_ProxyFriendlyHeaders(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Hello"
+		close(responseChan)
+	})
+
+	body, _ := json.Marshal(models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat/stream", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Header().Get("Content-Type"), "text/event-stream")
+	assert.Equal(t, "no-cache, no-transform", w.Header().Get("Cache-Control"))
+	assert.Equal(t, "no", w.Header().Get("X-Accel-Buffering"))
+	assert.Contains(t, w.Body.String(), "data:Hello")
+}
+
+func TestStreamChat_SchemaV2(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/chat/stream", middleware.StreamSchema(models.StreamSchemaV1, models.StreamSchemas), handler.StreamChat)
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Hel"
+		responseChan <- "lo"
+		close(responseChan)
+	})
+
+	body, _ := json.Marshal(models.ChatRequest{Model: "llama3.2", Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+	req, _ := http.NewRequest("POST", "/chat/stream?schema=v2", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "v2", w.Header().Get("X-Stream-Schema"))
+
+	var chunks []models.ChatCompletionChunk
+	for _, line := range strings.Split(w.Body.String(), "\n") {
+		data, ok := strings.CutPrefix(line, "data:")
+		if !ok || data == "[DONE]" {
+			continue
+		}
+		var chunk models.ChatCompletionChunk
+		assert.NoError(t, json.Unmarshal([]byte(data), &chunk))
+		chunks = append(chunks, chunk)
+	}
+
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, "chat.completion.chunk", chunks[0].Object)
+	assert.Equal(t, "llama3.2", chunks[0].Model)
+	assert.Equal(t, mockService.GenerateID(), chunks[0].ID, "IDs and timestamps come from the service's clock")
+	assert.Equal(t, mockNow.Unix(), chunks[0].Created)
+	assert.Equal(t, models.ChunkDelta{Role: "assistant", Content: "Hel"}, chunks[0].Choices[0].Delta)
+	assert.Equal(t, models.ChunkDelta{Content: "lo"}, chunks[1].Choices[0].Delta)
+	assert.Nil(t, chunks[1].Choices[0].FinishReason)
+	assert.Equal(t, "stop", *chunks[2].Choices[0].FinishReason)
+	assert.Contains(t, w.Body.String(), "event:message.v2\ndata:[DONE]")
+}
+
+func TestStreamChat_SchemaV2Error(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/chat/stream", middleware.StreamSchema(models.StreamSchemaV1, models.StreamSchemas), handler.StreamChat)
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Error: request queue is full"
+		close(responseChan)
+	})
+
+	body, _ := json.Marshal(models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+	req, _ := http.NewRequest("POST", "/chat/stream", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Stream-Schema", "v2")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Body.String(), "event:error.v2\ndata:{\"error\":\"request queue is full\"}")
+	assert.NotContains(t, w.Body.String(), "[DONE]")
+}
+
+func TestCreateModel_InvalidModelfile(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	body, _ := json.Marshal(models.CreateModelRequest{Modelfile: "SYSTEM missing base model"})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/models/editor/create", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestAliases(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("SetAlias", "fast", "phi3:mini").Return(nil)
+	mockService.On("ListAliases").Return(map[string]string{"fast": "phi3:mini"})
+	mockService.On("DeleteAlias", "missing").Return(services.ErrAliasNotFound)
+
+	body, _ := json.Marshal(models.AliasRequest{Model: "phi3:mini"})
+	req, _ := http.NewRequest("PUT", "/api/v1/llama/aliases/fast", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/llama/aliases", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"aliases":{"fast":"phi3:mini"}}`, w.Body.String())
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/llama/aliases/missing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestPresets(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	preset := models.Preset{Name: "code-reviewer", SystemPrompt: "You review Go code."}
+	mockService.On("SetPreset", preset).Return(nil)
+	mockService.On("ListPresets").Return([]models.Preset{preset})
+	mockService.On("GetPreset", "missing").Return(models.Preset{}, services.ErrPresetNotFound)
+	mockService.On("DeletePreset", "code-reviewer").Return(nil)
+
+	body, _ := json.Marshal(models.PresetRequest{SystemPrompt: "You review Go code."})
+	req, _ := http.NewRequest("PUT", "/api/v1/llama/presets/code-reviewer", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/llama/presets", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"presets":[{"name":"code-reviewer","system_prompt":"You review Go code."}]}`, w.Body.String())
+
+	req, _ = http.NewRequest("GET", "/api/v1/llama/presets/missing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/llama/presets/code-reviewer", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestChat_UnknownPreset(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	request := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+		Preset:   "missing",
+	}
+	mockService.On("Chat", request).Return(nil, fmt.Errorf("%w: missing", services.ErrPresetNotFound))
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
 func TestListCloudModels_Success(t *testing.T) {
-	handler := NewLlamaHandler(nil) // No mock needed for this simple handler
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
 	router := setupRouter(handler)
 
+	mockService.On("ListCloudModels").Return(services.CloudModels, nil)
+
 	req, _ := http.NewRequest("GET", "/api/v1/llama/cloud/models", nil)
 	w := httptest.NewRecorder()
 	router.ServeHTTP(w, req)
@@ -415,3 +1523,88 @@ func TestListCloudModels_Success(t *testing.T) {
 	assert.True(t, ok)
 	assert.NotNil(t, models)
 }
+
+func TestCloudUsage(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	mockService.On("Usage").Return(&models.UsageReport{
+		Object: "usage",
+		Local:  models.BackendUsage{Requests: 2, TotalTokens: 120},
+		Cloud:  models.BackendUsage{Requests: 1, TotalTokens: 80},
+	})
+
+	req, _ := http.NewRequest("GET", "/api/v1/llama/cloud/usage", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.UsageReport
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(120), response.Local.TotalTokens)
+	assert.Equal(t, int64(1), response.Cloud.Requests)
+	mockService.AssertExpectations(t)
+}
+
+func TestPrompts(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	prompt := models.PromptTemplate{Name: "summarize", Template: "Summarize {{.text}}"}
+	mockService.On("SetPrompt", prompt).Return(nil)
+	mockService.On("ListPrompts").Return([]models.PromptTemplate{prompt})
+	mockService.On("DeletePrompt", "missing").Return(services.ErrPromptNotFound)
+
+	body, _ := json.Marshal(models.PromptTemplateRequest{Template: "Summarize {{.text}}"})
+	req, _ := http.NewRequest("PUT", "/api/v1/llama/prompts/summarize", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ = http.NewRequest("GET", "/api/v1/llama/prompts", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"prompts":[{"name":"summarize","template":"Summarize {{.text}}"}]}`, w.Body.String())
+
+	req, _ = http.NewRequest("DELETE", "/api/v1/llama/prompts/missing", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	mockService.AssertExpectations(t)
+}
+
+func TestRunPrompt(t *testing.T) {
+	mockService := new(MockLlamaService)
+	handler := NewLlamaHandler(mockService)
+	router := setupRouter(handler)
+
+	request := models.RunPromptRequest{Variables: map[string]interface{}{"text": "Go is fun."}}
+	response := &models.RunPromptResponse{Object: "prompt.run", Prompt: "Summarize Go is fun.", Text: "Go is enjoyable."}
+	mockService.On("RunPrompt", "summarize", request).Return(response, nil)
+	mockService.On("RunPrompt", "summarize", models.RunPromptRequest{}).
+		Return(nil, fmt.Errorf("%w summarize: map has no entry for key \"text\"", services.ErrPromptRender))
+
+	body, _ := json.Marshal(request)
+	req, _ := http.NewRequest("POST", "/api/v1/llama/prompts/summarize/run", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var got models.RunPromptResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &got))
+	assert.Equal(t, "Go is enjoyable.", got.Text)
+
+	req, _ = http.NewRequest("POST", "/api/v1/llama/prompts/summarize/run", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	mockService.AssertExpectations(t)
+}
@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Stop reasons reported in Messages API responses
+const (
+	stopReasonEndTurn   = "end_turn"
+	stopReasonMaxTokens = "max_tokens"
+)
+
+// Messages serves Anthropic's Messages API format so clients written against it can use local models.
+// Requests are translated to chat requests and run through the regular chat pipeline; model names are
+// resolved like any other, so aliases can map the client's model name to an Ollama model.
+func (h *LlamaHandler) Messages(c *gin.Context) {
+	var request models.MessagesRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondMessagesError(c, http.StatusBadRequest, "invalid_request_error", err.Error())
+		return
+	}
+
+	// The system prompt counts towards the prompt length but is not one of the messages
+	chatRequest := chatRequestFromMessages(request)
+	err := messageLimitError(c, len(request.Messages))
+	if err == nil {
+		err = promptLimitError(c, messageContents(chatRequest.Messages)...)
+	}
+	if err != nil {
+		respondMessagesError(c, http.StatusUnprocessableEntity, "invalid_request_error", err.Error())
+		return
+	}
+	// The stop reason compares the output with max_tokens, so it follows the clamped value
+	clampMaxTokens(c, &request.MaxTokens, nil)
+	chatRequest.MaxTokens = request.MaxTokens
+	if request.Stream {
+		h.streamMessages(c, request, chatRequest)
+		return
+	}
+
+	response, err := h.llamaService.Chat(c.Request.Context(), chatRequest)
+	if err != nil {
+		status, errorType := messagesErrorStatus(err)
+		respondMessagesError(c, status, errorType, err.Error())
+		return
+	}
+
+	text := ""
+	if len(response.Choices) > 0 {
+		text = response.Choices[0].Message.Content
+	}
+	stopReason := stopReasonEndTurn
+	if response.Usage.CompletionTokens >= request.MaxTokens {
+		stopReason = stopReasonMaxTokens
+	}
+
+	c.JSON(http.StatusOK, models.MessagesResponse{
+		ID:         messagesID(response.ID),
+		Type:       "message",
+		Role:       "assistant",
+		Model:      request.Model,
+		Content:    []models.MessagesContentBlock{{Type: "text", Text: text}},
+		StopReason: &stopReason,
+		Usage: models.MessagesUsage{
+			InputTokens:  response.Usage.PromptTokens,
+			OutputTokens: response.Usage.CompletionTokens,
+		},
+	})
+}
+
+// streamMessages streams a reply as Messages API server-sent events: message_start, a single text
+// content block with its deltas, message_delta with the stop reason and message_stop
+func (h *LlamaHandler) streamMessages(c *gin.Context, request models.MessagesRequest, chatRequest models.ChatRequest) {
+	setStreamHeaders(c)
+
+	responseChan := make(chan string)
+	services.Go("stream_messages", func(context.Context) {
+		h.llamaService.StreamChat(c.Request.Context(), chatRequest, responseChan)
+	})
+
+	c.SSEvent("message_start", gin.H{
+		"type": "message_start",
+		"message": models.MessagesResponse{
+			ID:      messagesID(h.llamaService.GenerateID()),
+			Type:    "message",
+			Role:    "assistant",
+			Model:   request.Model,
+			Content: []models.MessagesContentBlock{},
+		},
+	})
+	c.SSEvent("content_block_start", gin.H{
+		"type":          "content_block_start",
+		"index":         0,
+		"content_block": models.MessagesContentBlock{Type: "text"},
+	})
+	c.Writer.Flush()
+
+	// Ollama sends about one token per chunk, which is the best output count available while streaming
+	outputTokens := 0
+	pacer := streamPacer(c)
+	for chunk := range responseChan {
+		if err := pacer.Wait(c.Request.Context(), 1); err != nil {
+			// The client is gone; drain the channel so the service can finish
+			for range responseChan {
+			}
+			return
+		}
+		if message, ok := strings.CutPrefix(chunk, "Error: "); ok {
+			c.SSEvent("error", gin.H{
+				"type":  "error",
+				"error": gin.H{"type": "api_error", "message": message},
+			})
+			c.Writer.Flush()
+			// Drain the channel so the service can finish
+			for range responseChan {
+			}
+			return
+		}
+
+		outputTokens++
+		c.SSEvent("content_block_delta", gin.H{
+			"type":  "content_block_delta",
+			"index": 0,
+			"delta": gin.H{"type": "text_delta", "text": chunk},
+		})
+		c.Writer.Flush()
+	}
+
+	stopReason := stopReasonEndTurn
+	if outputTokens >= request.MaxTokens {
+		stopReason = stopReasonMaxTokens
+	}
+	c.SSEvent("content_block_stop", gin.H{"type": "content_block_stop", "index": 0})
+	c.SSEvent("message_delta", gin.H{
+		"type":  "message_delta",
+		"delta": gin.H{"stop_reason": stopReason, "stop_sequence": nil},
+		"usage": gin.H{"output_tokens": outputTokens},
+	})
+	c.SSEvent("message_stop", gin.H{"type": "message_stop"})
+	c.Writer.Flush()
+}
+
+// chatRequestFromMessages translates a Messages API request into a chat request
+func chatRequestFromMessages(request models.MessagesRequest) models.ChatRequest {
+	messages := make([]models.Message, 0, len(request.Messages)+1)
+	if request.System != "" {
+		messages = append(messages, models.Message{Role: "system", Content: string(request.System)})
+	}
+	for _, message := range request.Messages {
+		messages = append(messages, models.Message{Role: message.Role, Content: string(message.Content)})
+	}
+
+	chatRequest := models.ChatRequest{
+		Model:     request.Model,
+		Messages:  messages,
+		MaxTokens: request.MaxTokens,
+		Stream:    request.Stream,
+	}
+	if request.Temperature != nil || request.TopP != nil || request.TopK != nil || len(request.StopSequences) > 0 {
+		chatRequest.Options = &models.Options{
+			Temperature: request.Temperature,
+			TopP:        request.TopP,
+			TopK:        request.TopK,
+			Stop:        request.StopSequences,
+		}
+	}
+	return chatRequest
+}
+
+// messagesErrorStatus maps a chat failure to a status and Messages API error type
+func messagesErrorStatus(err error) (int, string) {
+	var upstreamErr *services.UpstreamError
+	var queueErr *services.QueueError
+	var timeoutErr *services.GenerationTimeoutError
+	var budgetErr *services.RequestBudgetError
+	var moderationErr *services.ModerationError
+	switch {
+	case errors.Is(err, context.Canceled):
+		return StatusClientClosedRequest, "api_error"
+	case errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusNotFound:
+		return http.StatusNotFound, "not_found_error"
+	case errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusBadRequest:
+		return http.StatusBadRequest, "invalid_request_error"
+	case errors.As(err, &upstreamErr) && upstreamErr.StatusCode == http.StatusTooManyRequests:
+		return http.StatusTooManyRequests, "rate_limit_error"
+	case errors.As(err, &upstreamErr):
+		return http.StatusServiceUnavailable, "overloaded_error"
+	case errors.As(err, &queueErr) && errors.Is(err, services.ErrQueueFull):
+		return http.StatusTooManyRequests, "rate_limit_error"
+	case errors.As(err, &queueErr):
+		return http.StatusServiceUnavailable, "overloaded_error"
+	case errors.As(err, &timeoutErr), errors.As(err, &budgetErr):
+		return http.StatusGatewayTimeout, "timeout_error"
+	case errors.As(err, &moderationErr):
+		return http.StatusBadRequest, "invalid_request_error"
+	default:
+		return http.StatusInternalServerError, "api_error"
+	}
+}
+
+// respondMessagesError writes an error in the Messages API format
+func respondMessagesError(c *gin.Context, status int, errorType, message string) {
+	c.JSON(status, gin.H{
+		"type": "error",
+		"error": gin.H{
+			"type":    errorType,
+			"message": message,
+		},
+	})
+}
+
+// messagesID returns the Messages API ID of the response with ID id
+func messagesID(id string) string {
+	return "msg_" + strings.TrimPrefix(id, "chatcmpl-")
+}
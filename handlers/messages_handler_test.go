@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupMessagesRouter(handler *LlamaHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/v1/messages", handler.Messages)
+	return r
+}
+
+func TestMessages(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupMessagesRouter(NewLlamaHandler(mockService))
+
+	temperature := 0.2
+	mockService.On("Chat", models.ChatRequest{
+		Model: "claude-sonnet",
+		Messages: []models.Message{
+			{Role: "system", Content: "You are terse."},
+			{Role: "user", Content: "Hello\n\nThere"},
+		},
+		MaxTokens: 64,
+		Options:   &models.Options{Temperature: &temperature},
+	}).Return(&models.ChatResponse{
+		Model:   "llama3.2",
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Hi"}}},
+		Usage:   models.Usage{PromptTokens: 12, CompletionTokens: 2, TotalTokens: 14},
+	}, nil)
+
+	body := `{
+		"model": "claude-sonnet",
+		"max_tokens": 64,
+		"temperature": 0.2,
+		"system": "You are terse.",
+		"messages": [{"role": "user", "content": [{"type": "text", "text": "Hello"}, {"type": "text", "text": "There"}]}]
+	}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response models.MessagesResponse
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "message", response.Type)
+	assert.Equal(t, "claude-sonnet", response.Model)
+	assert.Equal(t, []models.MessagesContentBlock{{Type: "text", Text: "Hi"}}, response.Content)
+	assert.Equal(t, "end_turn", *response.StopReason)
+	assert.Equal(t, models.MessagesUsage{InputTokens: 12, OutputTokens: 2}, response.Usage)
+	mockService.AssertExpectations(t)
+}
+
+func TestMessages_InvalidRequests(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupMessagesRouter(NewLlamaHandler(mockService))
+
+	for _, body := range []string{
+		`{"model": "llama3.2", "messages": [{"role": "user", "content": "Hi"}]}`,
+		`{"model": "llama3.2", "max_tokens": 64, "messages": [{"role": "user", "content": [{"type": "image"}]}]}`,
+		`{"model": "llama3.2", "max_tokens": 64, "messages": [{"role": "tool", "content": "Hi"}]}`,
+	} {
+		req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, body)
+		assert.Contains(t, w.Body.String(), `"type":"invalid_request_error"`)
+	}
+	mockService.AssertNotCalled(t, "Chat")
+}
+
+func TestMessages_UpstreamError(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupMessagesRouter(NewLlamaHandler(mockService))
+
+	mockService.On("Chat", mock.Anything).Return(nil, &services.UpstreamError{
+		Backend: services.BackendLocal, StatusCode: http.StatusNotFound, Message: "model not found",
+	})
+
+	body := `{"model": "missing", "max_tokens": 64, "messages": [{"role": "user", "content": "Hi"}]}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), `"type":"not_found_error"`)
+}
+
+func TestMessages_Stream(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupMessagesRouter(NewLlamaHandler(mockService))
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Hel"
+		responseChan <- "lo"
+		close(responseChan)
+	})
+
+	body := `{"model": "llama3.2", "max_tokens": 64, "stream": true, "messages": [{"role": "user", "content": "Hi"}]}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	stream := w.Body.String()
+	for _, event := range []string{"message_start", "content_block_start", "content_block_delta", "content_block_stop", "message_delta", "message_stop"} {
+		assert.Contains(t, stream, "event:"+event+"\n")
+	}
+	assert.Contains(t, stream, `"delta":{"text":"Hel","type":"text_delta"}`)
+	assert.Contains(t, stream, `"usage":{"output_tokens":2}`)
+}
+
+func TestMessages_StreamError(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupMessagesRouter(NewLlamaHandler(mockService))
+
+	mockService.On("StreamChat", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		responseChan := args.Get(1).(chan<- string)
+		responseChan <- "Error: local ollama is unreachable"
+		close(responseChan)
+	})
+
+	body := `{"model": "llama3.2", "max_tokens": 64, "stream": true, "messages": [{"role": "user", "content": "Hi"}]}`
+	req, _ := http.NewRequest("POST", "/v1/messages", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Contains(t, w.Body.String(), "event:error\n")
+	assert.Contains(t, w.Body.String(), "local ollama is unreachable")
+	assert.NotContains(t, w.Body.String(), "event:message_stop")
+}
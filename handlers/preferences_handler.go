@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"net/http"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// preferencesKey holds the preferences of the request's workspace, set by ApplyPreferences
+	preferencesKey = "preferences"
+	// defaultWorkspace is used when a request names no workspace
+	defaultWorkspace = "default"
+)
+
+type PreferencesHandler struct {
+	preferences *services.PreferenceService
+}
+
+func NewPreferencesHandler(preferences *services.PreferenceService) *PreferencesHandler {
+	return &PreferencesHandler{
+		preferences: preferences,
+	}
+}
+
+// GetPreferences returns the preferences of the caller's workspace
+func (h *PreferencesHandler) GetPreferences(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"workspace":   workspaceID(c),
+		"preferences": h.preferences.Get(workspaceID(c)),
+	})
+}
+
+// UpdatePreferences replaces the preferences of the caller's workspace
+func (h *PreferencesHandler) UpdatePreferences(c *gin.Context) {
+	var request models.Preferences
+	if err := c.ShouldBindJSON(&request); err != nil {
+		respondError(c, http.StatusBadRequest, "Invalid request format", err.Error())
+		return
+	}
+
+	h.preferences.Set(workspaceID(c), request)
+	c.JSON(http.StatusOK, gin.H{
+		"workspace":   workspaceID(c),
+		"preferences": request,
+	})
+}
+
+// ApplyPreferences makes the preferences of the caller's workspace available to the handlers,
+// which use them for fields a request leaves empty
+func (h *PreferencesHandler) ApplyPreferences() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(preferencesKey, h.preferences.Get(workspaceID(c)))
+		c.Next()
+	}
+}
+
+// workspaceID identifies the caller's workspace by its API key when one authenticated the request,
+// otherwise by the X-Workspace-ID header
+func workspaceID(c *gin.Context) string {
+	if keyID := c.GetString(middleware.APIKeyIDKey); keyID != "" {
+		return keyID
+	}
+	if workspace := c.GetHeader("X-Workspace-ID"); workspace != "" {
+		return workspace
+	}
+	return defaultWorkspace
+}
+
+// requestPreferences returns the preferences set by ApplyPreferences, or none
+func requestPreferences(c *gin.Context) models.Preferences {
+	preferences, _ := c.Get(preferencesKey)
+	p, _ := preferences.(models.Preferences)
+	return p
+}
+
+// defaultTo returns value, or fallback when value is empty
+func defaultTo(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
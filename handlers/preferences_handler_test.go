@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupPreferencesRouter(preferencesHandler *PreferencesHandler, llamaHandler *LlamaHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.Default()
+
+	api := router.Group("/api/v1")
+	{
+		api.GET("/preferences", preferencesHandler.GetPreferences)
+		api.PUT("/preferences", preferencesHandler.UpdatePreferences)
+
+		llama := api.Group("/llama", preferencesHandler.ApplyPreferences())
+		{
+			llama.POST("/chat", llamaHandler.Chat)
+			llama.POST("/rewrite", llamaHandler.Rewrite)
+		}
+	}
+
+	return router
+}
+
+func putPreferences(router *gin.Engine, workspace string, preferences models.Preferences) *httptest.ResponseRecorder {
+	body, _ := json.Marshal(preferences)
+	req, _ := http.NewRequest("PUT", "/api/v1/preferences", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Workspace-ID", workspace)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestPreferences_GetAndUpdate(t *testing.T) {
+	router := setupPreferencesRouter(NewPreferencesHandler(services.NewPreferenceService()), NewLlamaHandler(new(MockLlamaService)))
+
+	w := putPreferences(router, "acme", models.Preferences{Model: "llama3.2", Language: "German"})
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	req, _ := http.NewRequest("GET", "/api/v1/preferences", nil)
+	req.Header.Set("X-Workspace-ID", "acme")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"workspace":"acme","preferences":{"model":"llama3.2","language":"German"}}`, w.Body.String())
+
+	// Other workspaces are unaffected
+	req, _ = http.NewRequest("GET", "/api/v1/preferences", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.JSONEq(t, `{"workspace":"default","preferences":{}}`, w.Body.String())
+}
+
+func TestPreferences_AppliedToOmittedFields(t *testing.T) {
+	mockService := new(MockLlamaService)
+	router := setupPreferencesRouter(NewPreferencesHandler(services.NewPreferenceService()), NewLlamaHandler(mockService))
+	putPreferences(router, "acme", models.Preferences{Model: "llama3.2", Style: "encyclopedic", Language: "German"})
+
+	messages := []models.Message{{Role: "user", Content: "Hello"}}
+	mockService.On("Chat", models.ChatRequest{Model: "llama3.2", Messages: messages}).Return(&models.ChatResponse{}, nil)
+	mockService.On("Rewrite", models.RewriteRequest{Text: "Paris is big.", Model: "mistral", Style: "encyclopedic", Language: "German"}).
+		Return(&models.RewriteResponse{}, nil)
+
+	body, _ := json.Marshal(models.ChatRequest{Messages: messages})
+	req, _ := http.NewRequest("POST", "/api/v1/llama/chat", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Workspace-ID", "acme")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	// Fields set on the request win over the preferences
+	body, _ = json.Marshal(models.RewriteRequest{Text: "Paris is big.", Model: "mistral"})
+	req, _ = http.NewRequest("POST", "/api/v1/llama/rewrite", bytes.NewBuffer(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Workspace-ID", "acme")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	mockService.AssertExpectations(t)
+}
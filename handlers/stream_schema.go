@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// streamEncoder writes streamed chat output as server-sent events in one schema version
+type streamEncoder interface {
+	// chunk writes a piece of output, or an error reported by the service as "Error: ..."
+	chunk(c *gin.Context, text string)
+	// finish writes whatever the schema sends after the last chunk
+	finish(c *gin.Context)
+}
+
+// newStreamEncoder returns the encoder of the schema version chosen by middleware.StreamSchema,
+// v1 when none was chosen. v2 chunks carry id and created.
+func newStreamEncoder(c *gin.Context, model, id string, created time.Time) streamEncoder {
+	if c.GetString(middleware.StreamSchemaKey) == models.StreamSchemaV2 {
+		return &chunkEncoder{
+			id:      id,
+			created: created.Unix(),
+			model:   model,
+		}
+	}
+	return rawEncoder{}
+}
+
+// rawEncoder writes the v1 schema: a "message" event with the raw text of each chunk, errors included
+type rawEncoder struct{}
+
+func (rawEncoder) chunk(c *gin.Context, text string) {
+	c.SSEvent("message", text)
+	c.Writer.Flush()
+}
+
+func (rawEncoder) finish(c *gin.Context) {}
+
+// chunkEncoder writes the v2 schema: "message.v2" events carrying chat.completion.chunk deltas, a
+// last chunk with the finish reason and a [DONE] sentinel, or an "error.v2" event on failure
+type chunkEncoder struct {
+	id      string
+	created int64
+	model   string
+	started bool
+	failed  bool
+}
+
+func (e *chunkEncoder) chunk(c *gin.Context, text string) {
+	if message, ok := strings.CutPrefix(text, "Error: "); ok {
+		e.failed = true
+		c.SSEvent("error.v2", gin.H{"error": message})
+		c.Writer.Flush()
+		return
+	}
+
+	delta := models.ChunkDelta{Content: text}
+	if !e.started {
+		delta.Role = "assistant"
+		e.started = true
+	}
+	c.SSEvent("message.v2", e.wrap(delta, nil))
+	c.Writer.Flush()
+}
+
+func (e *chunkEncoder) finish(c *gin.Context) {
+	if e.failed {
+		return
+	}
+	stop := "stop"
+	c.SSEvent("message.v2", e.wrap(models.ChunkDelta{}, &stop))
+	c.SSEvent("message.v2", "[DONE]")
+	c.Writer.Flush()
+}
+
+func (e *chunkEncoder) wrap(delta models.ChunkDelta, finishReason *string) models.ChatCompletionChunk {
+	return models.ChatCompletionChunk{
+		ID:      e.id,
+		Object:  "chat.completion.chunk",
+		Created: e.created,
+		Model:   e.model,
+		Choices: []models.ChunkChoice{{Delta: delta, FinishReason: finishReason}},
+	}
+}
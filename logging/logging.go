@@ -0,0 +1,67 @@
+// Package logging builds the application logger configured by LOG_LEVEL, LOG_FORMAT and
+// LOG_DEBUG_SAMPLING. Access logs are configured separately.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync/atomic"
+
+	"agent-ollama-gin/config"
+)
+
+// Log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// New builds a logger writing to out at the configured level and format. Debug records are sampled
+// when cfg.DebugSampling is above 1.
+func New(cfg config.LogConfig, out io.Writer) (*slog.Logger, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(cfg.Level)); err != nil {
+		return nil, fmt.Errorf("invalid LOG_LEVEL %q: %w", cfg.Level, err)
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler
+	switch cfg.Format {
+	case FormatText:
+		handler = slog.NewTextHandler(out, options)
+	case FormatJSON:
+		handler = slog.NewJSONHandler(out, options)
+	default:
+		return nil, fmt.Errorf("invalid LOG_FORMAT %q, expected %s or %s", cfg.Format, FormatText, FormatJSON)
+	}
+
+	if cfg.DebugSampling > 1 {
+		handler = &samplingHandler{Handler: handler, every: uint64(cfg.DebugSampling), seen: new(atomic.Uint64)}
+	}
+	return slog.New(handler), nil
+}
+
+// samplingHandler passes on one in every debug records, so high-volume debug logging can stay
+// enabled in production. Records at info and above are always passed on.
+type samplingHandler struct {
+	slog.Handler
+	every uint64
+	seen  *atomic.Uint64 // Shared with the handlers derived through WithAttrs and WithGroup
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if record.Level < slog.LevelInfo && h.seen.Add(1)%h.every != 1 {
+		return nil
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), every: h.every, seen: h.seen}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), every: h.every, seen: h.seen}
+}
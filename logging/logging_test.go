@@ -0,0 +1,64 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"agent-ollama-gin/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew_JSON(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(config.LogConfig{Level: "warn", Format: FormatJSON}, &out)
+	assert.NoError(t, err)
+
+	logger.Info("Not logged")
+	logger.Warn("Shadow request failed", "model", "phi3", "attempt", 2)
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "Shadow request failed", record["msg"])
+	assert.Equal(t, "phi3", record["model"])
+	assert.Equal(t, float64(2), record["attempt"])
+}
+
+func TestNew_Text(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(config.LogConfig{Level: "info", Format: FormatText}, &out)
+	assert.NoError(t, err)
+
+	logger.Info("Preloaded model", "model", "llama3.2")
+	assert.Contains(t, out.String(), `level=INFO msg="Preloaded model" model=llama3.2`)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	_, err := New(config.LogConfig{Level: "verbose", Format: FormatJSON}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "LOG_LEVEL")
+
+	_, err = New(config.LogConfig{Level: "info", Format: "xml"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "LOG_FORMAT")
+}
+
+func TestNew_DebugSampling(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := New(config.LogConfig{Level: "debug", Format: FormatText, DebugSampling: 10}, &out)
+	assert.NoError(t, err)
+
+	// Derived loggers share the sample
+	derived := logger.With("request_id", "abc")
+	for i := 0; i < 15; i++ {
+		logger.Debug("Ollama request")
+		derived.Debug("Ollama request")
+	}
+	for i := 0; i < 5; i++ {
+		logger.Warn("Retrying")
+	}
+
+	assert.Equal(t, 3, strings.Count(out.String(), "level=DEBUG"))
+	assert.Equal(t, 5, strings.Count(out.String(), "level=WARN"))
+}
@@ -1,57 +1,303 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
 	"log"
+	"log/slog"
+	"net"
+	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
 
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/grpcapi"
 	"agent-ollama-gin/handlers"
+	"agent-ollama-gin/logging"
+	"agent-ollama-gin/mcpserver"
+	"agent-ollama-gin/middleware"
+	"agent-ollama-gin/models"
 	"agent-ollama-gin/services"
+	"agent-ollama-gin/version"
 
-	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
+// flagSettings are the command-line flags that set an environment variable
+var flagSettings = map[string]struct {
+	env   string
+	usage string
+}{
+	"port":       {"PORT", "port to listen on"},
+	"ollama-url": {"LLAMA_BASE_URL", "URL of the local Ollama server"},
+	"config":     {"CONFIG_FILE", "YAML configuration file"},
+	"log-level":  {"LOG_LEVEL", "log level: debug, info, warn or error"},
+}
+
 func main() {
-	// Load environment variables
+	validateOnly := flag.Bool("validate-config", false, "check the configuration and that Ollama is reachable, then exit")
+	mcpStdio := flag.Bool("mcp", false, "serve Model Context Protocol tools on stdin and stdout instead of the HTTP API")
+	for name, setting := range flagSettings {
+		flag.String(name, "", fmt.Sprintf("%s (overrides %s)", setting.usage, setting.env))
+	}
+	flag.Parse()
+
+	// Flags given on the command line take precedence over every other source of configuration
+	flag.Visit(func(f *flag.Flag) {
+		if setting, ok := flagSettings[f.Name]; ok {
+			os.Setenv(setting.env, f.Value.String())
+		}
+	})
+
+	// Load environment variables. An imported configuration profile takes precedence over .env.
+	if err := godotenv.Load(config.ProfileFile()); err == nil {
+		log.Printf("Loaded configuration profile %s", config.ProfileFile())
+	}
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
+	// The configuration file applies where no environment variable is set. The default file is optional.
+	switch err := config.LoadFile(config.ConfigFile()); {
+	case err == nil:
+		log.Printf("Loaded configuration file %s", config.ConfigFile())
+	case errors.Is(err, fs.ErrNotExist) && os.Getenv("CONFIG_FILE") == "":
+	default:
+		log.Fatal(err)
+	}
+
+	cfg := config.Load()
+
+	// Application logs, including those of the standard log package, go through slog on stderr
+	logger, err := logging.New(cfg.Log, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	slog.SetDefault(logger)
+
+	// Refuse to start with a configuration the server cannot sensibly run with
+	if problems := validateConfig(cfg); len(problems) > 0 {
+		log.Fatalf("Invalid configuration: %s", strings.Join(problems, "; "))
+	}
+
 	// Initialize services
 	llamaService := services.NewLlamaService()
 
+	// Moderate prompts and replies as the policy in HOOK_MODERATION_POLICY_FILE says
+	if cfg.Hooks.ModerationPolicyFile != "" {
+		policy, err := services.LoadModerationPolicy(cfg.Hooks.ModerationPolicyFile)
+		if err != nil {
+			log.Fatal("Invalid moderation policy:", err)
+		}
+		llamaService.EnableModeration(policy, cfg.Hooks.ModerationEndpoints...)
+	}
+
+	// Dependencies checked by the readiness probe; Redis-backed stores add their own checks
+	readiness := services.NewReadiness(time.Duration(cfg.Server.ReadinessTimeout) * time.Second)
+	readiness.Add("ollama", llamaService.CheckOllama)
+	readiness.Add("default_model", llamaService.CheckDefaultModel)
+
+	// Report an unreachable Ollama up front. The server still starts, reporting not ready until
+	// Ollama comes up, unless only the configuration is being validated.
+	if err := checkOllama(cfg, llamaService); err != nil {
+		if *validateOnly {
+			log.Fatal(err)
+		}
+		slog.Warn(err.Error())
+	}
+	if *validateOnly {
+		log.Println("Configuration is valid")
+		return
+	}
+
+	// Stores the server runs without while they are down, instead of failing requests
+	persistence := services.NewPersistence(time.Duration(cfg.Server.PersistenceCheck)*time.Second, time.Duration(cfg.Server.ReadinessTimeout)*time.Second)
+
+	// Warm up configured models in the background so startup is not blocked
+	services.Go("preload", func(context.Context) { llamaService.PreloadModels() })
+
+	// Restore the semantic cache from disk and keep snapshotting it until shutdown
+	services.Go("semantic_cache_snapshot", llamaService.RestoreSemanticCache)
+
 	// Initialize handlers
+	maintenance := middleware.NewMaintenanceMode()
 	llamaHandler := handlers.NewLlamaHandler(llamaService)
+	adminHandler := handlers.NewAdminHandler(maintenance, llamaService)
+
+	// Keep usage records where USAGE_STORE says
+	llamaService.SetUsageStore(newUsageStore(cfg.Usage, readiness, persistence))
+
+	// MCP server mode for editors and agents that start this binary as a tool provider. Logs
+	// stay on stderr, so stdout carries protocol messages only.
+	if *mcpStdio {
+		serveMCP(mcpserver.NewServer(llamaService), cfg.Server.ShutdownTimeout)
+		return
+	}
+
+	// Record administrative actions where AUDIT_LOG_FILE says
+	auditLog := newAuditLog(cfg.Audit)
+	auditHandler := handlers.NewAuditHandler(auditLog)
+
+	conversationService := services.NewConversationService(newConversationStore(cfg.Conversations, readiness, persistence, llamaService.Now), llamaService, cfg.Conversations)
+	services.Go("persistence_monitor", persistence.Run)
+	conversationHandler := handlers.NewConversationHandler(conversationService)
+	preferencesHandler := handlers.NewPreferencesHandler(services.NewPreferenceService())
+	authHandler := handlers.NewAuthHandler(cfg.Auth)
+	capabilitiesHandler := handlers.NewCapabilitiesHandler(cfg, llamaService)
+	connections := middleware.NewConnCounter()
+	debugHandler := handlers.NewDebugHandler(connections)
+	healthHandler := handlers.NewHealthHandler(readiness)
+	docsHandler := handlers.NewDocsHandler()
+
+	// Access tokens, enabled by setting JWT_SECRET. Without them every route is open, and admin
+	// routes are guarded by ADMIN_TOKEN.
+	authenticate := gin.HandlerFunc(func(c *gin.Context) { c.Next() })
+	requireUser := authenticate
+	if cfg.Auth.JWTSecret != "" {
+		authenticate = middleware.JWTAuth(cfg.Auth.JWTSecret)
+		// For GET routes that generate, which readonly tokens could otherwise call
+		requireUser = middleware.RequireRole(middleware.RoleAdmin, middleware.RoleUser)
+	}
+	requireAdmin := middleware.RequireAdmin(cfg.Auth.JWTSecret, cfg.Server.AdminToken)
+
+	// Generation requests may bring their own time budget in X-Request-Timeout
+	requestBudget := middleware.RequestTimeout(time.Duration(cfg.Server.MaxRequestTimeout)*time.Second, services.WithRequestBudget)
+
+	// Generation requests may also pick the priority class they wait for a slot with in X-Request-Priority
+	requestPriority := middleware.RequestPriority(models.Priorities, services.WithPriority)
 
 	// Create Gin router
-	r := gin.Default()
+	r := gin.New()
+
+	// Only trust X-Forwarded-For from configured proxies when resolving the client IP
+	if err := r.SetTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		log.Fatal("Invalid TRUSTED_PROXIES:", err)
+	}
+
+	r.Use(gin.Recovery(), middleware.RequestID(services.WithRequestID), newAccessLogger(cfg.Server), middleware.Degraded(persistence.Degraded))
+
+	// Unknown routes answer in the error envelope too, rather than with plain text
+	r.NoRoute(func(c *gin.Context) {
+		middleware.AbortWithError(c, http.StatusNotFound, "Not found",
+			fmt.Sprintf("No route for %s %s", c.Request.Method, c.Request.URL.Path), nil)
+	})
 
-	// Configure CORS
-	config := cors.DefaultConfig()
-	config.AllowOrigins = []string{"*"}
-	config.AllowMethods = []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"}
-	config.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
-	r.Use(cors.New(config))
+	// Liveness and readiness probes, registered before IP filtering and rate limiting so
+	// orchestrators can always reach them
+	r.GET("/healthz", healthHandler.Liveness)
+	r.GET("/readyz", healthHandler.Readiness)
+
+	// Restrict access by client IP
+	var ipPolicy *middleware.IPPolicy
+	if len(cfg.Server.IPAllowList) > 0 || len(cfg.Server.IPDenyList) > 0 {
+		ipFilter, err := middleware.IPFilter(cfg.Server.IPAllowList, cfg.Server.IPDenyList)
+		if err != nil {
+			log.Fatal("Invalid IP filter:", err)
+		}
+		r.Use(ipFilter)
+		ipPolicy, _ = middleware.NewIPPolicy(cfg.Server.IPAllowList, cfg.Server.IPDenyList)
+	}
+
+	// Reject oversized request bodies before they are read
+	if cfg.Limits.MaxBodyBytes > 0 {
+		r.Use(middleware.MaxBodySize(int64(cfg.Limits.MaxBodyBytes)))
+	}
+
+	// Accept HTTP/2 over cleartext so streams can be multiplexed behind h2c-capable proxies.
+	// HTTP/2 over TLS is negotiated by the server when TLS is enabled.
+	r.UseH2C = cfg.Server.H2C
+
+	// Configure CORS, including preflight responses for every route. Admin and login routes
+	// get their own, stricter policy.
+	r.Use(middleware.CORSByPath(cfg.CORS,
+		middleware.CORSPolicy{Prefix: "/api/v1/admin", Config: cfg.AdminCORS},
+		middleware.CORSPolicy{Prefix: "/api/v1/auth", Config: cfg.AdminCORS},
+		middleware.CORSPolicy{Prefix: "/api/v1/usage", Config: cfg.AdminCORS},
+		middleware.CORSPolicy{Prefix: "/debug/pprof", Config: cfg.AdminCORS},
+	))
+
+	// Rate limit each client, identified by access token subject or else by IP
+	// The limiter and the quota are shared with the gRPC API, so both count against one budget
+	clientKey := middleware.ClientKey(cfg.Auth.JWTSecret)
+	var rateLimiter middleware.RateLimiter
+	if cfg.RateLimit.Requests > 0 {
+		rateLimiter = newRateLimiter(cfg.RateLimit, readiness)
+		r.Use(middleware.RateLimitBy(rateLimiter, clientKey))
+	}
+
+	// Attribute usage records to the client
+	r.Use(middleware.Caller(clientKey, services.WithCaller))
+
+	// Cap the tokens each client may consume per day
+	var tokenQuota middleware.TokenQuota
+	if cfg.RateLimit.DailyTokens > 0 {
+		tokenQuota = newTokenQuota(cfg.RateLimit, readiness)
+		r.Use(middleware.Quota(tokenQuota, cfg.RateLimit.DailyTokens, clientKey, services.WithTokenRecorder))
+	}
+
+	// Audit administrative actions, attributed to the client
+	audit := func(action string) gin.HandlerFunc {
+		return middleware.Audit(auditLog, action, clientKey)
+	}
+
+	// Generation caps per client and role, applied to routes after authentication
+	limits := middleware.Limits(cfg.Limits, clientKey)
+
+	// Streamed output rate per client, shared by its streams, from the generation caps
+	streamRate := middleware.StreamRate(middleware.NewOutputRate(), clientKey)
 
 	// Root route
 	r.GET("/", func(c *gin.Context) {
 		c.JSON(200, gin.H{
 			"message": "Welcome to Llama API with Ollama Cloud Support",
-			"version": "2.0.0",
+			"version": version.Version,
 			"endpoints": gin.H{
-				"health":       "/api/v1/health",
-				"chat":         "/api/v1/llama/chat",
-				"completion":   "/api/v1/llama/completion",
-				"embedding":    "/api/v1/llama/embedding",
-				"models":       "/api/v1/llama/models",
-				"cloud_models": "/api/v1/llama/cloud/models",
-				"signin":       "/api/v1/llama/cloud/signin",
-				"signout":      "/api/v1/llama/cloud/signout",
-				"pull_model":   "/api/v1/llama/models/:model/pull",
-				"stream_chat":  "/api/v1/llama/chat/stream",
+				"health":        "/api/v1/health",
+				"version":       "/api/v1/version",
+				"capabilities":  "/api/v1/capabilities",
+				"chat":          "/api/v1/llama/chat",
+				"completion":    "/api/v1/llama/completion",
+				"embedding":     "/api/v1/llama/embedding",
+				"rewrite":       "/api/v1/llama/rewrite",
+				"compare":       "/api/v1/llama/compare",
+				"chat_batch":    "/api/v1/llama/chat/batch",
+				"models":        "/api/v1/llama/models",
+				"cloud_models":  "/api/v1/llama/cloud/models",
+				"signin":        "/api/v1/llama/cloud/signin",
+				"signout":       "/api/v1/llama/cloud/signout",
+				"cloud_usage":   "/api/v1/llama/cloud/usage",
+				"pull_model":    "/api/v1/llama/models/:model/pull",
+				"delete_model":  "/api/v1/llama/models/:model",
+				"copy_model":    "/api/v1/llama/models/:model/copy",
+				"create_model":  "/api/v1/llama/models/:model/create",
+				"login":         "/api/v1/auth/login",
+				"aliases":       "/api/v1/llama/aliases",
+				"presets":       "/api/v1/llama/presets",
+				"stream_chat":   "/api/v1/llama/chat/stream",
+				"chat_socket":   "/api/v1/llama/chat/ws",
+				"messages":      "/v1/messages",
+				"conversations": "/api/v1/conversations",
+				"prompts":       "/api/v1/prompts",
+				"preferences":   "/api/v1/preferences",
+				"usage":         "/api/v1/usage",
+				"openapi":       "/openapi.json",
 			},
-			"docs": "Check README.md for full API documentation",
+			"docs": "Explore the API at /docs, and see README.md for configuration",
 			"features": []string{
 				"Local Ollama models",
 				"Ollama cloud models",
@@ -61,6 +307,11 @@ func main() {
 		})
 	})
 
+	// OpenAPI document and Swagger UI
+	r.GET("/openapi.yaml", docsHandler.GetSpecYAML)
+	r.GET("/openapi.json", docsHandler.GetSpecJSON)
+	r.GET("/docs", docsHandler.SwaggerUI)
+
 	// API routes
 	api := r.Group("/api/v1")
 	{
@@ -69,46 +320,419 @@ func main() {
 			c.JSON(200, gin.H{
 				"status":  "ok",
 				"message": "Llama API is running",
-				"version": "1.0.0",
+				"version": version.Version,
+				"build":   version.Get(),
 			})
 		})
 
-		// Llama LLM endpoints
-		llama := api.Group("/llama")
+		// Build information
+		api.GET("/version", func(c *gin.Context) {
+			c.JSON(200, version.Get())
+		})
+
+		// Features, models and formats on offer, so clients can adapt to this build. They list
+		// the installed models, so access tokens are required when enabled.
+		api.GET("/capabilities", authenticate, capabilitiesHandler.GetCapabilities)
+
+		// Log in for an access token
+		if cfg.Auth.JWTSecret != "" {
+			api.POST("/auth/login", middleware.ContentTypes("application/json"), authHandler.Login)
+		}
+
+		// Llama LLM endpoints accept JSON bodies only
+		llama := api.Group("/llama", authenticate, middleware.ContentTypes("application/json"), preferencesHandler.ApplyPreferences())
 		{
-			// Core endpoints
-			llama.POST("/chat", llamaHandler.Chat)
-			llama.POST("/completion", llamaHandler.Completion)
-			llama.POST("/embedding", llamaHandler.Embedding)
+			// Generation endpoints are rejected with 503 during maintenance
+			generation := llama.Group("", maintenance.Guard(), requestBudget, requestPriority, limits)
+			{
+				generation.POST("/chat", llamaHandler.Chat)
+				generation.POST("/completion", llamaHandler.Completion)
+				generation.POST("/embedding", llamaHandler.Embedding)
+				generation.POST("/rewrite", llamaHandler.Rewrite)
+				generation.POST("/glossary", llamaHandler.Glossary)
+				generation.POST("/compare", llamaHandler.Compare)
+				generation.POST("/chat/batch", llamaHandler.ChatBatch)
+
+				// Streaming endpoints
+				generation.POST("/chat/stream",
+					middleware.Streaming(cfg.Server.StreamCompression),
+					middleware.StreamSchema(cfg.Server.StreamSchema, models.StreamSchemas),
+					streamRate,
+					llamaHandler.StreamChat,
+				)
+
+				// Chat over a WebSocket, where the client can stop a reply mid-stream
+				generation.GET("/chat/ws", requireUser, llamaHandler.ChatSocket)
+			}
+
 			llama.GET("/models", llamaHandler.ListModels)
 
-			// Streaming endpoints
-			llama.POST("/chat/stream", llamaHandler.StreamChat)
+			// Model management, admins only
+			llama.POST("/models/:model/pull", requireAdmin, audit("model.pull"), llamaHandler.PullModel)
+			llama.DELETE("/models/:model", requireAdmin, audit("model.delete"), llamaHandler.DeleteModel)
+			llama.POST("/models/:model/copy", requireAdmin, audit("model.copy"), llamaHandler.CopyModel)
+			llama.POST("/models/:model/create", requireAdmin, audit("model.create"), middleware.Streaming(cfg.Server.StreamCompression), llamaHandler.CreateModel)
+
+			// Model aliases
+			llama.GET("/aliases", llamaHandler.ListAliases)
+			llama.PUT("/aliases/:alias", requireAdmin, llamaHandler.SetAlias)
+			llama.DELETE("/aliases/:alias", requireAdmin, llamaHandler.DeleteAlias)
 
-			// Model management
-			llama.POST("/models/:model/pull", llamaHandler.PullModel)
+			// System prompt presets
+			llama.GET("/presets", llamaHandler.ListPresets)
+			llama.GET("/presets/:name", llamaHandler.GetPreset)
+			llama.PUT("/presets/:name", requireAdmin, llamaHandler.SetPreset)
+			llama.DELETE("/presets/:name", requireAdmin, llamaHandler.DeletePreset)
 
 			// Cloud endpoints
 			cloud := llama.Group("/cloud")
 			{
-				cloud.POST("/signin", llamaHandler.SignIn)
-				cloud.POST("/signout", llamaHandler.SignOut)
+				cloud.POST("/signin", requireAdmin, audit("cloud.signin"), llamaHandler.SignIn)
+				cloud.POST("/signout", requireAdmin, audit("cloud.signout"), llamaHandler.SignOut)
 				cloud.GET("/models", llamaHandler.ListCloudModels)
+				cloud.GET("/usage", llamaHandler.CloudUsage)
 			}
 		}
 
+		// Prompt templates
+		prompts := api.Group("/prompts", authenticate, middleware.ContentTypes("application/json"), preferencesHandler.ApplyPreferences())
+		{
+			prompts.GET("", llamaHandler.ListPrompts)
+			prompts.GET("/:name", llamaHandler.GetPrompt)
+			prompts.PUT("/:name", requireAdmin, llamaHandler.SetPrompt)
+			prompts.DELETE("/:name", requireAdmin, llamaHandler.DeletePrompt)
+			prompts.POST("/:name/run", maintenance.Guard(), requestBudget, requestPriority, limits, llamaHandler.RunPrompt)
+		}
+
+		// Request defaults per workspace
+		api.GET("/preferences", authenticate, preferencesHandler.GetPreferences)
+		api.PUT("/preferences", authenticate, middleware.ContentTypes("application/json"), preferencesHandler.UpdatePreferences)
+
+		// Server-side conversations
+		conversations := api.Group("/conversations", authenticate, middleware.ContentTypes("application/json"), preferencesHandler.ApplyPreferences())
+		{
+			conversations.POST("", conversationHandler.CreateConversation)
+			conversations.GET("/:id", conversationHandler.GetConversation)
+			conversations.POST("/:id/messages", maintenance.Guard(), requestBudget, requestPriority, limits, conversationHandler.SendMessage)
+			conversations.DELETE("/:id", conversationHandler.DeleteConversation)
+		}
+
+		// Admin endpoints, enabled by setting ADMIN_TOKEN, or for admins when access tokens are enabled
+		if cfg.Server.AdminToken != "" || cfg.Auth.JWTSecret != "" {
+			admin := api.Group("/admin", authenticate, requireAdmin)
+			{
+				admin.GET("/maintenance", adminHandler.GetMaintenance)
+				admin.PUT("/maintenance", audit("maintenance.enable"), adminHandler.EnableMaintenance)
+				admin.DELETE("/maintenance", audit("maintenance.disable"), adminHandler.DisableMaintenance)
+				admin.POST("/models/swap", audit("model.swap"), adminHandler.SwapModel)
+				admin.GET("/upstreams", adminHandler.GetUpstreams)
+				admin.POST("/upstreams/:name/reset", audit("upstream.reset"), adminHandler.ResetUpstream)
+				admin.GET("/shadow", adminHandler.GetShadow)
+				admin.GET("/goroutines", adminHandler.GetGoroutines)
+				admin.GET("/config", adminHandler.ExportConfig)
+				admin.PUT("/config", audit("config.import"), adminHandler.ImportConfig)
+				admin.GET("/debug/stats", debugHandler.GetStats)
+				admin.GET("/audit", auditHandler.GetAuditLog)
+			}
+
+			// Usage records for billing and monitoring
+			api.GET("/usage", authenticate, requireAdmin, adminHandler.GetUsage)
+
+			// Runtime profiles, at the path go tool pprof expects
+			pprof := r.Group("/debug/pprof", authenticate, requireAdmin)
+			{
+				pprof.GET("/*profile", debugHandler.Pprof)
+				pprof.POST("/*profile", debugHandler.Pprof)
+			}
+		}
 	}
 
+	// Anthropic Messages API compatibility, at the path its SDKs expect
+	r.POST("/v1/messages",
+		authenticate,
+		middleware.ContentTypes("application/json"),
+		maintenance.Guard(),
+		requestBudget,
+		requestPriority,
+		limits,
+		streamRate,
+		middleware.Streaming(cfg.Server.StreamCompression),
+		llamaHandler.Messages,
+	)
+
 	// Get port from environment or use default
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 	}
 
-	log.Printf("Starting Llama API server with Ollama Cloud support on port %s", port)
+	server := &http.Server{Addr: ":" + port, Handler: r.Handler(), ConnState: connections.Track}
+	tlsConfig, err := newTLSConfig(cfg.TLS)
+	if err != nil {
+		log.Fatal("Invalid TLS configuration:", err)
+	}
+	serve := newServe(server, tlsConfig)
+
+	log.Printf("Starting Llama API server %s with Ollama Cloud support on port %s", version.Get(), port)
+
+	// Serve until SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatal("Failed to start server:", err)
+		}
+	}()
+
+	// gRPC API for internal services, enabled by setting GRPC_PORT
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		listener, err := net.Listen("tcp", ":"+cfg.Server.GRPCPort)
+		if err != nil {
+			log.Fatal("Failed to listen for gRPC:", err)
+		}
+		// The same protections as the HTTP API's generation routes
+		protections := grpcapi.Protections{
+			Secret:      cfg.Auth.JWTSecret,
+			IPPolicy:    ipPolicy,
+			Maintenance: maintenance,
+			RateLimiter: rateLimiter,
+			Quota:       tokenQuota,
+			DailyTokens: cfg.RateLimit.DailyTokens,
+			Limits:      cfg.Limits,
+		}
+		var options []grpc.ServerOption
+		if tlsConfig != nil {
+			options = append(options, grpc.Creds(credentials.NewTLS(tlsConfig)))
+		}
+		grpcServer = grpcapi.NewGRPCServer(llamaService, protections, options...)
+		log.Printf("Starting gRPC API on port %s, TLS %t", cfg.Server.GRPCPort, tlsConfig != nil)
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				log.Fatal("Failed to start gRPC server:", err)
+			}
+		}()
+	}
+	<-ctx.Done()
+	stop()
+
+	// Let in-flight requests and background work finish within SHUTDOWN_TIMEOUT
+	log.Printf("Shutting down, waiting up to %ds for requests and background work", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ShutdownTimeout)*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Server shutdown did not complete: %v", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+	if err := services.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Background work did not finish: %v", err)
+	}
+}
+
+// serveMCP serves MCP tools on stdin and stdout until the client disconnects or SIGINT or SIGTERM,
+// then lets background work finish within shutdownTimeout seconds
+func serveMCP(server *mcp.Server, shutdownTimeout int) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	log.Printf("Serving MCP tools %s on stdio", version.Get())
+	if err := server.Run(ctx, &mcp.StdioTransport{}); err != nil && !errors.Is(err, context.Canceled) {
+		log.Printf("MCP session ended: %v", err)
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), time.Duration(shutdownTimeout)*time.Second)
+	defer cancel()
+	if err := services.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Background work did not finish: %v", err)
+	}
+}
+
+// stopGRPC lets in-flight calls finish until ctx is done, then closes the remaining ones
+func stopGRPC(ctx context.Context, server *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		server.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("gRPC shutdown did not complete: %v", ctx.Err())
+		server.Stop()
+	}
+}
+
+// newTLSConfig returns the TLS configuration of the HTTP and gRPC servers: certificates from
+// Let's Encrypt when TLS_AUTOCERT_DOMAINS is set, TLS_CERT_FILE and TLS_KEY_FILE when they are
+// set, and nil for plaintext otherwise
+func newTLSConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	switch {
+	case len(cfg.AutocertDomains) > 0:
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.AutocertDomains...),
+			Cache:      autocert.DirCache(cfg.AutocertCacheDir),
+			Email:      cfg.AutocertEmail,
+		}
+		// Answers the TLS-ALPN-01 challenge, so Let's Encrypt must reach this server on port 443
+		log.Printf("Serving TLS with Let's Encrypt certificates for %v", cfg.AutocertDomains)
+		return manager.TLSConfig(), nil
+
+	case cfg.CertFile != "":
+		certificate, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		log.Printf("Serving TLS with certificate %s", cfg.CertFile)
+		return &tls.Config{Certificates: []tls.Certificate{certificate}}, nil
+	}
+
+	return nil, nil
+}
+
+// newServe picks how server listens: HTTPS with tlsConfig when set, and plain HTTP otherwise.
+// HTTPS is served with HTTP/2 for clients that negotiate it.
+func newServe(server *http.Server, tlsConfig *tls.Config) func() error {
+	if tlsConfig != nil {
+		server.TLSConfig = tlsConfig
+		return func() error { return server.ListenAndServeTLS("", "") }
+	}
+	return server.ListenAndServe
+}
+
+// validateConfig lists the problems of cfg, including those the config package cannot check
+func validateConfig(cfg *config.Config) []string {
+	problems := cfg.Validate()
+	for name, user := range cfg.Auth.Users {
+		if !middleware.ValidRole(user.Role) {
+			problems = append(problems, fmt.Sprintf("Invalid role %q for user %s in AUTH_USERS", user.Role, name))
+		}
+	}
+	// Event schema of streaming chat for clients that do not ask for a version
+	if !slices.Contains(models.StreamSchemas, cfg.Server.StreamSchema) {
+		problems = append(problems, fmt.Sprintf("Invalid STREAM_SCHEMA_VERSION %q, supported versions are %v", cfg.Server.StreamSchema, models.StreamSchemas))
+	}
+	return problems
+}
+
+// checkOllama returns an error naming LLAMA_BASE_URL if Ollama cannot be reached
+func checkOllama(cfg *config.Config, llamaService *services.LlamaService) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(cfg.Server.ReadinessTimeout)*time.Second)
+	defer cancel()
+	if err := llamaService.CheckOllama(ctx); err != nil {
+		return fmt.Errorf("LLAMA_BASE_URL %s: %w", cfg.Llama.BaseURL, err)
+	}
+	return nil
+}
+
+// newTokenQuota builds the token quota store selected by RATE_LIMIT_BACKEND
+func newTokenQuota(cfg config.RateLimitConfig, readiness *services.Readiness) middleware.TokenQuota {
+	if cfg.Backend == "redis" {
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		client := redis.NewClient(options)
+		readiness.AddOptional("token_quota", pingRedis(client))
+		log.Printf("Using Redis token quota at %s", options.Addr)
+		return middleware.NewRedisQuota(client)
+	}
+
+	return middleware.NewMemoryQuota()
+}
+
+// newRateLimiter builds the limiter selected by RATE_LIMIT_BACKEND
+func newRateLimiter(cfg config.RateLimitConfig, readiness *services.Readiness) middleware.RateLimiter {
+	window := time.Duration(cfg.Window) * time.Second
+
+	if cfg.Backend == "redis" {
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		client := redis.NewClient(options)
+		readiness.AddOptional("rate_limiter", pingRedis(client))
+		log.Printf("Using Redis rate limiter at %s", options.Addr)
+		return middleware.NewRedisLimiter(client, cfg.Requests, window)
+	}
+
+	return middleware.NewTokenBucketLimiter(cfg.Requests, window)
+}
+
+// newConversationStore builds the conversation store selected by CONVERSATION_STORE
+func newConversationStore(cfg config.ConversationConfig, readiness *services.Readiness, persistence *services.Persistence, now func() time.Time) services.ConversationStore {
+	ttl := time.Duration(cfg.TTL) * time.Minute
+
+	if cfg.Store == "redis" {
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		client := redis.NewClient(options)
+		readiness.AddOptional("conversation_store", pingRedis(client))
+		persistence.Add("conversation_store", pingRedis(client))
+		log.Printf("Using Redis conversation store at %s", options.Addr)
+		return persistence.GuardConversationStore("conversation_store", services.NewRedisConversationStore(client, ttl))
+	}
+
+	return services.NewMemoryConversationStore(ttl, now)
+}
+
+// newAuditLog opens the audit log selected by AUDIT_LOG_FILE
+func newAuditLog(cfg config.AuditConfig) services.AuditLog {
+	if cfg.File == "" {
+		return services.NewMemoryAuditLog()
+	}
+	auditLog, err := services.NewFileAuditLog(cfg.File)
+	if err != nil {
+		log.Fatal("Invalid audit log:", err)
+	}
+	return auditLog
+}
+
+// newUsageStore builds the usage record store selected by USAGE_STORE
+func newUsageStore(cfg config.UsageConfig, readiness *services.Readiness, persistence *services.Persistence) services.UsageStore {
+	if cfg.Store == "redis" {
+		options, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			log.Fatal("Invalid REDIS_URL:", err)
+		}
+		client := redis.NewClient(options)
+		readiness.AddOptional("usage_store", pingRedis(client))
+		persistence.Add("usage_store", pingRedis(client))
+		log.Printf("Using Redis usage store at %s", options.Addr)
+		return persistence.GuardUsageStore("usage_store", services.NewRedisUsageStore(client, cfg.RetentionDays))
+	}
+
+	return services.NewMemoryUsageStore(cfg.RetentionDays)
+}
+
+// pingRedis checks that a Redis server answers PING
+func pingRedis(client *redis.Client) services.ReadinessCheck {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
+
+// newAccessLogger builds the access log middleware selected by ACCESS_LOG_FORMAT.
+// Access logs go to stdout or ACCESS_LOG_FILE, separate from application logs on stderr.
+func newAccessLogger(cfg config.ServerConfig) gin.HandlerFunc {
+	out := io.Writer(os.Stdout)
+	if cfg.AccessLogFile != "" {
+		file, err := os.OpenFile(cfg.AccessLogFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+		if err != nil {
+			log.Fatal("Failed to open access log:", err)
+		}
+		out = file
+	}
 
-	// Start the server
-	if err := r.Run(":" + port); err != nil {
-		log.Fatal("Failed to start server:", err)
+	switch cfg.AccessLogFormat {
+	case middleware.AccessLogCombined, middleware.AccessLogJSON:
+		return middleware.AccessLog(cfg.AccessLogFormat, out)
+	default:
+		return gin.LoggerWithWriter(out)
 	}
 }
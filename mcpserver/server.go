@@ -0,0 +1,159 @@
+// Package mcpserver exposes the LLM as Model Context Protocol tools, so editors and agents can use
+// this server as a tool provider. main serves them on stdin and stdout when started with --mcp.
+package mcpserver
+
+import (
+	"context"
+	"errors"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+	"agent-ollama-gin/version"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// caller is who usage of MCP tool calls is recorded against
+const caller = "mcp"
+
+// ChatInput is the input of the chat tool
+type ChatInput struct {
+	Prompt string `json:"prompt" jsonschema:"the user message to answer"`
+	System string `json:"system,omitempty" jsonschema:"an optional system prompt"`
+	Model  string `json:"model,omitempty" jsonschema:"the model to use, the server's default when empty"`
+}
+
+// ChatOutput is the result of the chat tool
+type ChatOutput struct {
+	Reply string       `json:"reply"`
+	Model string       `json:"model"`
+	Usage models.Usage `json:"usage"`
+}
+
+// CompleteInput is the input of the complete tool
+type CompleteInput struct {
+	Prompt    string `json:"prompt" jsonschema:"the text to continue"`
+	Model     string `json:"model,omitempty" jsonschema:"the model to use, the server's default when empty"`
+	MaxTokens int    `json:"max_tokens,omitempty" jsonschema:"the most tokens to generate"`
+}
+
+// CompleteOutput is the result of the complete tool
+type CompleteOutput struct {
+	Text  string       `json:"text"`
+	Model string       `json:"model"`
+	Usage models.Usage `json:"usage"`
+}
+
+// EmbedInput is the input of the embed tool
+type EmbedInput struct {
+	Input string `json:"input" jsonschema:"the text to embed"`
+	Model string `json:"model,omitempty" jsonschema:"the embedding model, the server's default when empty"`
+}
+
+// EmbedOutput is the result of the embed tool
+type EmbedOutput struct {
+	Embedding []float64 `json:"embedding"`
+	Model     string    `json:"model"`
+}
+
+// ListModelsOutput is the result of the list_models tool
+type ListModelsOutput struct {
+	Models []string `json:"models"`
+}
+
+// NewServer creates an MCP server with chat, complete, embed and list_models tools backed by
+// llamaService
+func NewServer(llamaService services.LlamaServiceInterface) *mcp.Server {
+	server := mcp.NewServer(&mcp.Implementation{Name: "agent-ollama-gin", Version: version.Version}, nil)
+	tools := &tools{llamaService: llamaService}
+
+	mcp.AddTool(server, &mcp.Tool{Name: "chat", Description: "Answer a message with a local or cloud LLM"}, tools.chat)
+	mcp.AddTool(server, &mcp.Tool{Name: "complete", Description: "Continue a piece of text with an LLM"}, tools.complete)
+	mcp.AddTool(server, &mcp.Tool{Name: "embed", Description: "Compute the embedding vector of a text"}, tools.embed)
+	mcp.AddTool(server, &mcp.Tool{Name: "list_models", Description: "List the models the server can use"}, tools.listModels)
+	return server
+}
+
+type tools struct {
+	llamaService services.LlamaServiceInterface
+}
+
+func (t *tools) chat(ctx context.Context, _ *mcp.CallToolRequest, input ChatInput) (*mcp.CallToolResult, ChatOutput, error) {
+	if input.Prompt == "" {
+		return nil, ChatOutput{}, errors.New("prompt is required")
+	}
+
+	var messages []models.Message
+	if input.System != "" {
+		messages = append(messages, models.Message{Role: "system", Content: input.System})
+	}
+	messages = append(messages, models.Message{Role: "user", Content: input.Prompt})
+
+	response, err := t.llamaService.Chat(services.WithCaller(ctx, caller), models.ChatRequest{Messages: messages, Model: input.Model})
+	if err != nil {
+		return nil, ChatOutput{}, err
+	}
+
+	output := ChatOutput{Model: response.Model, Usage: response.Usage}
+	if len(response.Choices) > 0 {
+		output.Reply = response.Choices[0].Message.Content
+	}
+	return textResult(output.Reply), output, nil
+}
+
+func (t *tools) complete(ctx context.Context, _ *mcp.CallToolRequest, input CompleteInput) (*mcp.CallToolResult, CompleteOutput, error) {
+	if input.Prompt == "" {
+		return nil, CompleteOutput{}, errors.New("prompt is required")
+	}
+
+	response, err := t.llamaService.Completion(services.WithCaller(ctx, caller), models.CompletionRequest{
+		Prompt:    input.Prompt,
+		Model:     input.Model,
+		MaxTokens: input.MaxTokens,
+	})
+	if err != nil {
+		return nil, CompleteOutput{}, err
+	}
+
+	output := CompleteOutput{Model: response.Model, Usage: response.Usage}
+	if len(response.Choices) > 0 {
+		output.Text = response.Choices[0].Message.Content
+	}
+	return textResult(output.Text), output, nil
+}
+
+func (t *tools) embed(ctx context.Context, _ *mcp.CallToolRequest, input EmbedInput) (*mcp.CallToolResult, EmbedOutput, error) {
+	if input.Input == "" {
+		return nil, EmbedOutput{}, errors.New("input is required")
+	}
+
+	response, err := t.llamaService.Embedding(services.WithCaller(ctx, caller), models.EmbeddingRequest{Input: input.Input, Model: input.Model})
+	if err != nil {
+		return nil, EmbedOutput{}, err
+	}
+
+	output := EmbedOutput{Model: response.Model}
+	if len(response.Data) > 0 {
+		output.Embedding = response.Data[0].Embedding
+	}
+	return nil, output, nil
+}
+
+func (t *tools) listModels(ctx context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, ListModelsOutput, error) {
+	available, err := t.llamaService.ListModels()
+	if err != nil {
+		return nil, ListModelsOutput{}, err
+	}
+
+	output := ListModelsOutput{Models: []string{}}
+	for _, model := range available {
+		output.Models = append(output.Models, model.ID)
+	}
+	return nil, output, nil
+}
+
+// textResult returns generated text as the tool's content, so clients show the text itself
+// rather than the JSON of the structured output
+func textResult(text string) *mcp.CallToolResult {
+	return &mcp.CallToolResult{Content: []mcp.Content{&mcp.TextContent{Text: text}}}
+}
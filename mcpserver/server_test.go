@@ -0,0 +1,110 @@
+package mcpserver
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/services"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeService answers chat, embeddings and model listing; other methods are not called
+type fakeService struct {
+	services.LlamaServiceInterface
+	chatRequest models.ChatRequest
+	chatErr     error
+}
+
+func (f *fakeService) Chat(ctx context.Context, request models.ChatRequest) (*models.ChatResponse, error) {
+	f.chatRequest = request
+	if f.chatErr != nil {
+		return nil, f.chatErr
+	}
+	return &models.ChatResponse{
+		Model:   "llama2",
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Hello"}}},
+		Usage:   models.Usage{PromptTokens: 3, CompletionTokens: 1, TotalTokens: 4},
+	}, nil
+}
+
+func (f *fakeService) Embedding(ctx context.Context, request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
+	return &models.EmbeddingResponse{Model: "nomic-embed-text", Data: []models.Embedding{{Embedding: []float64{0.5, 0.5}}}}, nil
+}
+
+func (f *fakeService) ListModels() ([]models.Model, error) {
+	return []models.Model{{ID: "llama2"}, {ID: "nomic-embed-text"}}, nil
+}
+
+// connect starts the MCP server over in-memory transports and returns a client session on it
+func connect(t *testing.T, service services.LlamaServiceInterface) *mcp.ClientSession {
+	ctx := context.Background()
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	serverSession, err := NewServer(service).Connect(ctx, serverTransport, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { serverSession.Close() })
+
+	client := mcp.NewClient(&mcp.Implementation{Name: "test"}, nil)
+	session, err := client.Connect(ctx, clientTransport, nil)
+	assert.NoError(t, err)
+	t.Cleanup(func() { session.Close() })
+	return session
+}
+
+func TestListTools(t *testing.T) {
+	session := connect(t, &fakeService{})
+
+	result, err := session.ListTools(context.Background(), nil)
+	assert.NoError(t, err)
+
+	var names []string
+	for _, tool := range result.Tools {
+		names = append(names, tool.Name)
+	}
+	assert.ElementsMatch(t, []string{"chat", "complete", "embed", "list_models"}, names)
+}
+
+func TestChatTool(t *testing.T) {
+	service := &fakeService{}
+	session := connect(t, service)
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "chat",
+		Arguments: map[string]any{"prompt": "Hi", "system": "Be brief"},
+	})
+	assert.NoError(t, err)
+	assert.False(t, result.IsError)
+	assert.Equal(t, "Hello", result.Content[0].(*mcp.TextContent).Text)
+	assert.Equal(t, "Hello", result.StructuredContent.(map[string]any)["reply"])
+	assert.Equal(t, []models.Message{{Role: "system", Content: "Be brief"}, {Role: "user", Content: "Hi"}}, service.chatRequest.Messages)
+}
+
+func TestChatTool_Errors(t *testing.T) {
+	session := connect(t, &fakeService{chatErr: errors.New("ollama is down")})
+
+	// Errors are tool results the model can read, not protocol errors
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "chat", Arguments: map[string]any{"prompt": "Hi"}})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+	assert.Contains(t, result.Content[0].(*mcp.TextContent).Text, "ollama is down")
+
+	result, err = session.CallTool(context.Background(), &mcp.CallToolParams{Name: "chat", Arguments: map[string]any{"prompt": ""}})
+	assert.NoError(t, err)
+	assert.True(t, result.IsError)
+}
+
+func TestEmbedAndListModelsTools(t *testing.T) {
+	session := connect(t, &fakeService{})
+
+	result, err := session.CallTool(context.Background(), &mcp.CallToolParams{Name: "embed", Arguments: map[string]any{"input": "text"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{0.5, 0.5}, result.StructuredContent.(map[string]any)["embedding"])
+
+	result, err = session.CallTool(context.Background(), &mcp.CallToolParams{Name: "list_models", Arguments: map[string]any{}})
+	assert.NoError(t, err)
+	assert.Equal(t, []any{"llama2", "nomic-embed-text"}, result.StructuredContent.(map[string]any)["models"])
+}
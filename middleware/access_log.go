@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Context keys shared with other middleware
+const (
+	// RequestIDKey holds the request ID assigned by RequestID
+	RequestIDKey = "request_id"
+	// APIKeyIDKey holds the identifier of the API key that authenticated the request, if any
+	APIKeyIDKey = "api_key_id"
+)
+
+// Access log formats
+const (
+	AccessLogCombined = "combined"
+	AccessLogJSON     = "json"
+)
+
+// accessLogEntry is one request in the JSON access log
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	RemoteAddr string  `json:"remote_addr"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Protocol   string  `json:"protocol"`
+	Status     int     `json:"status"`
+	BytesSent  int     `json:"bytes_sent"`
+	DurationMs float64 `json:"duration_ms"`
+	Referer    string  `json:"referer,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+	APIKeyID   string  `json:"api_key_id,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+}
+
+// AccessLog writes one line per request to out in Combined Log Format or JSON.
+// In Combined Log Format the API key ID takes the authuser field and the request ID
+// is appended as a trailing quoted field.
+func AccessLog(format string, out io.Writer) gin.HandlerFunc {
+	var mu sync.Mutex
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := accessLogEntry{
+			Time:       start.Format(time.RFC3339),
+			RemoteAddr: c.ClientIP(),
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.RequestURI(),
+			Protocol:   c.Request.Proto,
+			Status:     c.Writer.Status(),
+			BytesSent:  max(c.Writer.Size(), 0),
+			DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+			Referer:    c.Request.Referer(),
+			UserAgent:  c.Request.UserAgent(),
+			APIKeyID:   c.GetString(APIKeyIDKey),
+			RequestID:  c.GetString(RequestIDKey),
+		}
+
+		var line []byte
+		if format == AccessLogJSON {
+			line, _ = json.Marshal(entry)
+		} else {
+			line = []byte(fmt.Sprintf("%s - %s [%s] \"%s %s %s\" %d %s %q %q %q",
+				entry.RemoteAddr,
+				orDash(entry.APIKeyID),
+				start.Format("02/Jan/2006:15:04:05 -0700"),
+				entry.Method, entry.Path, entry.Protocol,
+				entry.Status,
+				bytesField(entry.BytesSent),
+				orDash(entry.Referer),
+				orDash(entry.UserAgent),
+				orDash(entry.RequestID),
+			))
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		out.Write(append(line, '\n'))
+	}
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func bytesField(size int) string {
+	if size == 0 {
+		return "-"
+	}
+	return strconv.Itoa(size)
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupAccessLogRouter(format string, out *bytes.Buffer) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(nil), AccessLog(format, out))
+	router.GET("/ping", func(c *gin.Context) {
+		c.Set(APIKeyIDKey, "key-42")
+		c.String(http.StatusOK, "pong")
+	})
+	return router
+}
+
+func TestRequestID(t *testing.T) {
+	router := setupAccessLogRouter(AccessLogJSON, &bytes.Buffer{})
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Len(t, w.Header().Get("X-Request-ID"), 16)
+
+	req.Header.Set("X-Request-ID", "abc-123")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "abc-123", w.Header().Get("X-Request-ID"))
+}
+
+func TestAccessLog_Combined(t *testing.T) {
+	var out bytes.Buffer
+	router := setupAccessLogRouter(AccessLogCombined, &out)
+
+	req, _ := http.NewRequest("GET", "/ping?x=1", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	req.Header.Set("X-Request-ID", "abc-123")
+	req.RemoteAddr = "10.0.0.1:1234"
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	pattern := `^10\.0\.0\.1 - key-42 \[\d{2}/\w{3}/\d{4}:\d{2}:\d{2}:\d{2} [+-]\d{4}\] "GET /ping\?x=1 HTTP/1\.1" 200 4 "-" "curl/8\.0" "abc-123"\n$`
+	assert.Regexp(t, regexp.MustCompile(pattern), out.String())
+}
+
+func TestAccessLog_JSON(t *testing.T) {
+	var out bytes.Buffer
+	router := setupAccessLogRouter(AccessLogJSON, &out)
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.Header.Set("User-Agent", "curl/8.0")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	var entry accessLogEntry
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+	assert.Equal(t, "GET", entry.Method)
+	assert.Equal(t, "/ping", entry.Path)
+	assert.Equal(t, 200, entry.Status)
+	assert.Equal(t, 4, entry.BytesSent)
+	assert.Equal(t, "curl/8.0", entry.UserAgent)
+	assert.Equal(t, "key-42", entry.APIKeyID)
+	assert.NotEmpty(t, entry.RequestID)
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdminAuth requires the admin token as a bearer token
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			AbortWithError(c, http.StatusUnauthorized, "Admin token required", "", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// RequireAdmin guards administrative routes. With access tokens enabled by secret it requires the
+// admin role, checked after JWTAuth; otherwise it requires adminToken, and lets every request
+// through when that is not set either.
+func RequireAdmin(secret, adminToken string) gin.HandlerFunc {
+	switch {
+	case secret != "":
+		return RequireRole(RoleAdmin)
+	case adminToken != "":
+		return AdminAuth(adminToken)
+	}
+	return func(c *gin.Context) { c.Next() }
+}
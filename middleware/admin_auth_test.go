@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// setupAdminRouter registers administrative routes the way main.go does
+func setupAdminRouter(secret, adminToken string) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	authenticate := gin.HandlerFunc(func(c *gin.Context) { c.Next() })
+	if secret != "" {
+		authenticate = JWTAuth(secret)
+	}
+	requireAdmin := RequireAdmin(secret, adminToken)
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	api := router.Group("", authenticate)
+	api.GET("/aliases", ok)
+	api.PUT("/aliases/:alias", requireAdmin, ok)
+	api.DELETE("/presets/:name", requireAdmin, ok)
+	api.PUT("/prompts/:name", requireAdmin, ok)
+	api.POST("/models/:model/pull", requireAdmin, ok)
+	api.POST("/cloud/signin", requireAdmin, ok)
+	return router
+}
+
+var adminRoutes = []struct{ method, path string }{
+	{"PUT", "/aliases/fast"},
+	{"DELETE", "/presets/concise"},
+	{"PUT", "/prompts/summary"},
+	{"POST", "/models/llama2/pull"},
+	{"POST", "/cloud/signin"},
+}
+
+func TestRequireAdmin_AdminToken(t *testing.T) {
+	router := setupAdminRouter("", "admin-token")
+
+	for _, route := range adminRoutes {
+		assert.Equal(t, http.StatusUnauthorized, jwtRequest(router, route.method, route.path, ""), route.path)
+		assert.Equal(t, http.StatusUnauthorized, jwtRequest(router, route.method, route.path, "wrong"), route.path)
+		assert.Equal(t, http.StatusOK, jwtRequest(router, route.method, route.path, "admin-token"), route.path)
+	}
+	assert.Equal(t, http.StatusOK, jwtRequest(router, "GET", "/aliases", ""))
+}
+
+func TestRequireAdmin_AccessTokens(t *testing.T) {
+	router := setupAdminRouter(testSecret, "admin-token")
+	admin, _ := IssueToken(testSecret, "alice", RoleAdmin, time.Hour)
+	user, _ := IssueToken(testSecret, "bob", RoleUser, time.Hour)
+
+	for _, route := range adminRoutes {
+		assert.Equal(t, http.StatusUnauthorized, jwtRequest(router, route.method, route.path, ""), route.path)
+		assert.Equal(t, http.StatusForbidden, jwtRequest(router, route.method, route.path, user), route.path)
+		assert.Equal(t, http.StatusOK, jwtRequest(router, route.method, route.path, admin), route.path)
+	}
+	assert.Equal(t, http.StatusOK, jwtRequest(router, "GET", "/aliases", user))
+}
+
+func TestRequireAdmin_Open(t *testing.T) {
+	router := setupAdminRouter("", "")
+
+	for _, route := range adminRoutes {
+		assert.Equal(t, http.StatusOK, jwtRequest(router, route.method, route.path, ""), route.path)
+	}
+}
@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AdmissionCheck decides whether a client may start another generation, returning an
+// *AdmissionError when it may not. The rate limit, token quota and maintenance middleware make
+// these checks as requests arrive; connections carrying several generations, such as WebSocket
+// chats and gRPC, repeat them for each one.
+type AdmissionCheck func(ctx context.Context) error
+
+// AdmissionError is a refusal by an AdmissionCheck
+type AdmissionError struct {
+	Status     int // HTTP status the refusal is answered with
+	Message    string
+	Details    string
+	RetryAfter time.Duration // 0 when unknown
+}
+
+func (e *AdmissionError) Error() string {
+	return e.Message + ": " + e.Details
+}
+
+// admissionChecksKey is the gin context key of the checks a request passed
+const admissionChecksKey = "admission_checks"
+
+// passed records that the request passed check, so Readmit can repeat it
+func passed(c *gin.Context, check AdmissionCheck) {
+	checks, _ := c.Get(admissionChecksKey)
+	list, _ := checks.([]AdmissionCheck)
+	c.Set(admissionChecksKey, append(list, check))
+}
+
+// Readmit repeats the admission checks the request passed on its way in, for each generation a
+// long-lived connection such as a WebSocket chat starts. It returns the first refusal.
+func Readmit(c *gin.Context) error {
+	checks, _ := c.Get(admissionChecksKey)
+	list, _ := checks.([]AdmissionCheck)
+	for _, check := range list {
+		if err := check(c.Request.Context()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RateCheck takes a request from key's budget in limiter. If the limiter fails, the request is
+// admitted, as RateLimitBy does.
+func RateCheck(limiter RateLimiter, key string) AdmissionCheck {
+	return func(ctx context.Context) error {
+		result, err := limiter.Allow(ctx, key)
+		if err != nil {
+			slog.Warn("Rate limiter unavailable, allowing request", "client", key, "error", err)
+			return nil
+		}
+		if result.Allowed {
+			return nil
+		}
+		return &AdmissionError{
+			Status:     http.StatusTooManyRequests,
+			Message:    "Rate limit exceeded",
+			Details:    "Too many requests, retry after the indicated delay",
+			RetryAfter: result.RetryAfter,
+		}
+	}
+}
+
+// QuotaCheck refuses key once it spent its daily budget of limit tokens. If the quota store
+// fails, the request is admitted, as Quota does.
+func QuotaCheck(quota TokenQuota, limit int, key string) AdmissionCheck {
+	return func(ctx context.Context) error {
+		used, err := quota.Used(ctx, key)
+		if err != nil {
+			slog.Warn("Token quota unavailable, allowing request", "client", key, "error", err)
+			return nil
+		}
+		if used < limit {
+			return nil
+		}
+		return &AdmissionError{
+			Status:     http.StatusTooManyRequests,
+			Message:    "Token quota exceeded",
+			Details:    fmt.Sprintf("The daily budget of %d tokens is spent, it resets at midnight UTC", limit),
+			RetryAfter: untilMidnightUTC(time.Now()),
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadmit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	quota := NewMemoryQuota()
+	maintenance := NewMaintenanceMode()
+	key := func(c *gin.Context) string { return "alice" }
+
+	var readmit func() error
+	router := gin.New()
+	router.GET("/socket",
+		RateLimitBy(NewTokenBucketLimiter(10, time.Hour), key),
+		Quota(quota, 10, key, func(ctx context.Context, record func(int)) context.Context { return ctx }),
+		maintenance.Guard(),
+		func(c *gin.Context) { readmit = func() error { return Readmit(c) } },
+	)
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/socket", nil))
+
+	assert.NoError(t, readmit())
+
+	maintenance.Enable("Upgrading", nil)
+	var refusal *AdmissionError
+	assert.True(t, errors.As(readmit(), &refusal))
+	assert.Equal(t, http.StatusServiceUnavailable, refusal.Status)
+	maintenance.Disable()
+
+	quota.Add(context.Background(), "alice", 10)
+	assert.True(t, errors.As(readmit(), &refusal))
+	assert.Equal(t, "Token quota exceeded", refusal.Message)
+	assert.Positive(t, refusal.RetryAfter)
+}
+
+func TestRateCheck(t *testing.T) {
+	check := RateCheck(NewTokenBucketLimiter(1, time.Hour), "alice")
+	assert.NoError(t, check(context.Background()))
+
+	var refusal *AdmissionError
+	assert.True(t, errors.As(check(context.Background()), &refusal))
+	assert.Equal(t, http.StatusTooManyRequests, refusal.Status)
+	assert.Positive(t, refusal.RetryAfter)
+}
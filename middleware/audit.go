@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AuditLog records administrative actions
+type AuditLog interface {
+	Append(ctx context.Context, entry models.AuditEntry) error
+}
+
+// Audit records action in log once the handler has answered, with the actor identified by key and
+// the outcome taken from the response status. A failure to record is logged, not returned to the
+// client, since the action has already happened.
+func Audit(log AuditLog, action string, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		outcome := models.AuditSuccess
+		if c.Writer.Status() >= http.StatusBadRequest {
+			outcome = models.AuditFailure
+		}
+		entry := models.AuditEntry{
+			Time:      time.Now().Unix(),
+			Actor:     key(c),
+			Action:    action,
+			Target:    c.Request.URL.Path,
+			Outcome:   outcome,
+			Status:    c.Writer.Status(),
+			RequestID: c.GetString(RequestIDKey),
+		}
+		// The request may have been cancelled by the time its response is written
+		if err := log.Append(context.WithoutCancel(c.Request.Context()), entry); err != nil {
+			logger(c).Error("Failed to record audit entry", "action", action, "error", err)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingAuditLog struct {
+	entries []models.AuditEntry
+}
+
+func (l *recordingAuditLog) Append(ctx context.Context, entry models.AuditEntry) error {
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func TestAudit(t *testing.T) {
+	auditLog := &recordingAuditLog{}
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(nil))
+	key := func(*gin.Context) string { return "user:alice" }
+	router.DELETE("/models/:model", Audit(auditLog, "model.delete", key), func(c *gin.Context) {
+		if c.Param("model") == "missing" {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Model not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"message": "deleted"})
+	})
+
+	for _, path := range []string{"/models/llama3.2", "/models/missing"} {
+		req, _ := http.NewRequest("DELETE", path, nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if assert.Len(t, auditLog.entries, 2) {
+		entry := auditLog.entries[0]
+		assert.NotZero(t, entry.Time)
+		entry.Time = 0
+		assert.Equal(t, models.AuditEntry{
+			Actor:     "user:alice",
+			Action:    "model.delete",
+			Target:    "/models/llama3.2",
+			Outcome:   models.AuditSuccess,
+			Status:    http.StatusOK,
+			RequestID: "req-1",
+		}, entry)
+		assert.Equal(t, models.AuditFailure, auditLog.entries[1].Outcome)
+		assert.Equal(t, http.StatusNotFound, auditLog.entries[1].Status)
+	}
+}
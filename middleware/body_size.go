@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaxBodySize rejects requests whose body is larger than limit bytes with 413, before any handler
+// reads it. Bodies of unknown length are read up to the limit, so a chunked upload cannot get past
+// it either.
+func MaxBodySize(limit int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > limit {
+			abortBodyTooLarge(c, limit)
+			return
+		}
+
+		if c.Request.ContentLength < 0 && c.Request.Body != nil {
+			body, err := io.ReadAll(io.LimitReader(c.Request.Body, limit+1))
+			if err != nil {
+				AbortWithError(c, http.StatusBadRequest, "Invalid request body", err.Error(), nil)
+				return
+			}
+			if int64(len(body)) > limit {
+				abortBodyTooLarge(c, limit)
+				return
+			}
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+		}
+
+		c.Next()
+	}
+}
+
+func abortBodyTooLarge(c *gin.Context, limit int64) {
+	AbortWithError(c, http.StatusRequestEntityTooLarge, "Request body too large",
+		fmt.Sprintf("The request body is larger than the limit of %d bytes", limit), gin.H{"limit_bytes": limit})
+}
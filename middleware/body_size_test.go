@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxBodySize(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(MaxBodySize(10))
+	router.POST("/echo", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		c.String(http.StatusOK, string(body))
+	})
+
+	tests := []struct {
+		name    string
+		body    string
+		chunked bool
+		status  int
+	}{
+		{"within the limit", "0123456789", false, http.StatusOK},
+		{"over the limit", "0123456789A", false, http.StatusRequestEntityTooLarge},
+		{"chunked within the limit", "0123456789", true, http.StatusOK},
+		{"chunked over the limit", "0123456789A", true, http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/echo", strings.NewReader(tt.body))
+			if tt.chunked {
+				req.ContentLength = -1
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.body, w.Body.String())
+			} else {
+				assert.JSONEq(t, `{
+					"code": "request_entity_too_large",
+					"message": "Request body too large",
+					"error": "Request body too large",
+					"details": "The request body is larger than the limit of 10 bytes",
+					"limit_bytes": 10
+				}`, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextTagger attaches a value identifying a request, or the client making it, to ctx
+type ContextTagger func(ctx context.Context, value string) context.Context
+
+// Caller tags each request's context with the client identified by key, so the work done for it,
+// such as usage records, can be attributed to that client
+func Caller(key func(c *gin.Context) string, tag ContextTagger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(tag(c.Request.Context(), key(c)))
+		c.Next()
+	}
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type callerKey struct{}
+
+func TestCaller(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Caller(ClientKey(testSecret), func(ctx context.Context, caller string) context.Context {
+		return context.WithValue(ctx, callerKey{}, caller)
+	}))
+	router.GET("/whoami", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Context().Value(callerKey{}).(string))
+	})
+
+	token, _ := IssueToken(testSecret, "alice", RoleUser, time.Hour)
+	for header, caller := range map[string]string{"": "ip:1.2.3.4", "Bearer " + token: "user:alice"} {
+		req, _ := http.NewRequest("GET", "/whoami", nil)
+		req.RemoteAddr = "1.2.3.4:5678"
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, caller, w.Body.String())
+	}
+}
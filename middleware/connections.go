@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"sync"
+
+	"agent-ollama-gin/models"
+)
+
+// ConnCounter counts the open connections of an http.Server by state. Install Track as the
+// server's ConnState hook.
+type ConnCounter struct {
+	mu    sync.Mutex
+	conns map[net.Conn]http.ConnState
+}
+
+// NewConnCounter creates a counter with no open connections
+func NewConnCounter() *ConnCounter {
+	return &ConnCounter{conns: map[net.Conn]http.ConnState{}}
+}
+
+// Track records a connection changing state. Closed and hijacked connections are forgotten.
+func (c *ConnCounter) Track(conn net.Conn, state http.ConnState) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch state {
+	case http.StateClosed, http.StateHijacked:
+		delete(c.conns, conn)
+	default:
+		c.conns[conn] = state
+	}
+}
+
+// Stats counts the open connections by state
+func (c *ConnCounter) Stats() models.ConnectionStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := models.ConnectionStats{Open: len(c.conns)}
+	for _, state := range c.conns {
+		switch state {
+		case http.StateNew:
+			stats.New++
+		case http.StateActive:
+			stats.Active++
+		case http.StateIdle:
+			stats.Idle++
+		}
+	}
+	return stats
+}
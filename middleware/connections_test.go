@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConnCounter(t *testing.T) {
+	counter := NewConnCounter()
+	first, _ := net.Pipe()
+	second, _ := net.Pipe()
+	third, _ := net.Pipe()
+
+	counter.Track(first, http.StateNew)
+	counter.Track(second, http.StateNew)
+	counter.Track(third, http.StateNew)
+	counter.Track(first, http.StateActive)
+	counter.Track(second, http.StateActive)
+	counter.Track(second, http.StateIdle)
+	assert.Equal(t, models.ConnectionStats{Open: 3, New: 1, Active: 1, Idle: 1}, counter.Stats())
+
+	counter.Track(first, http.StateHijacked)
+	counter.Track(second, http.StateClosed)
+	assert.Equal(t, models.ConnectionStats{Open: 1, New: 1}, counter.Stats())
+}
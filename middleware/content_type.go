@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContentTypes rejects requests whose body is not one of the accepted media types with 415.
+// Media type parameters such as charset and multipart boundaries are ignored when matching,
+// and requests without a body are always allowed.
+func ContentTypes(accepted ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength == 0 {
+			c.Next()
+			return
+		}
+
+		mediaType, _, err := mime.ParseMediaType(c.GetHeader("Content-Type"))
+		if err == nil && acceptsMediaType(accepted, mediaType) {
+			c.Next()
+			return
+		}
+
+		details := fmt.Sprintf("Content-Type %q is not accepted", c.GetHeader("Content-Type"))
+		if err != nil {
+			details = fmt.Sprintf("Invalid Content-Type %q: %v", c.GetHeader("Content-Type"), err)
+		}
+		AbortWithError(c, http.StatusUnsupportedMediaType, "Unsupported content type", details, gin.H{"accepted_types": accepted})
+	}
+}
+
+func acceptsMediaType(accepted []string, mediaType string) bool {
+	for _, candidate := range accepted {
+		if strings.EqualFold(candidate, mediaType) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContentTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ContentTypes("application/json", "multipart/form-data"))
+	router.POST("/upload", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name         string
+		contentType  string
+		body         string
+		expectedCode int
+	}{
+		{"json", "application/json", `{}`, http.StatusOK},
+		{"json with charset", "application/json; charset=utf-8", `{}`, http.StatusOK},
+		{"case insensitive", "Application/JSON", `{}`, http.StatusOK},
+		{"multipart with boundary", "multipart/form-data; boundary=xyz", "--xyz--", http.StatusOK},
+		{"empty body", "", "", http.StatusOK},
+		{"plain text", "text/plain", "hello", http.StatusUnsupportedMediaType},
+		{"missing header", "", "hello", http.StatusUnsupportedMediaType},
+		{"malformed header", "application/json; charset", `{}`, http.StatusUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/upload", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestContentTypes_ListsAcceptedTypes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(ContentTypes("application/json"))
+	router.POST("/chat", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	req, _ := http.NewRequest("POST", "/chat", strings.NewReader("hello"))
+	req.Header.Set("Content-Type", "text/plain")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Unsupported content type", response["error"])
+	assert.Equal(t, []interface{}{"application/json"}, response["accepted_types"])
+}
@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"agent-ollama-gin/config"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+)
+
+// CORSPolicy is the cross-origin policy of the routes under a path prefix
+type CORSPolicy struct {
+	Prefix string
+	Config config.CORSConfig
+}
+
+// CORS applies the configured cross-origin policy and answers preflight requests.
+// Credentials are never combined with a wildcard origin; when both are configured,
+// credentials are dropped. Without origins, cross-origin requests are rejected.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	corsConfig := cors.Config{
+		AllowMethods:     cfg.AllowMethods,
+		AllowHeaders:     cfg.AllowHeaders,
+		ExposeHeaders:    []string{"X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset", "Retry-After"},
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           time.Duration(cfg.MaxAge) * time.Second,
+	}
+
+	switch {
+	case len(cfg.AllowOrigins) == 0:
+		corsConfig.AllowOriginFunc = func(string) bool { return false }
+	case slices.Contains(cfg.AllowOrigins, "*"):
+		corsConfig.AllowAllOrigins = true
+		if cfg.AllowCredentials {
+			slog.Warn("CORS credentials ignored because the policy allows any origin")
+			corsConfig.AllowCredentials = false
+		}
+	default:
+		corsConfig.AllowOrigins = cfg.AllowOrigins
+	}
+
+	return cors.New(corsConfig)
+}
+
+// CORSByPath applies the policy of the longest prefix matching the request path, and cfg to
+// other paths. It must be registered on the engine rather than on route groups: preflight
+// requests match no route, so group middleware never sees them.
+func CORSByPath(cfg config.CORSConfig, policies ...CORSPolicy) gin.HandlerFunc {
+	fallback := CORS(cfg)
+	handlers := make([]gin.HandlerFunc, len(policies))
+	for i, policy := range policies {
+		handlers[i] = CORS(policy.Config)
+	}
+
+	return func(c *gin.Context) {
+		handler, matched := fallback, ""
+		for i, policy := range policies {
+			if len(policy.Prefix) > len(matched) && underPrefix(c.Request.URL.Path, policy.Prefix) {
+				handler, matched = handlers[i], policy.Prefix
+			}
+		}
+		handler(c)
+	}
+}
+
+// underPrefix reports whether path is prefix or lies below it
+func underPrefix(path, prefix string) bool {
+	rest, ok := strings.CutPrefix(path, strings.TrimSuffix(prefix, "/"))
+	return ok && (rest == "" || strings.HasPrefix(rest, "/"))
+}
@@ -0,0 +1,151 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupCORSRouter(cfg config.CORSConfig) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(CORS(cfg))
+	router.POST("/chat", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestCORS_Preflight(t *testing.T) {
+	router := setupCORSRouter(config.CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		AllowMethods: []string{"GET", "POST"},
+		AllowHeaders: []string{"Content-Type"},
+		MaxAge:       600,
+	})
+
+	req, _ := http.NewRequest("OPTIONS", "/chat", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+}
+
+func TestCORS_RejectsUnknownOrigin(t *testing.T) {
+	router := setupCORSRouter(config.CORSConfig{
+		AllowOrigins: []string{"https://app.example.com"},
+		AllowMethods: []string{"POST"},
+	})
+
+	req, _ := http.NewRequest("POST", "/chat", nil)
+	req.Header.Set("Origin", "https://evil.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+}
+
+func TestCORS_WildcardDropsCredentials(t *testing.T) {
+	router := setupCORSRouter(config.CORSConfig{
+		AllowOrigins:     []string{"*"},
+		AllowMethods:     []string{"POST"},
+		AllowCredentials: true,
+	})
+
+	req, _ := http.NewRequest("POST", "/chat", nil)
+	req.Header.Set("Origin", "https://any.example.com")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestCORS_NoOriginsRejectsCrossOrigin(t *testing.T) {
+	router := setupCORSRouter(config.CORSConfig{AllowMethods: []string{"POST"}})
+
+	req, _ := http.NewRequest("OPTIONS", "/chat", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// Same-origin requests are not cross-origin
+	req, _ = http.NewRequest("POST", "/chat", nil)
+	req.Host = "api.example.com"
+	req.Header.Set("Origin", "https://api.example.com")
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestCORSByPath_Preflight(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	strict := config.CORSConfig{
+		AllowOrigins: []string{"https://console.example.com"},
+		AllowMethods: []string{"GET", "PUT"},
+		AllowHeaders: []string{"Authorization"},
+		MaxAge:       60,
+	}
+	router.Use(CORSByPath(config.CORSConfig{
+		AllowOrigins: []string{"*"},
+		AllowMethods: []string{"GET", "POST"},
+		MaxAge:       600,
+	},
+		CORSPolicy{Prefix: "/api/v1/admin", Config: strict},
+		CORSPolicy{Prefix: "/api/v1/auth", Config: config.CORSConfig{AllowMethods: []string{"POST"}}},
+	))
+	for _, path := range []string{"/api/v1/llama/chat", "/api/v1/administrator", "/api/v1/auth/login"} {
+		router.POST(path, func(c *gin.Context) { c.Status(http.StatusOK) })
+	}
+	router.PUT("/api/v1/admin/config", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	preflight := func(path, origin, method string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("OPTIONS", path, nil)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// Generation routes keep the default policy
+	w := preflight("/api/v1/llama/chat", "https://any.example.com", "POST")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+
+	// Prefixes match whole path segments
+	w = preflight("/api/v1/administrator", "https://any.example.com", "POST")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+
+	// Admin routes admit only their own origins
+	w = preflight("/api/v1/admin/config", "https://any.example.com", "PUT")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+	w = preflight("/api/v1/admin/config", "https://console.example.com", "PUT")
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "https://console.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET,PUT", w.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "60", w.Header().Get("Access-Control-Max-Age"))
+
+	// Login admits no cross-origin callers
+	w = preflight("/api/v1/auth/login", "https://any.example.com", "POST")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	w = preflight("/api/v1/auth/login", "https://console.example.com", "POST")
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
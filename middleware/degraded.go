@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Degraded lists the dependencies the server is running without, as reported by degraded, in
+// the X-Degraded header of every response, e.g. "conversation_store,usage_store"
+func Degraded(degraded func() []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if names := degraded(); len(names) > 0 {
+			c.Header("X-Degraded", strings.Join(names, ","))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDegraded(t *testing.T) {
+	var degraded []string
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Degraded(func() []string { return degraded }))
+	router.GET("/ping", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Empty(t, w.Header().Get("X-Degraded"))
+
+	degraded = []string{"conversation_store", "usage_store"}
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "conversation_store,usage_store", w.Header().Get("X-Degraded"))
+}
@@ -0,0 +1,57 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ErrorCode is the machine-readable code of an error response with status, the status text in
+// snake case, e.g. "not_found" for 404 and "too_many_requests" for 429
+func ErrorCode(status int) string {
+	text := http.StatusText(status)
+	if status == 499 {
+		// Not a registered status, nginx's name for a request the client abandoned
+		text = "Client Closed Request"
+	}
+	if text == "" {
+		return "error"
+	}
+
+	var code strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			code.WriteRune(r)
+		case r == ' ' || r == '-':
+			code.WriteByte('_')
+		}
+	}
+	return code.String()
+}
+
+// NewErrorResponse returns the standard error envelope of a request failing with status
+func NewErrorResponse(c *gin.Context, status int, message, details string) models.ErrorResponse {
+	return models.ErrorResponse{
+		Code:      ErrorCode(status),
+		Message:   message,
+		Error:     message,
+		Details:   details,
+		RequestID: c.GetString(RequestIDKey),
+	}
+}
+
+// AbortWithError ends the request with the standard error envelope. fields, such as the delay
+// before a rate limited client may retry, are added to it. Middleware and handlers answer every
+// failure through it, so all errors carry the same code, message and request ID.
+func AbortWithError(c *gin.Context, status int, message, details string, fields gin.H) {
+	response := NewErrorResponse(c, status, message, details)
+	if len(fields) == 0 {
+		c.AbortWithStatusJSON(status, response)
+		return
+	}
+	c.AbortWithStatusJSON(status, response.WithFields(fields))
+}
@@ -0,0 +1,45 @@
+package middleware
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorCode(t *testing.T) {
+	assert.Equal(t, "bad_request", ErrorCode(http.StatusBadRequest))
+	assert.Equal(t, "too_many_requests", ErrorCode(http.StatusTooManyRequests))
+	assert.Equal(t, "request_entity_too_large", ErrorCode(http.StatusRequestEntityTooLarge))
+	assert.Equal(t, "client_closed_request", ErrorCode(499))
+	assert.Equal(t, "error", ErrorCode(599))
+}
+
+func TestAbortWithError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(nil))
+	router.GET("/fail", func(c *gin.Context) {
+		AbortWithError(c, http.StatusNotFound, "Model not found", "no model named mistral", nil)
+	})
+	router.GET("/fail/fields", func(c *gin.Context) {
+		AbortWithError(c, http.StatusTooManyRequests, "Rate limit exceeded", "", gin.H{"retry_after_seconds": 3})
+	})
+	router.GET("/fail/other", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"type": "error", "error": gin.H{"type": "invalid_request_error", "message": "bad"}})
+	})
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/fail", `{"code":"not_found","message":"Model not found","error":"Model not found","details":"no model named mistral","request_id":"abc-123"}`},
+		{"/fail/fields", `{"code":"too_many_requests","message":"Rate limit exceeded","error":"Rate limit exceeded","retry_after_seconds":3,"request_id":"abc-123"}`},
+		{"/fail/other", `{"type":"error","error":{"type":"invalid_request_error","message":"bad"},"request_id":"abc-123"}`},
+	}
+	for _, tt := range tests {
+		w := requestIDRequest(router, tt.path, "abc-123")
+		assert.JSONEq(t, tt.body, w.Body.String(), tt.path)
+	}
+}
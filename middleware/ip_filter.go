@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// IPPolicy admits clients by IP address. Deny entries take precedence; when the allow list is
+// non-empty, only clients matching it are admitted. Entries are CIDR ranges or single addresses.
+type IPPolicy struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// NewIPPolicy parses the allow and deny lists of an IPPolicy
+func NewIPPolicy(allow, deny []string) (*IPPolicy, error) {
+	allowNets, err := parseNetworks(allow)
+	if err != nil {
+		return nil, fmt.Errorf("invalid allow list: %w", err)
+	}
+	denyNets, err := parseNetworks(deny)
+	if err != nil {
+		return nil, fmt.Errorf("invalid deny list: %w", err)
+	}
+	return &IPPolicy{allow: allowNets, deny: denyNets}, nil
+}
+
+// Admits reports whether the client at address may call the API
+func (p *IPPolicy) Admits(address string) bool {
+	ip := net.ParseIP(address)
+	return ip != nil && !containsIP(p.deny, ip) && (len(p.allow) == 0 || containsIP(p.allow, ip))
+}
+
+// IPFilter rejects clients by IP address with 403, as the IPPolicy of allow and deny decides.
+// The client IP is resolved by gin, so trusted proxies must be configured for forwarded
+// addresses to be honoured.
+func IPFilter(allow, deny []string) (gin.HandlerFunc, error) {
+	policy, err := NewIPPolicy(allow, deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(c *gin.Context) {
+		if !policy.Admits(c.ClientIP()) {
+			AbortWithError(c, http.StatusForbidden, "Forbidden",
+				fmt.Sprintf("Client IP %s is not allowed", c.ClientIP()), nil)
+			return
+		}
+		c.Next()
+	}, nil
+}
+
+// parseNetworks parses CIDR ranges, treating bare addresses as single-host ranges
+func parseNetworks(entries []string) ([]*net.IPNet, error) {
+	networks := make([]*net.IPNet, 0, len(entries))
+	for _, entry := range entries {
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("%q is not an IP address or CIDR range", entry)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				bits = 32
+			}
+			entry = fmt.Sprintf("%s/%d", entry, bits)
+		}
+
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIPFilter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		allow        []string
+		deny         []string
+		remoteAddr   string
+		expectedCode int
+	}{
+		{"no lists", nil, nil, "203.0.113.7:1234", http.StatusOK},
+		{"allowed range", []string{"10.0.0.0/8"}, nil, "10.1.2.3:1234", http.StatusOK},
+		{"outside allowed range", []string{"10.0.0.0/8"}, nil, "203.0.113.7:1234", http.StatusForbidden},
+		{"denied address", nil, []string{"203.0.113.7"}, "203.0.113.7:1234", http.StatusForbidden},
+		{"deny wins over allow", []string{"10.0.0.0/8"}, []string{"10.0.0.0/16"}, "10.0.5.5:1234", http.StatusForbidden},
+		{"ipv6 range", []string{"2001:db8::/32"}, nil, "[2001:db8::1]:1234", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filter, err := IPFilter(tt.allow, tt.deny)
+			assert.NoError(t, err)
+
+			router := gin.New()
+			router.Use(filter)
+			router.GET("/ping", func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/ping", nil)
+			req.RemoteAddr = tt.remoteAddr
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
+
+func TestIPFilter_InvalidEntry(t *testing.T) {
+	_, err := IPFilter([]string{"not-an-ip"}, nil)
+	assert.Error(t, err)
+
+	_, err = IPFilter(nil, []string{"10.0.0.0/99"})
+	assert.Error(t, err)
+}
+
+func TestIPFilter_TrustedProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	filter, _ := IPFilter(nil, []string{"198.51.100.1"})
+
+	router := gin.New()
+	router.SetTrustedProxies([]string{"10.0.0.1"})
+	router.Use(filter)
+	router.GET("/ping", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	// Forwarded address is honoured from a trusted proxy
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+	req.Header.Set("X-Forwarded-For", "198.51.100.1")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusForbidden, w.Code)
+
+	// ...and ignored from anyone else
+	req.RemoteAddr = "10.0.0.2:1234"
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
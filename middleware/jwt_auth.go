@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Roles carried by access tokens. Admins may use every route, users every route but the sensitive
+// ones, and readonly users only read.
+const (
+	RoleAdmin    = "admin"
+	RoleUser     = "user"
+	RoleReadOnly = "readonly"
+)
+
+// roleKey is the context key the authenticated role is stored under
+const roleKey = "role"
+
+// tokenIssuer names this service in the tokens it issues
+const tokenIssuer = "agent-ollama-gin"
+
+// ValidRole reports whether role is one of the known roles
+func ValidRole(role string) bool {
+	return role == RoleAdmin || role == RoleUser || role == RoleReadOnly
+}
+
+// accessClaims are the claims of an access token
+type accessClaims struct {
+	Role string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+// IssueToken signs an access token for subject with role, valid for ttl
+func IssueToken(secret, subject, role string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims{
+		Role: role,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	})
+	return token.SignedString([]byte(secret))
+}
+
+// parseToken validates an access token and returns its claims
+func parseToken(secret, token string) (*accessClaims, error) {
+	claims := &accessClaims{}
+	_, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(secret), nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(tokenIssuer),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if !ValidRole(claims.Role) {
+		return nil, fmt.Errorf("unknown role %q", claims.Role)
+	}
+	return claims, nil
+}
+
+// VerifyToken validates an access token issued by IssueToken and returns its subject and role,
+// for transports that do not go through JWTAuth
+func VerifyToken(secret, token string) (subject, role string, err error) {
+	claims, err := parseToken(secret, token)
+	if err != nil {
+		return "", "", err
+	}
+	return claims.Subject, claims.Role, nil
+}
+
+// JWTAuth requires an access token issued by IssueToken as a bearer token. Readonly tokens are
+// limited to GET and HEAD requests.
+func JWTAuth(secret string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			c.Header("WWW-Authenticate", "Bearer")
+			AbortWithError(c, http.StatusUnauthorized, "Access token required", "", nil)
+			return
+		}
+
+		claims, err := parseToken(secret, provided)
+		if err != nil {
+			c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+			AbortWithError(c, http.StatusUnauthorized, "Invalid access token", err.Error(), nil)
+			return
+		}
+
+		if claims.Role == RoleReadOnly && c.Request.Method != http.MethodGet && c.Request.Method != http.MethodHead {
+			AbortWithError(c, http.StatusForbidden, "Read-only access", "", nil)
+			return
+		}
+
+		c.Set(roleKey, claims.Role)
+		c.Next()
+	}
+}
+
+// RequireRole rejects requests whose access token, checked by JWTAuth, has none of roles
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, c.GetString(roleKey)) {
+			AbortWithError(c, http.StatusForbidden, fmt.Sprintf("Requires role %s", strings.Join(roles, " or ")), "", nil)
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "test-secret"
+
+func setupJWTRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	api := router.Group("", JWTAuth(testSecret))
+	api.GET("/models", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/chat", func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.POST("/models/pull", RequireRole(RoleAdmin), func(c *gin.Context) { c.Status(http.StatusOK) })
+	api.GET("/chat/ws", RequireRole(RoleAdmin, RoleUser), func(c *gin.Context) { c.Status(http.StatusOK) })
+	return router
+}
+
+func jwtRequest(router *gin.Engine, method, path, token string) int {
+	req, _ := http.NewRequest(method, path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w.Code
+}
+
+func TestJWTAuth_Roles(t *testing.T) {
+	router := setupJWTRouter()
+	admin, _ := IssueToken(testSecret, "alice", RoleAdmin, time.Hour)
+	user, _ := IssueToken(testSecret, "bob", RoleUser, time.Hour)
+	readonly, _ := IssueToken(testSecret, "carol", RoleReadOnly, time.Hour)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		status int
+	}{
+		{"missing token", "GET", "/models", "", http.StatusUnauthorized},
+		{"admin reads", "GET", "/models", admin, http.StatusOK},
+		{"admin pulls", "POST", "/models/pull", admin, http.StatusOK},
+		{"user chats", "POST", "/chat", user, http.StatusOK},
+		{"user cannot pull", "POST", "/models/pull", user, http.StatusForbidden},
+		{"readonly reads", "GET", "/models", readonly, http.StatusOK},
+		{"readonly cannot chat", "POST", "/chat", readonly, http.StatusForbidden},
+		{"user opens chat socket", "GET", "/chat/ws", user, http.StatusOK},
+		{"readonly cannot open chat socket", "GET", "/chat/ws", readonly, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.status, jwtRequest(router, tt.method, tt.path, tt.token))
+		})
+	}
+}
+
+func TestJWTAuth_RejectsInvalidTokens(t *testing.T) {
+	router := setupJWTRouter()
+
+	expired, _ := IssueToken(testSecret, "alice", RoleAdmin, -time.Minute)
+	otherSecret, _ := IssueToken("other-secret", "alice", RoleAdmin, time.Hour)
+	unknownRole, _ := IssueToken(testSecret, "alice", "root", time.Hour)
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, accessClaims{
+		Role: RoleAdmin,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenIssuer,
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+
+	for name, token := range map[string]string{
+		"expired":      expired,
+		"other secret": otherSecret,
+		"unknown role": unknownRole,
+		"unsigned":     unsigned,
+		"garbage":      "not-a-token",
+	} {
+		assert.Equal(t, http.StatusUnauthorized, jwtRequest(router, "GET", "/models", token), name)
+	}
+}
@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LimitsKey holds the models.GenerationLimits chosen by Limits
+const LimitsKey = "generation_limits"
+
+// Limits picks the generation limits of each request from cfg: an override for the client, as
+// identified by key, wins over one for the role of its access token, which wins over the default.
+// It must run after JWTAuth for role overrides to apply.
+func Limits(cfg config.LimitsConfig, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(LimitsKey, LimitsFor(cfg, key(c), c.GetString(roleKey)))
+		c.Next()
+	}
+}
+
+// LimitsFor returns the generation limits of client, whose access token has role, or "" without one
+func LimitsFor(cfg config.LimitsConfig, client, role string) models.GenerationLimits {
+	return models.GenerationLimits{
+		MaxTokens:             limitFor(cfg.MaxTokens, cfg.MaxTokensOverrides, client, role),
+		MaxMessages:           limitFor(cfg.MaxMessages, cfg.MaxMessagesOverrides, client, role),
+		MaxPromptChars:        cfg.MaxPromptChars,
+		MaxEmbeddingChars:     cfg.MaxEmbeddingChars,
+		StreamTokensPerSecond: limitFor(cfg.StreamTokensPerSecond, cfg.StreamTokensPerSecondOverrides, client, role),
+	}
+}
+
+// ClampMaxTokens lowers a request's max_tokens, and its num_predict option when set, to
+// limits.MaxTokens. A request that asks for no limit gets the limit. It returns the values that
+// were clamped, to report in the response.
+func ClampMaxTokens(limits models.GenerationLimits, maxTokens *int, options *models.Options) []models.ClampedLimit {
+	limit := limits.MaxTokens
+	if limit <= 0 {
+		return nil
+	}
+
+	// num_predict overrides max_tokens when both are set
+	requested := *maxTokens
+	if options != nil && options.NumPredict != nil {
+		requested = *options.NumPredict
+	}
+	if requested > 0 && requested <= limit {
+		return nil
+	}
+
+	*maxTokens = limit
+	if options != nil && options.NumPredict != nil {
+		options.NumPredict = &limit
+	}
+	return []models.ClampedLimit{{Name: "max_tokens", Requested: max(requested, 0), Limit: limit}}
+}
+
+// MessageLimitError returns an error if a chat request has more messages than limits allow
+func MessageLimitError(limits models.GenerationLimits, messages int) error {
+	limit := limits.MaxMessages
+	if limit <= 0 || messages <= limit {
+		return nil
+	}
+	return fmt.Errorf("the request has %d messages, the limit is %d", messages, limit)
+}
+
+// PromptLimitError returns an error if a prompt is longer than limits allow
+func PromptLimitError(limits models.GenerationLimits, prompt ...string) error {
+	limit := limits.MaxPromptChars
+	if limit <= 0 {
+		return nil
+	}
+
+	length := 0
+	for _, text := range prompt {
+		length += utf8.RuneCountInString(text)
+	}
+	if length <= limit {
+		return nil
+	}
+	return fmt.Errorf("the prompt has %d characters, the limit is %d", length, limit)
+}
+
+// limitFor returns the override of client or, failing that, of role, or fallback without either
+func limitFor(fallback int, overrides map[string]int, client, role string) int {
+	if limit, ok := overrides[client]; ok {
+		return limit
+	}
+	if limit, ok := overrides[role]; ok && role != "" {
+		return limit
+	}
+	return fallback
+}
+
+// EmbeddingLimitError returns an error if an embedding input is longer than limits allow
+func EmbeddingLimitError(limits models.GenerationLimits, input string) error {
+	limit := limits.MaxEmbeddingChars
+	if limit <= 0 {
+		return nil
+	}
+	if length := utf8.RuneCountInString(input); length > limit {
+		return fmt.Errorf("the input has %d characters, the limit is %d", length, limit)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimits(t *testing.T) {
+	cfg := config.LimitsConfig{
+		MaxTokens:                      1024,
+		MaxMessages:                    50,
+		MaxPromptChars:                 8000,
+		StreamTokensPerSecond:          40,
+		MaxTokensOverrides:             map[string]int{"readonly": 256, "user:alice": 0},
+		MaxMessagesOverrides:           map[string]int{"readonly": 10},
+		StreamTokensPerSecondOverrides: map[string]int{"user:alice": 100},
+	}
+
+	tests := []struct {
+		client string
+		role   string
+		limits models.GenerationLimits
+	}{
+		{"ip:1.2.3.4", "", models.GenerationLimits{MaxTokens: 1024, MaxMessages: 50, MaxPromptChars: 8000, StreamTokensPerSecond: 40}},
+		{"user:bob", "readonly", models.GenerationLimits{MaxTokens: 256, MaxMessages: 10, MaxPromptChars: 8000, StreamTokensPerSecond: 40}},
+		{"user:alice", "readonly", models.GenerationLimits{MaxTokens: 0, MaxMessages: 10, MaxPromptChars: 8000, StreamTokensPerSecond: 100}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.client, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				if tt.role != "" {
+					c.Set(roleKey, tt.role)
+				}
+			})
+			router.Use(Limits(cfg, func(*gin.Context) string { return tt.client }))
+
+			var limits models.GenerationLimits
+			router.GET("/limits", func(c *gin.Context) {
+				limits = c.MustGet(LimitsKey).(models.GenerationLimits)
+			})
+
+			req, _ := http.NewRequest("GET", "/limits", nil)
+			router.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tt.limits, limits)
+		})
+	}
+}
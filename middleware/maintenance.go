@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MaintenanceStatus describes the current maintenance window
+type MaintenanceStatus struct {
+	Enabled bool       `json:"enabled"`
+	Message string     `json:"message,omitempty"`
+	Until   *time.Time `json:"until,omitempty"` // Estimated end, if known
+}
+
+// MaintenanceMode is a switch that makes guarded routes answer 503 while models are being upgraded
+type MaintenanceMode struct {
+	mu     sync.RWMutex
+	status MaintenanceStatus
+}
+
+// NewMaintenanceMode creates a maintenance switch that starts disabled
+func NewMaintenanceMode() *MaintenanceMode {
+	return &MaintenanceMode{}
+}
+
+// Enable starts maintenance with a message for clients and an optional estimated end time
+func (m *MaintenanceMode) Enable(message string, until *time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = MaintenanceStatus{Enabled: true, Message: message, Until: until}
+}
+
+// Disable ends maintenance
+func (m *MaintenanceMode) Disable() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status = MaintenanceStatus{}
+}
+
+// Status returns the current maintenance window
+func (m *MaintenanceMode) Status() MaintenanceStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// Check refuses generations with 503 while maintenance is enabled, as an AdmissionCheck
+func (m *MaintenanceMode) Check(ctx context.Context) error {
+	status := m.Status()
+	if !status.Enabled {
+		return nil
+	}
+
+	refusal := &AdmissionError{Status: http.StatusServiceUnavailable, Message: "Service under maintenance", Details: status.Message}
+	if status.Until != nil {
+		refusal.RetryAfter = max(time.Until(*status.Until), time.Second)
+	}
+	return refusal
+}
+
+// Guard rejects requests with 503 while maintenance is enabled. Connections carrying several
+// generations are checked again before each, see Readmit.
+func (m *MaintenanceMode) Guard() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		status := m.Status()
+		if !status.Enabled {
+			passed(c, m.Check)
+			c.Next()
+			return
+		}
+
+		var fields gin.H
+		if status.Until != nil {
+			retryAfter := max(int(math.Ceil(time.Until(*status.Until).Seconds())), 1)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			fields = gin.H{
+				"maintenance_until":   status.Until.UTC().Format(time.RFC3339),
+				"retry_after_seconds": retryAfter,
+			}
+		}
+		AbortWithError(c, http.StatusServiceUnavailable, "Service under maintenance", status.Message, fields)
+	}
+}
@@ -0,0 +1,102 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupMaintenanceRouter(maintenance *MaintenanceMode) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/health", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.POST("/chat", maintenance.Guard(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestMaintenanceMode_Guard(t *testing.T) {
+	maintenance := NewMaintenanceMode()
+	router := setupMaintenanceRouter(maintenance)
+
+	req, _ := http.NewRequest("POST", "/chat", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	until := time.Now().Add(10 * time.Minute)
+	maintenance.Enable("Upgrading to llama3.3", &until)
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Equal(t, "600", w.Header().Get("Retry-After"))
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Upgrading to llama3.3", response["details"])
+	assert.Equal(t, until.UTC().Format(time.RFC3339), response["maintenance_until"])
+
+	// Unguarded routes keep working
+	healthReq, _ := http.NewRequest("GET", "/health", nil)
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, healthReq)
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	maintenance.Disable()
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestMaintenanceMode_WithoutEndTime(t *testing.T) {
+	maintenance := NewMaintenanceMode()
+	maintenance.Enable("Back soon", nil)
+	router := setupMaintenanceRouter(maintenance)
+
+	req, _ := http.NewRequest("POST", "/chat", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Empty(t, w.Header().Get("Retry-After"))
+}
+
+func TestAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/admin", AdminAuth("secret"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	tests := []struct {
+		name         string
+		header       string
+		expectedCode int
+	}{
+		{"valid token", "Bearer secret", http.StatusOK},
+		{"wrong token", "Bearer nope", http.StatusUnauthorized},
+		{"missing header", "", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/admin", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedCode, w.Code)
+		})
+	}
+}
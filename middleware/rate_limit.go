@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RateLimitResult describes the state of a client's budget after a request
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // Time until the budget is fully restored
+	RetryAfter time.Duration // Time until the next request would be allowed
+}
+
+// RateLimiter decides whether a request identified by key may proceed
+type RateLimiter interface {
+	Allow(ctx context.Context, key string) (RateLimitResult, error)
+}
+
+// RateLimit enforces limiter per client IP and emits X-RateLimit-* headers on every response.
+// If the limiter itself fails, the request is let through rather than rejected.
+func RateLimit(limiter RateLimiter) gin.HandlerFunc {
+	return RateLimitBy(limiter, func(c *gin.Context) string {
+		return c.ClientIP()
+	})
+}
+
+// RateLimitBy is RateLimit with clients identified by key, such as ClientKey. Connections
+// carrying several requests take one from the budget for each, see Readmit.
+func RateLimitBy(limiter RateLimiter, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := key(c)
+		result, err := limiter.Allow(c.Request.Context(), client)
+		if err != nil {
+			logger(c).Warn("Rate limiter unavailable, allowing request", "error", err)
+			passed(c, RateCheck(limiter, client))
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			retryAfter := ceilSeconds(result.RetryAfter)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			AbortWithError(c, http.StatusTooManyRequests, "Rate limit exceeded",
+				"Too many requests, retry after the indicated delay", gin.H{"retry_after_seconds": retryAfter})
+			return
+		}
+
+		passed(c, RateCheck(limiter, client))
+		c.Next()
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// TokenBucketLimiter is an in-memory RateLimiter with one token bucket per key.
+// Each bucket holds up to limit tokens and refills completely over window.
+type TokenBucketLimiter struct {
+	limit  int
+	window time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// NewTokenBucketLimiter creates a limiter allowing limit requests per window for each key
+func NewTokenBucketLimiter(limit int, window time.Duration) *TokenBucketLimiter {
+	return &TokenBucketLimiter{
+		limit:   limit,
+		window:  window,
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// Allow takes a token from key's bucket if one is available
+func (l *TokenBucketLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	refillPerSecond := float64(l.limit) / l.window.Seconds()
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(l.limit), updated: now}
+		l.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.updated).Seconds()
+		bucket.tokens = math.Min(float64(l.limit), bucket.tokens+elapsed*refillPerSecond)
+		bucket.updated = now
+	}
+
+	result := RateLimitResult{Limit: l.limit}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - bucket.tokens) / refillPerSecond)
+	}
+	result.Remaining = int(bucket.tokens)
+	result.Reset = secondsToDuration((float64(l.limit) - bucket.tokens) / refillPerSecond)
+
+	return result, nil
+}
+
+// sweep drops buckets that have been idle long enough to be full again
+func (l *TokenBucketLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.updated) >= l.window {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
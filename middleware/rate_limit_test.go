@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type failingLimiter struct{}
+
+func (failingLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("backend down")
+}
+
+func setupRateLimitRouter(limiter RateLimiter) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RateLimit(limiter))
+	router.GET("/ping", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"message": "pong"})
+	})
+	return router
+}
+
+func TestTokenBucketLimiter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewTokenBucketLimiter(2, 10*time.Second)
+	limiter.now = func() time.Time { return now }
+
+	result, _ := limiter.Allow(context.Background(), "1.2.3.4")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+
+	result, _ = limiter.Allow(context.Background(), "1.2.3.4")
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 10*time.Second, result.Reset)
+
+	result, _ = limiter.Allow(context.Background(), "1.2.3.4")
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 5*time.Second, result.RetryAfter)
+
+	// Other clients have their own bucket
+	result, _ = limiter.Allow(context.Background(), "5.6.7.8")
+	assert.True(t, result.Allowed)
+
+	// Tokens refill over time
+	now = now.Add(5 * time.Second)
+	result, _ = limiter.Allow(context.Background(), "1.2.3.4")
+	assert.True(t, result.Allowed)
+}
+
+func TestTokenBucketLimiter_SweepsIdleBuckets(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter := NewTokenBucketLimiter(2, 10*time.Second)
+	limiter.now = func() time.Time { return now }
+
+	limiter.Allow(context.Background(), "1.2.3.4")
+	now = now.Add(time.Minute)
+	limiter.Allow(context.Background(), "5.6.7.8")
+
+	assert.Len(t, limiter.buckets, 1)
+	assert.Contains(t, limiter.buckets, "5.6.7.8")
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	router := setupRateLimitRouter(NewTokenBucketLimiter(1, time.Minute))
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-RateLimit-Limit"))
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "60", w.Header().Get("X-RateLimit-Reset"))
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(60), response["retry_after_seconds"])
+}
+
+func TestRateLimit_FailsOpen(t *testing.T) {
+	router := setupRateLimitRouter(failingLimiter{})
+
+	req, _ := http.NewRequest("GET", "/ping", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
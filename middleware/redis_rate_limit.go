@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// gcraScript implements the generic cell rate algorithm. It stores one theoretical arrival time
+// (TAT) per key and uses the Redis clock so every replica shares the same view of time.
+// It returns {allowed, remaining, reset_ms, retry_after_ms}.
+var gcraScript = redis.NewScript(`
+local interval = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+local time = redis.call('TIME')
+local now = time[1] * 1000 + math.floor(time[2] / 1000)
+local tolerance = interval * limit
+
+local tat = tonumber(redis.call('GET', KEYS[1])) or now
+if tat < now then
+	tat = now
+end
+
+local new_tat = tat + interval
+local allow_at = new_tat - tolerance
+if now < allow_at then
+	return {0, math.floor((tolerance - (tat - now)) / interval), tat - now, allow_at - now}
+end
+
+redis.call('SET', KEYS[1], new_tat, 'PX', math.ceil(new_tat - now))
+return {1, math.floor((tolerance - (new_tat - now)) / interval), new_tat - now, 0}
+`)
+
+// RedisLimiter is a RateLimiter shared by every replica connected to the same Redis.
+// It allows limit requests per window for each key, with the same burst behaviour as TokenBucketLimiter.
+type RedisLimiter struct {
+	client redis.Scripter
+	limit  int
+	window time.Duration
+	prefix string
+}
+
+// NewRedisLimiter creates a limiter storing its state in client under "ratelimit:" keys
+func NewRedisLimiter(client redis.Scripter, limit int, window time.Duration) *RedisLimiter {
+	return &RedisLimiter{
+		client: client,
+		limit:  limit,
+		window: window,
+		prefix: "ratelimit:",
+	}
+}
+
+// Allow records a request for key and reports whether it is within the limit
+func (l *RedisLimiter) Allow(ctx context.Context, key string) (RateLimitResult, error) {
+	interval := float64(l.window.Milliseconds()) / float64(l.limit)
+
+	values, err := gcraScript.Run(ctx, l.client, []string{l.prefix + key}, interval, l.limit).Int64Slice()
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to evaluate rate limit: %w", err)
+	}
+	if len(values) != 4 {
+		return RateLimitResult{}, fmt.Errorf("unexpected rate limit reply: %v", values)
+	}
+
+	return RateLimitResult{
+		Allowed:    values[0] == 1,
+		Limit:      l.limit,
+		Remaining:  int(values[1]),
+		Reset:      time.Duration(values[2]) * time.Millisecond,
+		RetryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeScripter answers EVALSHA with a canned reply and records the call
+type fakeScripter struct {
+	redis.Scripter
+	reply []interface{}
+	err   error
+	keys  []string
+	args  []interface{}
+}
+
+func (f *fakeScripter) EvalSha(ctx context.Context, sha1 string, keys []string, args ...interface{}) *redis.Cmd {
+	f.keys, f.args = keys, args
+	cmd := redis.NewCmd(ctx)
+	if f.err != nil {
+		cmd.SetErr(f.err)
+	} else {
+		cmd.SetVal(f.reply)
+	}
+	return cmd
+}
+
+func TestRedisLimiter_Allowed(t *testing.T) {
+	client := &fakeScripter{reply: []interface{}{int64(1), int64(4), int64(12000), int64(0)}}
+	limiter := NewRedisLimiter(client, 5, time.Minute)
+
+	result, err := limiter.Allow(context.Background(), "1.2.3.4")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ratelimit:1.2.3.4"}, client.keys)
+	assert.Equal(t, []interface{}{float64(12000), 5}, client.args)
+	assert.Equal(t, RateLimitResult{
+		Allowed:   true,
+		Limit:     5,
+		Remaining: 4,
+		Reset:     12 * time.Second,
+	}, result)
+}
+
+func TestRedisLimiter_Denied(t *testing.T) {
+	client := &fakeScripter{reply: []interface{}{int64(0), int64(0), int64(60000), int64(12000)}}
+	limiter := NewRedisLimiter(client, 5, time.Minute)
+
+	result, err := limiter.Allow(context.Background(), "1.2.3.4")
+
+	assert.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 12*time.Second, result.RetryAfter)
+}
+
+func TestRedisLimiter_Error(t *testing.T) {
+	client := &fakeScripter{err: errors.New("connection refused")}
+	limiter := NewRedisLimiter(client, 5, time.Minute)
+
+	_, err := limiter.Allow(context.Background(), "1.2.3.4")
+
+	assert.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxRequestIDLength bounds the X-Request-ID accepted from callers
+const maxRequestIDLength = 128
+
+// RequestID propagates the caller's X-Request-ID header, or assigns a new ID, and echoes it on the
+// response. The ID is attached to the request context with tag, unless tag is nil, so services can
+// log it and forward it upstream. AbortWithError puts it in the error envelope; JSON error
+// responses in other formats, such as the Messages API's, get it as a request_id field.
+func RequestID(tag ContextTagger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-ID")
+		if !validRequestID(requestID) {
+			requestID = newRequestID()
+		}
+		c.Set(RequestIDKey, requestID)
+		c.Header("X-Request-ID", requestID)
+		if tag != nil {
+			c.Request = c.Request.WithContext(tag(c.Request.Context(), requestID))
+		}
+		c.Writer = &requestIDWriter{ResponseWriter: c.Writer, requestID: requestID}
+		c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// logger returns the default logger, with the ID RequestID assigned to the request as the
+// request_id field
+func logger(c *gin.Context) *slog.Logger {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return slog.With("request_id", id)
+	}
+	return slog.Default()
+}
+
+// validRequestID accepts caller IDs of printable ASCII without spaces, so they are safe to log and
+// forward as a header
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		if r <= ' ' || r > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDWriter adds the request ID to JSON error bodies. gin renders a JSON body in a single
+// write, so each write of an error response is a complete document.
+type requestIDWriter struct {
+	gin.ResponseWriter
+	requestID string
+}
+
+func (w *requestIDWriter) Write(data []byte) (int, error) {
+	if w.Status() < http.StatusBadRequest || !strings.HasPrefix(w.Header().Get("Content-Type"), "application/json") {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.Write(withRequestID(data, w.requestID)); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+// withRequestID adds a request_id field to a JSON object, leaving anything else unchanged
+func withRequestID(data []byte, requestID string) []byte {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		return data
+	}
+	if _, ok := fields["request_id"]; ok {
+		return data
+	}
+
+	id, _ := json.Marshal(requestID)
+	body := bytes.TrimRight(data, " \t\r\n")
+	field := append([]byte(`"request_id":`), id...)
+	if len(fields) > 0 {
+		field = append([]byte(","), field...)
+	}
+
+	out := make([]byte, 0, len(body)+len(field))
+	out = append(out, body[:len(body)-1]...)
+	out = append(out, field...)
+	return append(out, '}')
+}
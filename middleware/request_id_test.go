@@ -0,0 +1,81 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type requestIDContextKey struct{}
+
+func setupRequestIDRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestID(func(ctx context.Context, id string) context.Context {
+		return context.WithValue(ctx, requestIDContextKey{}, id)
+	}))
+	router.GET("/context", func(c *gin.Context) {
+		c.String(http.StatusOK, c.Request.Context().Value(requestIDContextKey{}).(string))
+	})
+	router.GET("/ok", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	})
+	router.GET("/fail/empty", func(c *gin.Context) {
+		c.AbortWithStatusJSON(http.StatusNotFound, gin.H{})
+	})
+	router.GET("/fail/own", func(c *gin.Context) {
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Upstream failed", "request_id": "upstream-1"})
+	})
+	return router
+}
+
+func requestIDRequest(router *gin.Engine, path, id string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("GET", path, nil)
+	if id != "" {
+		req.Header.Set("X-Request-ID", id)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRequestID_TagsContext(t *testing.T) {
+	w := requestIDRequest(setupRequestIDRouter(), "/context", "abc-123")
+	assert.Equal(t, "abc-123", w.Body.String())
+}
+
+func TestRequestID_ReplacesInvalidIDs(t *testing.T) {
+	router := setupRequestIDRouter()
+
+	for _, id := range []string{"has space", strings.Repeat("a", 129), "café"} {
+		w := requestIDRequest(router, "/context", id)
+		assert.Len(t, w.Header().Get("X-Request-ID"), 16, id)
+		assert.Equal(t, w.Header().Get("X-Request-ID"), w.Body.String())
+	}
+}
+
+func TestRequestID_ErrorBodies(t *testing.T) {
+	router := setupRequestIDRouter()
+
+	tests := []struct {
+		path string
+		body string
+	}{
+		{"/ok", `{"status":"ok"}`},
+		{"/fail", `{"error":"Invalid request format","request_id":"abc-123"}`},
+		{"/fail/empty", `{"request_id":"abc-123"}`},
+		{"/fail/own", `{"error":"Upstream failed","request_id":"upstream-1"}`},
+	}
+	for _, tt := range tests {
+		w := requestIDRequest(router, tt.path, "abc-123")
+		assert.JSONEq(t, tt.body, w.Body.String(), tt.path)
+	}
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PriorityTagger sets the priority class of the request of ctx
+type PriorityTagger func(ctx context.Context, priority string) context.Context
+
+// RequestPriority lets clients choose the priority class of their request with the
+// X-Request-Priority header, one of classes. Requests without the header keep the default of
+// their endpoint.
+func RequestPriority(classes []string, tag PriorityTagger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		priority := c.GetHeader("X-Request-Priority")
+		if priority == "" {
+			c.Next()
+			return
+		}
+
+		if !slices.Contains(classes, priority) {
+			AbortWithError(c, http.StatusBadRequest, "Invalid X-Request-Priority",
+				fmt.Sprintf("priority %q is not one of %s", priority, strings.Join(classes, ", ")), nil)
+			return
+		}
+
+		c.Request = c.Request.WithContext(tag(c.Request.Context(), priority))
+		c.Next()
+	}
+}
@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type priorityKey struct{}
+
+func TestRequestPriority(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestPriority([]string{"interactive", "normal", "background"}, func(ctx context.Context, priority string) context.Context {
+		return context.WithValue(ctx, priorityKey{}, priority)
+	}))
+	router.GET("/priority", func(c *gin.Context) {
+		priority, ok := c.Request.Context().Value(priorityKey{}).(string)
+		if !ok {
+			priority = "none"
+		}
+		c.String(http.StatusOK, priority)
+	})
+
+	tests := []struct {
+		header   string
+		status   int
+		priority string
+	}{
+		{"", http.StatusOK, "none"},
+		{"background", http.StatusOK, "background"},
+		{"interactive", http.StatusOK, "interactive"},
+		{"urgent", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/priority", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-Priority", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.priority, w.Body.String())
+			}
+		})
+	}
+}
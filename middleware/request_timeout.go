@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BudgetTagger marks the deadline of ctx as a time budget set by the client
+type BudgetTagger func(ctx context.Context, budget time.Duration) context.Context
+
+// RequestTimeout lets clients set a time budget for their whole request with the X-Request-Timeout
+// header, in seconds ("2.5") or as a duration ("1500ms"). The budget is capped at max and becomes the
+// deadline of the request's context; requests without the header keep the server's own timeouts.
+func RequestTimeout(max time.Duration, tag BudgetTagger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value := c.GetHeader("X-Request-Timeout")
+		if value == "" {
+			c.Next()
+			return
+		}
+
+		budget, ok := parseBudget(value)
+		if !ok {
+			AbortWithError(c, http.StatusBadRequest, "Invalid X-Request-Timeout",
+				"expected a positive number of seconds or a duration such as 1500ms", nil)
+			return
+		}
+		budget = min(budget, max)
+
+		ctx, cancel := context.WithTimeout(c.Request.Context(), budget)
+		defer cancel()
+		if tag != nil {
+			ctx = tag(ctx, budget)
+		}
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+	}
+}
+
+// parseBudget reads a budget given in seconds or as a Go duration
+func parseBudget(value string) (time.Duration, bool) {
+	budget, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, false
+		}
+		budget = time.Duration(seconds * float64(time.Second))
+	}
+	return budget, budget > 0
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type budgetKey struct{}
+
+func TestRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(RequestTimeout(10*time.Second, func(ctx context.Context, budget time.Duration) context.Context {
+		return context.WithValue(ctx, budgetKey{}, budget)
+	}))
+	router.GET("/budget", func(c *gin.Context) {
+		ctx := c.Request.Context()
+		if _, ok := ctx.Deadline(); !ok {
+			c.String(http.StatusOK, "none")
+			return
+		}
+		c.String(http.StatusOK, ctx.Value(budgetKey{}).(time.Duration).String())
+	})
+
+	tests := []struct {
+		header string
+		status int
+		budget string
+	}{
+		{"", http.StatusOK, "none"},
+		{"2.5", http.StatusOK, "2.5s"},
+		{"1500ms", http.StatusOK, "1.5s"},
+		{"3600", http.StatusOK, "10s"},
+		{"0", http.StatusBadRequest, ""},
+		{"-1s", http.StatusBadRequest, ""},
+		{"soon", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", "/budget", nil)
+			if tt.header != "" {
+				req.Header.Set("X-Request-Timeout", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.budget, w.Body.String())
+			}
+		})
+	}
+}
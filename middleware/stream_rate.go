@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamPacerKey holds the *StreamPacer set by StreamRate
+const StreamPacerKey = "stream_pacer"
+
+// outputRateIdle is how long a client's output bucket is kept after its last stream
+const outputRateIdle = time.Minute
+
+// OutputRate is an in-memory token bucket per client for streamed output. A client's streams
+// share its bucket, so opening more streams does not raise its output rate.
+type OutputRate struct {
+	now func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+// NewOutputRate creates an output rate limiter with empty buckets
+func NewOutputRate() *OutputRate {
+	return &OutputRate{
+		now:     time.Now,
+		buckets: map[string]*tokenBucket{},
+	}
+}
+
+// Reserve takes tokens from key's bucket and returns how long to wait before sending them. The
+// bucket refills at rate tokens per second and holds at most one second of them, so short bursts
+// go out immediately and sustained output is held to rate.
+func (o *OutputRate) Reserve(key string, rate, tokens int) time.Duration {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	now := o.now()
+	o.sweep(now)
+
+	bucket, ok := o.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(rate), updated: now}
+		o.buckets[key] = bucket
+	} else {
+		elapsed := now.Sub(bucket.updated).Seconds()
+		bucket.tokens = math.Min(float64(rate), bucket.tokens+elapsed*float64(rate))
+		bucket.updated = now
+	}
+
+	// The bucket goes into debt, which later reservations of the same client wait for as well
+	bucket.tokens -= float64(tokens)
+	if bucket.tokens >= 0 {
+		return 0
+	}
+	return secondsToDuration(-bucket.tokens / float64(rate))
+}
+
+func (o *OutputRate) sweep(now time.Time) {
+	if now.Sub(o.lastSweep) < outputRateIdle {
+		return
+	}
+	o.lastSweep = now
+
+	for key, bucket := range o.buckets {
+		if now.Sub(bucket.updated) >= outputRateIdle {
+			delete(o.buckets, key)
+		}
+	}
+}
+
+// StreamPacer holds one stream to its client's output rate and measures the rate it achieved.
+// A nil *StreamPacer does not pace.
+type StreamPacer struct {
+	rate    *OutputRate
+	key     string
+	limit   int
+	started time.Time
+	tokens  int
+}
+
+// Wait blocks until tokens more may be sent, or ctx ends
+func (p *StreamPacer) Wait(ctx context.Context, tokens int) error {
+	if p == nil {
+		return nil
+	}
+	p.tokens += tokens
+
+	delay := p.rate.Reserve(p.key, p.limit, tokens)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Report describes the ceiling and the effective output rate of the stream so far
+func (p *StreamPacer) Report() models.StreamRate {
+	elapsed := time.Since(p.started)
+	report := models.StreamRate{
+		LimitTokensPerSecond: p.limit,
+		Tokens:               p.tokens,
+		ElapsedMs:            elapsed.Milliseconds(),
+	}
+	if elapsed > 0 {
+		report.TokensPerSecond = math.Round(float64(p.tokens)/elapsed.Seconds()*100) / 100
+	}
+	return report
+}
+
+// StreamRate paces streamed output at the stream_tokens_per_second limit chosen by Limits for
+// the client identified by key, and announces the ceiling in X-Stream-Rate-Limit. It must run
+// after Limits; streams without a limit are not paced.
+func StreamRate(rate *OutputRate, key func(c *gin.Context) string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		value, _ := c.Get(LimitsKey)
+		limits, _ := value.(models.GenerationLimits)
+		if limit := limits.StreamTokensPerSecond; limit > 0 {
+			c.Header("X-Stream-Rate-Limit", strconv.Itoa(limit))
+			c.Set(StreamPacerKey, &StreamPacer{rate: rate, key: key(c), limit: limit, started: time.Now()})
+		}
+		c.Next()
+	}
+}
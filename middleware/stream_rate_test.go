@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputRate_Reserve(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	rate := NewOutputRate()
+	rate.now = func() time.Time { return now }
+
+	// A second of output goes out immediately
+	assert.Zero(t, rate.Reserve("user:alice", 10, 10))
+
+	// Further output waits for the bucket to refill, including other streams of the same client
+	assert.Equal(t, 100*time.Millisecond, rate.Reserve("user:alice", 10, 1))
+	assert.Equal(t, 200*time.Millisecond, rate.Reserve("user:alice", 10, 1))
+	assert.Zero(t, rate.Reserve("user:bob", 10, 1))
+
+	now = now.Add(time.Second)
+	assert.Zero(t, rate.Reserve("user:alice", 10, 1))
+}
+
+func TestStreamPacer_Wait(t *testing.T) {
+	pacer := &StreamPacer{rate: NewOutputRate(), key: "user:alice", limit: 50, started: time.Now()}
+
+	start := time.Now()
+	for range 55 {
+		assert.NoError(t, pacer.Wait(context.Background(), 1))
+	}
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, 55, pacer.Report().Tokens)
+	assert.Equal(t, 50, pacer.Report().LimitTokensPerSecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, pacer.Wait(ctx, 100), context.Canceled)
+
+	// Streams without a limit are not paced
+	var unpaced *StreamPacer
+	assert.NoError(t, unpaced.Wait(context.Background(), 1000))
+}
+
+func TestStreamRate(t *testing.T) {
+	cfg := config.LimitsConfig{StreamTokensPerSecondOverrides: map[string]int{"user:alice": 20}}
+
+	for client, limit := range map[string]string{"user:alice": "20", "user:bob": ""} {
+		gin.SetMode(gin.TestMode)
+		router := gin.New()
+		key := func(*gin.Context) string { return client }
+		router.Use(Limits(cfg, key), StreamRate(NewOutputRate(), key))
+
+		var paced bool
+		router.GET("/stream", func(c *gin.Context) {
+			_, paced = c.Get(StreamPacerKey)
+		})
+
+		req, _ := http.NewRequest("GET", "/stream", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, limit, w.Header().Get("X-Stream-Rate-Limit"), client)
+		assert.Equal(t, limit != "", paced, client)
+	}
+}
@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// StreamSchemaKey holds the event schema version chosen by StreamSchema
+const StreamSchemaKey = "stream_schema"
+
+// Streaming configures compression for a streaming route. Unless compression is enabled, the
+// response is marked with an identity Content-Encoding, which nginx and most CDNs take as a
+// signal not to compress, and therefore not to buffer, the stream.
+func Streaming(compression bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !compression {
+			c.Header("Content-Encoding", "identity")
+		}
+		c.Next()
+	}
+}
+
+// StreamSchema picks the event schema version of a streaming response from the schema query
+// parameter or the X-Stream-Schema header, in that order, falling back to fallback. The chosen
+// version is echoed in X-Stream-Schema; versions not in supported are rejected with 400.
+func StreamSchema(fallback string, supported []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := c.Query("schema")
+		if version == "" {
+			version = c.GetHeader("X-Stream-Schema")
+		}
+		if version == "" {
+			version = fallback
+		}
+		if !slices.Contains(supported, version) {
+			AbortWithError(c, http.StatusBadRequest, "Unsupported stream schema",
+				fmt.Sprintf("schema %q is not one of %s", version, strings.Join(supported, ", ")), nil)
+			return
+		}
+
+		c.Set(StreamSchemaKey, version)
+		c.Header("X-Stream-Schema", version)
+		c.Next()
+	}
+}
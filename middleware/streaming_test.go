@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStreaming(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name             string
+		compression      bool
+		expectedEncoding string
+	}{
+		{"compression disabled", false, "identity"},
+		{"compression enabled", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/stream", Streaming(tt.compression), func(c *gin.Context) {
+				c.SSEvent("message", "hello")
+			})
+
+			req, _ := http.NewRequest("POST", "/stream", nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedEncoding, w.Header().Get("Content-Encoding"))
+		})
+	}
+}
+
+func TestStreamSchema(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/stream", StreamSchema("v1", []string{"v1", "v2"}), func(c *gin.Context) {
+		c.String(http.StatusOK, c.GetString(StreamSchemaKey))
+	})
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		status int
+		schema string
+	}{
+		{"default", "", "", http.StatusOK, "v1"},
+		{"query", "?schema=v2", "", http.StatusOK, "v2"},
+		{"header", "", "v2", http.StatusOK, "v2"},
+		{"query wins over header", "?schema=v1", "v2", http.StatusOK, "v1"},
+		{"unsupported", "?schema=v9", "", http.StatusBadRequest, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/stream"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("X-Stream-Schema", tt.header)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.status, w.Code)
+			assert.Equal(t, tt.schema, w.Header().Get("X-Stream-Schema"))
+			if tt.status == http.StatusOK {
+				assert.Equal(t, tt.schema, w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenQuota counts the tokens each client consumed today, in UTC
+type TokenQuota interface {
+	Used(ctx context.Context, key string) (int, error)
+	Add(ctx context.Context, key string, tokens int) error
+}
+
+// TokenRecorder attaches a callback to ctx that generations report their token usage to
+type TokenRecorder func(ctx context.Context, record func(tokens int)) context.Context
+
+// ClientKey identifies the client a request is counted against: the subject of a valid access
+// token signed with secret, or else the client IP. Invalid tokens fall back to the IP so they
+// cannot be used to get a fresh budget.
+func ClientKey(secret string) func(c *gin.Context) string {
+	return func(c *gin.Context) string {
+		if secret != "" {
+			if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+				if claims, err := parseToken(secret, token); err == nil && claims.Subject != "" {
+					return "user:" + claims.Subject
+				}
+			}
+		}
+		return "ip:" + c.ClientIP()
+	}
+}
+
+// Quota enforces a daily budget of limit tokens per client, as identified by key, and emits
+// X-RateLimit-*-Tokens headers on every response. Requests are refused once the budget is spent;
+// the request that crosses it still completes. Tokens are counted through recorder as generations
+// finish. If the quota store fails, the request is let through rather than rejected. Connections
+// carrying several generations are checked again before each, see Readmit.
+func Quota(quota TokenQuota, limit int, key func(c *gin.Context) string, recorder TokenRecorder) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		client := key(c)
+		used, err := quota.Used(c.Request.Context(), client)
+		if err != nil {
+			logger(c).Warn("Token quota unavailable, allowing request", "error", err)
+			passed(c, QuotaCheck(quota, limit, client))
+			c.Next()
+			return
+		}
+
+		reset := untilMidnightUTC(time.Now())
+		c.Header("X-RateLimit-Limit-Tokens", strconv.Itoa(limit))
+		c.Header("X-RateLimit-Remaining-Tokens", strconv.Itoa(max(limit-used, 0)))
+		c.Header("X-RateLimit-Reset-Tokens", strconv.Itoa(ceilSeconds(reset)))
+
+		if used >= limit {
+			retryAfter := ceilSeconds(reset)
+			c.Header("Retry-After", strconv.Itoa(retryAfter))
+			AbortWithError(c, http.StatusTooManyRequests, "Token quota exceeded",
+				fmt.Sprintf("The daily budget of %d tokens is spent, it resets at midnight UTC", limit),
+				gin.H{"retry_after_seconds": retryAfter})
+			return
+		}
+
+		// Count the tokens against the client once the generation reports them. Streaming
+		// responses are already sent by then, so the headers cannot reflect this request.
+		// The callback may run after the handler returned, so it does not touch c.
+		storeCtx, log := context.WithoutCancel(c.Request.Context()), logger(c)
+		ctx := recorder(c.Request.Context(), func(tokens int) {
+			if err := quota.Add(storeCtx, client, tokens); err != nil {
+				log.Error("Failed to record tokens", "tokens", tokens, "client", client, "error", err)
+			}
+		})
+		c.Request = c.Request.WithContext(ctx)
+		passed(c, QuotaCheck(quota, limit, client))
+		c.Next()
+	}
+}
+
+func untilMidnightUTC(now time.Time) time.Duration {
+	now = now.UTC()
+	midnight := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+	return midnight.Sub(now)
+}
+
+// MemoryQuota is an in-memory TokenQuota, counting per replica
+type MemoryQuota struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	day  string
+	used map[string]int
+}
+
+func NewMemoryQuota() *MemoryQuota {
+	return &MemoryQuota{now: time.Now, used: map[string]int{}}
+}
+
+// Used returns the tokens key consumed today
+func (q *MemoryQuota) Used(ctx context.Context, key string) (int, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollOver()
+	return q.used[key], nil
+}
+
+// Add counts tokens against key for today
+func (q *MemoryQuota) Add(ctx context.Context, key string, tokens int) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollOver()
+	q.used[key] += tokens
+	return nil
+}
+
+// rollOver forgets the previous day's usage once the day changes
+func (q *MemoryQuota) rollOver() {
+	if today := q.now().UTC().Format(time.DateOnly); today != q.day {
+		q.day = today
+		q.used = map[string]int{}
+	}
+}
+
+// quotaAddScript adds to a day's counter and lets it expire a day after the day ended
+var quotaAddScript = redis.NewScript(`
+local used = redis.call('INCRBY', KEYS[1], ARGV[1])
+redis.call('EXPIRE', KEYS[1], ARGV[2])
+return used
+`)
+
+// quotaUsedScript reads a day's counter, 0 when unset
+var quotaUsedScript = redis.NewScript(`
+return tonumber(redis.call('GET', KEYS[1])) or 0
+`)
+
+// RedisQuota is a TokenQuota shared by every replica connected to the same Redis
+type RedisQuota struct {
+	client redis.Scripter
+	prefix string
+	now    func() time.Time
+}
+
+// NewRedisQuota creates a quota storing daily counters in client under "quota:" keys
+func NewRedisQuota(client redis.Scripter) *RedisQuota {
+	return &RedisQuota{client: client, prefix: "quota:", now: time.Now}
+}
+
+func (q *RedisQuota) dayKey(key string) string {
+	return q.prefix + key + ":" + q.now().UTC().Format(time.DateOnly)
+}
+
+// Used returns the tokens key consumed today
+func (q *RedisQuota) Used(ctx context.Context, key string) (int, error) {
+	used, err := quotaUsedScript.Run(ctx, q.client, []string{q.dayKey(key)}).Int()
+	if err != nil {
+		return 0, fmt.Errorf("failed to read token quota: %w", err)
+	}
+	return used, nil
+}
+
+// Add counts tokens against key for today
+func (q *RedisQuota) Add(ctx context.Context, key string, tokens int) error {
+	ttl := untilMidnightUTC(q.now()) + 24*time.Hour
+	if err := quotaAddScript.Run(ctx, q.client, []string{q.dayKey(key)}, tokens, ceilSeconds(ttl)).Err(); err != nil {
+		return fmt.Errorf("failed to record token usage: %w", err)
+	}
+	return nil
+}
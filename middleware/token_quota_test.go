@@ -0,0 +1,144 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type recorderKey struct{}
+
+// testRecorder stands in for the service's token recorder
+func testRecorder(ctx context.Context, record func(tokens int)) context.Context {
+	return context.WithValue(ctx, recorderKey{}, record)
+}
+
+type failingQuota struct{}
+
+func (failingQuota) Used(ctx context.Context, key string) (int, error) {
+	return 0, errors.New("backend down")
+}
+
+func (failingQuota) Add(ctx context.Context, key string, tokens int) error {
+	return errors.New("backend down")
+}
+
+func setupQuotaRouter(quota TokenQuota, limit int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(Quota(quota, limit, ClientKey(testSecret), testRecorder))
+	router.POST("/chat", func(c *gin.Context) {
+		// A generation consuming 60 tokens
+		if record, ok := c.Request.Context().Value(recorderKey{}).(func(int)); ok {
+			record(60)
+		}
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func quotaRequest(router *gin.Engine, token string) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/chat", nil)
+	req.RemoteAddr = "1.2.3.4:5678"
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestQuota_EnforcesDailyBudget(t *testing.T) {
+	router := setupQuotaRouter(NewMemoryQuota(), 100)
+
+	w := quotaRequest(router, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "100", w.Header().Get("X-RateLimit-Limit-Tokens"))
+	assert.Equal(t, "100", w.Header().Get("X-RateLimit-Remaining-Tokens"))
+	assert.NotEmpty(t, w.Header().Get("X-RateLimit-Reset-Tokens"))
+
+	// The request crossing the budget still completes
+	w = quotaRequest(router, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "40", w.Header().Get("X-RateLimit-Remaining-Tokens"))
+
+	w = quotaRequest(router, "")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "0", w.Header().Get("X-RateLimit-Remaining-Tokens"))
+	assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+	// A logged-in user has a budget of their own, even from the same IP
+	token, _ := IssueToken(testSecret, "alice", RoleUser, time.Hour)
+	assert.Equal(t, http.StatusOK, quotaRequest(router, token).Code)
+}
+
+func TestQuota_FailsOpen(t *testing.T) {
+	router := setupQuotaRouter(failingQuota{}, 100)
+
+	w := quotaRequest(router, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("X-RateLimit-Remaining-Tokens"))
+}
+
+func TestClientKey(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	valid, _ := IssueToken(testSecret, "alice", RoleUser, time.Hour)
+	forged, _ := IssueToken("other-secret", "mallory", RoleUser, time.Hour)
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		key    string
+	}{
+		{"no token", testSecret, "", "ip:1.2.3.4"},
+		{"valid token", testSecret, valid, "user:alice"},
+		{"forged token", testSecret, forged, "ip:1.2.3.4"},
+		{"tokens disabled", "", valid, "ip:1.2.3.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request, _ = http.NewRequest("GET", "/", nil)
+			c.Request.RemoteAddr = "1.2.3.4:5678"
+			if tt.token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			assert.Equal(t, tt.key, ClientKey(tt.secret)(c))
+		})
+	}
+}
+
+func TestMemoryQuota_ResetsDaily(t *testing.T) {
+	now := time.Date(2025, 1, 1, 23, 59, 0, 0, time.UTC)
+	quota := NewMemoryQuota()
+	quota.now = func() time.Time { return now }
+
+	assert.NoError(t, quota.Add(context.Background(), "ip:1.2.3.4", 500))
+	used, _ := quota.Used(context.Background(), "ip:1.2.3.4")
+	assert.Equal(t, 500, used)
+
+	now = now.Add(2 * time.Minute)
+	used, _ = quota.Used(context.Background(), "ip:1.2.3.4")
+	assert.Zero(t, used)
+}
+
+func TestRedisQuota(t *testing.T) {
+	client := &fakeScripter{}
+	quota := NewRedisQuota(client)
+	quota.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+	assert.NoError(t, quota.Add(context.Background(), "user:alice", 42))
+	assert.Equal(t, []string{"quota:user:alice:2025-01-01"}, client.keys)
+	assert.Equal(t, []interface{}{42, 36 * 60 * 60}, client.args)
+
+	client.err = errors.New("connection refused")
+	_, err := quota.Used(context.Background(), "user:alice")
+	assert.Error(t, err)
+}
@@ -0,0 +1,15 @@
+package models
+
+// LoginRequest holds the credentials exchanged for an access token
+type LoginRequest struct {
+	Username string `json:"username" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// LoginResponse carries an access token to send as "Authorization: Bearer <token>"
+type LoginResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"` // Always "Bearer"
+	ExpiresIn   int    `json:"expires_in"` // Seconds
+	Role        string `json:"role"`
+}
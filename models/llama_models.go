@@ -1,6 +1,9 @@
 package models
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Message represents a chat message
 type Message struct {
@@ -8,6 +11,22 @@ type Message struct {
 	Content string `json:"content" binding:"required"`
 }
 
+// Options represents generation options passed through to Ollama's options object
+type Options struct {
+	Temperature      *float64 `json:"temperature,omitempty"`
+	TopP             *float64 `json:"top_p,omitempty"`
+	TopK             *int     `json:"top_k,omitempty"`
+	MinP             *float64 `json:"min_p,omitempty"`
+	Seed             *int     `json:"seed,omitempty"`
+	Stop             []string `json:"stop,omitempty"`
+	RepeatPenalty    *float64 `json:"repeat_penalty,omitempty"`
+	RepeatLastN      *int     `json:"repeat_last_n,omitempty"`
+	PresencePenalty  *float64 `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64 `json:"frequency_penalty,omitempty"`
+	NumCtx           *int     `json:"num_ctx,omitempty"`
+	NumPredict       *int     `json:"num_predict,omitempty"`
+}
+
 // ChatRequest represents a chat completion request
 type ChatRequest struct {
 	Messages    []Message `json:"messages" binding:"required"`
@@ -15,23 +34,168 @@ type ChatRequest struct {
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
 	Stream      bool      `json:"stream,omitempty"`
+	Options     *Options  `json:"options,omitempty"`
+	Preset      string    `json:"preset,omitempty"`                              // Name of a system prompt preset to start the conversation with
+	Logprobs    bool      `json:"logprobs,omitempty"`                            // Return the log probability of each generated token
+	TopLogprobs int       `json:"top_logprobs,omitempty" binding:"min=0,max=20"` // Most likely alternatives returned per token, implies logprobs
+	// "json" or a JSON schema the answer must match; invalid answers are sent back to the model for repair
+	Format json.RawMessage `json:"format,omitempty"`
+	// Flags raised by moderating the prompt, carried over to the response
+	Moderation []ModerationFlag `json:"-"`
+}
+
+// GenerationLimits are the server-enforced caps applying to a request, 0 meaning unlimited
+type GenerationLimits struct {
+	MaxTokens             int
+	MaxMessages           int
+	MaxPromptChars        int
+	MaxEmbeddingChars     int
+	StreamTokensPerSecond int
+}
+
+// ClampedLimit reports a request value lowered to a server-enforced limit
+type ClampedLimit struct {
+	Name      string `json:"name"`                // e.g. "max_tokens"
+	Requested int    `json:"requested,omitempty"` // Left out when the request did not set a value
+	Limit     int    `json:"limit"`
 }
 
 // ChatResponse represents a chat completion response
 type ChatResponse struct {
-	ID      string   `json:"id"`
-	Object  string   `json:"object"`
-	Created int64    `json:"created"`
-	Model   string   `json:"model"`
-	Choices []Choice `json:"choices"`
-	Usage   Usage    `json:"usage"`
+	ID              string   `json:"id"`
+	Object          string   `json:"object"`
+	Created         int64    `json:"created"`
+	Model           string   `json:"model"`
+	Choices         []Choice `json:"choices"`
+	Usage           Usage    `json:"usage"`
+	Backend         string   `json:"backend,omitempty"`          // "local" or "cloud"
+	TrimmedMessages int      `json:"trimmed_messages,omitempty"` // Oldest messages dropped to fit the context window
+	// Models of the endpoint's fallback chain that failed before Model answered
+	FallbackAttempts []FallbackAttempt `json:"fallback_attempts,omitempty"`
+	// Times the answer was sent back to the model because it did not match the requested format
+	RepairAttempts int `json:"repair_attempts,omitempty"`
+	// Set when the answer came from a generation shared with identical requests in flight
+	Coalesced bool `json:"coalesced,omitempty"`
+	// Request values lowered to the limits of the client
+	Clamped []ClampedLimit `json:"clamped,omitempty"`
+	// Set when the answer was reused from the semantic cache, with the prompts' cosine similarity
+	CacheHit        bool    `json:"cache_hit,omitempty"`
+	CacheSimilarity float64 `json:"cache_similarity,omitempty"`
+	// Content the moderation policy flagged and let through
+	Moderation []ModerationFlag `json:"moderation,omitempty"`
+}
+
+// ModerationFlag reports a moderation policy category matched by a prompt or a reply
+type ModerationFlag struct {
+	Stage    string `json:"stage"` // "prompt" or "completion"
+	Category string `json:"category"`
+	Action   string `json:"action"` // "redact" or "annotate"
+}
+
+// Streaming event schema versions
+const (
+	StreamSchemaV1 = "v1" // "message" events carrying the raw text of each chunk
+	StreamSchemaV2 = "v2" // "message.v2" events carrying OpenAI-style chat.completion.chunk deltas
+)
+
+// StreamSchemas lists the supported streaming event schema versions
+var StreamSchemas = []string{StreamSchemaV1, StreamSchemaV2}
+
+// Request priority classes. Requests waiting for a generation slot are served by class, then in
+// arrival order.
+const (
+	PriorityInteractive = "interactive"
+	PriorityNormal      = "normal"
+	PriorityBackground  = "background"
+)
+
+// Priorities lists the request priority classes, highest first
+var Priorities = []string{PriorityInteractive, PriorityNormal, PriorityBackground}
+
+// StreamRate reports the output rate ceiling of a paced stream and the rate it achieved
+type StreamRate struct {
+	LimitTokensPerSecond int     `json:"limit_tokens_per_second"`
+	Tokens               int     `json:"tokens"` // Chunks sent, about one token each
+	ElapsedMs            int64   `json:"elapsed_ms"`
+	TokensPerSecond      float64 `json:"tokens_per_second"` // Effective rate
+}
+
+// ChatCompletionChunk is one streamed delta of a chat completion in the v2 event schema
+type ChatCompletionChunk struct {
+	ID      string        `json:"id"`
+	Object  string        `json:"object"` // "chat.completion.chunk"
+	Created int64         `json:"created"`
+	Model   string        `json:"model,omitempty"`
+	Choices []ChunkChoice `json:"choices"`
+}
+
+// ChunkChoice carries the text added by a chunk. FinishReason is set on the last chunk only.
+type ChunkChoice struct {
+	Index        int        `json:"index"`
+	Delta        ChunkDelta `json:"delta"`
+	FinishReason *string    `json:"finish_reason"`
+}
+
+// ChunkDelta is the part of the assistant message added by a chunk; the role is sent once, first
+type ChunkDelta struct {
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content,omitempty"`
+}
+
+// WebSocket chat message and event types
+const (
+	ChatSocketMessage = "message" // Client: add a user message and generate a reply
+	ChatSocketStop    = "stop"    // Client: cancel the reply being generated
+	ChatSocketDelta   = "delta"   // Server: text added to the reply
+	ChatSocketDone    = "done"    // Server: the reply is complete or was stopped
+	ChatSocketError   = "error"   // Server: a message was rejected or generation failed
+)
+
+// ChatSocketRequest is a message sent by the client of a WebSocket chat. Model, options and max
+// tokens apply to the reply to this message only.
+type ChatSocketRequest struct {
+	Type      string   `json:"type"`
+	Content   string   `json:"content,omitempty"`
+	Model     string   `json:"model,omitempty"`
+	MaxTokens int      `json:"max_tokens,omitempty"`
+	Options   *Options `json:"options,omitempty"`
+}
+
+// ChatSocketEvent is a message sent by the server of a WebSocket chat
+type ChatSocketEvent struct {
+	Type         string `json:"type"`
+	Content      string `json:"content,omitempty"`       // Delta text, or the whole reply when done
+	FinishReason string `json:"finish_reason,omitempty"` // "stop", or "cancelled" when the client stopped the reply
+	Error        string `json:"error,omitempty"`
+}
+
+// FallbackAttempt records a model that failed while a request walked its fallback chain
+type FallbackAttempt struct {
+	Model string `json:"model"`
+	Error string `json:"error"`
 }
 
 // Choice represents a completion choice
 type Choice struct {
-	Index   int     `json:"index"`
-	Message Message `json:"message"`
-	Delta   Message `json:"delta,omitempty"` // For streaming
+	Index    int            `json:"index"`
+	Message  Message        `json:"message"`
+	Delta    Message        `json:"delta,omitempty"`    // For streaming
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"` // Set when the request asked for logprobs
+}
+
+// TokenLogprob is the log probability of a generated token, with the most likely alternatives when requested
+type TokenLogprob struct {
+	Token       string       `json:"token"`
+	Logprob     float64      `json:"logprob"`
+	Bytes       []int        `json:"bytes,omitempty"`
+	TopLogprobs []TopLogprob `json:"top_logprobs,omitempty"`
+}
+
+// TopLogprob is a candidate token considered at a position of the output
+type TopLogprob struct {
+	Token   string  `json:"token"`
+	Logprob float64 `json:"logprob"`
+	Bytes   []int   `json:"bytes,omitempty"`
 }
 
 // Usage represents token usage information
@@ -41,13 +205,121 @@ type Usage struct {
 	TotalTokens      int `json:"total_tokens"`
 }
 
+// BackendUsage totals the generations served by one backend
+type BackendUsage struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+}
+
+// UsageReport represents the token usage tracked by this server
+type UsageReport struct {
+	Object    string       `json:"object"`
+	Since     int64        `json:"since"` // Unix time tracking started
+	Local     BackendUsage `json:"local"`
+	Cloud     BackendUsage `json:"cloud"`
+	SignedIn  bool         `json:"signed_in"`
+	QuotaNote string       `json:"quota_note"`
+}
+
+// UsageRecord is one generation as recorded for usage accounting
+type UsageRecord struct {
+	Time             int64  `json:"time"`   // Unix time the generation finished
+	Caller           string `json:"caller"` // "user:<subject>" for access tokens, else "ip:<address>"
+	Model            string `json:"model"`
+	Backend          string `json:"backend"`
+	PromptTokens     int    `json:"prompt_tokens"`
+	CompletionTokens int    `json:"completion_tokens"`
+	TotalTokens      int    `json:"total_tokens"`
+	LatencyMs        int64  `json:"latency_ms"`
+}
+
+// UsageQuery selects usage records. Empty filters match every record.
+type UsageQuery struct {
+	Day    string `form:"day"`                                      // UTC day as YYYY-MM-DD, empty for every retained day
+	Model  string `form:"model"`                                    // Exact model name
+	Caller string `form:"key"`                                      // Exact caller, e.g. user:alice
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"` // Records returned, newest first; totals cover all matches
+}
+
+// UsageTotals sums a set of usage records
+type UsageTotals struct {
+	Requests         int64 `json:"requests"`
+	PromptTokens     int64 `json:"prompt_tokens"`
+	CompletionTokens int64 `json:"completion_tokens"`
+	TotalTokens      int64 `json:"total_tokens"`
+	AverageLatencyMs int64 `json:"average_latency_ms"`
+}
+
+// UsageRecordList answers a usage query with totals overall, per model and per caller
+type UsageRecordList struct {
+	Object   string                 `json:"object"`
+	Totals   UsageTotals            `json:"totals"`
+	ByModel  map[string]UsageTotals `json:"by_model"`
+	ByCaller map[string]UsageTotals `json:"by_caller"`
+	Data     []UsageRecord          `json:"data"`
+}
+
+// EffectiveConfig is the configuration the server is running with
+type EffectiveConfig struct {
+	Object      string          `json:"object"`
+	ConfigFile  string          `json:"config_file,omitempty"` // YAML file applied at startup
+	ProfileFile string          `json:"profile_file"`          // Where imported profiles are saved
+	Settings    []ConfigSetting `json:"settings"`
+}
+
+// ConfigSetting is one environment variable of the effective configuration, secrets redacted
+type ConfigSetting struct {
+	Name    string `json:"name"`
+	Value   string `json:"value"`
+	Default string `json:"default"`
+	Source  string `json:"source"` // "environment", "config_file" or "default"
+}
+
+// Outcomes of an audited action
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// AuditEntry is one administrative action as recorded in the audit log
+type AuditEntry struct {
+	Time      int64  `json:"time"`   // Unix time the action finished
+	Actor     string `json:"actor"`  // "user:<subject>" for access tokens, else "ip:<address>"
+	Action    string `json:"action"` // e.g. "model.pull"
+	Target    string `json:"target"` // Request path acted on
+	Outcome   string `json:"outcome"`
+	Status    int    `json:"status"` // HTTP status of the response
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// AuditQuery selects audit log entries. Empty filters match every entry.
+type AuditQuery struct {
+	Actor  string `form:"actor"`                                    // Exact actor, e.g. user:alice
+	Action string `form:"action"`                                   // Exact action, e.g. model.delete
+	Since  int64  `form:"since"`                                    // Unix time, entries before it are skipped
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=1000"` // Entries returned, newest first
+}
+
+// AuditEntryList answers an audit log query
+type AuditEntryList struct {
+	Object string       `json:"object"`
+	Data   []AuditEntry `json:"data"`
+}
+
 // CompletionRequest represents a text completion request
 type CompletionRequest struct {
-	Prompt      string  `json:"prompt" binding:"required"`
-	Model       string  `json:"model,omitempty"`
-	Temperature float64 `json:"temperature,omitempty"`
-	MaxTokens   int     `json:"max_tokens,omitempty"`
-	Stop        string  `json:"stop,omitempty"`
+	Prompt      string   `json:"prompt" binding:"required"`
+	Model       string   `json:"model,omitempty"`
+	Temperature float64  `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Stop        string   `json:"stop,omitempty"`
+	Options     *Options `json:"options,omitempty"`
+	Logprobs    bool     `json:"logprobs,omitempty"`                            // Return the log probability of each generated token
+	TopLogprobs int      `json:"top_logprobs,omitempty" binding:"min=0,max=20"` // Most likely alternatives returned per token, implies logprobs
+	// Flags raised by moderating the prompt, carried over to the response
+	Moderation []ModerationFlag `json:"-"`
 }
 
 // CompletionResponse represents a text completion response
@@ -58,12 +330,21 @@ type CompletionResponse struct {
 	Model   string   `json:"model"`
 	Choices []Choice `json:"choices"`
 	Usage   Usage    `json:"usage"`
+	Backend string   `json:"backend,omitempty"` // "local" or "cloud"
+	// Models of the fallback chain that failed before Model answered
+	FallbackAttempts []FallbackAttempt `json:"fallback_attempts,omitempty"`
+	// Request values lowered to the limits of the client
+	Clamped []ClampedLimit `json:"clamped,omitempty"`
+	// Content the moderation policy flagged and let through
+	Moderation []ModerationFlag `json:"moderation,omitempty"`
 }
 
 // EmbeddingRequest represents an embedding request
 type EmbeddingRequest struct {
-	Input string `json:"input" binding:"required"`
-	Model string `json:"model,omitempty"`
+	Input      string `json:"input" binding:"required"`
+	Model      string `json:"model,omitempty"`
+	Dimensions int    `json:"dimensions,omitempty"` // Truncate vectors to this many leading dimensions (Matryoshka)
+	Normalize  bool   `json:"normalize,omitempty"`  // Scale vectors to unit length
 }
 
 // EmbeddingResponse represents an embedding response
@@ -114,11 +395,217 @@ type AuthResponse struct {
 	Message string `json:"message"`
 }
 
-// ErrorResponse represents an error response
+// MaintenanceRequest represents a request to enable maintenance mode
+type MaintenanceRequest struct {
+	Message         string `json:"message,omitempty"`
+	DurationMinutes int    `json:"duration_minutes,omitempty" binding:"min=0"` // Estimated length, used for Retry-After
+}
+
+// SwapModelRequest represents a request to move an alias, or the default model, to a new model
+type SwapModelRequest struct {
+	Model string `json:"model" binding:"required"`
+	Alias string `json:"alias,omitempty"` // Empty swaps the default model
+}
+
+// SwapStep reports the outcome of one stage of a model swap
+type SwapStep struct {
+	Name   string `json:"name"`   // pull, warm, switch, unload or rollback
+	Status string `json:"status"` // ok, failed or skipped
+	Error  string `json:"error,omitempty"`
+}
+
+// SwapModelResponse represents the result of a model swap
+type SwapModelResponse struct {
+	Success    bool       `json:"success"`
+	Alias      string     `json:"alias,omitempty"`
+	Previous   string     `json:"previous"`
+	Current    string     `json:"current"`
+	RolledBack bool       `json:"rolled_back"`
+	Steps      []SwapStep `json:"steps"`
+}
+
+// Conversation is a multi-turn chat whose history is kept by the server
+type Conversation struct {
+	ID        string               `json:"id"`
+	Model     string               `json:"model,omitempty"`
+	Messages  []Message            `json:"messages"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+	Metadata  ConversationMetadata `json:"metadata"`
+}
+
+// ConversationMetadata reports the size of a conversation and whether older turns were summarized
+type ConversationMetadata struct {
+	EstimatedTokens    int        `json:"estimated_tokens"`
+	Compressed         bool       `json:"compressed"`                    // Older turns were replaced by a summary
+	SummarizedMessages int        `json:"summarized_messages,omitempty"` // Messages folded into the summary so far
+	LastCompressedAt   *time.Time `json:"last_compressed_at,omitempty"`
+	CompressionError   string     `json:"compression_error,omitempty"` // Why the last summarization failed
+}
+
+// CreateConversationRequest represents a request to start a conversation
+type CreateConversationRequest struct {
+	Model        string `json:"model,omitempty"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+}
+
+// ConversationMessageRequest represents a new user message in a conversation
+type ConversationMessageRequest struct {
+	Content     string   `json:"content" binding:"required"`
+	Temperature float64  `json:"temperature,omitempty"`
+	MaxTokens   int      `json:"max_tokens,omitempty"`
+	Options     *Options `json:"options,omitempty"`
+}
+
+// ConversationReply represents the model's answer to a conversation message
+type ConversationReply struct {
+	ConversationID string  `json:"conversation_id"`
+	Model          string  `json:"model"`
+	Message        Message `json:"message"`
+	Usage          Usage   `json:"usage"`
+	Backend        string  `json:"backend,omitempty"`
+	// Request values lowered to the limits of the client
+	Clamped []ClampedLimit `json:"clamped,omitempty"`
+}
+
+// Preferences holds the defaults a workspace applies to requests that omit these fields
+type Preferences struct {
+	Model    string `json:"model,omitempty"`
+	Style    string `json:"style,omitempty"`    // Rewrite style
+	Tone     string `json:"tone,omitempty"`     // Rewrite tone
+	Language string `json:"language,omitempty"` // Rewrite output language
+}
+
+// UpstreamStatus reports the health of an Ollama backend as seen by the circuit breaker
+type UpstreamStatus struct {
+	Name                string     `json:"name"` // "local" or "cloud"
+	URL                 string     `json:"url"`
+	State               string     `json:"state"` // "closed", "open" or "half_open"
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	RecentRequests      int        `json:"recent_requests"`
+	ErrorRate           float64    `json:"error_rate"` // Share of recent requests that failed, 0 to 1
+	AverageLatencyMs    float64    `json:"average_latency_ms"`
+	LastFailure         string     `json:"last_failure,omitempty"`
+	LastFailureAt       *time.Time `json:"last_failure_at,omitempty"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+}
+
+// GoroutineStats counts the managed goroutines started under one name
+type GoroutineStats struct {
+	Started int64 `json:"started"`
+	Running int64 `json:"running"`
+	Panics  int64 `json:"panics"` // Goroutines that panicked and were recovered
+}
+
+// Readiness probe statuses
+const (
+	StatusReady      = "ready"
+	StatusDegraded   = "degraded" // Ready, with an optional dependency down
+	StatusNotReady   = "not_ready"
+	DependencyOK     = "ok"
+	DependencyFailed = "failed"
+)
+
+// ReadinessReport answers the readiness probe with the status of each dependency
+type ReadinessReport struct {
+	Status string                      `json:"status"` // "ready", "degraded" or "not_ready"
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+// DependencyStatus is the result of checking one dependency
+type DependencyStatus struct {
+	Status    string `json:"status"`             // "ok" or "failed"
+	Optional  bool   `json:"optional,omitempty"` // The server keeps serving without it
+	LatencyMs int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// DebugStats is a snapshot of the runtime for diagnosing performance problems
+type DebugStats struct {
+	Goroutines        int                       `json:"goroutines"`         // All goroutines in the process
+	ManagedGoroutines map[string]GoroutineStats `json:"managed_goroutines"` // Background goroutines by name
+	Heap              HeapStats                 `json:"heap"`
+	GC                GCStats                   `json:"gc"`
+	Connections       ConnectionStats           `json:"connections"`
+}
+
+// HeapStats reports the size of the heap in bytes
+type HeapStats struct {
+	AllocBytes    uint64 `json:"alloc_bytes"` // Bytes of allocated heap objects
+	InuseBytes    uint64 `json:"inuse_bytes"`
+	IdleBytes     uint64 `json:"idle_bytes"`
+	ReleasedBytes uint64 `json:"released_bytes"` // Bytes returned to the OS
+	SysBytes      uint64 `json:"sys_bytes"`      // Bytes obtained from the OS for the whole runtime
+	Objects       uint64 `json:"objects"`
+}
+
+// GCStats reports garbage collection activity since the process started
+type GCStats struct {
+	Cycles       uint32     `json:"cycles"`
+	PauseTotalMs float64    `json:"pause_total_ms"`
+	LastPauseMs  float64    `json:"last_pause_ms"`
+	LastGC       *time.Time `json:"last_gc,omitempty"`
+	NextGCBytes  uint64     `json:"next_gc_bytes"` // Heap size that triggers the next cycle
+	CPUFraction  float64    `json:"cpu_fraction"`  // Share of CPU time spent in GC, 0 to 1
+	ForcedCycles uint32     `json:"forced_cycles"`
+}
+
+// ConnectionStats counts the server's open client connections by state
+type ConnectionStats struct {
+	Open   int `json:"open"`
+	New    int `json:"new"`    // Connected, no request read yet
+	Active int `json:"active"` // Serving a request
+	Idle   int `json:"idle"`   // Kept alive between requests
+}
+
+// ShadowResult pairs a production chat answer with the answer of the shadow model for the same request
+type ShadowResult struct {
+	ID              string    `json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	Prompt          string    `json:"prompt"` // Last user message of the request
+	Model           string    `json:"model"`
+	Output          string    `json:"output"`
+	LatencyMs       int64     `json:"latency_ms"`
+	ShadowModel     string    `json:"shadow_model"`
+	ShadowOutput    string    `json:"shadow_output,omitempty"`
+	ShadowLatencyMs int64     `json:"shadow_latency_ms"`
+	ShadowUsage     Usage     `json:"shadow_usage"`
+	Error           string    `json:"error,omitempty"` // Set when the shadow request failed
+}
+
+// ShadowReport describes shadow mode and the most recent shadow results, newest first
+type ShadowReport struct {
+	Model    string         `json:"model,omitempty"`
+	Percent  int            `json:"percent"`
+	Mirrored int64          `json:"mirrored"` // Requests sent to the shadow model
+	Dropped  int64          `json:"dropped"`  // Sampled requests skipped because enough shadow requests were in flight
+	Failed   int64          `json:"failed"`
+	Results  []ShadowResult `json:"results"`
+}
+
+// ErrorResponse is the envelope of every error response
 type ErrorResponse struct {
-	Error   string `json:"error"`
-	Details string `json:"details,omitempty"`
-	Code    int    `json:"code"`
+	Code      string `json:"code"`              // Machine-readable, e.g. "not_found"
+	Message   string `json:"message"`           // Human-readable message
+	Error     string `json:"error"`             // Same as Message, kept for existing clients
+	Details   string `json:"details,omitempty"` // What went wrong, when there is more to say
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// WithFields returns the envelope as a map with fields added, for errors that carry more than the
+// envelope, such as the delay before a rate limited client may retry
+func (r ErrorResponse) WithFields(fields map[string]interface{}) map[string]interface{} {
+	body := map[string]interface{}{"code": r.Code, "message": r.Message, "error": r.Error}
+	if r.Details != "" {
+		body["details"] = r.Details
+	}
+	if r.RequestID != "" {
+		body["request_id"] = r.RequestID
+	}
+	for name, value := range fields {
+		body[name] = value
+	}
+	return body
 }
 
 // StreamResponse represents a streaming response chunk
@@ -137,3 +624,220 @@ type HealthResponse struct {
 	Version   string    `json:"version"`
 	Timestamp time.Time `json:"timestamp"`
 }
+
+// RewriteRequest represents a request to rewrite existing text in a new style
+type RewriteRequest struct {
+	Text              string  `json:"text" binding:"required"`
+	Style             string  `json:"style,omitempty"`    // e.g. "encyclopedic", "casual", "academic"
+	Tone              string  `json:"tone,omitempty"`     // e.g. "neutral", "friendly", "formal"
+	Length            string  `json:"length,omitempty"`   // e.g. "shorter", "same", "longer", "about 200 words"
+	Language          string  `json:"language,omitempty"` // e.g. "en", "German"; defaults to the language of the text
+	PreserveCitations bool    `json:"preserve_citations,omitempty"`
+	Model             string  `json:"model,omitempty"`
+	Temperature       float64 `json:"temperature,omitempty"`
+}
+
+// RewriteResponse represents the rewritten text
+type RewriteResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Text    string `json:"text"`
+	Usage   Usage  `json:"usage"`
+	// Models of the fallback chain that failed before Model answered
+	FallbackAttempts []FallbackAttempt `json:"fallback_attempts,omitempty"`
+	// Citations the model dropped from Text, with preserve_citations
+	MissingCitations []string `json:"missing_citations,omitempty"`
+}
+
+// GlossaryRequest represents a request to extract and define the key terms of a text
+type GlossaryRequest struct {
+	Text        string  `json:"text" binding:"required"`
+	MaxTerms    int     `json:"max_terms,omitempty" binding:"min=0,max=50"` // Defaults to 10
+	Language    string  `json:"language,omitempty"`                         // Language of the definitions; defaults to the language of the text
+	Model       string  `json:"model,omitempty"`
+	Temperature float64 `json:"temperature,omitempty"`
+}
+
+// GlossaryEntry is a key term of a text with its definition
+type GlossaryEntry struct {
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+	Excerpt    string `json:"excerpt"` // First sentence of the text mentioning the term
+}
+
+// GlossaryResponse represents the glossary of a text
+type GlossaryResponse struct {
+	ID      string          `json:"id"`
+	Object  string          `json:"object"`
+	Created int64           `json:"created"`
+	Model   string          `json:"model"`
+	Entries []GlossaryEntry `json:"entries"`
+	Usage   Usage           `json:"usage"`
+	// Models of the fallback chain that failed before Model answered
+	FallbackAttempts []FallbackAttempt `json:"fallback_attempts,omitempty"`
+}
+
+// CompareRequest represents a request to run the same prompt against several models
+type CompareRequest struct {
+	Prompt       string   `json:"prompt" binding:"required"`
+	SystemPrompt string   `json:"system_prompt,omitempty"`
+	Models       []string `json:"models" binding:"required"`
+	Temperature  float64  `json:"temperature,omitempty"`
+	MaxTokens    int      `json:"max_tokens,omitempty"`
+	Options      *Options `json:"options,omitempty"`
+}
+
+// CompareResult represents one model's output in a comparison
+type CompareResult struct {
+	Model     string `json:"model"`
+	Output    string `json:"output,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+	Usage     Usage  `json:"usage"`
+	Error     string `json:"error,omitempty"`
+	// The status and error body a request to this model alone would have been answered with
+	Status  int                    `json:"status,omitempty"`
+	Failure map[string]interface{} `json:"failure,omitempty"`
+	Err     error                  `json:"-"` // What the model failed with, mapped to Status and Failure
+}
+
+// CompareResponse represents side-by-side results of a model comparison
+type CompareResponse struct {
+	ID      string          `json:"id"`
+	Object  string          `json:"object"`
+	Created int64           `json:"created"`
+	Prompt  string          `json:"prompt"`
+	Results []CompareResult `json:"results"`
+	Clamped []ClampedLimit  `json:"clamped,omitempty"` // Request values lowered to the limits of the client
+}
+
+// BatchChatRequest represents a request to run several independent chat requests
+type BatchChatRequest struct {
+	Requests []ChatRequest `json:"requests" binding:"required"`
+	// Seconds each request may take, overriding the server's default
+	ItemTimeoutSeconds int `json:"item_timeout_seconds,omitempty"`
+}
+
+// BatchChatResult represents the response or the error of one request of a batch
+type BatchChatResult struct {
+	Response  *ChatResponse `json:"response,omitempty"`
+	Error     string        `json:"error,omitempty"`
+	LatencyMs int64         `json:"latency_ms"`
+}
+
+// BatchChatResponse represents the results of a batch, in the order of its requests
+type BatchChatResponse struct {
+	ID        string            `json:"id"`
+	Object    string            `json:"object"`
+	Created   int64             `json:"created"`
+	Results   []BatchChatResult `json:"results"`
+	Succeeded int               `json:"succeeded"`
+	Failed    int               `json:"failed"`
+	Usage     Usage             `json:"usage"` // Summed over the requests that succeeded
+}
+
+// CopyModelRequest represents a request to copy a model to a new name
+type CopyModelRequest struct {
+	Destination string `json:"destination" binding:"required"`
+}
+
+// AliasRequest represents a request to point an alias at a model
+type AliasRequest struct {
+	Model string `json:"model" binding:"required"`
+}
+
+// Preset is a named system prompt that chat requests can reference
+type Preset struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	SystemPrompt string `json:"system_prompt"`
+}
+
+// PresetRequest represents a request to create or replace a preset
+type PresetRequest struct {
+	Description  string `json:"description,omitempty"`
+	SystemPrompt string `json:"system_prompt" binding:"required"`
+}
+
+// PromptTemplate is a named prompt written as a Go text/template, rendered with request variables
+type PromptTemplate struct {
+	Name         string `json:"name"`
+	Description  string `json:"description,omitempty"`
+	Template     string `json:"template"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	Model        string `json:"model,omitempty"` // Used when the run request names no model
+}
+
+// PromptTemplateRequest represents a request to register or replace a prompt template
+type PromptTemplateRequest struct {
+	Description  string `json:"description,omitempty"`
+	Template     string `json:"template" binding:"required"`
+	SystemPrompt string `json:"system_prompt,omitempty"`
+	Model        string `json:"model,omitempty"`
+}
+
+// RunPromptRequest represents a request to render a prompt template and run it
+type RunPromptRequest struct {
+	Variables   map[string]interface{} `json:"variables,omitempty"`
+	Model       string                 `json:"model,omitempty"`
+	Temperature float64                `json:"temperature,omitempty"`
+	MaxTokens   int                    `json:"max_tokens,omitempty"`
+	Options     *Options               `json:"options,omitempty"`
+}
+
+// RunPromptResponse represents the output of a prompt template run
+type RunPromptResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Prompt  string `json:"prompt"` // The rendered template
+	Text    string `json:"text"`
+	Usage   Usage  `json:"usage"`
+	Backend string `json:"backend,omitempty"`
+	// Models of the fallback chain that failed before Model answered
+	FallbackAttempts []FallbackAttempt `json:"fallback_attempts,omitempty"`
+	// Request values lowered to the limits of the client
+	Clamped []ClampedLimit `json:"clamped,omitempty"`
+}
+
+// CreateModelRequest represents a request to build a model from a Modelfile
+type CreateModelRequest struct {
+	Modelfile string `json:"modelfile" binding:"required"`
+}
+
+// Capabilities describes what a server offers, so clients can adapt instead of assuming a build
+type Capabilities struct {
+	Version      string                `json:"version"`
+	DefaultModel string                `json:"default_model"`
+	Models       []string              `json:"models"`                 // Empty when Ollama could not be reached
+	ModelsError  string                `json:"models_error,omitempty"` // Why the model list is empty
+	Features     map[string]bool       `json:"features"`
+	Streaming    StreamingCapabilities `json:"streaming"`
+	Auth         AuthCapabilities      `json:"auth"`
+	Limits       LimitCapabilities     `json:"limits"`
+}
+
+// StreamingCapabilities describes the event schemas of streaming chat
+type StreamingCapabilities struct {
+	Schemas       []string `json:"schemas"`
+	DefaultSchema string   `json:"default_schema"`
+	Compression   bool     `json:"compression"`
+}
+
+// AuthCapabilities describes how clients authenticate
+type AuthCapabilities struct {
+	Mode  string `json:"mode"`  // "none" or "jwt"
+	Admin string `json:"admin"` // How admin endpoints are guarded: "disabled", "token" or "role"
+}
+
+// LimitCapabilities are the default generation caps, 0 meaning unlimited
+type LimitCapabilities struct {
+	MaxTokens                int `json:"max_tokens"`
+	MaxMessages              int `json:"max_messages"`
+	MaxPromptChars           int `json:"max_prompt_chars"`
+	MaxEmbeddingChars        int `json:"max_embedding_chars"`
+	MaxBodyBytes             int `json:"max_body_bytes"`
+	MaxRequestTimeoutSeconds int `json:"max_request_timeout_seconds"`
+}
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// MessagesRequest is a request in the format of Anthropic's Messages API (POST /v1/messages)
+type MessagesRequest struct {
+	Model         string            `json:"model" binding:"required"`
+	MaxTokens     int               `json:"max_tokens" binding:"required,min=1"`
+	System        MessagesContent   `json:"system,omitempty"`
+	Messages      []MessagesMessage `json:"messages" binding:"required,min=1,dive"`
+	Temperature   *float64          `json:"temperature,omitempty"`
+	TopP          *float64          `json:"top_p,omitempty"`
+	TopK          *int              `json:"top_k,omitempty"`
+	StopSequences []string          `json:"stop_sequences,omitempty"`
+	Stream        bool              `json:"stream,omitempty"`
+}
+
+// MessagesMessage is a conversation turn of a Messages API request
+type MessagesMessage struct {
+	Role    string          `json:"role" binding:"required,oneof=user assistant"`
+	Content MessagesContent `json:"content" binding:"required"`
+}
+
+// MessagesContent is message content given either as a plain string or as a list of content blocks.
+// Only text blocks are supported; their text is joined with blank lines.
+type MessagesContent string
+
+func (c *MessagesContent) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*c = MessagesContent(text)
+		return nil
+	}
+
+	var blocks []MessagesContentBlock
+	if err := json.Unmarshal(data, &blocks); err != nil {
+		return fmt.Errorf("content must be a string or a list of content blocks")
+	}
+	texts := make([]string, 0, len(blocks))
+	for _, block := range blocks {
+		if block.Type != "text" {
+			return fmt.Errorf("content block type %q is not supported", block.Type)
+		}
+		texts = append(texts, block.Text)
+	}
+	*c = MessagesContent(strings.Join(texts, "\n\n"))
+	return nil
+}
+
+// MessagesContentBlock is a block of message content
+type MessagesContentBlock struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// MessagesResponse is a response in the format of Anthropic's Messages API
+type MessagesResponse struct {
+	ID           string                 `json:"id"`
+	Type         string                 `json:"type"` // Always "message"
+	Role         string                 `json:"role"` // Always "assistant"
+	Model        string                 `json:"model"`
+	Content      []MessagesContentBlock `json:"content"`
+	StopReason   *string                `json:"stop_reason"` // "end_turn" or "max_tokens", null while streaming
+	StopSequence *string                `json:"stop_sequence"`
+	Usage        MessagesUsage          `json:"usage"`
+}
+
+// MessagesUsage reports token usage in the Messages API format
+type MessagesUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
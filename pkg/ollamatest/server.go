@@ -0,0 +1,276 @@
+// Package ollamatest runs a fake Ollama server for integration tests. It serves the endpoints the
+// API uses: model listing, pulling, deleting and showing, streamed chat and generate responses,
+// and embeddings, and records every request it receives.
+package ollamatest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Request is a request received by the fake server
+type Request struct {
+	Method string
+	Path   string
+	Body   map[string]interface{}
+}
+
+// Server is a fake Ollama server. Its URL is the base URL to configure as LLAMA_BASE_URL.
+type Server struct {
+	*httptest.Server
+
+	mu            sync.Mutex
+	models        []string
+	reply         string
+	embedding     []float64
+	contextLength int
+	requests      []Request
+}
+
+// NewServer starts a fake Ollama server with models installed. It answers every chat and generate
+// request with "Hello from Ollama" until SetReply changes the reply. Close it when done.
+func NewServer(models ...string) *Server {
+	s := &Server{
+		models:        append([]string(nil), models...),
+		reply:         "Hello from Ollama",
+		embedding:     []float64{0.1, 0.2, 0.3},
+		contextLength: 4096,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/tags", s.tags)
+	mux.HandleFunc("POST /api/pull", s.pull)
+	mux.HandleFunc("DELETE /api/delete", s.delete)
+	mux.HandleFunc("POST /api/show", s.show)
+	mux.HandleFunc("POST /api/chat", s.chat)
+	mux.HandleFunc("POST /api/generate", s.generate)
+	mux.HandleFunc("POST /api/embeddings", s.embeddings)
+	s.Server = httptest.NewServer(s.record(mux))
+	return s
+}
+
+// SetReply sets the text returned by chat and generate requests. Streamed responses send it one
+// word per chunk.
+func (s *Server) SetReply(reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reply = reply
+}
+
+// SetEmbedding sets the vector returned by embedding requests
+func (s *Server) SetEmbedding(vector []float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.embedding = append([]float64(nil), vector...)
+}
+
+// SetContextLength sets the context window /api/show reports for every model
+func (s *Server) SetContextLength(length int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.contextLength = length
+}
+
+// Models returns the installed models, in the order they were installed
+func (s *Server) Models() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.models...)
+}
+
+// Requests returns the requests received so far, oldest first
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// record stores each request with its decoded JSON body before serving it
+func (s *Server) record(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if r.Body != nil {
+			json.NewDecoder(r.Body).Decode(&body)
+		}
+
+		s.mu.Lock()
+		s.requests = append(s.requests, Request{Method: r.Method, Path: r.URL.Path, Body: body})
+		s.mu.Unlock()
+
+		next.ServeHTTP(w, r.WithContext(withBody(r.Context(), body)))
+	})
+}
+
+func (s *Server) tags(w http.ResponseWriter, r *http.Request) {
+	list := []map[string]interface{}{}
+	for _, name := range s.Models() {
+		list = append(list, map[string]interface{}{"name": name, "model": name})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"models": list})
+}
+
+func (s *Server) pull(w http.ResponseWriter, r *http.Request) {
+	name := modelName(r)
+	s.mu.Lock()
+	if !slices.Contains(s.models, name) {
+		s.models = append(s.models, name)
+	}
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "success"})
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request) {
+	name := modelName(r)
+	s.mu.Lock()
+	index := slices.Index(s.models, name)
+	if index >= 0 {
+		s.models = slices.Delete(s.models, index, index+1)
+	}
+	s.mu.Unlock()
+
+	if index < 0 {
+		notFound(w, name)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) show(w http.ResponseWriter, r *http.Request) {
+	name := modelName(r)
+	if !s.installed(name) {
+		notFound(w, name)
+		return
+	}
+
+	s.mu.Lock()
+	length := s.contextLength
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"parameters": "",
+		"model_info": map[string]interface{}{"llama.context_length": length},
+	})
+}
+
+func (s *Server) chat(w http.ResponseWriter, r *http.Request) {
+	body := requestBody(r)
+	var prompt strings.Builder
+	if messages, ok := body["messages"].([]interface{}); ok {
+		for _, message := range messages {
+			if message, ok := message.(map[string]interface{}); ok {
+				content, _ := message["content"].(string)
+				prompt.WriteString(content + " ")
+			}
+		}
+	}
+
+	s.respond(w, r, prompt.String(), func(text string) map[string]interface{} {
+		return map[string]interface{}{"message": map[string]interface{}{"role": "assistant", "content": text}}
+	})
+}
+
+func (s *Server) generate(w http.ResponseWriter, r *http.Request) {
+	prompt, _ := requestBody(r)["prompt"].(string)
+	s.respond(w, r, prompt, func(text string) map[string]interface{} {
+		return map[string]interface{}{"response": text}
+	})
+}
+
+// respond answers a chat or generate request with the reply, as one chunk per word when the
+// request streams, which Ollama does unless "stream" is false. Token counts are word counts.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, prompt string, chunk func(string) map[string]interface{}) {
+	name := modelName(r)
+	if !s.installed(name) {
+		notFound(w, name)
+		return
+	}
+
+	s.mu.Lock()
+	reply := s.reply
+	s.mu.Unlock()
+
+	done := func(text string) map[string]interface{} {
+		part := chunk(text)
+		part["model"] = name
+		part["done"] = true
+		part["prompt_eval_count"] = len(strings.Fields(prompt))
+		part["eval_count"] = len(strings.Fields(reply))
+		return part
+	}
+	if stream, ok := requestBody(r)["stream"].(bool); ok && !stream {
+		writeJSON(w, http.StatusOK, done(reply))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	encoder := json.NewEncoder(w)
+	for _, word := range strings.SplitAfter(reply, " ") {
+		if word == "" {
+			continue
+		}
+		part := chunk(word)
+		part["model"] = name
+		part["done"] = false
+		encoder.Encode(part)
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+	}
+	encoder.Encode(done(""))
+}
+
+func (s *Server) embeddings(w http.ResponseWriter, r *http.Request) {
+	name := modelName(r)
+	if !s.installed(name) {
+		notFound(w, name)
+		return
+	}
+
+	s.mu.Lock()
+	vector := append([]float64(nil), s.embedding...)
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"embedding": vector})
+}
+
+func (s *Server) installed(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.models, name)
+}
+
+// modelName reads the model a request names, in "model" or the older "name" field
+func modelName(r *http.Request) string {
+	body := requestBody(r)
+	if name, ok := body["model"].(string); ok && name != "" {
+		return name
+	}
+	name, _ := body["name"].(string)
+	return name
+}
+
+type bodyKey struct{}
+
+func withBody(ctx context.Context, body map[string]interface{}) context.Context {
+	return context.WithValue(ctx, bodyKey{}, body)
+}
+
+// requestBody returns the JSON body decoded when the request was recorded
+func requestBody(r *http.Request) map[string]interface{} {
+	body, _ := r.Context().Value(bodyKey{}).(map[string]interface{})
+	return body
+}
+
+func notFound(w http.ResponseWriter, name string) {
+	writeJSON(w, http.StatusNotFound, map[string]interface{}{"error": fmt.Sprintf("model '%s' not found", name)})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,95 @@
+package ollamatest
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func post(t *testing.T, url, body string) *http.Response {
+	resp, err := http.Post(url, "application/json", strings.NewReader(body))
+	assert.NoError(t, err)
+	return resp
+}
+
+func TestServer_StreamsChat(t *testing.T) {
+	server := NewServer("llama2")
+	defer server.Close()
+	server.SetReply("one two")
+
+	resp := post(t, server.URL+"/api/chat", `{"model":"llama2","messages":[{"role":"user","content":"Hi"}]}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var chunks []map[string]interface{}
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var chunk map[string]interface{}
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &chunk))
+		chunks = append(chunks, chunk)
+	}
+	assert.Len(t, chunks, 3)
+	assert.Equal(t, "one ", chunks[0]["message"].(map[string]interface{})["content"])
+	assert.Equal(t, true, chunks[2]["done"])
+	assert.Equal(t, float64(1), chunks[2]["prompt_eval_count"])
+	assert.Equal(t, float64(2), chunks[2]["eval_count"])
+
+	requests := server.Requests()
+	assert.Len(t, requests, 1)
+	assert.Equal(t, "/api/chat", requests[0].Path)
+	assert.Equal(t, "llama2", requests[0].Body["model"])
+}
+
+func TestServer_GenerateWithoutStreaming(t *testing.T) {
+	server := NewServer("llama2")
+	defer server.Close()
+
+	resp := post(t, server.URL+"/api/generate", `{"model":"llama2","prompt":"Hi","stream":false}`)
+	defer resp.Body.Close()
+
+	var body map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, "Hello from Ollama", body["response"])
+	assert.Equal(t, true, body["done"])
+}
+
+func TestServer_UnknownModel(t *testing.T) {
+	server := NewServer()
+	defer server.Close()
+
+	resp := post(t, server.URL+"/api/chat", `{"model":"missing"}`)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestServer_PullAndDelete(t *testing.T) {
+	server := NewServer("llama2")
+	defer server.Close()
+
+	post(t, server.URL+"/api/pull", `{"name":"mistral"}`).Body.Close()
+	assert.Equal(t, []string{"llama2", "mistral"}, server.Models())
+
+	req, _ := http.NewRequest("DELETE", server.URL+"/api/delete", strings.NewReader(`{"model":"llama2"}`))
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, []string{"mistral"}, server.Models())
+}
+
+func TestServer_Embeddings(t *testing.T) {
+	server := NewServer("nomic-embed-text")
+	defer server.Close()
+	server.SetEmbedding([]float64{1, 0})
+
+	resp := post(t, server.URL+"/api/embeddings", `{"model":"nomic-embed-text","prompt":"Hi"}`)
+	defer resp.Body.Close()
+
+	var body map[string][]float64
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, []float64{1, 0}, body["embedding"])
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// resolveAlias maps an alias to its target model, returning the name unchanged if it is not an alias
+func (s *LlamaService) resolveAlias(name string) string {
+	s.aliasMu.RLock()
+	defer s.aliasMu.RUnlock()
+
+	if target, ok := s.aliases[name]; ok {
+		return target
+	}
+	return name
+}
+
+// ListAliases returns a copy of the alias table
+func (s *LlamaService) ListAliases() map[string]string {
+	s.aliasMu.RLock()
+	defer s.aliasMu.RUnlock()
+
+	aliases := make(map[string]string, len(s.aliases))
+	for alias, model := range s.aliases {
+		aliases[alias] = model
+	}
+	return aliases
+}
+
+// SetAlias points an alias at a model, replacing any previous target
+func (s *LlamaService) SetAlias(alias, model string) error {
+	if alias == model {
+		return fmt.Errorf("alias %s cannot point to itself", alias)
+	}
+
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+
+	if s.aliases == nil {
+		s.aliases = map[string]string{}
+	}
+	s.aliases[alias] = model
+	return nil
+}
+
+// DeleteAlias removes an alias from the table
+func (s *LlamaService) DeleteAlias(alias string) error {
+	s.aliasMu.Lock()
+	defer s.aliasMu.Unlock()
+
+	if _, ok := s.aliases[alias]; !ok {
+		return fmt.Errorf("%w: %s", ErrAliasNotFound, alias)
+	}
+	delete(s.aliases, alias)
+	return nil
+}
+
+// CopyModel copies a local model to a new name using Ollama's copy API
+func (s *LlamaService) CopyModel(source, destination string) error {
+	copyRequest := map[string]interface{}{
+		"source":      s.resolveAlias(source),
+		"destination": destination,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "POST", "/api/copy", copyRequest, s.config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to copy model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrModelNotFound, source)
+	case resp.StatusCode != http.StatusOK:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
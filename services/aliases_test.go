@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAliasResolution(t *testing.T) {
+	service := NewLlamaService()
+	service.config.DefaultModel = "default"
+
+	assert.NoError(t, service.SetAlias("fast", "phi3:mini"))
+	assert.NoError(t, service.SetAlias("default", "llama3.1:8b"))
+	assert.Error(t, service.SetAlias("loop", "loop"))
+
+	assert.Equal(t, "phi3:mini", service.getModel("fast"))
+	assert.Equal(t, "llama3.1:8b", service.getModel(""))
+	assert.Equal(t, "mistral", service.getModel("mistral"))
+	assert.Equal(t, map[string]string{"fast": "phi3:mini", "default": "llama3.1:8b"}, service.ListAliases())
+
+	assert.NoError(t, service.DeleteAlias("fast"))
+	assert.ErrorIs(t, service.DeleteAlias("fast"), ErrAliasNotFound)
+	assert.Equal(t, "fast", service.getModel("fast"))
+
+	// The default model is protected even when addressed through its alias target
+	assert.ErrorIs(t, service.DeleteModel("llama3.1:8b"), ErrDefaultModelProtected)
+}
+
+func TestCopyModel(t *testing.T) {
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/copy", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&body)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.SetAlias("fast", "phi3:mini")
+
+	assert.NoError(t, service.CopyModel("fast", "phi3-backup"))
+	assert.Equal(t, map[string]string{"source": "phi3:mini", "destination": "phi3-backup"}, body)
+}
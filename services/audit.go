@@ -0,0 +1,129 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"agent-ollama-gin/models"
+)
+
+// AuditLog is an append-only record of administrative actions
+type AuditLog interface {
+	// Append records one action
+	Append(ctx context.Context, entry models.AuditEntry) error
+	// Entries returns every recorded action, oldest first
+	Entries(ctx context.Context) ([]models.AuditEntry, error)
+}
+
+// MemoryAuditLog keeps the audit log in process memory. Entries are lost on restart and are not
+// shared between replicas.
+type MemoryAuditLog struct {
+	mu      sync.Mutex
+	entries []models.AuditEntry
+}
+
+// NewMemoryAuditLog creates an empty in-memory audit log
+func NewMemoryAuditLog() *MemoryAuditLog {
+	return &MemoryAuditLog{}
+}
+
+func (l *MemoryAuditLog) Append(ctx context.Context, entry models.AuditEntry) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, entry)
+	return nil
+}
+
+func (l *MemoryAuditLog) Entries(ctx context.Context) ([]models.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]models.AuditEntry(nil), l.entries...), nil
+}
+
+// FileAuditLog appends the audit log to a file, one JSON entry per line, so it survives restarts
+// and can be shipped by log collectors. The file is only ever appended to.
+type FileAuditLog struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+}
+
+// NewFileAuditLog opens the audit log at path, creating it if needed
+func NewFileAuditLog(path string) (*FileAuditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	return &FileAuditLog{path: path, file: file}, nil
+}
+
+func (l *FileAuditLog) Append(ctx context.Context, entry models.AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+	return nil
+}
+
+func (l *FileAuditLog) Entries(ctx context.Context) ([]models.AuditEntry, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []models.AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry models.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode audit log: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Close closes the audit log file
+func (l *FileAuditLog) Close() error {
+	return l.file.Close()
+}
+
+// QueryAuditLog returns the entries of log matching query, newest first
+func QueryAuditLog(ctx context.Context, log AuditLog, query models.AuditQuery) (*models.AuditEntryList, error) {
+	entries, err := log.Entries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &models.AuditEntryList{Object: "list", Data: []models.AuditEntry{}}
+	for i := len(entries) - 1; i >= 0; i-- {
+		if query.Limit > 0 && len(list.Data) == query.Limit {
+			break
+		}
+		entry := entries[i]
+		if (query.Actor != "" && entry.Actor != query.Actor) ||
+			(query.Action != "" && entry.Action != query.Action) ||
+			entry.Time < query.Since {
+			continue
+		}
+		list.Data = append(list.Data, entry)
+	}
+	return list, nil
+}
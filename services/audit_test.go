@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileAuditLog(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	ctx := context.Background()
+
+	auditLog, err := NewFileAuditLog(path)
+	assert.NoError(t, err)
+	first := models.AuditEntry{Time: 100, Actor: "user:alice", Action: "model.pull", Target: "/api/v1/llama/models/llama3.2/pull", Outcome: models.AuditSuccess, Status: 200}
+	assert.NoError(t, auditLog.Append(ctx, first))
+	assert.NoError(t, auditLog.Close())
+
+	// Reopening appends to the entries already recorded
+	auditLog, err = NewFileAuditLog(path)
+	assert.NoError(t, err)
+	defer auditLog.Close()
+	second := models.AuditEntry{Time: 200, Actor: "user:bob", Action: "model.delete", Target: "/api/v1/llama/models/mistral", Outcome: models.AuditFailure, Status: 404}
+	assert.NoError(t, auditLog.Append(ctx, second))
+
+	entries, err := auditLog.Entries(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.AuditEntry{first, second}, entries)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestQueryAuditLog(t *testing.T) {
+	ctx := context.Background()
+	auditLog := NewMemoryAuditLog()
+	for _, entry := range []models.AuditEntry{
+		{Time: 100, Actor: "user:alice", Action: "model.pull"},
+		{Time: 200, Actor: "user:bob", Action: "model.delete"},
+		{Time: 300, Actor: "user:alice", Action: "model.delete"},
+		{Time: 400, Actor: "user:alice", Action: "cloud.signout"},
+	} {
+		assert.NoError(t, auditLog.Append(ctx, entry))
+	}
+
+	tests := []struct {
+		name  string
+		query models.AuditQuery
+		times []int64
+	}{
+		{"all, newest first", models.AuditQuery{}, []int64{400, 300, 200, 100}},
+		{"by actor", models.AuditQuery{Actor: "user:alice"}, []int64{400, 300, 100}},
+		{"by action", models.AuditQuery{Action: "model.delete"}, []int64{300, 200}},
+		{"since", models.AuditQuery{Since: 300}, []int64{400, 300}},
+		{"limited", models.AuditQuery{Actor: "user:alice", Limit: 2}, []int64{400, 300}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := QueryAuditLog(ctx, auditLog, tt.query)
+			assert.NoError(t, err)
+			assert.Equal(t, "list", list.Object)
+			times := []int64{}
+			for _, entry := range list.Data {
+				times = append(times, entry.Time)
+			}
+			assert.Equal(t, tt.times, times)
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// ChatBatch runs independent chat requests with bounded parallelism, each within its own time
+// limit. A failing request is reported in its result instead of failing the whole batch; only a
+// client going away does. Batches wait for generation slots in the background class unless the
+// client chose another.
+func (s *LlamaService) ChatBatch(ctx context.Context, request models.BatchChatRequest) (*models.BatchChatResponse, error) {
+	ctx = withDefaultPriority(ctx, models.PriorityBackground)
+	workers := s.config.BatchWorkers
+	if workers < 1 {
+		workers = 1
+	}
+	timeout := time.Duration(s.config.BatchItemTimeout) * time.Second
+	if request.ItemTimeoutSeconds > 0 {
+		timeout = time.Duration(request.ItemTimeoutSeconds) * time.Second
+	}
+
+	results := make([]models.BatchChatResult, len(request.Requests))
+	semaphore := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, item := range request.Requests {
+		// Reported if the request panics before filling in the result
+		results[i] = models.BatchChatResult{Error: "request did not complete"}
+
+		wg.Add(1)
+		Go("chat_batch", func(context.Context) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			itemCtx := ctx
+			if timeout > 0 {
+				var cancel context.CancelFunc
+				itemCtx, cancel = context.WithTimeout(ctx, timeout)
+				defer cancel()
+			}
+
+			start := time.Now()
+			response, err := s.chat(itemCtx, EndpointChat, item)
+
+			result := models.BatchChatResult{Response: response, LatencyMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Error = err.Error()
+				if ctx.Err() == nil && errors.Is(itemCtx.Err(), context.DeadlineExceeded) {
+					result.Error = fmt.Sprintf("request exceeded the %s item timeout", timeout)
+				}
+			}
+			results[i] = result
+		})
+	}
+	wg.Wait()
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return nil, ctx.Err()
+	}
+
+	response := &models.BatchChatResponse{
+		ID:      s.GenerateID(),
+		Object:  "chat.batch",
+		Created: s.Now().Unix(),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Response == nil {
+			response.Failed++
+			continue
+		}
+		response.Succeeded++
+		response.Usage.PromptTokens += result.Response.Usage.PromptTokens
+		response.Usage.CompletionTokens += result.Response.Usage.CompletionTokens
+		response.Usage.TotalTokens += result.Response.Usage.TotalTokens
+	}
+	return response, nil
+}
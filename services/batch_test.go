@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChatBatch(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+
+		var body struct {
+			Model    string           `json:"model"`
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Model == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("not json"))
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           map[string]interface{}{"role": "assistant", "content": "re: " + body.Messages[0].Content},
+			"prompt_eval_count": 4.0,
+			"eval_count":        6.0,
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.BatchWorkers = 2
+
+	chat := func(model, content string) models.ChatRequest {
+		return models.ChatRequest{Model: model, Messages: []models.Message{{Role: "user", Content: content}}}
+	}
+	response, err := service.ChatBatch(context.Background(), models.BatchChatRequest{
+		Requests: []models.ChatRequest{
+			chat("llama2", "one"),
+			chat("broken", "two"),
+			chat("llama2", "three"),
+			chat("llama2", "four"),
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "chat.batch", response.Object)
+	assert.Len(t, response.Results, 4)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	assert.Equal(t, "re: one", response.Results[0].Response.Choices[0].Message.Content)
+	assert.Nil(t, response.Results[1].Response)
+	assert.NotEmpty(t, response.Results[1].Error)
+	assert.Equal(t, "re: three", response.Results[2].Response.Choices[0].Message.Content)
+	assert.Equal(t, 3, response.Succeeded)
+	assert.Equal(t, 1, response.Failed)
+	assert.Equal(t, models.Usage{PromptTokens: 12, CompletionTokens: 18, TotalTokens: 30}, response.Usage)
+}
+
+func TestChatBatch_ItemTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] == "slow" {
+			<-r.Context().Done()
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "fast"},
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.BatchItemTimeout = 60
+
+	// The batch's timeout overrides the configured one
+	response, err := service.ChatBatch(context.Background(), models.BatchChatRequest{
+		Requests: []models.ChatRequest{
+			{Model: "slow", Messages: []models.Message{{Role: "user", Content: "Hi"}}},
+			{Model: "llama2", Messages: []models.Message{{Role: "user", Content: "Hi"}}},
+		},
+		ItemTimeoutSeconds: 1,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "request exceeded the 1s item timeout", response.Results[0].Error)
+	assert.Equal(t, "fast", response.Results[1].Response.Choices[0].Message.Content)
+}
+
+func TestChatBatch_ClientCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewLlamaService().ChatBatch(ctx, models.BatchChatRequest{
+		Requests: []models.ChatRequest{{Messages: []models.Message{{Role: "user", Content: "Hi"}}}},
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
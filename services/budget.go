@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Stages of the generation pipeline a request budget is spent in
+const (
+	StageSemanticCache = "semantic_cache"
+	StageContextWindow = "context_window"
+	StageQueue         = "queue"
+	StageGeneration    = "generation"
+)
+
+// requestBudgetKey is the context key of the budget set by WithRequestBudget
+type requestBudgetKey struct{}
+
+// requestBudget follows a request with a client-set time budget through the pipeline stages
+type requestBudget struct {
+	mu       sync.Mutex
+	budget   time.Duration
+	deadline time.Time
+	stage    string
+	entered  time.Time
+	spent    map[string]time.Duration
+}
+
+// WithRequestBudget marks the deadline of ctx as a time budget set by the client, so running out of
+// it is reported as a RequestBudgetError naming the stage that was running
+func WithRequestBudget(ctx context.Context, budget time.Duration) context.Context {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(budget)
+	}
+	return context.WithValue(ctx, requestBudgetKey{}, &requestBudget{
+		budget:   budget,
+		deadline: deadline,
+		spent:    map[string]time.Duration{},
+	})
+}
+
+// enterStage records that the request of ctx moved on to stage. Once the budget has run out the
+// stage is kept, so it names the stage that used up the budget.
+func enterStage(ctx context.Context, stage string) {
+	b, ok := ctx.Value(requestBudgetKey{}).(*requestBudget)
+	if !ok {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	if !now.Before(b.deadline) {
+		return
+	}
+	if b.stage != "" {
+		b.spent[b.stage] += now.Sub(b.entered)
+	}
+	b.stage, b.entered = stage, now
+}
+
+// budgetError returns a RequestBudgetError when the client-set budget of ctx has run out, or nil
+func budgetError(ctx context.Context, model, partial string) error {
+	b, ok := ctx.Value(requestBudgetKey{}).(*requestBudget)
+	if !ok || time.Now().Before(b.deadline) {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	spent := make(map[string]time.Duration, len(b.spent)+1)
+	for stage, d := range b.spent {
+		spent[stage] = d
+	}
+	if b.stage != "" {
+		spent[b.stage] += b.deadline.Sub(b.entered)
+	}
+	return &RequestBudgetError{Model: model, Budget: b.budget, Stage: b.stage, Spent: spent, Partial: partial}
+}
@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func budgetContext(t *testing.T, budget time.Duration) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), budget)
+	t.Cleanup(cancel)
+	return WithRequestBudget(ctx, budget)
+}
+
+func TestRequestBudget_Generation(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Thinking"},"done":false}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.CoalesceRequests = false
+
+	_, err := service.Chat(budgetContext(t, 100*time.Millisecond), models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	var budgetErr *RequestBudgetError
+	assert.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, StageGeneration, budgetErr.Stage)
+	assert.Equal(t, 100*time.Millisecond, budgetErr.Budget)
+	assert.Equal(t, "Thinking", budgetErr.Partial)
+	assert.Greater(t, budgetErr.Spent[StageGeneration], 50*time.Millisecond)
+}
+
+func TestRequestBudget_Queue(t *testing.T) {
+	service := NewLlamaService()
+	service.queue = newRequestQueue(1, 0, 1, time.Second)
+
+	release, err := service.queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	defer release()
+
+	_, err = service.Completion(budgetContext(t, 50*time.Millisecond), models.CompletionRequest{Model: "llama2", Prompt: "Hi"})
+
+	var budgetErr *RequestBudgetError
+	assert.ErrorAs(t, err, &budgetErr)
+	assert.Equal(t, StageQueue, budgetErr.Stage)
+	assert.NotContains(t, budgetErr.Spent, StageGeneration)
+}
+
+func TestRequestBudget_StageKeptAfterDeadline(t *testing.T) {
+	ctx := budgetContext(t, 20*time.Millisecond)
+	enterStage(ctx, StageSemanticCache)
+	assert.NoError(t, budgetError(ctx, "llama2", ""))
+
+	<-ctx.Done()
+	enterStage(ctx, StageQueue)
+
+	var budgetErr *RequestBudgetError
+	assert.ErrorAs(t, budgetError(ctx, "llama2", ""), &budgetErr)
+	assert.Equal(t, StageSemanticCache, budgetErr.Stage)
+}
+
+func TestRequestBudget_ServerTimeoutIsNotABudgetError(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+
+	var timeoutErr *GenerationTimeoutError
+	assert.ErrorAs(t, contextError(ctx, "llama2", time.Millisecond, ""), &timeoutErr)
+}
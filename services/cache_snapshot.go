@@ -0,0 +1,166 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// cacheSnapshot is the on-disk form of the semantic cache
+type cacheSnapshot struct {
+	Model   string               `json:"model"` // Embedding model the vectors came from
+	Entries []cacheSnapshotEntry `json:"entries"`
+}
+
+type cacheSnapshotEntry struct {
+	Scope    string              `json:"scope"`
+	Vector   []float64           `json:"vector"`
+	Response models.ChatResponse `json:"response"`
+	Expires  time.Time           `json:"expires"`
+}
+
+// compact drops expired entries. It reports whether the cache changed since the last snapshot.
+func (c *semanticCache) compact(now time.Time) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = slices.DeleteFunc(c.entries, func(entry semanticCacheEntry) bool {
+		if now.After(entry.expires) {
+			c.dirty = true
+			return true
+		}
+		return false
+	})
+	return c.dirty
+}
+
+// save writes the cache to path, replacing the previous snapshot atomically
+func (c *semanticCache) save(path string) (err error) {
+	c.mu.Lock()
+	snapshot := cacheSnapshot{Model: c.model, Entries: make([]cacheSnapshotEntry, len(c.entries))}
+	for i, entry := range c.entries {
+		snapshot.Entries[i] = cacheSnapshotEntry{
+			Scope:    entry.scope,
+			Vector:   entry.vector,
+			Response: entry.response,
+			Expires:  entry.expires,
+		}
+	}
+	c.dirty = false
+	c.mu.Unlock()
+
+	defer func() {
+		if err != nil {
+			c.mu.Lock()
+			c.dirty = true
+			c.mu.Unlock()
+		}
+	}()
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cache snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cache snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cache snapshot: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// load restores a snapshot written by save. Expired entries are dropped, and so is the whole
+// snapshot when it was taken with another embedding model, since its vectors are not comparable.
+func (c *semanticCache) load(path string, now time.Time) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read cache snapshot: %w", err)
+	}
+
+	var snapshot cacheSnapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return 0, fmt.Errorf("invalid cache snapshot: %w", err)
+	}
+	if snapshot.Model != c.model {
+		return 0, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = c.entries[:0]
+	for _, entry := range snapshot.Entries {
+		if now.After(entry.Expires) {
+			continue
+		}
+		c.entries = append(c.entries, semanticCacheEntry{
+			scope:    entry.Scope,
+			vector:   entry.Vector,
+			response: entry.Response,
+			expires:  entry.Expires,
+		})
+	}
+	if len(c.entries) > c.limit {
+		c.entries = slices.Delete(c.entries, 0, len(c.entries)-c.limit)
+	}
+	return len(c.entries), nil
+}
+
+// RestoreSemanticCache loads the semantic cache from LLAMA_SEMANTIC_CACHE_FILE, then keeps the file
+// up to date: every LLAMA_SEMANTIC_CACHE_SNAPSHOT_INTERVAL seconds expired answers are dropped and
+// the cache is written out when it changed. It does nothing when the cache is disabled or kept in
+// memory only, and otherwise runs until ctx is cancelled, saving a last snapshot before returning.
+func (s *LlamaService) RestoreSemanticCache(ctx context.Context) {
+	path := s.config.SemanticCacheFile
+	if !s.cache.enabled() || path == "" {
+		return
+	}
+
+	restored, err := s.cache.load(path, s.Now())
+	if err != nil {
+		slog.Info("Starting with an empty semantic cache", "error", err)
+	} else {
+		slog.Info("Restored semantic cache", "entries", restored, "path", path)
+	}
+
+	ticker := time.NewTicker(time.Duration(max(s.config.SemanticCacheSnapshot, 1)) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			s.snapshotSemanticCache(path)
+			return
+		}
+		s.snapshotSemanticCache(path)
+	}
+}
+
+// snapshotSemanticCache drops expired answers and writes the cache to path if it changed
+func (s *LlamaService) snapshotSemanticCache(path string) {
+	if !s.cache.compact(s.Now()) {
+		return
+	}
+	if err := s.cache.save(path); err != nil {
+		slog.Error("Semantic cache snapshot failed", "error", err)
+	}
+}
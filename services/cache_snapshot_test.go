@@ -0,0 +1,73 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemanticCache_SnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "semantic-cache.json")
+	now := time.Now()
+
+	cache := newSemanticCache("nomic-embed-text", 95, 10, time.Hour)
+	cache.store(&semanticKey{scope: "a", vector: []float64{1, 0}}, &models.ChatResponse{
+		Model:   "llama2",
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: "Paris"}}},
+	}, now)
+	cache.store(&semanticKey{scope: "b", vector: []float64{0, 1}}, &models.ChatResponse{}, now.Add(-2*time.Hour))
+	assert.NoError(t, cache.save(path))
+	assert.False(t, cache.dirty)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	restored := newSemanticCache("nomic-embed-text", 95, 10, time.Hour)
+	count, err := restored.load(path, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count, "expired entry dropped on load")
+
+	response, _ := restored.lookup(&semanticKey{scope: "a", vector: []float64{1, 0}}, now)
+	if assert.NotNil(t, response) {
+		assert.Equal(t, "Paris", response.Choices[0].Message.Content)
+	}
+}
+
+func TestSemanticCache_LoadSkipsOtherModelAndMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "semantic-cache.json")
+	now := time.Now()
+
+	count, err := newSemanticCache("nomic-embed-text", 95, 10, time.Hour).load(path, now)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+
+	cache := newSemanticCache("nomic-embed-text", 95, 10, time.Hour)
+	cache.store(&semanticKey{scope: "a", vector: []float64{1, 0}}, &models.ChatResponse{}, now)
+	assert.NoError(t, cache.save(path))
+
+	count, err = newSemanticCache("mxbai-embed-large", 95, 10, time.Hour).load(path, now)
+	assert.NoError(t, err)
+	assert.Zero(t, count, "vectors of another embedding model are not comparable")
+
+	assert.NoError(t, os.WriteFile(path, []byte("not json"), 0o600))
+	_, err = newSemanticCache("nomic-embed-text", 95, 10, time.Hour).load(path, now)
+	assert.Error(t, err)
+}
+
+func TestSemanticCache_Compact(t *testing.T) {
+	now := time.Now()
+	cache := newSemanticCache("nomic-embed-text", 95, 10, time.Minute)
+	assert.False(t, cache.compact(now))
+
+	cache.store(&semanticKey{scope: "a", vector: []float64{1, 0}}, &models.ChatResponse{}, now)
+	cache.dirty = false
+	assert.False(t, cache.compact(now))
+	assert.True(t, cache.compact(now.Add(2*time.Minute)))
+	assert.Empty(t, cache.entries)
+}
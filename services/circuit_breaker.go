@@ -0,0 +1,194 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// Circuit states reported in upstream status
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half_open"
+)
+
+// breakerWindow is how many recent requests the error rate and latency are computed over
+const breakerWindow = 100
+
+// circuitBreaker stops sending requests to an upstream after threshold consecutive failures.
+// Once cooldown has passed a single trial request is let through: success closes the circuit,
+// failure opens it again. It also keeps the outcome of recent requests for status reports.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	state               string
+	consecutiveFailures int
+	openedAt            time.Time
+	probing             bool // A half-open trial request is in flight
+
+	outcomes      []requestOutcome
+	next          int
+	lastFailure   string
+	lastFailureAt time.Time
+}
+
+type requestOutcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+// newCircuitBreaker creates a closed circuit that reads the time for cooldowns and failure times from now
+func newCircuitBreaker(threshold int, cooldown time.Duration, now func() time.Time) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       now,
+		state:     CircuitClosed,
+	}
+}
+
+// allow reports whether a request may be sent to the upstream
+func (b *circuitBreaker) allow() bool {
+	if b.threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return true
+	case CircuitHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record stores the outcome of a request; failure is empty when the request succeeded
+func (b *circuitBreaker) record(latency time.Duration, failure string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	outcome := requestOutcome{failed: failure != "", latency: latency}
+	if len(b.outcomes) < breakerWindow {
+		b.outcomes = append(b.outcomes, outcome)
+	} else {
+		b.outcomes[b.next] = outcome
+		b.next = (b.next + 1) % breakerWindow
+	}
+
+	b.probing = false
+	if failure == "" {
+		b.consecutiveFailures = 0
+		b.state = CircuitClosed
+		return
+	}
+
+	b.consecutiveFailures++
+	b.lastFailure = failure
+	b.lastFailureAt = b.now()
+	if b.threshold > 0 && (b.state == CircuitHalfOpen || b.consecutiveFailures >= b.threshold) {
+		b.state = CircuitOpen
+		b.openedAt = b.lastFailureAt
+	}
+}
+
+// abandon releases a trial request that ended without an outcome, such as a cancelled request
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// reset closes the circuit; the recent request history is kept
+func (b *circuitBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.state = CircuitClosed
+	b.consecutiveFailures = 0
+	b.probing = false
+}
+
+func (b *circuitBreaker) status(name, url string) models.UpstreamStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := models.UpstreamStatus{
+		Name:                name,
+		URL:                 url,
+		State:               b.state,
+		ConsecutiveFailures: b.consecutiveFailures,
+		RecentRequests:      len(b.outcomes),
+		LastFailure:         b.lastFailure,
+	}
+
+	var failed int
+	var latency time.Duration
+	for _, outcome := range b.outcomes {
+		if outcome.failed {
+			failed++
+		}
+		latency += outcome.latency
+	}
+	if len(b.outcomes) > 0 {
+		status.ErrorRate = float64(failed) / float64(len(b.outcomes))
+		status.AverageLatencyMs = float64(latency.Milliseconds()) / float64(len(b.outcomes))
+	}
+	if !b.lastFailureAt.IsZero() {
+		lastFailureAt := b.lastFailureAt
+		status.LastFailureAt = &lastFailureAt
+	}
+	if b.state != CircuitClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// Upstreams reports the circuit state and recent health of each Ollama backend
+func (s *LlamaService) Upstreams() []models.UpstreamStatus {
+	statuses := make([]models.UpstreamStatus, 0, len(s.breakers))
+	for name, breaker := range s.breakers {
+		statuses = append(statuses, breaker.status(name, s.upstreamURL(name)))
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name > statuses[j].Name // local before cloud
+	})
+	return statuses
+}
+
+// ResetUpstream closes the circuit of a backend so requests are sent to it again
+func (s *LlamaService) ResetUpstream(name string) error {
+	breaker, ok := s.breakers[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUpstreamNotFound, name)
+	}
+	breaker.reset()
+	return nil
+}
+
+func (s *LlamaService) upstreamURL(name string) string {
+	if name == BackendCloud {
+		return s.config.CloudAPIURL
+	}
+	return s.config.BaseURL
+}
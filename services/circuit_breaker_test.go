@@ -0,0 +1,115 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock is a clock tests move forward by hand
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	breaker := newCircuitBreaker(2, time.Minute, clock.Now)
+
+	assert.True(t, breaker.allow())
+	breaker.record(10*time.Millisecond, "status 503")
+	assert.True(t, breaker.allow())
+	breaker.record(30*time.Millisecond, "status 503")
+
+	// Two consecutive failures open the circuit
+	assert.False(t, breaker.allow())
+	status := breaker.status(BackendLocal, "http://localhost:11434")
+	assert.Equal(t, CircuitOpen, status.State)
+	assert.Equal(t, 1.0, status.ErrorRate)
+	assert.Equal(t, 20.0, status.AverageLatencyMs)
+	assert.Equal(t, "status 503", status.LastFailure)
+	assert.Equal(t, clock.now, *status.OpenedAt)
+
+	// After the cooldown a single trial request is allowed
+	clock.now = clock.now.Add(59 * time.Second)
+	assert.False(t, breaker.allow())
+	clock.now = clock.now.Add(time.Second)
+	assert.True(t, breaker.allow())
+	assert.False(t, breaker.allow())
+	assert.Equal(t, CircuitHalfOpen, breaker.status(BackendLocal, "").State)
+
+	breaker.record(10*time.Millisecond, "")
+	assert.Equal(t, CircuitClosed, breaker.status(BackendLocal, "").State)
+	assert.True(t, breaker.allow())
+}
+
+func TestCircuitBreaker_FailedTrialReopens(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	breaker := newCircuitBreaker(1, time.Minute, clock.Now)
+	breaker.record(0, "connection refused")
+
+	clock.now = clock.now.Add(time.Minute)
+	assert.True(t, breaker.allow())
+	breaker.record(0, "connection refused")
+
+	assert.False(t, breaker.allow())
+	breaker.reset()
+	assert.True(t, breaker.allow())
+}
+
+func TestCircuitBreaker_Disabled(t *testing.T) {
+	breaker := newCircuitBreaker(0, time.Minute, time.Now)
+	for i := 0; i < 10; i++ {
+		breaker.record(0, "status 500")
+	}
+
+	assert.True(t, breaker.allow())
+	assert.Equal(t, CircuitClosed, breaker.status(BackendLocal, "").State)
+}
+
+func TestChat_OpenCircuitSkipsUpstream(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/chat" {
+			atomic.AddInt32(&calls, 1)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.breakers[BackendLocal] = newCircuitBreaker(2, time.Minute, service.Now)
+
+	request := models.ChatRequest{Model: "llama3.2", Messages: []models.Message{{Role: "user", Content: "hi"}}}
+	for i := 0; i < 3; i++ {
+		_, err := service.Chat(context.Background(), request)
+		assert.Error(t, err)
+	}
+
+	_, err := service.Chat(context.Background(), request)
+	var upstreamErr *UpstreamError
+	assert.ErrorAs(t, err, &upstreamErr)
+	assert.Equal(t, BackendLocal, upstreamErr.Backend)
+	assert.Contains(t, upstreamErr.Message, ErrCircuitOpen.Error())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	upstreams := service.Upstreams()
+	assert.Equal(t, BackendLocal, upstreams[0].Name)
+	assert.Equal(t, CircuitOpen, upstreams[0].State)
+
+	assert.NoError(t, service.ResetUpstream(BackendLocal))
+	assert.ErrorIs(t, service.ResetUpstream("remote"), ErrUpstreamNotFound)
+	assert.Equal(t, CircuitClosed, service.Upstreams()[0].State)
+}
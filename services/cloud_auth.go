@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// SignIn authenticates with Ollama Cloud using an API key created at https://ollama.com/settings/keys.
+// The key is verified against the cloud API before it is stored, and persisted to the configured
+// token file so the sign-in survives restarts. Ollama API keys do not expire, so there is nothing to refresh;
+// a key revoked on ollama.com signs the service out the next time the cloud rejects it.
+func (s *LlamaService) SignIn(ctx context.Context, request models.AuthRequest) (*models.AuthResponse, error) {
+	if !s.config.CloudEnabled {
+		return &models.AuthResponse{
+			Success: true,
+			Message: "Cloud mode is not enabled",
+		}, nil
+	}
+
+	token := strings.TrimSpace(request.Token)
+	if token == "" {
+		return &models.AuthResponse{
+			Success: false,
+			Message: "Ollama Cloud signs in with an API key; create one at https://ollama.com/settings/keys and pass it as token",
+		}, nil
+	}
+
+	resp, err := s.cloudRequest(ctx, "/api/tags", token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify API key: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return &models.AuthResponse{
+			Success: false,
+			Message: "Invalid credentials",
+		}, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("ollama cloud returned status %d", resp.StatusCode)
+	}
+
+	if err := s.saveCloudToken(token); err != nil {
+		return nil, fmt.Errorf("failed to persist token: %w", err)
+	}
+	s.setCloudToken(token)
+	s.isSignedIn.Store(true)
+
+	return &models.AuthResponse{
+		Success: true,
+		Message: "Successfully signed in to Ollama cloud",
+	}, nil
+}
+
+// SignOut signs out from Ollama cloud and removes the persisted token
+func (s *LlamaService) SignOut() error {
+	s.isSignedIn.Store(false)
+	s.setCloudToken("")
+
+	if s.config.CloudTokenFile != "" {
+		if err := os.Remove(s.config.CloudTokenFile); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove token file: %w", err)
+		}
+	}
+	return nil
+}
+
+// ListCloudModels returns the live Ollama Cloud catalog. When the service is not signed in or the
+// catalog cannot be fetched or read, the built-in CloudModels list is returned instead.
+func (s *LlamaService) ListCloudModels(ctx context.Context) ([]models.CloudModel, error) {
+	token := s.cloudToken()
+	if !s.config.CloudEnabled || !s.isSignedIn.Load() || token == "" {
+		return CloudModels, nil
+	}
+
+	resp, err := s.cloudRequest(ctx, "/api/tags", token)
+	if err != nil {
+		logger(ctx).Warn("Failed to fetch cloud catalog, using built-in list", "error", err)
+		return CloudModels, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusUnauthorized {
+		logger(ctx).Warn("Ollama Cloud rejected the stored API key, signing out")
+		if err := s.SignOut(); err != nil {
+			logger(ctx).Error("Failed to sign out", "error", err)
+		}
+		return CloudModels, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger(ctx).Warn("Failed to fetch cloud catalog, using built-in list", "status", resp.StatusCode)
+		return CloudModels, nil
+	}
+
+	var catalog struct {
+		Models []struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&catalog); err != nil {
+		logger(ctx).Warn("Failed to decode cloud catalog, using built-in list", "error", err)
+		return CloudModels, nil
+	}
+
+	cloudModels := make([]models.CloudModel, 0, len(catalog.Models))
+	for _, model := range catalog.Models {
+		cloudModels = append(cloudModels, models.CloudModel{
+			Name:      model.Name,
+			ID:        model.Name,
+			Size:      formatBytes(model.Size),
+			Available: true,
+		})
+	}
+	return cloudModels, nil
+}
+
+// cloudRequest issues an authenticated GET against the Ollama Cloud API with token
+func (s *LlamaService) cloudRequest(ctx context.Context, endpoint, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.config.CloudAPIURL+endpoint, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	forwardRequestID(ctx, req.Header)
+
+	return s.httpClient.Do(req)
+}
+
+func (s *LlamaService) cloudToken() string {
+	s.authMu.RLock()
+	defer s.authMu.RUnlock()
+	return s.config.CloudAPIKey
+}
+
+func (s *LlamaService) setCloudToken(token string) {
+	s.authMu.Lock()
+	defer s.authMu.Unlock()
+	s.config.CloudAPIKey = token
+}
+
+// loadCloudToken reads a token persisted by an earlier sign-in
+func (s *LlamaService) loadCloudToken() string {
+	if s.config.CloudTokenFile == "" {
+		return ""
+	}
+	data, err := os.ReadFile(s.config.CloudTokenFile)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			slog.Warn("Failed to read cloud token file", "error", err)
+		}
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// saveCloudToken writes token to the token file, readable by the current user only
+func (s *LlamaService) saveCloudToken(token string) error {
+	if s.config.CloudTokenFile == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(s.config.CloudTokenFile), 0o700); err != nil {
+		return err
+	}
+	return os.WriteFile(s.config.CloudTokenFile, []byte(token), 0o600)
+}
+
+// formatBytes renders a model size such as 4.7GB
+func formatBytes(size int64) string {
+	const unit = 1000
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+	value, exp := float64(size), 0
+	for value >= unit && exp < 4 {
+		value /= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", value, "KMGT"[exp-1])
+}
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCatalogServer(t *testing.T, validKey string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		if r.Header.Get("Authorization") != "Bearer "+validKey {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"models": []map[string]interface{}{
+				{"name": "gpt-oss:120b-cloud", "size": 65000000000},
+				{"name": "kimi-k2:1t-cloud", "size": 1000},
+			},
+		})
+	}))
+}
+
+func newCloudService(cloudURL, tokenFile string) *LlamaService {
+	service := NewLlamaService()
+	service.config.CloudEnabled = true
+	service.config.CloudAPIURL = cloudURL
+	service.config.CloudAPIKey = ""
+	service.config.CloudTokenFile = tokenFile
+	service.isSignedIn.Store(false)
+	return service
+}
+
+func TestSignIn_VerifiesAndPersistsKey(t *testing.T) {
+	server := newCatalogServer(t, "good-key")
+	defer server.Close()
+
+	tokenFile := filepath.Join(t.TempDir(), "auth", "cloud_token")
+	service := newCloudService(server.URL, tokenFile)
+
+	response, err := service.SignIn(context.Background(), models.AuthRequest{Token: "good-key"})
+
+	assert.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.True(t, service.isSignedIn.Load())
+	assert.Equal(t, "good-key", service.cloudToken())
+
+	info, err := os.Stat(tokenFile)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	assert.Equal(t, "good-key", service.loadCloudToken())
+
+	// Signing out forgets the key and removes the file
+	assert.NoError(t, service.SignOut())
+	assert.Empty(t, service.cloudToken())
+	assert.NoFileExists(t, tokenFile)
+}
+
+func TestSignIn_RejectsInvalidKey(t *testing.T) {
+	server := newCatalogServer(t, "good-key")
+	defer server.Close()
+
+	service := newCloudService(server.URL, "")
+
+	response, err := service.SignIn(context.Background(), models.AuthRequest{Token: "bad-key"})
+
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.False(t, service.isSignedIn.Load())
+}
+
+func TestListCloudModels_LiveCatalog(t *testing.T) {
+	server := newCatalogServer(t, "good-key")
+	defer server.Close()
+
+	service := newCloudService(server.URL, "")
+	service.setCloudToken("good-key")
+	service.isSignedIn.Store(true)
+
+	cloudModels, err := service.ListCloudModels(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.CloudModel{
+		{Name: "gpt-oss:120b-cloud", ID: "gpt-oss:120b-cloud", Size: "65.0GB", Available: true},
+		{Name: "kimi-k2:1t-cloud", ID: "kimi-k2:1t-cloud", Size: "1.0KB", Available: true},
+	}, cloudModels)
+}
+
+func TestListCloudModels_UndecodableCatalog(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<html>maintenance</html>"))
+	}))
+	defer server.Close()
+
+	service := newCloudService(server.URL, "")
+	service.setCloudToken("good-key")
+	service.isSignedIn.Store(true)
+
+	cloudModels, err := service.ListCloudModels(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, CloudModels, cloudModels)
+}
+
+func TestListModels_CloudCatalog(t *testing.T) {
+	server := newCatalogServer(t, "good-key")
+	defer server.Close()
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"models":[{"name":"llama3.2"}]}`))
+	}))
+	defer local.Close()
+
+	service := newCloudService(server.URL, "")
+	service.config.BaseURL = local.URL
+	service.setCloudToken("good-key")
+	service.isSignedIn.Store(true)
+
+	modelList, err := service.ListModels()
+
+	assert.NoError(t, err)
+	cloud := map[string]bool{}
+	for _, model := range modelList {
+		cloud[model.ID] = model.IsCloud
+	}
+	assert.Equal(t, map[string]bool{"llama3.2": false, "gpt-oss:120b-cloud": true, "kimi-k2:1t-cloud": true}, cloud)
+}
+
+func TestListCloudModels_RevokedKeySignsOut(t *testing.T) {
+	server := newCatalogServer(t, "good-key")
+	defer server.Close()
+
+	service := newCloudService(server.URL, "")
+	service.setCloudToken("revoked-key")
+	service.isSignedIn.Store(true)
+
+	cloudModels, err := service.ListCloudModels(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, CloudModels, cloudModels)
+	assert.False(t, service.isSignedIn.Load())
+}
+
+func TestListCloudModels_NotSignedIn(t *testing.T) {
+	service := newCloudService("http://cloud.invalid", "")
+
+	cloudModels, err := service.ListCloudModels(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, CloudModels, cloudModels)
+}
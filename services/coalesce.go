@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescer shares one generation between identical requests in flight at the same time.
+// The generation runs detached from the caller that started it, so one client disconnecting does
+// not fail the others; it is cancelled once every caller waiting for it has gone. Its context
+// carries only the starting caller's request ID and priority class, none of its other values.
+type coalescer struct {
+	group   singleflight.Group
+	mu      sync.Mutex
+	flights map[string]*flight
+}
+
+// flight is the context shared by the callers of one coalesced generation
+type flight struct {
+	ctx     context.Context
+	cancel  context.CancelFunc
+	waiters int
+}
+
+func newCoalescer() *coalescer {
+	return &coalescer{flights: make(map[string]*flight)}
+}
+
+// do runs generate once for all callers asking for key and returns the shared response, or
+// ctx's error when ctx ends first. shared reports whether other callers got the same response.
+func (c *coalescer) do(ctx context.Context, key string, generate func(context.Context) (*models.ChatResponse, error)) (response *models.ChatResponse, shared bool, err error) {
+	c.mu.Lock()
+	f, ok := c.flights[key]
+	if !ok {
+		flightCtx, cancel := context.WithCancel(flightContext(ctx))
+		f = &flight{ctx: flightCtx, cancel: cancel}
+		c.flights[key] = f
+	}
+	f.waiters++
+	c.mu.Unlock()
+
+	// singleflight re-panics where nothing can recover, so a panic becomes the flight's error
+	result := c.group.DoChan(key, func() (response interface{}, err error) {
+		defer background.recoverPanic("coalesced generation", &err)
+		return generate(f.ctx)
+	})
+	select {
+	case <-ctx.Done():
+		c.leave(key, f)
+		return nil, false, ctx.Err()
+	case r := <-result:
+		c.leave(key, f)
+		if r.Err != nil {
+			return nil, r.Shared, r.Err
+		}
+		return r.Val.(*models.ChatResponse), r.Shared, nil
+	}
+}
+
+// flightKey is the context key marking the context of a coalesced generation
+type flightKey struct{}
+
+// flightContext returns the base context of a generation started by the caller of ctx
+func flightContext(ctx context.Context) context.Context {
+	flightCtx := context.WithValue(context.Background(), flightKey{}, true)
+	if id := requestID(ctx); id != "" {
+		flightCtx = WithRequestID(flightCtx, id)
+	}
+	return WithPriority(flightCtx, RequestPriority(ctx))
+}
+
+// inFlight reports whether ctx is the context of a coalesced generation
+func inFlight(ctx context.Context) bool {
+	return ctx.Value(flightKey{}) != nil
+}
+
+// leave removes a caller from f and cancels the generation when it was the last one waiting
+func (c *coalescer) leave(key string, f *flight) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+	f.cancel()
+	if c.flights[key] == f {
+		delete(c.flights, key)
+		// Callers arriving after this start a new generation instead of joining the cancelled one
+		c.group.Forget(key)
+	}
+}
+
+// coalesce runs generate once for identical requests in flight at the same time and hands every
+// caller its own copy of the response. Each caller is charged the response's usage against its own
+// token quota and usage records, with the time it waited as latency; cached answers are free.
+func (s *LlamaService) coalesce(ctx context.Context, endpoint, model string, request models.ChatRequest, generate func(context.Context) (*models.ChatResponse, error)) (*models.ChatResponse, error) {
+	key, err := requestKey(endpoint, model, request)
+	if err != nil {
+		logger(ctx).Warn("Not coalescing request", "endpoint", endpoint, "error", err)
+		return generate(ctx)
+	}
+
+	// Callers joining a generation already running spend their budget waiting for it
+	enterStage(ctx, StageGeneration)
+	start := time.Now()
+	shared, coalesced, err := s.inflight.do(ctx, key, generate)
+	if err != nil {
+		if budgetErr := budgetError(ctx, model, ""); budgetErr != nil {
+			return nil, budgetErr
+		}
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return nil, &CancelledError{Model: model}
+		}
+		return nil, err
+	}
+	if !shared.CacheHit {
+		s.chargeUsage(ctx, shared.Backend, shared.Model, shared.Usage, time.Since(start))
+	}
+	// After-hooks edit the response, so each caller gets its own copy
+	response := copyChatResponse(shared)
+	response.Coalesced = coalesced
+	return response, nil
+}
+
+// requestKey identifies a chat request after presets, hooks and alias resolution were applied
+func requestKey(endpoint, model string, request models.ChatRequest) (string, error) {
+	request.Model = model
+	data, err := json.Marshal(struct {
+		Endpoint string             `json:"endpoint"`
+		Request  models.ChatRequest `json:"request"`
+	}{endpoint, request})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
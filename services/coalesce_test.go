@@ -0,0 +1,232 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// coalescingServer answers chat requests once release is closed and counts them
+func coalescingServer(calls *atomic.Int32, release chan struct{}) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		calls.Add(1)
+		select {
+		case <-release:
+		case <-r.Context().Done():
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Paris"},"done":true,"prompt_eval_count":5,"eval_count":1}`))
+	}))
+}
+
+func waiters(service *LlamaService) int {
+	service.inflight.mu.Lock()
+	defer service.inflight.mu.Unlock()
+	total := 0
+	for _, f := range service.inflight.flights {
+		total += f.waiters
+	}
+	return total
+}
+
+func TestChat_CoalescesIdenticalRequests(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := coalescingServer(&calls, release)
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	request := models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Capital of France?"}},
+	}
+
+	responses := make([]*models.ChatResponse, 3)
+	var wg sync.WaitGroup
+	for i := range responses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			response, err := service.Chat(context.Background(), request)
+			assert.NoError(t, err)
+			responses[i] = response
+		}()
+	}
+	assert.Eventually(t, func() bool { return waiters(service) == 3 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	for _, response := range responses {
+		assert.Equal(t, "Paris", response.Choices[0].Message.Content)
+		assert.True(t, response.Coalesced)
+	}
+	// Every caller gets its own copy of the choices
+	responses[0].Choices[0].Message.Content = "edited"
+	assert.Equal(t, "Paris", responses[1].Choices[0].Message.Content)
+	assert.Empty(t, service.inflight.flights)
+}
+
+func TestChat_CoalescedRequestSurvivesCallerCancel(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := coalescingServer(&calls, release)
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	request := models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Capital of France?"}},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := service.Chat(ctx, request)
+		firstErr <- err
+	}()
+	assert.Eventually(t, func() bool { return calls.Load() == 1 }, time.Second, 5*time.Millisecond)
+
+	secondResponse := make(chan *models.ChatResponse)
+	go func() {
+		response, err := service.Chat(context.Background(), request)
+		assert.NoError(t, err)
+		secondResponse <- response
+	}()
+	assert.Eventually(t, func() bool { return waiters(service) == 2 }, time.Second, 5*time.Millisecond)
+
+	// The caller that started the generation leaves; the other one still gets the answer
+	cancel()
+	assert.ErrorIs(t, <-firstErr, context.Canceled)
+	close(release)
+
+	response := <-secondResponse
+	assert.Equal(t, "Paris", response.Choices[0].Message.Content)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestChat_NoCoalescingWhenDisabled(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	close(release)
+	server := coalescingServer(&calls, release)
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.CoalesceRequests = false
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Capital of France?"}},
+	})
+
+	assert.NoError(t, err)
+	assert.False(t, response.Coalesced)
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestRequestKey(t *testing.T) {
+	request := models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hi"}}}
+	key, err := requestKey(EndpointChat, "llama2", request)
+	assert.NoError(t, err)
+
+	same, _ := requestKey(EndpointChat, "llama2", request)
+	otherModel, _ := requestKey(EndpointChat, "mistral", request)
+	otherEndpoint, _ := requestKey(EndpointRewrite, "llama2", request)
+	temperature := 0.2
+	request.Options = &models.Options{Temperature: &temperature}
+	otherOptions, _ := requestKey(EndpointChat, "llama2", request)
+
+	assert.Equal(t, key, same)
+	assert.NotEqual(t, key, otherModel)
+	assert.NotEqual(t, key, otherEndpoint)
+	assert.NotEqual(t, key, otherOptions)
+}
+
+func TestCoalescer_PanicBecomesError(t *testing.T) {
+	coalescer := newCoalescer()
+
+	_, _, err := coalescer.do(context.Background(), "key", func(context.Context) (*models.ChatResponse, error) {
+		panic("boom")
+	})
+
+	assert.EqualError(t, err, "coalesced generation panicked: boom")
+}
+
+func TestChat_CoalescedCallersPayTheirOwnUsage(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	server := coalescingServer(&calls, release)
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	request := models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Capital of France?"}},
+	}
+
+	var mu sync.Mutex
+	charged := map[string]int{}
+	var wg sync.WaitGroup
+	for _, caller := range []string{"user:alice", "user:bob"} {
+		ctx := WithCaller(context.Background(), caller)
+		ctx = WithTokenRecorder(ctx, func(tokens int) {
+			mu.Lock()
+			defer mu.Unlock()
+			charged[caller] += tokens
+		})
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := service.Chat(ctx, request)
+			assert.NoError(t, err)
+		}()
+	}
+	assert.Eventually(t, func() bool { return waiters(service) == 2 }, time.Second, 5*time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, map[string]int{"user:alice": 6, "user:bob": 6}, charged)
+	list, err := service.UsageRecords(context.Background(), models.UsageQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), list.ByCaller["user:alice"].TotalTokens)
+	assert.Equal(t, int64(6), list.ByCaller["user:bob"].TotalTokens)
+	assert.NotContains(t, list.ByCaller, "")
+	// Ollama did the work once
+	assert.Equal(t, int64(1), service.Usage().Local.Requests)
+}
+
+func TestFlightContext(t *testing.T) {
+	ctx := WithCaller(context.Background(), "user:alice")
+	ctx = WithRequestID(ctx, "req-1")
+	ctx = WithPriority(ctx, models.PriorityBackground)
+	ctx = WithTokenRecorder(ctx, func(int) {})
+
+	flightCtx := flightContext(ctx)
+
+	assert.Equal(t, "req-1", requestID(flightCtx))
+	assert.Equal(t, models.PriorityBackground, RequestPriority(flightCtx))
+	assert.Nil(t, flightCtx.Value(callerKey{}))
+	assert.Nil(t, flightCtx.Value(tokenRecorderKey{}))
+	assert.True(t, inFlight(flightCtx))
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// Compare runs the same prompt against several models with bounded parallelism.
+// A failing model is reported in its result instead of failing the whole comparison.
+func (s *LlamaService) Compare(ctx context.Context, request models.CompareRequest) (*models.CompareResponse, error) {
+	messages := []models.Message{}
+	if request.SystemPrompt != "" {
+		messages = append(messages, models.Message{Role: "system", Content: request.SystemPrompt})
+	}
+	messages = append(messages, models.Message{Role: "user", Content: request.Prompt})
+
+	workers := s.config.CompareWorkers
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]models.CompareResult, len(request.Models))
+	semaphore := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, model := range request.Models {
+		// Reported if the comparison panics before filling in the result
+		results[i] = models.CompareResult{Model: model, Error: "comparison did not complete"}
+
+		wg.Add(1)
+		Go("compare", func(context.Context) {
+			defer wg.Done()
+			semaphore <- struct{}{}
+			defer func() { <-semaphore }()
+
+			start := time.Now()
+			response, err := s.chat(ctx, EndpointCompare, models.ChatRequest{
+				Model:       model,
+				Messages:    messages,
+				Temperature: request.Temperature,
+				MaxTokens:   request.MaxTokens,
+				Options:     request.Options,
+			})
+
+			result := models.CompareResult{
+				Model:     model,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Error, result.Err = err.Error(), err
+			} else {
+				result.Usage = response.Usage
+				if len(response.Choices) > 0 {
+					result.Output = response.Choices[0].Message.Content
+				}
+			}
+			results[i] = result
+		})
+	}
+	wg.Wait()
+
+	return &models.CompareResponse{
+		ID:      s.GenerateID(),
+		Object:  "model.comparison",
+		Created: s.Now().Unix(),
+		Prompt:  request.Prompt,
+		Results: results,
+	}, nil
+}
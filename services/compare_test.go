@@ -0,0 +1,66 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	var inFlight, maxInFlight int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		current := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			seen := atomic.LoadInt32(&maxInFlight)
+			if current <= seen || atomic.CompareAndSwapInt32(&maxInFlight, seen, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] == "broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte("not json"))
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message":           map[string]interface{}{"role": "assistant", "content": "answer from " + body["model"].(string)},
+			"prompt_eval_count": 4.0,
+			"eval_count":        6.0,
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.CompareWorkers = 2
+
+	response, err := service.Compare(context.Background(), models.CompareRequest{
+		Prompt: "What is Go?",
+		Models: []string{"llama2", "phi3:mini", "mistral", "broken"},
+	})
+
+	assert.NoError(t, err)
+	assert.Len(t, response.Results, 4)
+	assert.LessOrEqual(t, atomic.LoadInt32(&maxInFlight), int32(2))
+
+	assert.Equal(t, "llama2", response.Results[0].Model)
+	assert.Equal(t, "answer from llama2", response.Results[0].Output)
+	assert.Equal(t, 10, response.Results[0].Usage.TotalTokens)
+	assert.Equal(t, "answer from phi3:mini", response.Results[1].Output)
+	assert.NotEmpty(t, response.Results[3].Error)
+	var upstreamErr *UpstreamError
+	assert.ErrorAs(t, response.Results[3].Err, &upstreamErr, "the typed error is kept for the handler to map")
+}
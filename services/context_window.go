@@ -0,0 +1,147 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"agent-ollama-gin/models"
+)
+
+const (
+	// charsPerToken approximates how many characters a token covers
+	charsPerToken = 4
+	// messageOverhead approximates the tokens the chat template adds around each message
+	messageOverhead = 4
+)
+
+// fitContext drops the oldest messages of a conversation that would not fit the context window of
+// model, so the request is trimmed predictably instead of being truncated silently by Ollama.
+// System messages and the latest message are always kept. It returns the messages to send and
+// the number of messages dropped.
+func (s *LlamaService) fitContext(ctx context.Context, model string, request models.ChatRequest) ([]models.Message, int) {
+	if !s.config.ContextTrimming || len(request.Messages) < 2 {
+		return request.Messages, 0
+	}
+
+	window := 0
+	if request.Options != nil && request.Options.NumCtx != nil {
+		window = *request.Options.NumCtx
+	} else {
+		enterStage(ctx, StageContextWindow)
+		window = s.contextWindow(ctx, model)
+	}
+
+	reserve := s.config.ContextReserve
+	switch {
+	case request.Options != nil && request.Options.NumPredict != nil && *request.Options.NumPredict > 0:
+		reserve = *request.Options.NumPredict
+	case request.MaxTokens > 0:
+		reserve = request.MaxTokens
+	}
+
+	budget := window - reserve
+	if window <= 0 || budget <= 0 {
+		return request.Messages, 0
+	}
+
+	total := 0
+	for _, message := range request.Messages {
+		total += estimateTokens(message)
+	}
+	if total <= budget {
+		return request.Messages, 0
+	}
+
+	last := len(request.Messages) - 1
+	kept := make([]models.Message, 0, len(request.Messages))
+	dropped := 0
+	for i, message := range request.Messages {
+		if total > budget && i != last && message.Role != "system" {
+			total -= estimateTokens(message)
+			dropped++
+			continue
+		}
+		kept = append(kept, message)
+	}
+
+	if total > budget {
+		logger(ctx).Warn("Conversation does not fit the context window after trimming", "model", model, "tokens", total+reserve, "context_window", window)
+	}
+	return kept, dropped
+}
+
+// estimateTokens roughly counts the tokens a message takes up in the prompt
+func estimateTokens(message models.Message) int {
+	chars := utf8.RuneCountInString(message.Role) + utf8.RuneCountInString(message.Content)
+	return (chars+charsPerToken-1)/charsPerToken + messageOverhead
+}
+
+// contextWindow returns the context length of model as reported by /api/show, preferring a num_ctx
+// parameter set in its Modelfile over the architecture's maximum. Results are cached per model;
+// 0 means the window is unknown.
+func (s *LlamaService) contextWindow(ctx context.Context, model string) int {
+	s.contextMu.Lock()
+	window, ok := s.contexts[model]
+	s.contextMu.Unlock()
+	if ok {
+		return window
+	}
+
+	baseURL, _ := s.backendFor(model)
+	resp, err := s.makeRequest(ctx, "POST", "/api/show", map[string]interface{}{"model": model}, baseURL)
+	if err != nil {
+		logger(ctx).Warn("Failed to read context window", "model", model, "error", err)
+		return 0
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		logger(ctx).Warn("Failed to read context window", "model", model, "status", resp.StatusCode)
+		return 0
+	}
+
+	var show struct {
+		Parameters string                 `json:"parameters"`
+		ModelInfo  map[string]interface{} `json:"model_info"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&show); err != nil {
+		logger(ctx).Warn("Failed to decode model info", "model", model, "error", err)
+		return 0
+	}
+
+	window = parseNumCtx(show.Parameters)
+	if window == 0 {
+		for key, value := range show.ModelInfo {
+			if length, ok := value.(float64); ok && strings.HasSuffix(key, ".context_length") {
+				window = int(length)
+				break
+			}
+		}
+	}
+
+	s.contextMu.Lock()
+	if s.contexts == nil {
+		s.contexts = map[string]int{}
+	}
+	s.contexts[model] = window
+	s.contextMu.Unlock()
+
+	return window
+}
+
+// parseNumCtx reads num_ctx from the parameters block of /api/show, one "name value" pair per line
+func parseNumCtx(parameters string) int {
+	for _, line := range strings.Split(parameters, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "num_ctx" {
+			if value, err := strconv.Atoi(fields[1]); err == nil {
+				return value
+			}
+		}
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNumCtx(t *testing.T) {
+	assert.Equal(t, 8192, parseNumCtx("stop                           \"<|eot_id|>\"\nnum_ctx                        8192"))
+	assert.Equal(t, 0, parseNumCtx("temperature 0.7"))
+	assert.Equal(t, 0, parseNumCtx(""))
+}
+
+func TestContextWindow_FromModelInfo(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/show", r.URL.Path)
+		atomic.AddInt32(&calls, 1)
+		w.Write([]byte(`{"parameters":"temperature 0.7","model_info":{"general.architecture":"llama","llama.context_length":131072}}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	assert.Equal(t, 131072, service.contextWindow(context.Background(), "llama3.2"))
+	assert.Equal(t, 131072, service.contextWindow(context.Background(), "llama3.2"))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls), "window should be cached per model")
+}
+
+func TestChat_TrimsOldestMessages(t *testing.T) {
+	var sent []models.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/show":
+			w.Write([]byte(`{"parameters":"num_ctx 100"}`))
+		case "/api/chat":
+			var body struct {
+				Messages []models.Message `json:"messages"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			sent = body.Messages
+			w.Write([]byte(`{"message":{"role":"assistant","content":"ok"},"done":true}`))
+		}
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.ContextReserve = 20
+
+	// Each filler message is roughly 32 tokens, so the 80 token budget leaves room for only one
+	filler := strings.Repeat("word ", 20)
+	messages := []models.Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "first " + filler},
+		{Role: "assistant", Content: "second " + filler},
+		{Role: "user", Content: "third " + filler},
+		{Role: "user", Content: "latest question"},
+	}
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{Model: "llama3.2", Messages: messages})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, response.TrimmedMessages)
+	assert.Equal(t, []models.Message{messages[0], messages[3], messages[4]}, sent)
+}
+
+func TestFitContext_KeepsShortConversations(t *testing.T) {
+	service := NewLlamaService()
+	numCtx := 4096
+	request := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "hi"}, {Role: "user", Content: "again"}},
+		Options:  &models.Options{NumCtx: &numCtx},
+	}
+
+	messages, trimmed := service.fitContext(context.Background(), "llama3.2", request)
+
+	assert.Equal(t, request.Messages, messages)
+	assert.Zero(t, trimmed)
+}
+
+func TestFitContext_Disabled(t *testing.T) {
+	service := NewLlamaService()
+	service.config.ContextTrimming = false
+	service.config.ContextReserve = 0
+	numCtx := 1
+	request := models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "a long message"}, {Role: "user", Content: "another one"}},
+		Options:  &models.Options{NumCtx: &numCtx},
+	}
+
+	_, trimmed := service.fitContext(context.Background(), "llama3.2", request)
+
+	assert.Zero(t, trimmed)
+}
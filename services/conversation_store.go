@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConversationStore persists conversations between requests
+type ConversationStore interface {
+	// Get returns the conversation with id, or ErrConversationNotFound
+	Get(ctx context.Context, id string) (*models.Conversation, error)
+	// Save creates or replaces a conversation and restarts its expiry
+	Save(ctx context.Context, conversation *models.Conversation) error
+	// Delete removes a conversation, returning ErrConversationNotFound if it does not exist
+	Delete(ctx context.Context, id string) error
+}
+
+// MemoryConversationStore keeps conversations in process memory. Conversations are lost on restart
+// and are not shared between replicas.
+type MemoryConversationStore struct {
+	mu            sync.Mutex
+	ttl           time.Duration
+	now           func() time.Time
+	conversations map[string]memoryConversation
+}
+
+type memoryConversation struct {
+	conversation models.Conversation
+	expires      time.Time
+}
+
+// NewMemoryConversationStore creates a store that forgets conversations ttl after their last update,
+// reading the time from now
+func NewMemoryConversationStore(ttl time.Duration, now func() time.Time) *MemoryConversationStore {
+	return &MemoryConversationStore{
+		ttl:           ttl,
+		now:           now,
+		conversations: map[string]memoryConversation{},
+	}
+}
+
+func (s *MemoryConversationStore) Get(ctx context.Context, id string) (*models.Conversation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.conversations[id]
+	if !ok || s.now().After(entry.expires) {
+		delete(s.conversations, id)
+		return nil, ErrConversationNotFound
+	}
+	return copyConversation(entry.conversation), nil
+}
+
+func (s *MemoryConversationStore) Save(ctx context.Context, conversation *models.Conversation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop expired conversations so abandoned ones do not accumulate
+	now := s.now()
+	for id, entry := range s.conversations {
+		if now.After(entry.expires) {
+			delete(s.conversations, id)
+		}
+	}
+
+	s.conversations[conversation.ID] = memoryConversation{
+		conversation: *copyConversation(*conversation),
+		expires:      now.Add(s.ttl),
+	}
+	return nil
+}
+
+func (s *MemoryConversationStore) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.conversations[id]
+	delete(s.conversations, id)
+	if !ok || s.now().After(entry.expires) {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+// copyConversation returns a copy whose message slice is not shared with the original
+func copyConversation(conversation models.Conversation) *models.Conversation {
+	conversation.Messages = append([]models.Message(nil), conversation.Messages...)
+	return &conversation
+}
+
+// RedisConversationStore keeps conversations in Redis as JSON, so every replica connected to the
+// same Redis serves the same conversations and they survive restarts.
+type RedisConversationStore struct {
+	client redis.Cmdable
+	ttl    time.Duration
+	prefix string
+}
+
+// NewRedisConversationStore creates a store that expires conversations ttl after their last update
+func NewRedisConversationStore(client redis.Cmdable, ttl time.Duration) *RedisConversationStore {
+	return &RedisConversationStore{
+		client: client,
+		ttl:    ttl,
+		prefix: "conversation:",
+	}
+}
+
+func (s *RedisConversationStore) Get(ctx context.Context, id string) (*models.Conversation, error) {
+	data, err := s.client.Get(ctx, s.prefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrConversationNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load conversation: %w", err)
+	}
+
+	var conversation models.Conversation
+	if err := json.Unmarshal(data, &conversation); err != nil {
+		return nil, fmt.Errorf("failed to decode conversation: %w", err)
+	}
+	return &conversation, nil
+}
+
+func (s *RedisConversationStore) Save(ctx context.Context, conversation *models.Conversation) error {
+	data, err := json.Marshal(conversation)
+	if err != nil {
+		return fmt.Errorf("failed to encode conversation: %w", err)
+	}
+	if err := s.client.Set(ctx, s.prefix+conversation.ID, data, s.ttl).Err(); err != nil {
+		return fmt.Errorf("failed to save conversation: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisConversationStore) Delete(ctx context.Context, id string) error {
+	deleted, err := s.client.Del(ctx, s.prefix+id).Result()
+	if err != nil {
+		return fmt.Errorf("failed to delete conversation: %w", err)
+	}
+	if deleted == 0 {
+		return ErrConversationNotFound
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedis implements the key/value commands used by RedisConversationStore
+type fakeRedis struct {
+	redis.Cmdable
+	values map[string]string
+	ttls   map[string]time.Duration
+}
+
+func newFakeRedis() *fakeRedis {
+	return &fakeRedis{values: map[string]string{}, ttls: map[string]time.Duration{}}
+}
+
+func (f *fakeRedis) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := f.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+
+func (f *fakeRedis) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	f.values[key] = string(value.([]byte))
+	f.ttls[key] = expiration
+	return redis.NewStatusResult("OK", nil)
+}
+
+func (f *fakeRedis) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	var deleted int64
+	for _, key := range keys {
+		if _, ok := f.values[key]; ok {
+			delete(f.values, key)
+			deleted++
+		}
+	}
+	return redis.NewIntResult(deleted, nil)
+}
+
+func testConversationStore(t *testing.T, store ConversationStore) {
+	ctx := context.Background()
+	conversation := &models.Conversation{
+		ID:       "conv_1",
+		Model:    "llama3.2",
+		Messages: []models.Message{{Role: "user", Content: "hello"}},
+	}
+
+	_, err := store.Get(ctx, "conv_1")
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+
+	assert.NoError(t, store.Save(ctx, conversation))
+
+	loaded, err := store.Get(ctx, "conv_1")
+	assert.NoError(t, err)
+	assert.Equal(t, "llama3.2", loaded.Model)
+	assert.Equal(t, conversation.Messages, loaded.Messages)
+
+	assert.NoError(t, store.Delete(ctx, "conv_1"))
+	assert.ErrorIs(t, store.Delete(ctx, "conv_1"), ErrConversationNotFound)
+}
+
+func TestMemoryConversationStore(t *testing.T) {
+	testConversationStore(t, NewMemoryConversationStore(time.Hour, time.Now))
+}
+
+func TestMemoryConversationStore_Expiry(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	store := NewMemoryConversationStore(time.Hour, clock.Now)
+	assert.NoError(t, store.Save(context.Background(), &models.Conversation{ID: "conv_1"}))
+
+	clock.now = clock.now.Add(time.Hour)
+	_, err := store.Get(context.Background(), "conv_1")
+	assert.NoError(t, err)
+
+	clock.now = clock.now.Add(time.Second)
+	_, err = store.Get(context.Background(), "conv_1")
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
+
+func TestRedisConversationStore(t *testing.T) {
+	client := newFakeRedis()
+	testConversationStore(t, NewRedisConversationStore(client, time.Hour))
+
+	store := NewRedisConversationStore(client, time.Hour)
+	assert.NoError(t, store.Save(context.Background(), &models.Conversation{ID: "conv_2"}))
+	assert.Equal(t, time.Hour, client.ttls["conversation:conv_2"])
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// conversationSummaryPrefix marks the system message that replaces summarized turns
+const conversationSummaryPrefix = "Summary of the earlier conversation:\n"
+
+const summarizePrompt = "Summarize the conversation below for your own later reference. " +
+	"Keep names, facts, decisions, open questions and anything the user asked you to remember. " +
+	"Write a concise summary in plain prose and do not add anything that was not said."
+
+// compress replaces the older turns of a conversation with a summary written by the model once it
+// grows past the token budget. The system prompt and the most recent messages are kept verbatim,
+// and an earlier summary is folded into the new one. A failed summarization leaves the history
+// unchanged and is reported in the conversation metadata.
+func (s *ConversationService) compress(ctx context.Context, conversation *models.Conversation) {
+	defer func() {
+		conversation.Metadata.EstimatedTokens = estimateConversationTokens(conversation.Messages)
+	}()
+
+	if s.config.TokenBudget <= 0 || estimateConversationTokens(conversation.Messages) <= s.config.TokenBudget {
+		return
+	}
+
+	// Leading system prompts stay as they are
+	start := 0
+	for start < len(conversation.Messages) && isSystemPrompt(conversation.Messages[start]) {
+		start++
+	}
+	end := len(conversation.Messages) - max(s.config.KeepMessages, 0)
+	if end-start < 2 {
+		return
+	}
+
+	older := conversation.Messages[start:end]
+	summary, err := s.summarize(ctx, conversation.Model, older)
+	if err != nil {
+		logger(ctx).Warn("Failed to summarize conversation", "conversation_id", conversation.ID, "error", err)
+		conversation.Metadata.CompressionError = err.Error()
+		return
+	}
+
+	summarized := 0
+	for _, message := range older {
+		if !isConversationSummary(message) {
+			summarized++
+		}
+	}
+
+	messages := append([]models.Message(nil), conversation.Messages[:start]...)
+	messages = append(messages, models.Message{Role: "system", Content: conversationSummaryPrefix + summary})
+	messages = append(messages, conversation.Messages[end:]...)
+	conversation.Messages = messages
+
+	now := s.llamaService.Now()
+	conversation.Metadata.Compressed = true
+	conversation.Metadata.SummarizedMessages += summarized
+	conversation.Metadata.LastCompressedAt = &now
+	conversation.Metadata.CompressionError = ""
+}
+
+// summarize asks the conversation's model to condense messages into a short summary
+func (s *ConversationService) summarize(ctx context.Context, model string, messages []models.Message) (string, error) {
+	var transcript strings.Builder
+	for _, message := range messages {
+		if isConversationSummary(message) {
+			fmt.Fprintf(&transcript, "Earlier summary: %s\n\n", strings.TrimPrefix(message.Content, conversationSummaryPrefix))
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n\n", message.Role, message.Content)
+	}
+
+	response, err := s.llamaService.Chat(ctx, models.ChatRequest{
+		Model: model,
+		Messages: []models.Message{
+			{Role: "system", Content: summarizePrompt},
+			{Role: "user", Content: transcript.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(response.Choices) == 0 || strings.TrimSpace(response.Choices[0].Message.Content) == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return strings.TrimSpace(response.Choices[0].Message.Content), nil
+}
+
+func estimateConversationTokens(messages []models.Message) int {
+	total := 0
+	for _, message := range messages {
+		total += estimateTokens(message)
+	}
+	return total
+}
+
+func isConversationSummary(message models.Message) bool {
+	return message.Role == "system" && strings.HasPrefix(message.Content, conversationSummaryPrefix)
+}
+
+func isSystemPrompt(message models.Message) bool {
+	return message.Role == "system" && !isConversationSummary(message)
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSummaryServer answers summarization requests with summary and every other chat with "ok",
+// recording the transcripts it was asked to summarize
+func newSummaryServer(summary string, status int) (*httptest.Server, *[]string) {
+	var transcripts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		content := "ok"
+		if body.Messages[0].Content == summarizePrompt {
+			if status != http.StatusOK {
+				w.WriteHeader(status)
+				return
+			}
+			transcripts = append(transcripts, body.Messages[1].Content)
+			content = summary
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": content},
+			"done":    true,
+		})
+	}))
+	return server, &transcripts
+}
+
+func newSummaryConversationService(baseURL string) *ConversationService {
+	llamaService := NewLlamaService()
+	llamaService.config.BaseURL = baseURL
+	llamaService.config.RetryMaxAttempts = 1
+	return NewConversationService(NewMemoryConversationStore(time.Hour, time.Now), llamaService, config.ConversationConfig{
+		TokenBudget:  60,
+		KeepMessages: 2,
+	})
+}
+
+func TestConversationService_SummarizesOlderTurns(t *testing.T) {
+	server, transcripts := newSummaryServer("The user is Ada and likes tea.", http.StatusOK)
+	defer server.Close()
+
+	service := newSummaryConversationService(server.URL)
+	ctx := context.Background()
+	conversation, _ := service.Create(ctx, models.CreateConversationRequest{SystemPrompt: "Be brief."})
+
+	for _, content := range []string{"My name is Ada.", "I like tea " + strings.Repeat("very ", 30), "What is my name?"} {
+		_, err := service.SendMessage(ctx, conversation.ID, models.ConversationMessageRequest{Content: content})
+		assert.NoError(t, err)
+	}
+
+	stored, err := service.Get(ctx, conversation.ID)
+	assert.NoError(t, err)
+
+	// System prompt, summary, then the latest question and answer
+	assert.Len(t, stored.Messages, 4)
+	assert.Equal(t, models.Message{Role: "system", Content: "Be brief."}, stored.Messages[0])
+	assert.Equal(t, conversationSummaryPrefix+"The user is Ada and likes tea.", stored.Messages[1].Content)
+	assert.Equal(t, "What is my name?", stored.Messages[2].Content)
+
+	assert.True(t, stored.Metadata.Compressed)
+	assert.Equal(t, 4, stored.Metadata.SummarizedMessages)
+	assert.NotNil(t, stored.Metadata.LastCompressedAt)
+	assert.Equal(t, estimateConversationTokens(stored.Messages), stored.Metadata.EstimatedTokens)
+	assert.Contains(t, (*transcripts)[0], "user: My name is Ada.")
+}
+
+func TestConversationService_SummaryFailureKeepsHistory(t *testing.T) {
+	server, _ := newSummaryServer("", http.StatusInternalServerError)
+	defer server.Close()
+
+	service := newSummaryConversationService(server.URL)
+	ctx := context.Background()
+	conversation, _ := service.Create(ctx, models.CreateConversationRequest{})
+
+	for _, content := range []string{"first " + strings.Repeat("word ", 40), "second"} {
+		_, err := service.SendMessage(ctx, conversation.ID, models.ConversationMessageRequest{Content: content})
+		assert.NoError(t, err)
+	}
+
+	stored, _ := service.Get(ctx, conversation.ID)
+	assert.Len(t, stored.Messages, 4)
+	assert.False(t, stored.Metadata.Compressed)
+	assert.NotEmpty(t, stored.Metadata.CompressionError)
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+)
+
+// ConversationService keeps the history of multi-turn chats on the server, so clients only send
+// the newest user message with a conversation ID. Conversations that outgrow the configured token
+// budget have their older turns summarized.
+type ConversationService struct {
+	store        ConversationStore
+	llamaService LlamaServiceInterface
+	config       config.ConversationConfig
+	locks        conversationLocks
+}
+
+func NewConversationService(store ConversationStore, llamaService LlamaServiceInterface, cfg config.ConversationConfig) *ConversationService {
+	return &ConversationService{
+		store:        store,
+		llamaService: llamaService,
+		config:       cfg,
+		locks:        conversationLocks{locks: map[string]*conversationLock{}},
+	}
+}
+
+// Create starts a conversation, optionally pinned to a model and opened with a system prompt
+func (s *ConversationService) Create(ctx context.Context, request models.CreateConversationRequest) (*models.Conversation, error) {
+	now := s.llamaService.Now()
+	conversation := &models.Conversation{
+		ID:        newConversationID(),
+		Model:     request.Model,
+		Messages:  []models.Message{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if request.SystemPrompt != "" {
+		conversation.Messages = append(conversation.Messages, models.Message{Role: "system", Content: request.SystemPrompt})
+	}
+	conversation.Metadata.EstimatedTokens = estimateConversationTokens(conversation.Messages)
+
+	if err := s.store.Save(ctx, conversation); err != nil {
+		return nil, err
+	}
+	return conversation, nil
+}
+
+// Get returns a conversation with its full history
+func (s *ConversationService) Get(ctx context.Context, id string) (*models.Conversation, error) {
+	return s.store.Get(ctx, id)
+}
+
+// Delete removes a conversation and its history
+func (s *ConversationService) Delete(ctx context.Context, id string) error {
+	return s.store.Delete(ctx, id)
+}
+
+// SendMessage appends a user message to a conversation, asks the model to answer it with the whole
+// history as context and stores the answer. Messages to the same conversation are handled one at
+// a time so concurrent sends do not interleave; the history is only updated if the chat succeeds.
+func (s *ConversationService) SendMessage(ctx context.Context, id string, request models.ConversationMessageRequest) (*models.ConversationReply, error) {
+	unlock := s.locks.lock(id)
+	defer unlock()
+
+	conversation, err := s.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chat hooks may rewrite the messages they are given, so send a copy of the history
+	userMessage := models.Message{Role: "user", Content: request.Content}
+	history := append(append([]models.Message(nil), conversation.Messages...), userMessage)
+	chatResponse, err := s.llamaService.Chat(ctx, models.ChatRequest{
+		Model:       conversation.Model,
+		Messages:    history,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+		Options:     request.Options,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(chatResponse.Choices) == 0 {
+		return nil, fmt.Errorf("model returned no answer")
+	}
+
+	answer := chatResponse.Choices[0].Message
+	conversation.Messages = append(conversation.Messages, userMessage, answer)
+	conversation.UpdatedAt = s.llamaService.Now()
+	s.compress(ctx, conversation)
+	if err := s.store.Save(ctx, conversation); err != nil {
+		return nil, err
+	}
+
+	return &models.ConversationReply{
+		ConversationID: conversation.ID,
+		Model:          chatResponse.Model,
+		Message:        answer,
+		Usage:          chatResponse.Usage,
+		Backend:        chatResponse.Backend,
+	}, nil
+}
+
+func newConversationID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "conv_" + hex.EncodeToString(b)
+}
+
+// conversationLocks hands out one mutex per conversation, dropping it when nobody holds it
+type conversationLocks struct {
+	mu    sync.Mutex
+	locks map[string]*conversationLock
+}
+
+type conversationLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (l *conversationLocks) lock(id string) func() {
+	l.mu.Lock()
+	lock, ok := l.locks[id]
+	if !ok {
+		lock = &conversationLock{}
+		l.locks[id] = lock
+	}
+	lock.refs++
+	l.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+
+		l.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(l.locks, id)
+		}
+		l.mu.Unlock()
+	}
+}
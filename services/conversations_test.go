@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConversationService_SendMessage(t *testing.T) {
+	var sent [][]models.Message
+	replies := []string{"Hi Ada!", "Your name is Ada."}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]string{"role": "assistant", "content": replies[len(sent)]},
+			"done":    true,
+		})
+		sent = append(sent, body.Messages)
+	}))
+	defer server.Close()
+
+	llamaService := NewLlamaService()
+	llamaService.config.BaseURL = server.URL
+	service := NewConversationService(NewMemoryConversationStore(time.Hour, time.Now), llamaService, config.ConversationConfig{})
+	ctx := context.Background()
+
+	conversation, err := service.Create(ctx, models.CreateConversationRequest{Model: "llama3.2", SystemPrompt: "Be brief."})
+	assert.NoError(t, err)
+
+	_, err = service.SendMessage(ctx, conversation.ID, models.ConversationMessageRequest{Content: "I am Ada."})
+	assert.NoError(t, err)
+	reply, err := service.SendMessage(ctx, conversation.ID, models.ConversationMessageRequest{Content: "What is my name?"})
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Your name is Ada.", reply.Message.Content)
+	assert.Equal(t, conversation.ID, reply.ConversationID)
+	// The second request carries the whole history
+	assert.Equal(t, []models.Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "I am Ada."},
+		{Role: "assistant", Content: "Hi Ada!"},
+		{Role: "user", Content: "What is my name?"},
+	}, sent[1])
+
+	stored, err := service.Get(ctx, conversation.ID)
+	assert.NoError(t, err)
+	assert.Len(t, stored.Messages, 5)
+}
+
+func TestConversationService_FailedChatKeepsHistory(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	llamaService := NewLlamaService()
+	llamaService.config.BaseURL = server.URL
+	llamaService.config.RetryMaxAttempts = 1
+	service := NewConversationService(NewMemoryConversationStore(time.Hour, time.Now), llamaService, config.ConversationConfig{})
+	ctx := context.Background()
+
+	conversation, _ := service.Create(ctx, models.CreateConversationRequest{})
+	_, err := service.SendMessage(ctx, conversation.ID, models.ConversationMessageRequest{Content: "hello"})
+
+	assert.Error(t, err)
+	stored, _ := service.Get(ctx, conversation.ID)
+	assert.Empty(t, stored.Messages)
+}
+
+func TestConversationService_UnknownConversation(t *testing.T) {
+	service := NewConversationService(NewMemoryConversationStore(time.Hour, time.Now), NewLlamaService(), config.ConversationConfig{})
+
+	_, err := service.SendMessage(context.Background(), "conv_missing", models.ConversationMessageRequest{Content: "hello"})
+
+	assert.ErrorIs(t, err, ErrConversationNotFound)
+}
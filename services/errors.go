@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrDefaultModelProtected is returned when deleting the configured default model
+	ErrDefaultModelProtected = errors.New("the configured default model cannot be deleted")
+	// ErrModelNotFound is returned when Ollama does not know the requested model
+	ErrModelNotFound = errors.New("model not found")
+	// ErrInvalidDimensions is returned when requested embedding dimensions exceed the model's output
+	ErrInvalidDimensions = errors.New("requested dimensions exceed the embedding size")
+	// ErrAliasNotFound is returned when removing an alias that does not exist
+	ErrAliasNotFound = errors.New("alias not found")
+	// ErrPresetNotFound is returned when a request references a preset that does not exist
+	ErrPresetNotFound = errors.New("preset not found")
+	// ErrPromptNotFound is returned when a prompt template does not exist
+	ErrPromptNotFound = errors.New("prompt template not found")
+	// ErrPromptRender is returned when a prompt template cannot be rendered with the given variables
+	ErrPromptRender = errors.New("failed to render prompt template")
+	// ErrQueueFull is returned when too many requests are already waiting for a generation slot
+	ErrQueueFull = errors.New("request queue is full")
+	// ErrQueueTimeout is returned when a request waited too long for a generation slot
+	ErrQueueTimeout = errors.New("timed out waiting in request queue")
+	// ErrSwapInProgress is returned when a model swap is requested while another is running
+	ErrSwapInProgress = errors.New("another model swap is in progress")
+	// ErrConversationNotFound is returned when a conversation does not exist or has expired
+	ErrConversationNotFound = errors.New("conversation not found")
+	// ErrPersistenceUnavailable is returned by stores while they are down and the server runs statelessly
+	ErrPersistenceUnavailable = errors.New("persistence is unavailable, the server is running without stored state")
+	// ErrCircuitOpen is returned when an upstream's circuit breaker is rejecting requests
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrUpstreamNotFound is returned when resetting a backend that does not exist
+	ErrUpstreamNotFound = errors.New("upstream not found")
+	// ErrInvalidFormat is returned when a chat request asks for an output format that is not "json" or a valid JSON schema
+	ErrInvalidFormat = errors.New("invalid output format")
+	// ErrChatOnly is returned when a completion or embedding asks for a model of a remote provider,
+	// which serve chat only
+	ErrChatOnly = errors.New("models of remote providers serve chat only")
+)
+
+// GenerationTimeoutError is returned when a generation exceeds its total time budget.
+// Partial holds whatever output the model produced before the budget ran out.
+type GenerationTimeoutError struct {
+	Model   string
+	Timeout time.Duration
+	Partial string
+}
+
+func (e *GenerationTimeoutError) Error() string {
+	return fmt.Sprintf("generation with model %s exceeded the %s time budget", e.Model, e.Timeout)
+}
+
+// RequestBudgetError is returned when a request runs out of the time budget its client set.
+// Stage names the pipeline stage that was running, Spent the time spent in each stage.
+type RequestBudgetError struct {
+	Model   string
+	Budget  time.Duration
+	Stage   string
+	Spent   map[string]time.Duration
+	Partial string
+}
+
+func (e *RequestBudgetError) Error() string {
+	return fmt.Sprintf("request with model %s ran out of its %s budget during %s", e.Model, e.Budget, e.Stage)
+}
+
+// CancelledError is returned when the client went away before a generation finished.
+// It unwraps to context.Canceled, so it is never mistaken for an upstream failure.
+type CancelledError struct {
+	Model string
+}
+
+func (e *CancelledError) Error() string {
+	return fmt.Sprintf("generation with model %s was cancelled by the client", e.Model)
+}
+
+func (e *CancelledError) Unwrap() error {
+	return context.Canceled
+}
+
+// contextError types the failure of a generation whose context has ended: a GenerationTimeoutError
+// carrying partial when the model's time budget ran out, a RequestBudgetError when the budget set by
+// the client did, or a CancelledError when the client went away.
+// It returns nil while ctx is still live.
+func contextError(ctx context.Context, model string, timeout time.Duration, partial string) error {
+	if err := budgetError(ctx, model, partial); err != nil {
+		return err
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return &GenerationTimeoutError{Model: model, Timeout: timeout, Partial: partial}
+	case errors.Is(ctx.Err(), context.Canceled):
+		return &CancelledError{Model: model}
+	}
+	return nil
+}
+
+// QueueError is returned when a request cannot get a generation slot.
+// RetryAfter suggests how long the client should wait before retrying.
+type QueueError struct {
+	Reason     error
+	RetryAfter time.Duration
+}
+
+func (e *QueueError) Error() string {
+	return e.Reason.Error()
+}
+
+func (e *QueueError) Unwrap() error {
+	return e.Reason
+}
+
+// UpstreamError is returned when the Ollama backend or remote provider serving a request is
+// unreachable, in which case StatusCode is 0, or answers with an error status
+type UpstreamError struct {
+	Backend    string // BackendLocal, BackendCloud or the name of a remote provider
+	StatusCode int
+	Message    string
+}
+
+func (e *UpstreamError) Error() string {
+	upstream := e.Backend
+	if e.Backend == BackendLocal || e.Backend == BackendCloud {
+		upstream += " ollama"
+	}
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("%s is unreachable: %s", upstream, e.Message)
+	}
+	return fmt.Sprintf("%s returned status %d: %s", upstream, e.StatusCode, e.Message)
+}
+
+// SchemaValidationError is returned when the answer still does not match the requested output
+// format after all repair attempts. Output holds the last answer.
+type SchemaValidationError struct {
+	Model    string
+	Errors   []string
+	Output   string
+	Attempts int
+}
+
+func (e *SchemaValidationError) Error() string {
+	return fmt.Sprintf("output of model %s does not match the requested format after %d attempts: %s", e.Model, e.Attempts, strings.Join(e.Errors, "; "))
+}
+
+// ModerationError is returned when the moderation policy blocks a prompt or a reply.
+// Stage is ModerationPrompt or ModerationCompletion, Categories the policy categories matched.
+type ModerationError struct {
+	Stage      string
+	Categories []string
+}
+
+func (e *ModerationError) Error() string {
+	return fmt.Sprintf("%s blocked by the moderation policy: %s", e.Stage, strings.Join(e.Categories, ", "))
+}
@@ -0,0 +1,25 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"syscall"
+)
+
+// Backends reported in generation responses
+const (
+	BackendLocal = "local"
+	BackendCloud = "cloud"
+)
+
+// shouldFailover reports whether a local request failed in a way the cloud backend could recover from
+func (s *LlamaService) shouldFailover(ctx context.Context, resp *http.Response, err error) bool {
+	if !s.config.FailoverToCloud || !s.isSignedIn.Load() || ctx.Err() != nil {
+		return false
+	}
+	if err != nil {
+		return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, ErrCircuitOpen)
+	}
+	return resp.StatusCode == http.StatusNotFound
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCloudServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer cloud-key", r.Header.Get("Authorization"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "from cloud"},
+			"done":    true,
+		})
+	}))
+}
+
+func newFailoverService(localURL, cloudURL string) *LlamaService {
+	service := NewLlamaService()
+	service.config.BaseURL = localURL
+	service.config.CloudAPIURL = cloudURL
+	service.config.CloudAPIKey = "cloud-key"
+	service.config.FailoverToCloud = true
+	service.isSignedIn.Store(true)
+	return service
+}
+
+func TestChat_FailsOverWhenModelMissing(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'llama2' not found"}`))
+	}))
+	defer local.Close()
+	cloud := newCloudServer(t)
+	defer cloud.Close()
+
+	service := newFailoverService(local.URL, cloud.URL)
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, BackendCloud, response.Backend)
+	assert.Equal(t, "from cloud", response.Choices[0].Message.Content)
+}
+
+func TestChat_FailsOverWhenLocalUnreachable(t *testing.T) {
+	local := httptest.NewServer(http.NotFoundHandler())
+	local.Close()
+	cloud := newCloudServer(t)
+	defer cloud.Close()
+
+	service := newFailoverService(local.URL, cloud.URL)
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, BackendCloud, response.Backend)
+}
+
+func TestChat_NoFailoverWhenDisabled(t *testing.T) {
+	local := httptest.NewServer(http.NotFoundHandler())
+	local.Close()
+	cloud := newCloudServer(t)
+	defer cloud.Close()
+
+	service := newFailoverService(local.URL, cloud.URL)
+	service.config.FailoverToCloud = false
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	assert.Error(t, err)
+}
+
+func TestCompletion_ReportsLocalBackend(t *testing.T) {
+	local := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": "from local", "done": true})
+	}))
+	defer local.Close()
+
+	service := newFailoverService(local.URL, "http://cloud.invalid")
+
+	response, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, BackendLocal, response.Backend)
+	assert.Equal(t, "from local", response.Choices[0].Message.Content)
+}
@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"errors"
+)
+
+// fallbackCandidates returns the models a request for model on endpoint is tried with, in order.
+// The requested model comes first, followed by the endpoint's fallback chain. When the requested
+// model is part of the chain only the models after it are used. Cloud models are left out of the
+// chain while the service is not signed in.
+func (s *LlamaService) fallbackCandidates(endpoint, model string) []string {
+	candidates := []string{model}
+
+	chain := s.config.FallbackChains[endpoint]
+	for i, entry := range chain {
+		if s.getModel(entry) == model {
+			chain = chain[i+1:]
+			break
+		}
+	}
+
+	for _, entry := range chain {
+		candidate := s.getModel(entry)
+		if s.IsCloudModel(candidate) && !s.isSignedIn.Load() {
+			continue
+		}
+		duplicate := false
+		for _, existing := range candidates {
+			duplicate = duplicate || existing == candidate
+		}
+		if !duplicate {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// shouldFallback reports whether a failed generation may be retried with the next model of a
+// fallback chain. Upstream errors, timeouts and full queues qualify; a cancelled request does not.
+func shouldFallback(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var upstreamErr *UpstreamError
+	var timeoutErr *GenerationTimeoutError
+	var queueErr *QueueError
+	return errors.As(err, &upstreamErr) || errors.As(err, &timeoutErr) || errors.As(err, &queueErr)
+}
@@ -0,0 +1,168 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFallbackCandidates(t *testing.T) {
+	service := NewLlamaService()
+	service.config.FallbackChains = map[string][]string{
+		EndpointChat: {"llama3.1:8b", "fast", "gpt-oss:120b-cloud"},
+	}
+	service.aliases = map[string]string{"fast": "phi3:mini"}
+
+	assert.Equal(t, []string{"llama3.1:8b", "phi3:mini"}, service.fallbackCandidates(EndpointChat, "llama3.1:8b"))
+	assert.Equal(t, []string{"phi3:mini"}, service.fallbackCandidates(EndpointChat, "phi3:mini"))
+	assert.Equal(t, []string{"mistral", "llama3.1:8b", "phi3:mini"}, service.fallbackCandidates(EndpointChat, "mistral"))
+	assert.Equal(t, []string{"mistral"}, service.fallbackCandidates(EndpointRewrite, "mistral"))
+
+	service.isSignedIn.Store(true)
+	assert.Equal(t, []string{"llama3.1:8b", "phi3:mini", "gpt-oss:120b-cloud"}, service.fallbackCandidates(EndpointChat, "llama3.1:8b"))
+}
+
+func TestChat_WalksFallbackChain(t *testing.T) {
+	var tried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		tried = append(tried, body.Model)
+		if body.Model != "phi3:mini" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"model runner crashed"}`))
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.config.FallbackChains = map[string][]string{
+		EndpointChat: {"llama3.1:8b", "mistral", "phi3:mini"},
+	}
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"llama3.1:8b", "mistral", "phi3:mini"}, tried)
+	assert.Equal(t, "phi3:mini", response.Model)
+	assert.Len(t, response.FallbackAttempts, 2)
+	assert.Equal(t, "llama3.1:8b", response.FallbackAttempts[0].Model)
+	assert.Contains(t, response.FallbackAttempts[0].Error, "model runner crashed")
+}
+
+func TestCompletion_WalksFallbackChain(t *testing.T) {
+	var tried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/generate" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		tried = append(tried, body.Model)
+		if body.Model != "phi3:mini" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"model runner crashed"}`))
+			return
+		}
+		w.Write([]byte(`{"response":"Hi","done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.config.FallbackChains = map[string][]string{
+		EndpointCompletion: {"llama3.1:8b", "phi3:mini"},
+	}
+
+	response, err := service.Completion(context.Background(), models.CompletionRequest{Model: "llama3.1:8b", Prompt: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"llama3.1:8b", "phi3:mini"}, tried)
+	assert.Equal(t, "phi3:mini", response.Model)
+	assert.Equal(t, "Hi", response.Choices[0].Message.Content)
+	assert.Len(t, response.FallbackAttempts, 1)
+	assert.Equal(t, "llama3.1:8b", response.FallbackAttempts[0].Model)
+	assert.Contains(t, response.FallbackAttempts[0].Error, "model runner crashed")
+}
+
+func TestChat_FallbackChainExhausted(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.config.FallbackChains = map[string][]string{EndpointChat: {"phi3:mini"}}
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var upstreamErr *UpstreamError
+	assert.ErrorAs(t, err, &upstreamErr)
+}
+
+func TestStreamChat_WalksFallbackChain(t *testing.T) {
+	var tried []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		tried = append(tried, body.Model)
+		if body.Model != "phi3:mini" {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"model runner crashed"}`))
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.config.FallbackChains = map[string][]string{
+		EndpointChatStream: {"llama3.1:8b", "phi3:mini"},
+	}
+
+	responseChan := make(chan string)
+	go service.StreamChat(context.Background(), models.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	}, responseChan)
+
+	var chunks []string
+	for chunk := range responseChan {
+		chunks = append(chunks, chunk)
+	}
+	assert.Equal(t, []string{"Hi"}, chunks)
+	assert.Equal(t, []string{"llama3.1:8b", "phi3:mini"}, tried)
+	assert.Equal(t, "status 500", service.breakers[BackendLocal].lastFailure, "the failed stream is seen by the circuit breaker")
+}
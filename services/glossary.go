@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// defaultGlossaryTerms is how many terms are extracted when the request does not say
+const defaultGlossaryTerms = 10
+
+// glossaryFormat is the JSON schema glossary answers must match
+var glossaryFormat = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"entries": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"term": {"type": "string", "minLength": 1},
+					"definition": {"type": "string", "minLength": 1}
+				},
+				"required": ["term", "definition"]
+			}
+		}
+	},
+	"required": ["entries"]
+}`)
+
+// sentencePattern splits text into sentences ending in ., ! or ? followed by whitespace
+var sentencePattern = regexp.MustCompile(`[^.!?]+(?:[.!?]+(?:\s+|$)|$)`)
+
+// Glossary extracts the key terms of a text and defines each one from what the text says about it
+func (s *LlamaService) Glossary(ctx context.Context, request models.GlossaryRequest) (*models.GlossaryResponse, error) {
+	maxTerms := request.MaxTerms
+	if maxTerms == 0 {
+		maxTerms = defaultGlossaryTerms
+	}
+
+	chatResponse, err := s.chat(ctx, EndpointGlossary, models.ChatRequest{
+		Model:       request.Model,
+		Temperature: request.Temperature,
+		Format:      glossaryFormat,
+		Messages: []models.Message{
+			{Role: "system", Content: buildGlossaryPrompt(request, maxTerms)},
+			{Role: "user", Content: request.Text},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract glossary: %w", err)
+	}
+
+	var answer struct {
+		Entries []models.GlossaryEntry `json:"entries"`
+	}
+	if len(chatResponse.Choices) > 0 {
+		// Decode the first JSON value only, so text appended by hooks such as a disclaimer is ignored
+		decoder := json.NewDecoder(strings.NewReader(chatResponse.Choices[0].Message.Content))
+		if err := decoder.Decode(&answer); err != nil {
+			return nil, fmt.Errorf("failed to parse glossary: %w", err)
+		}
+	}
+
+	return &models.GlossaryResponse{
+		ID:               s.GenerateID(),
+		Object:           "text.glossary",
+		Created:          s.Now().Unix(),
+		Model:            chatResponse.Model,
+		Entries:          groundGlossary(request.Text, answer.Entries, maxTerms),
+		Usage:            chatResponse.Usage,
+		FallbackAttempts: chatResponse.FallbackAttempts,
+	}, nil
+}
+
+// buildGlossaryPrompt builds the system prompt asking for grounded definitions
+func buildGlossaryPrompt(request models.GlossaryRequest, maxTerms int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "You are an editor writing a glossary. List up to %d key terms from the text provided by the user, ", maxTerms)
+	b.WriteString("most important first, and define each one in one or two sentences. ")
+	b.WriteString("Write every term exactly as it appears in the text. ")
+	b.WriteString("Base each definition only on what the text says; do not add facts it does not contain. ")
+	b.WriteString(`Reply with JSON of the form {"entries": [{"term": "...", "definition": "..."}]}.`)
+
+	if request.Language != "" {
+		fmt.Fprintf(&b, "\nWrite the definitions in this language: %s.", request.Language)
+	}
+
+	return b.String()
+}
+
+// groundGlossary keeps the entries whose term occurs in text, without duplicates and at most
+// maxTerms of them, and attaches the first sentence of text that mentions each term
+func groundGlossary(text string, entries []models.GlossaryEntry, maxTerms int) []models.GlossaryEntry {
+	lowerText := strings.ToLower(text)
+	seen := make(map[string]bool)
+	grounded := make([]models.GlossaryEntry, 0, min(len(entries), maxTerms))
+	for _, entry := range entries {
+		entry.Term = strings.TrimSpace(entry.Term)
+		entry.Definition = strings.TrimSpace(entry.Definition)
+		term := strings.ToLower(entry.Term)
+		if term == "" || entry.Definition == "" || seen[term] || !strings.Contains(lowerText, term) {
+			continue
+		}
+		seen[term] = true
+
+		entry.Excerpt = firstSentenceWith(text, term)
+		grounded = append(grounded, entry)
+		if len(grounded) == maxTerms {
+			break
+		}
+	}
+	return grounded
+}
+
+// firstSentenceWith returns the first sentence of text containing term, which is lower case
+func firstSentenceWith(text, term string) string {
+	for _, sentence := range sentencePattern.FindAllString(text, -1) {
+		if strings.Contains(strings.ToLower(sentence), term) {
+			return strings.Join(strings.Fields(sentence), " ")
+		}
+	}
+	return ""
+}
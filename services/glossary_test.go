@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const glossaryText = "Photosynthesis turns light into chemical energy. It takes place in the chloroplast!  " +
+	"The chloroplast contains chlorophyll, a green pigment."
+
+func TestGroundGlossary(t *testing.T) {
+	entries := groundGlossary(glossaryText, []models.GlossaryEntry{
+		{Term: " Chloroplast ", Definition: "The organelle where photosynthesis happens."},
+		{Term: "chloroplast", Definition: "Duplicate."},
+		{Term: "mitochondria", Definition: "Not in the text."},
+		{Term: "chlorophyll", Definition: ""},
+		{Term: "photosynthesis", Definition: "Turning light into chemical energy."},
+		{Term: "pigment", Definition: "A coloring substance."},
+	}, 2)
+
+	assert.Equal(t, []models.GlossaryEntry{
+		{Term: "Chloroplast", Definition: "The organelle where photosynthesis happens.", Excerpt: "It takes place in the chloroplast!"},
+		{Term: "photosynthesis", Definition: "Turning light into chemical energy.", Excerpt: "Photosynthesis turns light into chemical energy."},
+	}, entries)
+}
+
+func TestGlossary(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.NotNil(t, body["format"], "the glossary schema is sent to Ollama")
+
+		system := body["messages"].([]interface{})[0].(map[string]interface{})["content"].(string)
+		assert.Contains(t, system, "up to 3 key terms")
+		assert.Contains(t, system, "Write the definitions in this language: German.")
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{
+				"role":    "assistant",
+				"content": `{"entries":[{"term":"chlorophyll","definition":"Ein grünes Pigment."},{"term":"ribosome","definition":"Erfunden."}]}`,
+			},
+			"done": true,
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Glossary(context.Background(), models.GlossaryRequest{
+		Text:     glossaryText,
+		MaxTerms: 3,
+		Language: "German",
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text.glossary", response.Object)
+	assert.Equal(t, []models.GlossaryEntry{
+		{Term: "chlorophyll", Definition: "Ein grünes Pigment.", Excerpt: "The chloroplast contains chlorophyll, a green pigment."},
+	}, response.Entries)
+}
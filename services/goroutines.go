@@ -0,0 +1,127 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sync"
+
+	"agent-ollama-gin/models"
+)
+
+// background tracks the goroutines started with Go
+var background = newGoroutineGroup()
+
+// goroutineGroup runs goroutines that recover from panics and can be waited for on shutdown
+type goroutineGroup struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	stats  map[string]*models.GoroutineStats
+}
+
+func newGoroutineGroup() *goroutineGroup {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &goroutineGroup{ctx: ctx, cancel: cancel, stats: map[string]*models.GoroutineStats{}}
+}
+
+// Go runs fn in a goroutine that recovers from panics, so a bug in one stream or background task
+// cannot take the process down, and that Shutdown waits for. name groups the goroutine in logs and
+// GoroutineStats. fn's context is cancelled when Shutdown starts; request-scoped work may ignore it.
+func Go(name string, fn func(ctx context.Context)) {
+	background.run(name, fn)
+}
+
+// Shutdown cancels the context of the goroutines started with Go and waits for them to return,
+// giving up when ctx ends
+func Shutdown(ctx context.Context) error {
+	return background.shutdown(ctx)
+}
+
+// GoroutineStats reports the goroutines started with Go by name
+func GoroutineStats() map[string]models.GoroutineStats {
+	return background.snapshot()
+}
+
+func (g *goroutineGroup) run(name string, fn func(ctx context.Context)) {
+	g.wg.Add(1)
+	g.update(name, func(stats *models.GoroutineStats) {
+		stats.Started++
+		stats.Running++
+	})
+
+	go func() {
+		defer g.wg.Done()
+		defer g.update(name, func(stats *models.GoroutineStats) { stats.Running-- })
+		defer g.recoverPanic(name, nil)
+		fn(g.ctx)
+	}()
+}
+
+// recoverPanic logs and counts a panic under name and, if err is not nil, reports it there.
+// It must be deferred directly by the function that may panic.
+func (g *goroutineGroup) recoverPanic(name string, err *error) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	slog.Error("Recovered from panic", "goroutine", name, "panic", recovered, "stack", string(debug.Stack()))
+	g.update(name, func(stats *models.GoroutineStats) { stats.Panics++ })
+	if err != nil {
+		*err = fmt.Errorf("%s panicked: %v", name, recovered)
+	}
+}
+
+func (g *goroutineGroup) update(name string, change func(stats *models.GoroutineStats)) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	stats, ok := g.stats[name]
+	if !ok {
+		stats = &models.GoroutineStats{}
+		g.stats[name] = stats
+	}
+	change(stats)
+}
+
+func (g *goroutineGroup) shutdown(ctx context.Context) error {
+	g.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d goroutines still running: %w", g.running(), ctx.Err())
+	}
+}
+
+func (g *goroutineGroup) running() int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var running int64
+	for _, stats := range g.stats {
+		running += stats.Running
+	}
+	return running
+}
+
+func (g *goroutineGroup) snapshot() map[string]models.GoroutineStats {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	snapshot := make(map[string]models.GoroutineStats, len(g.stats))
+	for name, stats := range g.stats {
+		snapshot[name] = *stats
+	}
+	return snapshot
+}
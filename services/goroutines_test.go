@@ -0,0 +1,64 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGoroutineGroup_RecoversPanics(t *testing.T) {
+	group := newGoroutineGroup()
+
+	group.run("stream", func(context.Context) { panic("boom") })
+	group.run("stream", func(context.Context) {})
+
+	assert.NoError(t, group.shutdown(context.Background()))
+	assert.Equal(t, models.GoroutineStats{Started: 2, Running: 0, Panics: 1}, group.snapshot()["stream"])
+}
+
+func TestGoroutineGroup_ShutdownCancelsAndWaits(t *testing.T) {
+	group := newGoroutineGroup()
+
+	finished := false
+	group.run("snapshot", func(ctx context.Context) {
+		<-ctx.Done()
+		time.Sleep(10 * time.Millisecond)
+		finished = true
+	})
+
+	assert.NoError(t, group.shutdown(context.Background()))
+	assert.True(t, finished)
+}
+
+func TestGoroutineGroup_ShutdownGivesUp(t *testing.T) {
+	group := newGoroutineGroup()
+
+	release := make(chan struct{})
+	defer close(release)
+	group.run("stuck", func(context.Context) { <-release })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := group.shutdown(ctx)
+
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "1 goroutines still running")
+}
+
+func TestGoroutineGroup_RecoverPanicAsError(t *testing.T) {
+	group := newGoroutineGroup()
+
+	run := func() (err error) {
+		defer group.recoverPanic("generation", &err)
+		panic(errors.New("nil map"))
+	}
+
+	err := run()
+	assert.EqualError(t, err, "generation panicked: nil map")
+	assert.Equal(t, int64(1), group.snapshot()["generation"].Panics)
+}
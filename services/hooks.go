@@ -0,0 +1,171 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// Endpoint names used to scope chat hooks
+const (
+	EndpointChat       = "chat"
+	EndpointChatStream = "chat_stream"
+	EndpointRewrite    = "rewrite"
+	EndpointCompare    = "compare"
+	EndpointPrompt     = "prompt"
+	EndpointGlossary   = "glossary"
+	EndpointCompletion = "completion"
+)
+
+// ChatHook rewrites chat requests before they reach Ollama and responses before they reach the client.
+// Returning an error from BeforeChat aborts the request. For streaming chat AfterChat sees the complete
+// reply after it was sent, so only the text it appends reaches the client.
+type ChatHook interface {
+	BeforeChat(endpoint string, request *models.ChatRequest) error
+	AfterChat(endpoint string, response *models.ChatResponse) error
+}
+
+// CompletionHook is implemented by chat hooks that also apply to text completions, whose prompt
+// and reply are plain text. Hooks that only implement ChatHook are skipped for completions.
+type CompletionHook interface {
+	BeforeCompletion(endpoint string, request *models.CompletionRequest) error
+	AfterCompletion(endpoint string, response *models.CompletionResponse) error
+}
+
+type registeredHook struct {
+	hook      ChatHook
+	endpoints map[string]bool // nil means every endpoint
+}
+
+func (r registeredHook) appliesTo(endpoint string) bool {
+	return r.endpoints == nil || r.endpoints[endpoint]
+}
+
+// RegisterHook adds a hook to the chain for the given endpoints, or for every endpoint if none are given.
+// Hooks run in registration order and must be registered before the service starts handling requests.
+func (s *LlamaService) RegisterHook(hook ChatHook, endpoints ...string) {
+	registered := registeredHook{hook: hook}
+	if len(endpoints) > 0 {
+		registered.endpoints = make(map[string]bool, len(endpoints))
+		for _, endpoint := range endpoints {
+			registered.endpoints[endpoint] = true
+		}
+	}
+	s.hooks = append(s.hooks, registered)
+}
+
+func (s *LlamaService) runBeforeHooks(endpoint string, request *models.ChatRequest) error {
+	for _, registered := range s.hooks {
+		if registered.appliesTo(endpoint) {
+			if err := registered.hook.BeforeChat(endpoint, request); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *LlamaService) runAfterHooks(endpoint string, response *models.ChatResponse) error {
+	for _, registered := range s.hooks {
+		if registered.appliesTo(endpoint) {
+			if err := registered.hook.AfterChat(endpoint, response); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *LlamaService) runBeforeCompletionHooks(endpoint string, request *models.CompletionRequest) error {
+	for _, registered := range s.hooks {
+		if hook, ok := registered.hook.(CompletionHook); ok && registered.appliesTo(endpoint) {
+			if err := hook.BeforeCompletion(endpoint, request); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *LlamaService) runAfterCompletionHooks(endpoint string, response *models.CompletionResponse) error {
+	for _, registered := range s.hooks {
+		if hook, ok := registered.hook.(CompletionHook); ok && registered.appliesTo(endpoint) {
+			if err := hook.AfterCompletion(endpoint, response); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// SystemPromptHook enforces a system prompt ahead of any client-supplied messages
+type SystemPromptHook struct {
+	Prompt string
+}
+
+func (h SystemPromptHook) BeforeChat(endpoint string, request *models.ChatRequest) error {
+	messages := make([]models.Message, 0, len(request.Messages)+1)
+	messages = append(messages, models.Message{Role: "system", Content: h.Prompt})
+	request.Messages = append(messages, request.Messages...)
+	return nil
+}
+
+func (h SystemPromptHook) AfterChat(endpoint string, response *models.ChatResponse) error {
+	return nil
+}
+
+// DisclaimerHook appends a fixed disclaimer to every assistant reply
+type DisclaimerHook struct {
+	Text string
+}
+
+func (h DisclaimerHook) BeforeChat(endpoint string, request *models.ChatRequest) error {
+	return nil
+}
+
+func (h DisclaimerHook) AfterChat(endpoint string, response *models.ChatResponse) error {
+	for i := range response.Choices {
+		response.Choices[i].Message.Content = strings.TrimRight(response.Choices[i].Message.Content, "\n") + "\n\n" + h.Text
+	}
+	return nil
+}
+
+var (
+	markdownFence    = regexp.MustCompile("(?m)^```[^\n]*\n?")
+	markdownHeading  = regexp.MustCompile(`(?m)^#{1,6}\s+`)
+	markdownQuote    = regexp.MustCompile(`(?m)^>\s?`)
+	markdownLink     = regexp.MustCompile(`!?\[([^\]]*)\]\([^)]*\)`)
+	markdownEmphasis = []*regexp.Regexp{
+		regexp.MustCompile(`\*\*([^*\n]+)\*\*`),
+		regexp.MustCompile(`__([^_\n]+)__`),
+		regexp.MustCompile(`~~([^~\n]+)~~`),
+		regexp.MustCompile(`\*([^*\n]+)\*`),
+		regexp.MustCompile("`([^`\n]+)`"),
+	}
+)
+
+// StripMarkdownHook converts assistant replies to plain text
+type StripMarkdownHook struct{}
+
+func (StripMarkdownHook) BeforeChat(endpoint string, request *models.ChatRequest) error {
+	return nil
+}
+
+func (StripMarkdownHook) AfterChat(endpoint string, response *models.ChatResponse) error {
+	for i := range response.Choices {
+		response.Choices[i].Message.Content = stripMarkdown(response.Choices[i].Message.Content)
+	}
+	return nil
+}
+
+func stripMarkdown(text string) string {
+	text = markdownFence.ReplaceAllString(text, "")
+	text = markdownHeading.ReplaceAllString(text, "")
+	text = markdownQuote.ReplaceAllString(text, "")
+	text = markdownLink.ReplaceAllString(text, "$1")
+	for _, emphasis := range markdownEmphasis {
+		text = emphasis.ReplaceAllString(text, "$1")
+	}
+	return text
+}
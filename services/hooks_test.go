@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rejectingHook struct{}
+
+func (rejectingHook) BeforeChat(endpoint string, request *models.ChatRequest) error {
+	return errors.New("blocked")
+}
+
+func (rejectingHook) AfterChat(endpoint string, response *models.ChatResponse) error {
+	return nil
+}
+
+func TestStripMarkdown(t *testing.T) {
+	input := "# Title\n\nSome **bold**, *italic* and `code` with a [link](https://example.com).\n> quoted\n```go\nfmt.Println()\n```\n"
+	expected := "Title\n\nSome bold, italic and code with a link.\nquoted\nfmt.Println()\n"
+
+	assert.Equal(t, expected, stripMarkdown(input))
+}
+
+func TestHookChain(t *testing.T) {
+	var receivedMessages []models.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		receivedMessages = body.Messages
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "**Hello**"},
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.RegisterHook(SystemPromptHook{Prompt: "You work for ACME."}, EndpointChat)
+	service.RegisterHook(StripMarkdownHook{})
+	service.RegisterHook(DisclaimerHook{Text: "AI-generated."}, EndpointChat)
+
+	request := models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	}
+
+	response, err := service.Chat(context.Background(), request)
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Message{
+		{Role: "system", Content: "You work for ACME."},
+		{Role: "user", Content: "Hi"},
+	}, receivedMessages)
+	assert.Equal(t, "Hello\n\nAI-generated.", response.Choices[0].Message.Content)
+
+	// Hooks scoped to the chat endpoint do not apply to other endpoints
+	response, err = service.chat(context.Background(), EndpointCompare, request)
+	assert.NoError(t, err)
+	assert.Len(t, receivedMessages, 1)
+	assert.Equal(t, "Hello", response.Choices[0].Message.Content)
+}
+
+func TestHookChain_BeforeHookRejects(t *testing.T) {
+	service := NewLlamaService()
+	service.RegisterHook(rejectingHook{})
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	assert.ErrorContains(t, err, "blocked")
+}
+
+func TestStreamChat_AfterHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hello"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":" there"},"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	stream := func(service *LlamaService) []string {
+		responseChan := make(chan string)
+		go service.StreamChat(context.Background(), models.ChatRequest{
+			Messages: []models.Message{{Role: "user", Content: "Hi"}},
+		}, responseChan)
+
+		var chunks []string
+		for chunk := range responseChan {
+			chunks = append(chunks, chunk)
+		}
+		return chunks
+	}
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.RegisterHook(DisclaimerHook{Text: "AI-generated."}, EndpointChatStream)
+	assert.Equal(t, []string{"Hello", " there", "\n\nAI-generated."}, stream(service))
+
+	service = NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.RegisterHook(&ModerationHook{policy: &ModerationPolicy{
+		CompletionAction: ModerationBlock,
+		Rules:            []ModerationRule{{Category: "greeting", matchers: []*regexp.Regexp{keywordMatcher("hello")}}},
+	}}, EndpointChatStream)
+	assert.Equal(t, []string{"Hello", " there", "Error: chat response rejected: completion blocked by the moderation policy: greeting"}, stream(service))
+}
@@ -1,17 +1,52 @@
 package services
 
-import "agent-ollama-gin/models"
+import (
+	"context"
+	"time"
 
-// LlamaServiceInterface defines the interface for Llama service operations
+	"agent-ollama-gin/models"
+)
+
+// LlamaServiceInterface defines the interface for Llama service operations.
+// Generation methods abort the upstream request when ctx is cancelled.
 type LlamaServiceInterface interface {
-	Chat(request models.ChatRequest) (*models.ChatResponse, error)
-	Completion(request models.CompletionRequest) (*models.CompletionResponse, error)
-	Embedding(request models.EmbeddingRequest) (*models.EmbeddingResponse, error)
+	Chat(ctx context.Context, request models.ChatRequest) (*models.ChatResponse, error)
+	Completion(ctx context.Context, request models.CompletionRequest) (*models.CompletionResponse, error)
+	Embedding(ctx context.Context, request models.EmbeddingRequest) (*models.EmbeddingResponse, error)
 	ListModels() ([]models.Model, error)
-	SignIn(username, password string) (*models.AuthResponse, error)
+	DefaultModel() string
+	Now() time.Time
+	GenerateID() string
+	SignIn(ctx context.Context, request models.AuthRequest) (*models.AuthResponse, error)
 	SignOut() error
+	ListCloudModels(ctx context.Context) ([]models.CloudModel, error)
+	Usage() *models.UsageReport
+	UsageRecords(ctx context.Context, query models.UsageQuery) (*models.UsageRecordList, error)
+	Upstreams() []models.UpstreamStatus
+	ResetUpstream(name string) error
+	ShadowReport() models.ShadowReport
 	PullModel(modelName string) error
-	StreamChat(request models.ChatRequest, responseChan chan<- string)
+	DeleteModel(modelName string) error
+	CopyModel(source, destination string) error
+	CreateModel(ctx context.Context, modelName, modelfile string, progressChan chan<- string)
+	ListAliases() map[string]string
+	SetAlias(alias, model string) error
+	DeleteAlias(alias string) error
+	ListPresets() []models.Preset
+	GetPreset(name string) (models.Preset, error)
+	SetPreset(preset models.Preset) error
+	DeletePreset(name string) error
+	ListPrompts() []models.PromptTemplate
+	GetPrompt(name string) (models.PromptTemplate, error)
+	SetPrompt(prompt models.PromptTemplate) error
+	DeletePrompt(name string) error
+	RunPrompt(ctx context.Context, name string, request models.RunPromptRequest) (*models.RunPromptResponse, error)
+	SwapModel(ctx context.Context, request models.SwapModelRequest) (*models.SwapModelResponse, error)
+	StreamChat(ctx context.Context, request models.ChatRequest, responseChan chan<- string)
+	Rewrite(ctx context.Context, request models.RewriteRequest) (*models.RewriteResponse, error)
+	Glossary(ctx context.Context, request models.GlossaryRequest) (*models.GlossaryResponse, error)
+	Compare(ctx context.Context, request models.CompareRequest) (*models.CompareResponse, error)
+	ChatBatch(ctx context.Context, request models.BatchChatRequest) (*models.BatchChatResponse, error)
 }
 
 // Ensure LlamaService implements the interface
@@ -3,11 +3,17 @@ package services
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"agent-ollama-gin/config"
@@ -16,8 +22,30 @@ import (
 
 type LlamaService struct {
 	config     *config.LlamaConfig
-	httpClient *http.Client
-	isSignedIn bool
+	httpClient HTTPClient
+	loadClient HTTPClient       // httpClient without the header timeout
+	clock      func() time.Time // Time source for timestamps; time.Now when nil
+	lastID     atomic.Int64     // Latest response ID, in clock nanoseconds
+	isSignedIn atomic.Bool      // Changes on sign-in and sign-out
+	authMu     sync.RWMutex     // Guards config.CloudAPIKey, which changes on sign-in and sign-out
+	hooks      []registeredHook
+	aliases    map[string]string
+	aliasMu    sync.RWMutex // Guards aliases and config.DefaultModel, which change on model swaps
+	swapMu     sync.Mutex   // Allows one model swap at a time
+	presets    map[string]models.Preset
+	presetMu   sync.RWMutex
+	prompts    map[string]*promptTemplate
+	shadow     *shadowMirror
+	promptMu   sync.RWMutex
+	queue      *requestQueue
+	usage      *usageTracker
+	usageStore UsageStore                 // Per-request usage records
+	breakers   map[string]*circuitBreaker // Per backend, keyed by BackendLocal and BackendCloud
+	contextMu  sync.Mutex
+	contexts   map[string]int      // Context window per model, read from /api/show
+	inflight   *coalescer          // Identical chat requests in flight
+	cache      *semanticCache      // Answers to earlier prompts, reused for similar ones
+	providers  map[string]Provider // Remote providers by model prefix
 }
 
 // Available cloud models based on Ollama cloud documentation
@@ -52,58 +80,95 @@ var CloudModels = []models.CloudModel{
 	},
 }
 
-func NewLlamaService() *LlamaService {
+// NewLlamaService creates the service from the loaded configuration. Options replace the HTTP
+// client built from it or the clock.
+func NewLlamaService(opts ...Option) *LlamaService {
 	cfg := config.Load()
 
-	// Get timeout from environment or use default
-	timeout := time.Duration(cfg.Llama.Timeout) * time.Second
-
 	service := &LlamaService{
-		config: &cfg.Llama,
-		httpClient: &http.Client{
-			Timeout: timeout,
-		},
-		isSignedIn: cfg.Llama.SignedIn,
+		config:     &cfg.Llama,
+		httpClient: newHTTPClient(&cfg.Llama, time.Duration(cfg.Llama.HeaderTimeout)*time.Second),
+		loadClient: newHTTPClient(&cfg.Llama, 0),
+		aliases:    cfg.Llama.ModelAliases,
+		usage:      newUsageTracker(),
+		usageStore: NewMemoryUsageStore(cfg.Usage.RetentionDays),
+		inflight:   newCoalescer(),
+		cache: newSemanticCache(
+			cfg.Llama.SemanticCacheModel,
+			cfg.Llama.SemanticSimilarity,
+			cfg.Llama.SemanticCacheSize,
+			time.Duration(cfg.Llama.SemanticCacheTTL)*time.Second,
+		),
+		shadow: newShadowMirror(cfg.Llama.ShadowModel, cfg.Llama.ShadowPercent, cfg.Llama.ShadowResults),
+		queue: newRequestQueue(
+			cfg.Llama.MaxConcurrent,
+			cfg.Llama.MaxConcurrentPerModel,
+			cfg.Llama.MaxQueued,
+			time.Duration(cfg.Llama.QueueTimeout)*time.Second,
+		),
+	}
+	// Breakers read the service's clock, so they follow WithClock
+	service.breakers = map[string]*circuitBreaker{
+		BackendLocal: newCircuitBreaker(cfg.Llama.BreakerThreshold, time.Duration(cfg.Llama.BreakerCooldown)*time.Second, service.Now),
+		BackendCloud: newCircuitBreaker(cfg.Llama.BreakerThreshold, time.Duration(cfg.Llama.BreakerCooldown)*time.Second, service.Now),
+	}
+	service.isSignedIn.Store(cfg.Llama.SignedIn)
+	for _, opt := range opts {
+		opt(service)
+	}
+	service.registerProviders(cfg.Providers)
+
+	// Restore a token persisted by an earlier sign-in
+	if cfg.Llama.CloudAPIKey == "" {
+		cfg.Llama.CloudAPIKey = service.loadCloudToken()
 	}
 
 	// Auto-signin if cloud is enabled and credentials are available
 	if cfg.Llama.CloudEnabled && cfg.Llama.CloudAPIKey != "" {
-		service.isSignedIn = true
+		service.isSignedIn.Store(true)
 	}
 
-	return service
-}
-
-// SignIn authenticates with Ollama cloud
-func (s *LlamaService) SignIn(username, password string) (*models.AuthResponse, error) {
-	if !s.config.CloudEnabled {
-		return &models.AuthResponse{
-			Success: true,
-			Message: "Cloud mode is not enabled",
-		}, nil
+	// Register the built-in hooks enabled in configuration
+	if cfg.Hooks.SystemPrompt != "" {
+		service.RegisterHook(SystemPromptHook{Prompt: cfg.Hooks.SystemPrompt}, cfg.Hooks.SystemPromptEndpoints...)
 	}
-
-	// For now, we'll simulate sign-in since the actual Ollama cloud auth API isn't fully documented
-	// In a real implementation, this would make an actual API call to ollama.com
-	if username != "" && password != "" {
-		s.isSignedIn = true
-		return &models.AuthResponse{
-			Success: true,
-			Token:   "simulated-token",
-			Message: "Successfully signed in to Ollama cloud",
-		}, nil
+	if cfg.Hooks.StripMarkdown {
+		service.RegisterHook(StripMarkdownHook{}, cfg.Hooks.StripMarkdownEndpoints...)
+	}
+	if cfg.Hooks.Disclaimer != "" {
+		service.RegisterHook(DisclaimerHook{Text: cfg.Hooks.Disclaimer}, cfg.Hooks.DisclaimerEndpoints...)
 	}
 
-	return &models.AuthResponse{
-		Success: false,
-		Message: "Invalid credentials",
-	}, nil
+	return service
 }
 
-// SignOut signs out from Ollama cloud
-func (s *LlamaService) SignOut() error {
-	s.isSignedIn = false
-	return nil
+// newHTTPClient builds the upstream client. Connection and header timeouts are enforced by the
+// transport, no header timeout when it is 0; the total generation budget is applied per request
+// through a context deadline.
+func newHTTPClient(cfg *config.LlamaConfig, headerTimeout time.Duration) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   time.Duration(cfg.ConnectTimeout) * time.Second,
+		KeepAlive: 30 * time.Second,
+	}).DialContext
+	transport.ResponseHeaderTimeout = headerTimeout
+
+	return &http.Client{Transport: transport}
+}
+
+// generationTimeout returns the total time budget for a model, honouring per-model-class overrides
+// and the separate budget for cloud models
+func (s *LlamaService) generationTimeout(model string) time.Duration {
+	name := strings.ToLower(model)
+	for _, override := range s.config.ModelTimeouts {
+		if strings.Contains(name, override.Pattern) {
+			return time.Duration(override.Seconds) * time.Second
+		}
+	}
+	if s.IsCloudModel(model) && s.config.CloudTimeout > 0 {
+		return time.Duration(s.config.CloudTimeout) * time.Second
+	}
+	return time.Duration(s.config.Timeout) * time.Second
 }
 
 // IsCloudModel checks if a model is a cloud model
@@ -113,7 +178,7 @@ func (s *LlamaService) IsCloudModel(modelName string) bool {
 
 // PullModel pulls a model (cloud or local)
 func (s *LlamaService) PullModel(modelName string) error {
-	if s.IsCloudModel(modelName) && !s.isSignedIn {
+	if s.IsCloudModel(modelName) && !s.isSignedIn.Load() {
 		return fmt.Errorf("must be signed in to use cloud models")
 	}
 
@@ -126,7 +191,10 @@ func (s *LlamaService) PullModel(modelName string) error {
 		baseURL = s.config.CloudAPIURL
 	}
 
-	resp, err := s.makeRequest("POST", "/api/pull", pullRequest, baseURL)
+	ctx, cancel := context.WithTimeout(context.Background(), s.generationTimeout(modelName))
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "POST", "/api/pull", pullRequest, baseURL)
 	if err != nil {
 		return fmt.Errorf("failed to pull model: %w", err)
 	}
@@ -135,144 +203,329 @@ func (s *LlamaService) PullModel(modelName string) error {
 	return nil
 }
 
+// DeleteModel removes a model from the local Ollama instance
+func (s *LlamaService) DeleteModel(modelName string) error {
+	modelName = s.resolveAlias(modelName)
+	if modelName == s.getModel("") {
+		return ErrDefaultModelProtected
+	}
+
+	deleteRequest := map[string]interface{}{
+		"model": modelName,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "DELETE", "/api/delete", deleteRequest, s.config.BaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to delete model: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return fmt.Errorf("%w: %s", ErrModelNotFound, modelName)
+	case resp.StatusCode != http.StatusOK:
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
 // Chat handles chat completion using Ollama (local or cloud)
-func (s *LlamaService) Chat(request models.ChatRequest) (*models.ChatResponse, error) {
+func (s *LlamaService) Chat(ctx context.Context, request models.ChatRequest) (*models.ChatResponse, error) {
+	return s.chat(withDefaultPriority(ctx, models.PriorityInteractive), EndpointChat, request)
+}
+
+// chat runs a chat completion through the hook chain registered for endpoint
+func (s *LlamaService) chat(ctx context.Context, endpoint string, request models.ChatRequest) (*models.ChatResponse, error) {
+	if err := s.applyPreset(&request); err != nil {
+		return nil, err
+	}
+	if err := s.runBeforeHooks(endpoint, &request); err != nil {
+		return nil, fmt.Errorf("chat request rejected: %w", err)
+	}
+
 	model := s.getModel(request.Model)
 
+	format, err := parseFormat(request.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	generate := func(ctx context.Context) (*models.ChatResponse, error) {
+		return s.cachedGenerate(ctx, endpoint, model, request, func(ctx context.Context) (*models.ChatResponse, error) {
+			return s.generateWithFallback(ctx, endpoint, model, request, format)
+		})
+	}
+	var response *models.ChatResponse
+	if s.config.CoalesceRequests {
+		response, err = s.coalesce(ctx, endpoint, model, request, generate)
+	} else {
+		response, err = generate(ctx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Flags raised by moderating the prompt are reported with the reply
+	response.Moderation = request.Moderation
+	if err := s.runAfterHooks(endpoint, response); err != nil {
+		return nil, fmt.Errorf("chat response rejected: %w", err)
+	}
+	return response, nil
+}
+
+// generateWithFallback tries the requested model, then the endpoint's fallback chain while generations fail
+func (s *LlamaService) generateWithFallback(ctx context.Context, endpoint, model string, request models.ChatRequest, format *outputFormat) (*models.ChatResponse, error) {
+	candidates := s.fallbackCandidates(endpoint, model)
+	var attempts []models.FallbackAttempt
+	start := time.Now()
+	for i, candidate := range candidates {
+		response, err := s.generateStructured(ctx, candidate, request, format)
+		if err != nil {
+			if i == len(candidates)-1 || !shouldFallback(ctx, err) {
+				return nil, err
+			}
+			logger(ctx).Warn("Chat failed, trying the next model in the fallback chain", "model", candidate, "endpoint", endpoint, "error", err)
+			attempts = append(attempts, models.FallbackAttempt{Model: candidate, Error: err.Error()})
+			continue
+		}
+		response.FallbackAttempts = attempts
+
+		if endpoint == EndpointChat {
+			s.mirrorChat(ctx, request, response, time.Since(start))
+		}
+		return response, nil
+	}
+	return nil, fmt.Errorf("no model to send the %s request to", endpoint)
+}
+
+// generateChat sends a chat request to a single model
+func (s *LlamaService) generateChat(ctx context.Context, model string, request models.ChatRequest) (*models.ChatResponse, error) {
 	// Check if cloud model and authentication
-	if s.IsCloudModel(model) && !s.isSignedIn {
+	if s.IsCloudModel(model) && !s.isSignedIn.Load() {
 		return nil, fmt.Errorf("must be signed in to use cloud model: %s", model)
 	}
 
+	if backend, provider, remoteModel, ok := s.provider(model); ok {
+		return s.generateRemote(ctx, backend, provider, model, remoteModel, request)
+	}
+
+	// Drop the oldest messages that do not fit the model's context window
+	messages, trimmed := s.fitContext(ctx, model, request)
+
+	// Wait for a generation slot
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
+
 	// Convert to Ollama format
+	// Responses are read as a stream so partial output survives a timeout
 	ollamaRequest := map[string]interface{}{
 		"model":    model,
-		"messages": request.Messages,
-		"stream":   false,
+		"messages": messages,
+		"stream":   true,
 	}
 
-	if request.Temperature > 0 {
-		ollamaRequest["temperature"] = request.Temperature
+	if options := buildOptions(request.Temperature, request.MaxTokens, request.Options); len(options) > 0 {
+		ollamaRequest["options"] = options
 	}
-	if request.MaxTokens > 0 {
-		ollamaRequest["max_tokens"] = request.MaxTokens
+	requestLogprobs(ollamaRequest, request.Logprobs, request.TopLogprobs)
+	if len(request.Format) > 0 {
+		ollamaRequest["format"] = request.Format
 	}
 
-	// Determine which API to use
-	baseURL := s.config.BaseURL
-	if s.IsCloudModel(model) && s.config.CloudEnabled {
-		baseURL = s.config.CloudAPIURL
-	}
+	timeout := s.generationTimeout(model)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	// Make request to Ollama
-	resp, err := s.makeRequest("POST", "/api/chat", ollamaRequest, baseURL)
+	// Make request to Ollama, failing over to cloud if configured
+	start := time.Now()
+	resp, backend, err := s.sendGeneration(ctx, "/api/chat", ollamaRequest, model)
 	if err != nil {
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to make chat request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Parse Ollama response
-	var ollamaResp map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+	content, logprobs, ollamaResp, err := readGeneration(resp.Body, s.extractContent)
+	if err != nil {
+		if ctxErr := contextError(ctx, model, timeout, content); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Convert to our format
 	response := &models.ChatResponse{
-		ID:      generateID(),
+		ID:      s.GenerateID(),
 		Object:  "chat.completion",
-		Created: time.Now().Unix(),
+		Created: s.Now().Unix(),
 		Model:   model,
 		Choices: []models.Choice{
 			{
 				Index: 0,
 				Message: models.Message{
 					Role:    "assistant",
-					Content: s.extractContent(ollamaResp),
+					Content: content,
 				},
+				Logprobs: logprobs,
 			},
 		},
-		Usage: s.extractUsage(ollamaResp),
+		Usage:           s.extractUsage(ollamaResp),
+		Backend:         backend,
+		TrimmedMessages: trimmed,
 	}
 
+	s.recordUsage(ctx, backend, model, response.Usage, time.Since(start))
+
 	return response, nil
 }
 
-// Completion handles text completion using Ollama
-func (s *LlamaService) Completion(request models.CompletionRequest) (*models.CompletionResponse, error) {
+// Completion handles text completion using Ollama, trying the completion fallback chain while generations fail
+func (s *LlamaService) Completion(ctx context.Context, request models.CompletionRequest) (*models.CompletionResponse, error) {
 	model := s.getModel(request.Model)
+	if err := s.runBeforeCompletionHooks(EndpointCompletion, &request); err != nil {
+		return nil, fmt.Errorf("completion request rejected: %w", err)
+	}
+
+	candidates := s.fallbackCandidates(EndpointCompletion, model)
+	var attempts []models.FallbackAttempt
+	for i, candidate := range candidates {
+		response, err := s.complete(ctx, candidate, request)
+		if err != nil {
+			if i == len(candidates)-1 || !shouldFallback(ctx, err) {
+				return nil, err
+			}
+			logger(ctx).Warn("Completion failed, trying the next model in the fallback chain", "model", candidate, "error", err)
+			attempts = append(attempts, models.FallbackAttempt{Model: candidate, Error: err.Error()})
+			continue
+		}
+		response.FallbackAttempts = attempts
+
+		// Flags raised by moderating the prompt are reported with the reply
+		response.Moderation = request.Moderation
+		if err := s.runAfterCompletionHooks(EndpointCompletion, response); err != nil {
+			return nil, fmt.Errorf("completion response rejected: %w", err)
+		}
+		return response, nil
+	}
+	return nil, fmt.Errorf("no model to send the %s request to", EndpointCompletion)
+}
+
+// complete generates a completion of the request's prompt with model
+func (s *LlamaService) complete(ctx context.Context, model string, request models.CompletionRequest) (*models.CompletionResponse, error) {
+	if _, _, _, ok := s.provider(model); ok {
+		return nil, fmt.Errorf("%w: %s", ErrChatOnly, model)
+	}
 
 	// Check if cloud model and authentication
-	if s.IsCloudModel(model) && !s.isSignedIn {
+	if s.IsCloudModel(model) && !s.isSignedIn.Load() {
 		return nil, fmt.Errorf("must be signed in to use cloud model: %s", model)
 	}
 
+	// Wait for a generation slot
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
+
 	// Convert to Ollama format
+	// Responses are read as a stream so partial output survives a timeout
 	ollamaRequest := map[string]interface{}{
 		"model":  model,
 		"prompt": request.Prompt,
-		"stream": false,
+		"stream": true,
 	}
 
-	if request.Temperature > 0 {
-		ollamaRequest["temperature"] = request.Temperature
-	}
-	if request.MaxTokens > 0 {
-		ollamaRequest["max_tokens"] = request.MaxTokens
-	}
-	if request.Stop != "" {
-		ollamaRequest["stop"] = request.Stop
+	if options := buildOptions(request.Temperature, request.MaxTokens, request.Options, request.Stop); len(options) > 0 {
+		ollamaRequest["options"] = options
 	}
+	requestLogprobs(ollamaRequest, request.Logprobs, request.TopLogprobs)
 
-	// Determine which API to use
-	baseURL := s.config.BaseURL
-	if s.IsCloudModel(model) && s.config.CloudEnabled {
-		baseURL = s.config.CloudAPIURL
-	}
+	timeout := s.generationTimeout(model)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	// Make request to Ollama
-	resp, err := s.makeRequest("POST", "/api/generate", ollamaRequest, baseURL)
+	// Make request to Ollama, failing over to cloud if configured
+	start := time.Now()
+	resp, backend, err := s.sendGeneration(ctx, "/api/generate", ollamaRequest, model)
 	if err != nil {
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to make completion request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	// Parse Ollama response
-	var ollamaResp map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&ollamaResp); err != nil {
+	content, logprobs, ollamaResp, err := readGeneration(resp.Body, s.extractResponse)
+	if err != nil {
+		if ctxErr := contextError(ctx, model, timeout, content); ctxErr != nil {
+			return nil, ctxErr
+		}
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Convert to our format
 	response := &models.CompletionResponse{
-		ID:      generateID(),
+		ID:      s.GenerateID(),
 		Object:  "text_completion",
-		Created: time.Now().Unix(),
+		Created: s.Now().Unix(),
 		Model:   model,
 		Choices: []models.Choice{
 			{
 				Index: 0,
 				Message: models.Message{
 					Role:    "assistant",
-					Content: s.extractResponse(ollamaResp),
+					Content: content,
 				},
+				Logprobs: logprobs,
 			},
 		},
-		Usage: s.extractUsage(ollamaResp),
+		Usage:   s.extractUsage(ollamaResp),
+		Backend: backend,
 	}
 
+	s.recordUsage(ctx, backend, model, response.Usage, time.Since(start))
+
 	return response, nil
 }
 
 // Embedding handles embedding generation using Ollama
-func (s *LlamaService) Embedding(request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
+func (s *LlamaService) Embedding(ctx context.Context, request models.EmbeddingRequest) (*models.EmbeddingResponse, error) {
 	model := s.getModel(request.Model)
+	if _, _, _, ok := s.provider(model); ok {
+		return nil, fmt.Errorf("%w: %s", ErrChatOnly, model)
+	}
 
 	// Check if cloud model and authentication
-	if s.IsCloudModel(model) && !s.isSignedIn {
+	if s.IsCloudModel(model) && !s.isSignedIn.Load() {
 		return nil, fmt.Errorf("must be signed in to use cloud model: %s", model)
 	}
 
+	// Wait for a slot, as embedding models share the GPU with generation
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
+
 	// Convert to Ollama format
 	ollamaRequest := map[string]interface{}{
 		"model":  model,
@@ -286,7 +539,10 @@ func (s *LlamaService) Embedding(request models.EmbeddingRequest) (*models.Embed
 	}
 
 	// Make request to Ollama
-	resp, err := s.makeRequest("POST", "/api/embeddings", ollamaRequest, baseURL)
+	ctx, cancel := context.WithTimeout(ctx, s.generationTimeout(model))
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "POST", "/api/embeddings", ollamaRequest, baseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make embedding request: %w", err)
 	}
@@ -334,13 +590,24 @@ func (s *LlamaService) Embedding(request models.EmbeddingRequest) (*models.Embed
 		return nil, fmt.Errorf("invalid embedding response format - no embedding data found in response: %v", ollamaResp)
 	}
 
+	vector := convertToFloat64Slice(embeddingData)
+	if request.Dimensions > 0 {
+		if request.Dimensions > len(vector) {
+			return nil, fmt.Errorf("%w: requested %d, model %s returned %d", ErrInvalidDimensions, request.Dimensions, model, len(vector))
+		}
+		vector = vector[:request.Dimensions]
+	}
+	if request.Normalize {
+		vector = normalizeVector(vector)
+	}
+
 	// Convert to our format
 	response := &models.EmbeddingResponse{
 		Object: "list",
 		Data: []models.Embedding{
 			{
 				Object:    "embedding",
-				Embedding: convertToFloat64Slice(embeddingData),
+				Embedding: vector,
 				Index:     0,
 			},
 		},
@@ -356,7 +623,10 @@ func (s *LlamaService) ListModels() ([]models.Model, error) {
 	var allModels []models.Model
 
 	// Get local models
-	resp, err := s.makeRequest("GET", "/api/tags", nil, s.config.BaseURL)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "GET", "/api/tags", nil, s.config.BaseURL)
 	if err == nil {
 		defer resp.Body.Close()
 		var localResp map[string]interface{}
@@ -367,7 +637,7 @@ func (s *LlamaService) ListModels() ([]models.Model, error) {
 						model := models.Model{
 							ID:      modelMap["name"].(string),
 							Object:  "model",
-							Created: time.Now().Unix(),
+							Created: s.Now().Unix(),
 							OwnedBy: "ollama",
 							IsCloud: false,
 						}
@@ -381,14 +651,18 @@ func (s *LlamaService) ListModels() ([]models.Model, error) {
 		}
 	}
 
-	// Add cloud models if enabled and signed in
-	if s.config.CloudEnabled && s.isSignedIn {
-		for _, cloudModel := range CloudModels {
+	// Add the cloud catalog if enabled and signed in
+	if s.config.CloudEnabled && s.isSignedIn.Load() {
+		cloudModels, err := s.ListCloudModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, cloudModel := range cloudModels {
 			if cloudModel.Available {
 				model := models.Model{
 					ID:      cloudModel.ID,
 					Object:  "model",
-					Created: time.Now().Unix(),
+					Created: s.Now().Unix(),
 					OwnedBy: "ollama-cloud",
 					IsCloud: true,
 					Size:    cloudModel.Size,
@@ -401,44 +675,113 @@ func (s *LlamaService) ListModels() ([]models.Model, error) {
 	return allModels, nil
 }
 
-// StreamChat handles streaming chat completion
-func (s *LlamaService) StreamChat(request models.ChatRequest, responseChan chan<- string) {
+// StreamChat handles streaming chat completion.
+// Streams go through the endpoint's fallback chain until a model starts answering; once text was
+// sent a failure ends the stream. Response hooks run on the complete reply: text they append is
+// streamed at the end, an error they return ends the stream with an error, and any other change
+// cannot be applied to text that was already sent.
+func (s *LlamaService) StreamChat(ctx context.Context, request models.ChatRequest, responseChan chan<- string) {
 	defer close(responseChan)
+	ctx = withDefaultPriority(ctx, models.PriorityInteractive)
+
+	if err := s.applyPreset(&request); err != nil {
+		responseChan <- fmt.Sprintf("Error: %v", err)
+		return
+	}
+	if err := s.runBeforeHooks(EndpointChatStream, &request); err != nil {
+		responseChan <- fmt.Sprintf("Error: chat request rejected: %v", err)
+		return
+	}
 
 	model := s.getModel(request.Model)
+	candidates := s.fallbackCandidates(EndpointChatStream, model)
+	for i, candidate := range candidates {
+		response, err := s.streamModel(ctx, candidate, request, responseChan)
+		if err == nil {
+			s.finishStream(response, responseChan)
+			return
+		}
+		if response == nil && i < len(candidates)-1 && shouldFallback(ctx, err) {
+			logger(ctx).Warn("Streaming chat failed, trying the next model in the fallback chain", "model", candidate, "error", err)
+			continue
+		}
+		// Nobody is left to read an error once the client went away
+		if !errors.Is(err, context.Canceled) {
+			responseChan <- fmt.Sprintf("Error: %v", err)
+		}
+		return
+	}
+}
 
-	// Check if cloud model and authentication
-	if s.IsCloudModel(model) && !s.isSignedIn {
-		responseChan <- fmt.Sprintf("Error: must be signed in to use cloud model: %s", model)
+// finishStream runs the response hooks on a streamed reply and streams the text they appended
+func (s *LlamaService) finishStream(response *models.ChatResponse, responseChan chan<- string) {
+	streamed := response.Choices[0].Message.Content
+	if err := s.runAfterHooks(EndpointChatStream, response); err != nil {
+		responseChan <- fmt.Sprintf("Error: chat response rejected: %v", err)
 		return
 	}
+	if appended, ok := strings.CutPrefix(response.Choices[0].Message.Content, streamed); ok && appended != "" {
+		responseChan <- appended
+	}
+}
+
+// streamModel streams the reply of a single model to responseChan and returns it once complete.
+// The reply is also returned alongside the error when the stream fails after text was sent, and
+// is nil when it failed before.
+func (s *LlamaService) streamModel(ctx context.Context, model string, request models.ChatRequest, responseChan chan<- string) (*models.ChatResponse, error) {
+	// Check if cloud model and authentication
+	if s.IsCloudModel(model) && !s.isSignedIn.Load() {
+		return nil, fmt.Errorf("must be signed in to use cloud model: %s", model)
+	}
+
+	if backend, provider, remoteModel, ok := s.provider(model); ok {
+		return s.streamRemote(ctx, backend, provider, model, remoteModel, request, responseChan)
+	}
+
+	// Drop the oldest messages that do not fit the model's context window
+	messages, trimmed := s.fitContext(ctx, model, request)
+	if trimmed > 0 {
+		logger(ctx).Info("Dropped oldest messages to fit the context window", "model", model, "dropped", trimmed)
+	}
+
+	// Wait for a generation slot
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
 
 	// Convert to Ollama format
 	ollamaRequest := map[string]interface{}{
 		"model":    model,
-		"messages": request.Messages,
+		"messages": messages,
 		"stream":   true,
 	}
 
-	if request.Temperature > 0 {
-		ollamaRequest["temperature"] = request.Temperature
+	if options := buildOptions(request.Temperature, request.MaxTokens, request.Options); len(options) > 0 {
+		ollamaRequest["options"] = options
 	}
 
-	// Determine which API to use
-	baseURL := s.config.BaseURL
-	if s.IsCloudModel(model) && s.config.CloudEnabled {
-		baseURL = s.config.CloudAPIURL
-	}
+	timeout := s.generationTimeout(model)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
 
-	// Make request to Ollama
-	resp, err := s.makeRequest("POST", "/api/chat", ollamaRequest, baseURL)
+	// Make request to Ollama, failing over to cloud if configured
+	start := time.Now()
+	resp, backend, err := s.sendGeneration(ctx, "/api/chat", ollamaRequest, model)
 	if err != nil {
-		responseChan <- fmt.Sprintf("Error: %v", err)
-		return
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to make chat request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	// Read streaming response
+	// Read streaming response; the final chunk carries the token counts
+	var content strings.Builder
+	var last map[string]interface{}
 	scanner := bufio.NewScanner(resp.Body)
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -451,46 +794,175 @@ func (s *LlamaService) StreamChat(request models.ChatRequest, responseChan chan<
 			continue
 		}
 
-		if message, ok := streamResp["message"].(map[string]interface{}); ok {
-			if content, ok := message["content"].(string); ok {
-				responseChan <- content
+		if text := s.extractContent(streamResp); text != "" {
+			content.WriteString(text)
+			responseChan <- text
+		}
+		last = streamResp
+	}
+
+	response := &models.ChatResponse{
+		ID:      s.GenerateID(),
+		Object:  "chat.completion",
+		Created: s.Now().Unix(),
+		Model:   model,
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: content.String()}}},
+		Backend: backend,
+	}
+	var sent *models.ChatResponse
+	if content.Len() > 0 {
+		sent = response
+	}
+
+	if err := scanner.Err(); err != nil {
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return sent, ctxErr
+		}
+		return sent, fmt.Errorf("failed to read stream: %w", err)
+	}
+	if done, _ := last["done"].(bool); done {
+		response.Usage = s.extractUsage(last)
+		s.recordUsage(ctx, backend, model, response.Usage, time.Since(start))
+	}
+	return response, nil
+}
+
+// readGeneration reads an Ollama response that may be a single JSON object or a stream of
+// NDJSON chunks. It returns the accumulated content and token logprobs, and the final chunk,
+// which carries usage data. On error the content read so far is still returned.
+func readGeneration(body io.Reader, extract func(map[string]interface{}) string) (string, []models.TokenLogprob, map[string]interface{}, error) {
+	var content strings.Builder
+	var logprobs []models.TokenLogprob
+	last := map[string]interface{}{}
+
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk map[string]interface{}
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				break
 			}
+			return content.String(), logprobs, last, err
 		}
+		content.WriteString(extract(chunk))
+		logprobs = append(logprobs, chunkLogprobs(chunk)...)
+		last = chunk
 	}
+
+	return content.String(), logprobs, last, nil
+}
+
+// makeRequest makes HTTP request to Ollama API.
+// Transient failures are retried with exponential backoff up to the configured number of attempts.
+func (s *LlamaService) makeRequest(ctx context.Context, method, endpoint string, body interface{}, baseURL string) (*http.Response, error) {
+	return s.requestWith(ctx, s.httpClient, method, endpoint, body, baseURL)
 }
 
-// makeRequest makes HTTP request to Ollama API
-func (s *LlamaService) makeRequest(method, endpoint string, body interface{}, baseURL string) (*http.Response, error) {
-	var reqBody io.Reader
+// makeLoadRequest is makeRequest for pulls and model loads, which Ollama may only answer once the
+// model is in memory. It is not bound by the header timeout, only by the deadline of ctx.
+func (s *LlamaService) makeLoadRequest(ctx context.Context, method, endpoint string, body interface{}, baseURL string) (*http.Response, error) {
+	return s.requestWith(ctx, s.loadClient, method, endpoint, body, baseURL)
+}
+
+func (s *LlamaService) requestWith(ctx context.Context, client HTTPClient, method, endpoint string, body interface{}, baseURL string) (*http.Response, error) {
+	var jsonBody []byte
 	if body != nil {
-		jsonBody, err := json.Marshal(body)
+		var err error
+		jsonBody, err = json.Marshal(body)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request body: %w", err)
 		}
-		reqBody = bytes.NewBuffer(jsonBody)
 	}
 
-	req, err := http.NewRequest(method, baseURL+endpoint, reqBody)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	for attempt := 1; ; attempt++ {
+		var reqBody io.Reader
+		if jsonBody != nil {
+			reqBody = bytes.NewReader(jsonBody)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
+		req, err := http.NewRequestWithContext(ctx, method, baseURL+endpoint, reqBody)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
 
-	// Add authentication for cloud requests
-	if strings.Contains(baseURL, "api.ollama.com") && s.config.CloudAPIKey != "" {
-		req.Header.Set("Authorization", "Bearer "+s.config.CloudAPIKey)
-	}
+		req.Header.Set("Content-Type", "application/json")
+		forwardRequestID(ctx, req.Header)
+
+		// Add authentication for cloud requests
+		isCloud := baseURL == s.config.CloudAPIURL || strings.Contains(baseURL, "api.ollama.com")
+		if token := s.cloudToken(); isCloud && token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
 
-	return s.httpClient.Do(req)
+		start := time.Now()
+		resp, err := client.Do(req)
+		if resp != nil {
+			// Time to response headers; streamed bodies are still being generated
+			logger(ctx).Debug("Ollama request", "method", method, "path", endpoint, "status", resp.StatusCode, "attempt", attempt, "duration", time.Since(start))
+		}
+		if attempt >= s.config.RetryMaxAttempts || ctx.Err() != nil || !s.shouldRetry(resp, err) {
+			return resp, err
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		delay := s.retryDelay(attempt)
+		logger(ctx).Warn("Ollama request failed, retrying", "method", method, "path", endpoint, "attempt", attempt, "max_attempts", s.config.RetryMaxAttempts, "delay", delay)
+
+		select {
+		case <-time.After(delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
 }
 
 // Helper functions
 func (s *LlamaService) getModel(requestedModel string) string {
 	if requestedModel == "" {
-		return s.config.DefaultModel
+		return s.resolveAlias(s.DefaultModel())
+	}
+	return s.resolveAlias(requestedModel)
+}
+
+// buildOptions merges the top-level sampling fields and the explicit options into Ollama's options object.
+// Values set in opts take precedence over the top-level temperature and max_tokens fields.
+func buildOptions(temperature float64, maxTokens int, opts *models.Options, stop ...string) map[string]interface{} {
+	options := map[string]interface{}{}
+
+	if temperature > 0 {
+		options["temperature"] = temperature
+	}
+	if maxTokens > 0 {
+		options["num_predict"] = maxTokens
+	}
+	for _, sequence := range stop {
+		if sequence != "" {
+			options["stop"] = append(toStringSlice(options["stop"]), sequence)
+		}
 	}
-	return requestedModel
+
+	if opts != nil {
+		raw, err := json.Marshal(opts)
+		if err == nil {
+			var explicit map[string]interface{}
+			if json.Unmarshal(raw, &explicit) == nil {
+				for key, value := range explicit {
+					options[key] = value
+				}
+			}
+		}
+	}
+
+	return options
+}
+
+func toStringSlice(value interface{}) []string {
+	if slice, ok := value.([]string); ok {
+		return slice
+	}
+	return nil
 }
 
 func (s *LlamaService) extractContent(response map[string]interface{}) string {
@@ -523,8 +995,37 @@ func (s *LlamaService) extractUsage(response map[string]interface{}) models.Usag
 	return usage
 }
 
-func generateID() string {
-	return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+// GenerateID returns a response ID taken from the service's clock, made unique when the clock
+// stands still or goes back
+func (s *LlamaService) GenerateID() string {
+	for {
+		last := s.lastID.Load()
+		id := s.Now().UnixNano()
+		if id <= last {
+			id = last + 1
+		}
+		if s.lastID.CompareAndSwap(last, id) {
+			return fmt.Sprintf("chatcmpl-%d", id)
+		}
+	}
+}
+
+// normalizeVector scales a vector to unit L2 length; zero vectors are returned unchanged
+func normalizeVector(vector []float64) []float64 {
+	var sum float64
+	for _, v := range vector {
+		sum += v * v
+	}
+	if sum == 0 {
+		return vector
+	}
+
+	norm := math.Sqrt(sum)
+	normalized := make([]float64, len(vector))
+	for i, v := range vector {
+		normalized[i] = v / norm
+	}
+	return normalized
 }
 
 func convertToFloat64Slice(interfaceSlice []interface{}) []float64 {
@@ -1,8 +1,17 @@
 package services
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
+	"agent-ollama-gin/config"
 	"agent-ollama-gin/models"
 
 	"github.com/stretchr/testify/assert"
@@ -56,35 +65,27 @@ func TestIsCloudModel(t *testing.T) {
 
 func TestSignIn(t *testing.T) {
 	tests := []struct {
-		name     string
-		username string
-		password string
+		name    string
+		request models.AuthRequest
 	}{
 		{
-			name:     "Valid credentials",
-			username: "test@example.com",
-			password: "password123",
-		},
-		{
-			name:     "Empty credentials",
-			username: "",
-			password: "",
+			name:    "Username and password only",
+			request: models.AuthRequest{Username: "test@example.com", Password: "password123"},
 		},
 		{
-			name:     "Only username",
-			username: "test@example.com",
-			password: "",
+			name:    "Empty credentials",
+			request: models.AuthRequest{},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			service := NewLlamaService()
-			response, err := service.SignIn(tt.username, tt.password)
+			service.config.CloudEnabled = true
+			response, err := service.SignIn(context.Background(), tt.request)
 
 			assert.NoError(t, err)
-			assert.NotNil(t, response)
-			// Response will depend on cloud configuration
+			assert.False(t, response.Success)
 		})
 	}
 }
@@ -114,14 +115,17 @@ func TestCloudModelsAvailability(t *testing.T) {
 }
 
 func TestGenerateID(t *testing.T) {
-	id1 := generateID()
-	id2 := generateID()
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	service := NewLlamaService(WithClock(func() time.Time { return now }))
+	id1 := service.GenerateID()
+	id2 := service.GenerateID()
 
 	// IDs should not be empty
 	assert.NotEmpty(t, id1)
 	assert.NotEmpty(t, id2)
 
-	// IDs should be unique
+	// IDs come from the service's clock and stay unique while it stands still
+	assert.Equal(t, fmt.Sprintf("chatcmpl-%d", now.UnixNano()), id1)
 	assert.NotEqual(t, id1, id2)
 
 	// IDs should have the expected prefix
@@ -306,3 +310,235 @@ func TestGetModel(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildOptions(t *testing.T) {
+	topK := 40
+	seed := 42
+	topP := 0.9
+	temperature := 0.2
+
+	tests := []struct {
+		name        string
+		temperature float64
+		maxTokens   int
+		opts        *models.Options
+		stop        []string
+		expected    map[string]interface{}
+	}{
+		{
+			name:     "No options",
+			expected: map[string]interface{}{},
+		},
+		{
+			name:        "Top-level fields",
+			temperature: 0.7,
+			maxTokens:   128,
+			stop:        []string{"\n\n"},
+			expected: map[string]interface{}{
+				"temperature": 0.7,
+				"num_predict": 128,
+				"stop":        []string{"\n\n"},
+			},
+		},
+		{
+			name:        "Explicit options take precedence",
+			temperature: 0.7,
+			opts: &models.Options{
+				Temperature: &temperature,
+				TopK:        &topK,
+				TopP:        &topP,
+				Seed:        &seed,
+				Stop:        []string{"END"},
+			},
+			expected: map[string]interface{}{
+				"temperature": 0.2,
+				"top_k":       40.0,
+				"top_p":       0.9,
+				"seed":        42.0,
+				"stop":        []interface{}{"END"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := buildOptions(tt.temperature, tt.maxTokens, tt.opts, tt.stop...)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGenerationTimeout(t *testing.T) {
+	service := NewLlamaService()
+	service.config.Timeout = 60
+	service.config.ModelTimeouts = []config.ModelTimeout{
+		{Pattern: "70b", Seconds: 600},
+		{Pattern: "-cloud", Seconds: 300},
+	}
+
+	assert.Equal(t, 60*time.Second, service.generationTimeout("llama2"))
+	assert.Equal(t, 600*time.Second, service.generationTimeout("llama3.1:70B"))
+	assert.Equal(t, 300*time.Second, service.generationTimeout("gpt-oss:120b-cloud"))
+}
+
+func TestChat_StreamedResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hello"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":", world"},"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":""},"done":true,"prompt_eval_count":5,"eval_count":3}`)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello, world", response.Choices[0].Message.Content)
+	assert.Equal(t, 8, response.Usage.TotalTokens)
+}
+
+func TestChat_TimeoutReturnsPartialOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Partial"},"done":false}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.ModelTimeouts = []config.ModelTimeout{{Pattern: "slow", Seconds: 1}}
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "slow-model",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	var timeoutErr *GenerationTimeoutError
+	assert.ErrorAs(t, err, &timeoutErr)
+	assert.Equal(t, "Partial", timeoutErr.Partial)
+	assert.Equal(t, time.Second, timeoutErr.Timeout)
+}
+
+func TestDeleteModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "DELETE", r.Method)
+		assert.Equal(t, "/api/delete", r.URL.Path)
+
+		var body map[string]string
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["model"] != "phi3:mini" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.DefaultModel = "llama2"
+
+	assert.NoError(t, service.DeleteModel("phi3:mini"))
+	assert.ErrorIs(t, service.DeleteModel("missing"), ErrModelNotFound)
+	assert.ErrorIs(t, service.DeleteModel("llama2"), ErrDefaultModelProtected)
+}
+
+func TestNormalizeVector(t *testing.T) {
+	assert.InDeltaSlice(t, []float64{0.6, 0.8}, normalizeVector([]float64{3, 4}), 1e-9)
+	assert.Equal(t, []float64{0, 0}, normalizeVector([]float64{0, 0}))
+}
+
+func TestEmbedding_DimensionsAndNormalize(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"embedding": []float64{3, 4, 12, 1},
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Embedding(context.Background(), models.EmbeddingRequest{
+		Input:      "Test",
+		Model:      "nomic-embed-text",
+		Dimensions: 2,
+		Normalize:  true,
+	})
+	assert.NoError(t, err)
+	assert.InDeltaSlice(t, []float64{0.6, 0.8}, response.Data[0].Embedding, 1e-9)
+
+	_, err = service.Embedding(context.Background(), models.EmbeddingRequest{
+		Input:      "Test",
+		Model:      "nomic-embed-text",
+		Dimensions: 8,
+	})
+	assert.ErrorIs(t, err, ErrInvalidDimensions)
+}
+
+func TestChat_CancelledContextAbortsUpstream(t *testing.T) {
+	upstreamCancelled := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Thinking"},"done":false}`)
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		close(upstreamCancelled)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := service.Chat(ctx, models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	})
+
+	assert.ErrorIs(t, err, context.Canceled)
+	var cancelledErr *CancelledError
+	assert.ErrorAs(t, err, &cancelledErr)
+	var upstreamErr *UpstreamError
+	assert.False(t, errors.As(err, &upstreamErr))
+	select {
+	case <-upstreamCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("upstream request was not aborted")
+	}
+}
+
+func TestStreamChat_CancelledContextSendsNoError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/chat" {
+			// Drain the body so the server notices when the client disconnects
+			io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	responseChan := make(chan string)
+	go service.StreamChat(ctx, models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	}, responseChan)
+
+	for chunk := range responseChan {
+		assert.NotContains(t, chunk, "Error: ")
+	}
+}
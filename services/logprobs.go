@@ -0,0 +1,36 @@
+package services
+
+import (
+	"encoding/json"
+
+	"agent-ollama-gin/models"
+)
+
+// requestLogprobs asks Ollama for token log probabilities. Asking for alternatives implies logprobs.
+func requestLogprobs(ollamaRequest map[string]interface{}, logprobs bool, topLogprobs int) {
+	if topLogprobs > 0 {
+		ollamaRequest["logprobs"] = true
+		ollamaRequest["top_logprobs"] = topLogprobs
+	} else if logprobs {
+		ollamaRequest["logprobs"] = true
+	}
+}
+
+// chunkLogprobs returns the token logprobs carried by a response chunk. Backends without
+// logprob support leave the field out, in which case there are none.
+func chunkLogprobs(chunk map[string]interface{}) []models.TokenLogprob {
+	raw, ok := chunk["logprobs"]
+	if !ok || raw == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil
+	}
+	var logprobs []models.TokenLogprob
+	if err := json.Unmarshal(data, &logprobs); err != nil {
+		return nil
+	}
+	return logprobs
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChat_Logprobs(t *testing.T) {
+	var sent map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"Hel"},"logprobs":[{"token":"Hel","logprob":-0.1,"top_logprobs":[{"token":"Hel","logprob":-0.1},{"token":"Hi","logprob":-2.5}]}],"done":false}`)
+		fmt.Fprintln(w, `{"message":{"role":"assistant","content":"lo"},"logprobs":[{"token":"lo","logprob":-0.02,"bytes":[108,111]}],"done":true}`)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:       "llama3.2",
+		Messages:    []models.Message{{Role: "user", Content: "Say hello"}},
+		TopLogprobs: 2,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, true, sent["logprobs"])
+	assert.Equal(t, float64(2), sent["top_logprobs"])
+	assert.Equal(t, "Hello", response.Choices[0].Message.Content)
+	assert.Equal(t, []models.TokenLogprob{
+		{Token: "Hel", Logprob: -0.1, TopLogprobs: []models.TopLogprob{{Token: "Hel", Logprob: -0.1}, {Token: "Hi", Logprob: -2.5}}},
+		{Token: "lo", Logprob: -0.02, Bytes: []int{108, 111}},
+	}, response.Choices[0].Logprobs)
+}
+
+func TestRequestLogprobs(t *testing.T) {
+	request := map[string]interface{}{}
+	requestLogprobs(request, false, 0)
+	assert.Empty(t, request)
+
+	requestLogprobs(request, true, 0)
+	assert.Equal(t, map[string]interface{}{"logprobs": true}, request)
+}
@@ -0,0 +1,152 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ParseModelfile converts a Modelfile into the structured fields accepted by Ollama's create API.
+// The raw Modelfile is kept as well so older Ollama versions can still consume it.
+func ParseModelfile(modelfile string) (map[string]interface{}, error) {
+	fields := map[string]interface{}{
+		"modelfile": modelfile,
+	}
+	parameters := map[string]interface{}{}
+	var messages []map[string]string
+	var adapters []string
+
+	lines := strings.Split(strings.ReplaceAll(modelfile, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		instruction, rest, _ := strings.Cut(line, " ")
+		rest = strings.TrimSpace(rest)
+
+		// Triple-quoted values may span several lines
+		if strings.HasPrefix(rest, `"""`) {
+			value := strings.TrimPrefix(rest, `"""`)
+			for !strings.HasSuffix(value, `"""`) {
+				i++
+				if i >= len(lines) {
+					return nil, fmt.Errorf("unterminated triple-quoted value for %s", strings.ToUpper(instruction))
+				}
+				value += "\n" + lines[i]
+			}
+			rest = strings.TrimSuffix(value, `"""`)
+		}
+
+		switch strings.ToUpper(instruction) {
+		case "FROM":
+			fields["from"] = rest
+		case "SYSTEM":
+			fields["system"] = rest
+		case "TEMPLATE":
+			fields["template"] = rest
+		case "LICENSE":
+			fields["license"] = rest
+		case "ADAPTER":
+			adapters = append(adapters, rest)
+		case "PARAMETER":
+			name, value, found := strings.Cut(rest, " ")
+			if !found {
+				return nil, fmt.Errorf("PARAMETER %q has no value", name)
+			}
+			addParameter(parameters, name, strings.TrimSpace(value))
+		case "MESSAGE":
+			role, content, found := strings.Cut(rest, " ")
+			if !found {
+				return nil, fmt.Errorf("MESSAGE %q has no content", role)
+			}
+			messages = append(messages, map[string]string{"role": role, "content": strings.TrimSpace(content)})
+		default:
+			return nil, fmt.Errorf("unknown Modelfile instruction %q", instruction)
+		}
+	}
+
+	if _, ok := fields["from"]; !ok {
+		return nil, errors.New("modelfile must contain a FROM instruction")
+	}
+	if len(parameters) > 0 {
+		fields["parameters"] = parameters
+	}
+	if len(messages) > 0 {
+		fields["messages"] = messages
+	}
+	if len(adapters) > 0 {
+		fields["adapters"] = adapters
+	}
+
+	return fields, nil
+}
+
+// addParameter stores a Modelfile parameter, converting numbers and collecting repeated stop sequences
+func addParameter(parameters map[string]interface{}, name, value string) {
+	value = strings.Trim(value, `"`)
+
+	if name == "stop" {
+		stops, _ := parameters["stop"].([]string)
+		parameters["stop"] = append(stops, value)
+		return
+	}
+	if number, err := strconv.ParseFloat(value, 64); err == nil {
+		parameters[name] = number
+		return
+	}
+	parameters[name] = value
+}
+
+// CreateModel builds a model from a Modelfile, sending build progress to progressChan. Cancelling
+// ctx aborts the build.
+func (s *LlamaService) CreateModel(ctx context.Context, modelName, modelfile string, progressChan chan<- string) {
+	defer close(progressChan)
+
+	createRequest, err := ParseModelfile(modelfile)
+	if err != nil {
+		progressChan <- fmt.Sprintf("Error: %v", err)
+		return
+	}
+	createRequest["model"] = modelName
+	createRequest["name"] = modelName
+	createRequest["stream"] = true
+
+	ctx, cancel := context.WithTimeout(ctx, s.generationTimeout(modelName))
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "POST", "/api/create", createRequest, s.config.BaseURL)
+	if err != nil {
+		progressChan <- fmt.Sprintf("Error: failed to create model: %v", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		progressChan <- fmt.Sprintf("Error: ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+		return
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		var progress map[string]interface{}
+		if err := json.Unmarshal(scanner.Bytes(), &progress); err != nil {
+			continue
+		}
+		if errMsg, ok := progress["error"].(string); ok {
+			progressChan <- "Error: " + errMsg
+			return
+		}
+		if status, ok := progress["status"].(string); ok {
+			progressChan <- status
+		}
+	}
+}
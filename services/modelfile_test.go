@@ -0,0 +1,111 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseModelfile(t *testing.T) {
+	modelfile := `# Encyclopedia editor
+FROM llama3.2
+PARAMETER temperature 0.3
+PARAMETER stop "<|end|>"
+PARAMETER stop "###"
+SYSTEM """You are an encyclopedia editor.
+Write neutrally."""
+MESSAGE user Who wrote Hamlet?
+MESSAGE assistant William Shakespeare.
+`
+
+	fields, err := ParseModelfile(modelfile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "llama3.2", fields["from"])
+	assert.Equal(t, "You are an encyclopedia editor.\nWrite neutrally.", fields["system"])
+	assert.Equal(t, map[string]interface{}{
+		"temperature": 0.3,
+		"stop":        []string{"<|end|>", "###"},
+	}, fields["parameters"])
+	assert.Equal(t, []map[string]string{
+		{"role": "user", "content": "Who wrote Hamlet?"},
+		{"role": "assistant", "content": "William Shakespeare."},
+	}, fields["messages"])
+	assert.Equal(t, modelfile, fields["modelfile"])
+}
+
+func TestParseModelfile_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		modelfile string
+	}{
+		{name: "Missing FROM", modelfile: "SYSTEM hello"},
+		{name: "Unknown instruction", modelfile: "FROM llama3.2\nSTEAL everything"},
+		{name: "Unterminated quote", modelfile: "FROM llama3.2\nSYSTEM \"\"\"never closed"},
+		{name: "Parameter without value", modelfile: "FROM llama3.2\nPARAMETER temperature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseModelfile(tt.modelfile)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestCreateModel_StreamsProgress(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "editor", body["model"])
+		assert.Equal(t, "llama3.2", body["from"])
+
+		fmt.Fprintln(w, `{"status":"using existing layer"}`)
+		fmt.Fprintln(w, `{"status":"success"}`)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	progressChan := make(chan string)
+	go service.CreateModel(context.Background(), "editor", "FROM llama3.2", progressChan)
+
+	var progress []string
+	for status := range progressChan {
+		progress = append(progress, status)
+	}
+
+	assert.Equal(t, []string{"using existing layer", "success"}, progress)
+}
+
+func TestCreateModel_Cancelled(t *testing.T) {
+	var called atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called.Store(true)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	progressChan := make(chan string)
+	go service.CreateModel(ctx, "editor", "FROM llama3.2", progressChan)
+
+	var progress []string
+	for status := range progressChan {
+		progress = append(progress, status)
+	}
+
+	assert.Len(t, progress, 1)
+	assert.Contains(t, progress[0], "context canceled")
+	assert.False(t, called.Load(), "a disconnected client does not start a build")
+}
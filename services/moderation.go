@@ -0,0 +1,366 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Moderation stages
+const (
+	ModerationPrompt     = "prompt"
+	ModerationCompletion = "completion"
+)
+
+// Moderation actions, applied to content matching a policy category
+const (
+	ModerationBlock    = "block"    // Reject the request
+	ModerationRedact   = "redact"   // Replace the matched text
+	ModerationAnnotate = "annotate" // Pass the content on and report the categories in the response
+)
+
+const (
+	defaultModerationReplacement = "[redacted]"
+	defaultClassifierTimeout     = 30 * time.Second
+)
+
+// ModerationPolicy is a deployment's moderation policy: the categories to look for and what to
+// do with prompts and replies matching them. An empty action leaves that stage unmoderated.
+type ModerationPolicy struct {
+	PromptAction     string                `yaml:"prompt_action"`
+	CompletionAction string                `yaml:"completion_action"`
+	Replacement      string                `yaml:"replacement"` // Text redacted matches are replaced with
+	Rules            []ModerationRule      `yaml:"rules"`
+	Classifier       *ModerationClassifier `yaml:"classifier"`
+}
+
+// ModerationRule is a category matched by keywords, case-insensitively as whole words, or by
+// regular expressions
+type ModerationRule struct {
+	Category string   `yaml:"category"`
+	Keywords []string `yaml:"keywords"`
+	Patterns []string `yaml:"patterns"`
+
+	matchers []*regexp.Regexp
+}
+
+// ModerationClassifier is a model asked whether content is safe. It must answer "safe", or
+// "unsafe" followed by the violated categories, as Llama Guard does; Prompt is a system prompt
+// instructing general-purpose models to answer that way.
+type ModerationClassifier struct {
+	Model   string `yaml:"model"`
+	Prompt  string `yaml:"prompt"`
+	Timeout int    `yaml:"timeout"` // Seconds, 30 by default
+}
+
+// LoadModerationPolicy reads and checks the YAML moderation policy at path
+func LoadModerationPolicy(path string) (*ModerationPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var policy ModerationPolicy
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&policy); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if err := policy.compile(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &policy, nil
+}
+
+// compile checks the policy and compiles its rules
+func (p *ModerationPolicy) compile() error {
+	actions := []string{"", ModerationBlock, ModerationRedact, ModerationAnnotate}
+	if !slices.Contains(actions, p.PromptAction) {
+		return fmt.Errorf("unknown prompt_action %q, expected block, redact or annotate", p.PromptAction)
+	}
+	if !slices.Contains(actions, p.CompletionAction) {
+		return fmt.Errorf("unknown completion_action %q, expected block, redact or annotate", p.CompletionAction)
+	}
+	if p.PromptAction == "" && p.CompletionAction == "" {
+		return fmt.Errorf("neither prompt_action nor completion_action is set")
+	}
+	if len(p.Rules) == 0 && p.Classifier == nil {
+		return fmt.Errorf("the policy has no rules and no classifier")
+	}
+	if p.Classifier != nil && p.Classifier.Model == "" {
+		return fmt.Errorf("the classifier has no model")
+	}
+	if p.Replacement == "" {
+		p.Replacement = defaultModerationReplacement
+	}
+
+	for i := range p.Rules {
+		rule := &p.Rules[i]
+		if rule.Category == "" {
+			return fmt.Errorf("rule %d has no category", i+1)
+		}
+		if len(rule.Keywords) == 0 && len(rule.Patterns) == 0 {
+			return fmt.Errorf("rule %q has no keywords or patterns", rule.Category)
+		}
+		for _, keyword := range rule.Keywords {
+			if keyword = strings.TrimSpace(keyword); keyword == "" {
+				return fmt.Errorf("rule %q has an empty keyword", rule.Category)
+			}
+			rule.matchers = append(rule.matchers, keywordMatcher(keyword))
+		}
+		for _, pattern := range rule.Patterns {
+			matcher, err := regexp.Compile(pattern)
+			if err != nil {
+				return fmt.Errorf("rule %q: %w", rule.Category, err)
+			}
+			rule.matchers = append(rule.matchers, matcher)
+		}
+	}
+	return nil
+}
+
+var (
+	leadingWordChar  = regexp.MustCompile(`^\w`)
+	trailingWordChar = regexp.MustCompile(`\w$`)
+)
+
+// keywordMatcher matches keyword case-insensitively, as a whole word where it starts or ends
+// with a word character
+func keywordMatcher(keyword string) *regexp.Regexp {
+	pattern := regexp.QuoteMeta(keyword)
+	if leadingWordChar.MatchString(keyword) {
+		pattern = `\b` + pattern
+	}
+	if trailingWordChar.MatchString(keyword) {
+		pattern += `\b`
+	}
+	return regexp.MustCompile(`(?i)` + pattern)
+}
+
+// ModerationHook enforces a moderation policy on the prompts and replies of chat endpoints and
+// text completions.
+// Rules apply to every user message; the classifier, which costs a generation, sees only the
+// latest one, as earlier messages were moderated when they were sent. Classifier verdicts
+// cannot be redacted, so content it flags is blocked under the redact action.
+// Replies of streaming chat are checked once they were sent, so they can be blocked but not redacted.
+type ModerationHook struct {
+	policy   *ModerationPolicy
+	classify func(ctx context.Context, model string, request models.ChatRequest) (*models.ChatResponse, error)
+}
+
+// EnableModeration enforces policy on the given endpoints, or on every endpoint if none are given.
+// Classifier generations bypass the hook chain.
+func (s *LlamaService) EnableModeration(policy *ModerationPolicy, endpoints ...string) {
+	s.RegisterHook(&ModerationHook{
+		policy: policy,
+		classify: func(ctx context.Context, model string, request models.ChatRequest) (*models.ChatResponse, error) {
+			return s.generateChat(ctx, s.getModel(model), request)
+		},
+	}, endpoints...)
+}
+
+func (h *ModerationHook) BeforeChat(endpoint string, request *models.ChatRequest) error {
+	action := h.policy.PromptAction
+	if action == "" {
+		return nil
+	}
+
+	latest := -1
+	for i, message := range request.Messages {
+		if message.Role == "user" {
+			latest = i
+		}
+	}
+
+	// Redaction must not edit the caller's messages
+	request.Messages = slices.Clone(request.Messages)
+	for i, message := range request.Messages {
+		if message.Role != "user" {
+			continue
+		}
+		content, flags, err := h.moderate(ModerationPrompt, action, message.Content, i == latest)
+		if err != nil {
+			return err
+		}
+		request.Messages[i].Content = content
+		request.Moderation = appendFlags(request.Moderation, flags)
+	}
+	logFlags(endpoint, request.Moderation)
+	return nil
+}
+
+func (h *ModerationHook) AfterChat(endpoint string, response *models.ChatResponse) error {
+	action := h.policy.CompletionAction
+	if action == "" {
+		return nil
+	}
+
+	var raised []models.ModerationFlag
+	for i, choice := range response.Choices {
+		content, flags, err := h.moderate(ModerationCompletion, action, choice.Message.Content, true)
+		if err != nil {
+			return err
+		}
+		response.Choices[i].Message.Content = content
+		raised = appendFlags(raised, flags)
+	}
+	logFlags(endpoint, raised)
+	response.Moderation = append(response.Moderation, raised...)
+	return nil
+}
+
+func (h *ModerationHook) BeforeCompletion(endpoint string, request *models.CompletionRequest) error {
+	action := h.policy.PromptAction
+	if action == "" {
+		return nil
+	}
+
+	prompt, flags, err := h.moderate(ModerationPrompt, action, request.Prompt, true)
+	if err != nil {
+		return err
+	}
+	logFlags(endpoint, flags)
+	request.Prompt = prompt
+	request.Moderation = appendFlags(request.Moderation, flags)
+	return nil
+}
+
+func (h *ModerationHook) AfterCompletion(endpoint string, response *models.CompletionResponse) error {
+	action := h.policy.CompletionAction
+	if action == "" {
+		return nil
+	}
+
+	var raised []models.ModerationFlag
+	for i, choice := range response.Choices {
+		content, flags, err := h.moderate(ModerationCompletion, action, choice.Message.Content, true)
+		if err != nil {
+			return err
+		}
+		response.Choices[i].Message.Content = content
+		raised = appendFlags(raised, flags)
+	}
+	logFlags(endpoint, raised)
+	response.Moderation = append(response.Moderation, raised...)
+	return nil
+}
+
+// moderate applies action to text matching the policy, returning the text to pass on and the
+// flags raised. Content the action blocks yields a ModerationError.
+func (h *ModerationHook) moderate(stage, action, text string, classify bool) (string, []models.ModerationFlag, error) {
+	var categories []string
+	for _, rule := range h.policy.Rules {
+		matched := false
+		for _, matcher := range rule.matchers {
+			if !matcher.MatchString(text) {
+				continue
+			}
+			matched = true
+			if action == ModerationRedact {
+				text = matcher.ReplaceAllLiteralString(text, h.policy.Replacement)
+			}
+		}
+		if matched {
+			categories = append(categories, rule.Category)
+		}
+	}
+	if action == ModerationBlock && len(categories) > 0 {
+		return "", nil, &ModerationError{Stage: stage, Categories: categories}
+	}
+
+	if classify && h.policy.Classifier != nil {
+		flagged, violated, err := h.classifyText(text)
+		if err != nil {
+			return "", nil, fmt.Errorf("moderation classifier failed: %w", err)
+		}
+		if flagged && action != ModerationAnnotate {
+			return "", nil, &ModerationError{Stage: stage, Categories: violated}
+		}
+		if flagged {
+			categories = append(categories, violated...)
+		}
+	}
+
+	flags := make([]models.ModerationFlag, len(categories))
+	for i, category := range categories {
+		flags[i] = models.ModerationFlag{Stage: stage, Category: category, Action: action}
+	}
+	return text, flags, nil
+}
+
+// classifyText asks the classifier model for a verdict on text
+func (h *ModerationHook) classifyText(text string) (bool, []string, error) {
+	classifier := h.policy.Classifier
+	timeout := defaultClassifierTimeout
+	if classifier.Timeout > 0 {
+		timeout = time.Duration(classifier.Timeout) * time.Second
+	}
+	// Hooks run without the request's context, so the classifier gets its own deadline
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var messages []models.Message
+	if classifier.Prompt != "" {
+		messages = append(messages, models.Message{Role: "system", Content: classifier.Prompt})
+	}
+	messages = append(messages, models.Message{Role: "user", Content: text})
+	response, err := h.classify(ctx, classifier.Model, models.ChatRequest{Model: classifier.Model, Messages: messages})
+	if err != nil {
+		return false, nil, err
+	}
+	if len(response.Choices) == 0 {
+		return false, nil, fmt.Errorf("the classifier returned no verdict")
+	}
+	return parseVerdict(response.Choices[0].Message.Content)
+}
+
+// parseVerdict reads a verdict such as "safe" or "unsafe\nS1,S10". An unsafe verdict naming no
+// categories is reported as the "classifier" category.
+func parseVerdict(reply string) (bool, []string, error) {
+	verdict, rest, _ := strings.Cut(strings.TrimSpace(reply), "\n")
+	verdict = strings.TrimSpace(verdict)
+	switch lower := strings.ToLower(verdict); {
+	case strings.HasPrefix(lower, "safe"):
+		return false, nil, nil
+	case !strings.HasPrefix(lower, "unsafe"):
+		return false, nil, fmt.Errorf("unexpected classifier verdict %q", reply)
+	}
+
+	// Categories may also follow on the verdict's line, as in "unsafe: S1"
+	var categories []string
+	for _, category := range strings.FieldsFunc(verdict[len("unsafe"):]+"\n"+rest, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if category = strings.Trim(category, " :"); category != "" {
+			categories = append(categories, category)
+		}
+	}
+	if len(categories) == 0 {
+		categories = []string{"classifier"}
+	}
+	return true, categories, nil
+}
+
+// appendFlags adds the flags not raised yet
+func appendFlags(raised, flags []models.ModerationFlag) []models.ModerationFlag {
+	for _, flag := range flags {
+		if !slices.Contains(raised, flag) {
+			raised = append(raised, flag)
+		}
+	}
+	return raised
+}
+
+func logFlags(endpoint string, flags []models.ModerationFlag) {
+	for _, flag := range flags {
+		slog.Warn("Moderation policy matched", "endpoint", endpoint, "stage", flag.Stage, "category", flag.Category, "action", flag.Action)
+	}
+}
@@ -0,0 +1,253 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// moderationServer answers chat and completions with reply, and the "guard" model with an unsafe
+// verdict for messages mentioning a heist. It records the messages of chat requests and the
+// prompt of completions.
+type moderationServer struct {
+	*httptest.Server
+	mu              sync.Mutex
+	messages        []models.Message
+	prompt          string
+	classifications int
+}
+
+func newModerationServer(reply string) *moderationServer {
+	server := &moderationServer{}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model    string           `json:"model"`
+			Messages []models.Message `json:"messages"`
+			Prompt   string           `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		content := reply
+		server.mu.Lock()
+		if body.Model == "guard" {
+			server.classifications++
+			content = "safe"
+			if strings.Contains(body.Messages[len(body.Messages)-1].Content, "heist") {
+				content = "unsafe\nS2"
+			}
+		} else {
+			server.messages = body.Messages
+			server.prompt = body.Prompt
+		}
+		server.mu.Unlock()
+
+		if r.URL.Path == "/api/generate" {
+			json.NewEncoder(w).Encode(map[string]interface{}{"response": content, "done": true})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": content},
+		})
+	}))
+	return server
+}
+
+func moderatedService(t *testing.T, server *moderationServer, policy *ModerationPolicy) *LlamaService {
+	assert.NoError(t, policy.compile())
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.EnableModeration(policy, EndpointChat, EndpointCompletion)
+	return service
+}
+
+func TestLoadModerationPolicy(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "policy.yaml")
+	os.WriteFile(path, []byte(`
+prompt_action: block
+completion_action: redact
+rules:
+  - category: profanity
+    keywords: [darn]
+  - category: card_number
+    patterns: ['\b(?:\d[ -]?){13,16}\b']
+classifier:
+  model: llama-guard3:1b
+`), 0o600)
+
+	policy, err := LoadModerationPolicy(path)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultModerationReplacement, policy.Replacement)
+	assert.Len(t, policy.Rules[1].matchers, 1)
+	assert.Equal(t, "llama-guard3:1b", policy.Classifier.Model)
+
+	invalid := map[string]string{
+		"unknown action":   "prompt_action: reject\nrules: [{category: a, keywords: [b]}]",
+		"no action":        "rules: [{category: a, keywords: [b]}]",
+		"nothing to match": "prompt_action: block",
+		"no category":      "prompt_action: block\nrules: [{keywords: [b]}]",
+		"empty rule":       "prompt_action: block\nrules: [{category: a}]",
+		"bad pattern":      "prompt_action: block\nrules: [{category: a, patterns: ['(']}]",
+		"no model":         "prompt_action: block\nclassifier: {timeout: 5}",
+		"unknown field":    "prompt_action: block\nrules: [{category: a, keyword: [b]}]",
+	}
+	for name, document := range invalid {
+		os.WriteFile(path, []byte(document), 0o600)
+		_, err := LoadModerationPolicy(path)
+		assert.Error(t, err, name)
+	}
+}
+
+func TestModeration_BlockPrompt(t *testing.T) {
+	server := newModerationServer("Hello")
+	defer server.Close()
+	service := moderatedService(t, server, &ModerationPolicy{
+		PromptAction: ModerationBlock,
+		Rules:        []ModerationRule{{Category: "profanity", Keywords: []string{"darn"}}},
+	})
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Well, DARN it"}},
+	})
+	var moderationErr *ModerationError
+	assert.True(t, errors.As(err, &moderationErr))
+	assert.Equal(t, &ModerationError{Stage: ModerationPrompt, Categories: []string{"profanity"}}, moderationErr)
+	assert.Nil(t, server.messages, "blocked prompts do not reach the model")
+
+	// Keywords match whole words only
+	_, err = service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Darnell says hi"}},
+	})
+	assert.NoError(t, err)
+}
+
+func TestModeration_Completion(t *testing.T) {
+	server := newModerationServer("Sure, charge 4111 1111 1111 1111.")
+	defer server.Close()
+	service := moderatedService(t, server, &ModerationPolicy{
+		PromptAction:     ModerationBlock,
+		CompletionAction: ModerationRedact,
+		Rules: []ModerationRule{
+			{Category: "profanity", Keywords: []string{"darn"}},
+			{Category: "card_number", Patterns: []string{`\b(?:\d[ -]?){13,16}\b`}},
+		},
+	})
+
+	_, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Well, darn it"})
+	var moderationErr *ModerationError
+	assert.True(t, errors.As(err, &moderationErr))
+	assert.Equal(t, ModerationPrompt, moderationErr.Stage)
+	assert.Empty(t, server.prompt, "blocked prompts do not reach the model")
+
+	response, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Pay the invoice"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Pay the invoice", server.prompt)
+	assert.Equal(t, "Sure, charge [redacted].", response.Choices[0].Message.Content)
+	assert.Equal(t, []models.ModerationFlag{
+		{Stage: ModerationCompletion, Category: "card_number", Action: ModerationRedact},
+	}, response.Moderation)
+}
+
+func TestModeration_RedactAndAnnotate(t *testing.T) {
+	server := newModerationServer("Sure, charge 4111 1111 1111 1111.")
+	defer server.Close()
+	service := moderatedService(t, server, &ModerationPolicy{
+		PromptAction:     ModerationAnnotate,
+		CompletionAction: ModerationRedact,
+		Rules: []ModerationRule{
+			{Category: "profanity", Keywords: []string{"darn"}},
+			{Category: "card_number", Patterns: []string{`\b(?:\d[ -]?){13,16}\b`}},
+		},
+	})
+
+	messages := []models.Message{{Role: "user", Content: "Pay the darn bill"}}
+	response, err := service.Chat(context.Background(), models.ChatRequest{Messages: messages})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Pay the darn bill", server.messages[0].Content, "annotated prompts are passed on")
+	assert.Equal(t, "Sure, charge [redacted].", response.Choices[0].Message.Content)
+	assert.Equal(t, []models.ModerationFlag{
+		{Stage: ModerationPrompt, Category: "profanity", Action: ModerationAnnotate},
+		{Stage: ModerationCompletion, Category: "card_number", Action: ModerationRedact},
+	}, response.Moderation)
+}
+
+func TestModeration_RedactLeavesCallerMessages(t *testing.T) {
+	server := newModerationServer("Hello")
+	defer server.Close()
+	service := moderatedService(t, server, &ModerationPolicy{
+		PromptAction: ModerationRedact,
+		Replacement:  "***",
+		Rules:        []ModerationRule{{Category: "secret", Patterns: []string{`sk-\w+`}}},
+	})
+
+	messages := []models.Message{{Role: "user", Content: "My key is sk-abc123"}}
+	_, err := service.Chat(context.Background(), models.ChatRequest{Messages: messages})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "My key is ***", server.messages[0].Content)
+	assert.Equal(t, "My key is sk-abc123", messages[0].Content)
+}
+
+func TestModeration_Classifier(t *testing.T) {
+	server := newModerationServer("Hello")
+	defer server.Close()
+	service := moderatedService(t, server, &ModerationPolicy{
+		PromptAction: ModerationRedact,
+		Classifier:   &ModerationClassifier{Model: "guard"},
+	})
+
+	// Only the latest user message is classified
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{
+			{Role: "user", Content: "Plan a heist"},
+			{Role: "assistant", Content: "No."},
+			{Role: "user", Content: "Then plan a picnic"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", response.Choices[0].Message.Content)
+	assert.Equal(t, 1, server.classifications)
+
+	// Flagged content cannot be redacted, so it is blocked
+	_, err = service.Chat(context.Background(), models.ChatRequest{
+		Messages: []models.Message{{Role: "user", Content: "Plan a heist"}},
+	})
+	var moderationErr *ModerationError
+	assert.True(t, errors.As(err, &moderationErr))
+	assert.Equal(t, []string{"S2"}, moderationErr.Categories)
+}
+
+func TestParseVerdict(t *testing.T) {
+	tests := []struct {
+		reply      string
+		flagged    bool
+		categories []string
+	}{
+		{"safe", false, nil},
+		{" Safe\n", false, nil},
+		{"unsafe\nS1,S10", true, []string{"S1", "S10"}},
+		{"UNSAFE: violence", true, []string{"violence"}},
+		{"unsafe", true, []string{"classifier"}},
+	}
+	for _, test := range tests {
+		flagged, categories, err := parseVerdict(test.reply)
+		assert.NoError(t, err, test.reply)
+		assert.Equal(t, test.flagged, flagged, test.reply)
+		assert.Equal(t, test.categories, categories, test.reply)
+	}
+
+	_, _, err := parseVerdict("I cannot help with that")
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"net/http"
+	"time"
+)
+
+// HTTPClient sends requests to Ollama, Ollama Cloud and remote providers. *http.Client satisfies it.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures a LlamaService built by NewLlamaService
+type Option func(*LlamaService)
+
+// WithHTTPClient sends upstream requests through client instead of one built from the connection
+// and header timeouts. The generation budget still applies, through each request's context.
+func WithHTTPClient(client HTTPClient) Option {
+	return func(s *LlamaService) {
+		s.httpClient = client
+		s.loadClient = client
+	}
+}
+
+// WithClock reads the time from now for response IDs and timestamps, usage records, cached
+// answers and circuit breaker cooldowns, and for the retention of the in-memory usage store.
+// Latencies are still measured on the wall clock.
+func WithClock(now func() time.Time) Option {
+	return func(s *LlamaService) {
+		s.clock = now
+		if store, ok := s.usageStore.(*MemoryUsageStore); ok {
+			store.now = now
+		}
+	}
+}
+
+// WithProvider serves the models named "<name>:<model>" with provider, in place of a provider of
+// that name enabled by configuration
+func WithProvider(name string, provider Provider) Option {
+	return func(s *LlamaService) {
+		if s.providers == nil {
+			s.providers = map[string]Provider{}
+		}
+		s.providers[name] = provider
+	}
+}
+
+// Now returns the current time from the service's clock
+func (s *LlamaService) Now() time.Time {
+	if s.clock == nil {
+		return time.Now()
+	}
+	return s.clock()
+}
@@ -0,0 +1,53 @@
+package services
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+	"agent-ollama-gin/pkg/ollamatest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type countingClient struct {
+	requests int
+}
+
+func (c *countingClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests++
+	return http.DefaultClient.Do(req)
+}
+
+func TestNewLlamaService_Options(t *testing.T) {
+	server := ollamatest.NewServer("llama2")
+	defer server.Close()
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	client := &countingClient{}
+	service := NewLlamaService(WithHTTPClient(client), WithClock(func() time.Time { return now }))
+	service.config.BaseURL = server.URL
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "Hi there"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello from Ollama", response.Choices[0].Message.Content)
+	assert.Equal(t, now.Unix(), response.Created)
+	assert.Equal(t, models.Usage{PromptTokens: 2, CompletionTokens: 3, TotalTokens: 5}, response.Usage)
+	assert.Positive(t, client.requests)
+
+	records, err := service.usageStore.Records(context.Background(), "2025-03-01")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestNewLlamaService_DefaultClock(t *testing.T) {
+	service := NewLlamaService()
+
+	assert.WithinDuration(t, time.Now(), service.Now(), time.Second)
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// Persistence watches the stores that keep state between requests. While one of them is down the
+// server runs statelessly: usage records are not written and conversations are unavailable, but
+// generation is served as usual instead of waiting on the store.
+type Persistence struct {
+	checks   *Readiness
+	interval time.Duration
+
+	mu   sync.RWMutex
+	down map[string]string // Failing stores and their errors
+}
+
+// NewPersistence creates a monitor checking its stores every interval, each check bounded by timeout
+func NewPersistence(interval, timeout time.Duration) *Persistence {
+	return &Persistence{
+		checks:   NewReadiness(timeout),
+		interval: interval,
+		down:     map[string]string{},
+	}
+}
+
+// Add watches the store called name with check. Stores are added at startup, before Run.
+func (p *Persistence) Add(name string, check ReadinessCheck) {
+	p.checks.Add(name, check)
+}
+
+// Run checks the stores now and then every interval until ctx ends. It returns at once when there
+// is nothing to watch.
+func (p *Persistence) Run(ctx context.Context) {
+	if len(p.checks.names) == 0 {
+		return
+	}
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.update(p.checks.Check(ctx))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *Persistence) update(report models.ReadinessReport) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for name, status := range report.Checks {
+		_, wasDown := p.down[name]
+		switch {
+		case status.Status != models.DependencyOK:
+			if !wasDown {
+				slog.Warn("Store unavailable, running without it", "store", name, "error", status.Error)
+			}
+			p.down[name] = status.Error
+		case wasDown:
+			slog.Info("Store available again", "store", name)
+			delete(p.down, name)
+		}
+	}
+}
+
+// Available reports whether the store called name was reachable when last checked
+func (p *Persistence) Available(name string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	_, down := p.down[name]
+	return !down
+}
+
+// Degraded lists the stores that are down, sorted by name
+func (p *Persistence) Degraded() []string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	names := make([]string, 0, len(p.down))
+	for name := range p.down {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// GuardUsageStore skips usage writes to store, and fails reads with ErrPersistenceUnavailable,
+// while the store called name is down
+func (p *Persistence) GuardUsageStore(name string, store UsageStore) UsageStore {
+	return &guardedUsageStore{persistence: p, name: name, store: store}
+}
+
+type guardedUsageStore struct {
+	persistence *Persistence
+	name        string
+	store       UsageStore
+}
+
+func (s *guardedUsageStore) Append(ctx context.Context, record models.UsageRecord) error {
+	if !s.persistence.Available(s.name) {
+		return nil
+	}
+	return s.store.Append(ctx, record)
+}
+
+func (s *guardedUsageStore) Records(ctx context.Context, day string) ([]models.UsageRecord, error) {
+	if !s.persistence.Available(s.name) {
+		return nil, ErrPersistenceUnavailable
+	}
+	return s.store.Records(ctx, day)
+}
+
+// GuardConversationStore fails every operation on store with ErrPersistenceUnavailable while the
+// store called name is down
+func (p *Persistence) GuardConversationStore(name string, store ConversationStore) ConversationStore {
+	return &guardedConversationStore{persistence: p, name: name, store: store}
+}
+
+type guardedConversationStore struct {
+	persistence *Persistence
+	name        string
+	store       ConversationStore
+}
+
+func (s *guardedConversationStore) Get(ctx context.Context, id string) (*models.Conversation, error) {
+	if !s.persistence.Available(s.name) {
+		return nil, ErrPersistenceUnavailable
+	}
+	return s.store.Get(ctx, id)
+}
+
+func (s *guardedConversationStore) Save(ctx context.Context, conversation *models.Conversation) error {
+	if !s.persistence.Available(s.name) {
+		return ErrPersistenceUnavailable
+	}
+	return s.store.Save(ctx, conversation)
+}
+
+func (s *guardedConversationStore) Delete(ctx context.Context, id string) error {
+	if !s.persistence.Available(s.name) {
+		return ErrPersistenceUnavailable
+	}
+	return s.store.Delete(ctx, id)
+}
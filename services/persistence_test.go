@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPersistence_GuardsStoresWhileDown(t *testing.T) {
+	var down atomic.Bool
+	persistence := NewPersistence(10*time.Millisecond, time.Second)
+	persistence.Add("usage_store", func(context.Context) error {
+		if down.Load() {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+
+	usage := NewMemoryUsageStore(1)
+	guarded := persistence.GuardUsageStore("usage_store", usage)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go persistence.Run(ctx)
+
+	record := models.UsageRecord{Time: time.Now().Unix(), Model: "llama2"}
+	assert.NoError(t, guarded.Append(ctx, record))
+
+	// While the store is down writes are skipped and reads fail
+	down.Store(true)
+	assert.Eventually(t, func() bool { return !persistence.Available("usage_store") }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"usage_store"}, persistence.Degraded())
+	assert.NoError(t, guarded.Append(ctx, record))
+	_, err := guarded.Records(ctx, "")
+	assert.ErrorIs(t, err, ErrPersistenceUnavailable)
+
+	down.Store(false)
+	assert.Eventually(t, func() bool { return persistence.Available("usage_store") }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, persistence.Degraded())
+	records, err := guarded.Records(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func TestPersistence_GuardConversationStore(t *testing.T) {
+	persistence := NewPersistence(time.Minute, time.Second)
+	persistence.Add("conversation_store", func(context.Context) error { return errors.New("connection refused") })
+	persistence.update(persistence.checks.Check(context.Background()))
+
+	store := persistence.GuardConversationStore("conversation_store", NewMemoryConversationStore(time.Hour, time.Now))
+	assert.ErrorIs(t, store.Save(context.Background(), &models.Conversation{ID: "conv-1"}), ErrPersistenceUnavailable)
+	_, err := store.Get(context.Background(), "conv-1")
+	assert.ErrorIs(t, err, ErrPersistenceUnavailable)
+	assert.ErrorIs(t, store.Delete(context.Background(), "conv-1"), ErrPersistenceUnavailable)
+}
@@ -0,0 +1,38 @@
+package services
+
+import (
+	"sync"
+
+	"agent-ollama-gin/models"
+)
+
+// PreferenceService keeps the request defaults of each workspace in memory
+type PreferenceService struct {
+	mu          sync.RWMutex
+	preferences map[string]models.Preferences
+}
+
+func NewPreferenceService() *PreferenceService {
+	return &PreferenceService{
+		preferences: map[string]models.Preferences{},
+	}
+}
+
+// Get returns the preferences of workspace; a workspace without preferences gets the zero value
+func (s *PreferenceService) Get(workspace string) models.Preferences {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.preferences[workspace]
+}
+
+// Set replaces the preferences of workspace
+func (s *PreferenceService) Set(workspace string, preferences models.Preferences) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if preferences == (models.Preferences{}) {
+		delete(s.preferences, workspace)
+		return
+	}
+	s.preferences[workspace] = preferences
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreferenceService(t *testing.T) {
+	service := NewPreferenceService()
+	preferences := models.Preferences{Model: "llama3.2", Tone: "formal"}
+
+	service.Set("acme", preferences)
+
+	assert.Equal(t, preferences, service.Get("acme"))
+	assert.Equal(t, models.Preferences{}, service.Get("globex"))
+
+	// Clearing every field forgets the workspace
+	service.Set("acme", models.Preferences{})
+	assert.Empty(t, service.preferences)
+}
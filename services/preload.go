@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+// PreloadModels loads the configured models into memory by issuing empty generate calls with
+// keep_alive, so the first real request does not pay the model load time. Failures are logged
+// and returned together; a model that fails to load does not stop the others.
+func (s *LlamaService) PreloadModels() error {
+	var errs []error
+	for _, name := range s.config.PreloadModels {
+		model := s.getModel(name)
+		if err := s.preloadModel(context.Background(), model); err != nil {
+			slog.Warn("Failed to preload model", "model", model, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", model, err))
+			continue
+		}
+		slog.Info("Preloaded model", "model", model, "keep_alive", s.config.PreloadKeepAlive)
+	}
+	return errors.Join(errs...)
+}
+
+// preloadModel loads model into memory and waits until it is loaded, within the model's
+// generation budget and the deadline of ctx
+func (s *LlamaService) preloadModel(ctx context.Context, model string) error {
+	preloadRequest := map[string]interface{}{
+		"model":      model,
+		"keep_alive": s.config.PreloadKeepAlive,
+		"stream":     true,
+	}
+
+	baseURL := s.config.BaseURL
+	if s.IsCloudModel(model) && s.config.CloudEnabled {
+		baseURL = s.config.CloudAPIURL
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.generationTimeout(model))
+	defer cancel()
+
+	resp, err := s.makeLoadRequest(ctx, "POST", "/api/generate", preloadRequest, baseURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return readProgress(resp.Body, func(chunk map[string]interface{}) bool {
+		done, _ := chunk["done"].(bool)
+		return done
+	})
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPreloadModels(t *testing.T) {
+	var mu sync.Mutex
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/generate", r.URL.Path)
+
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		requests = append(requests, body)
+		mu.Unlock()
+
+		if body["model"] == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":"model not found"}`))
+			return
+		}
+		w.Write([]byte(`{"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.PreloadModels = []string{"fast", "missing"}
+	service.config.PreloadKeepAlive = "1h"
+	service.SetAlias("fast", "phi3:mini")
+
+	err := service.PreloadModels()
+
+	assert.ErrorContains(t, err, "missing")
+	assert.Len(t, requests, 2)
+	assert.Equal(t, "phi3:mini", requests[0]["model"])
+	assert.Equal(t, "1h", requests[0]["keep_alive"])
+	assert.NotContains(t, requests[0], "prompt")
+}
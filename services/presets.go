@@ -0,0 +1,84 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// ListPresets returns the system prompt presets sorted by name
+func (s *LlamaService) ListPresets() []models.Preset {
+	s.presetMu.RLock()
+	defer s.presetMu.RUnlock()
+
+	presets := make([]models.Preset, 0, len(s.presets))
+	for _, preset := range s.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool {
+		return presets[i].Name < presets[j].Name
+	})
+	return presets
+}
+
+// GetPreset returns a preset by name
+func (s *LlamaService) GetPreset(name string) (models.Preset, error) {
+	s.presetMu.RLock()
+	defer s.presetMu.RUnlock()
+
+	preset, ok := s.presets[name]
+	if !ok {
+		return models.Preset{}, fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	return preset, nil
+}
+
+// SetPreset creates a preset or replaces the one with the same name
+func (s *LlamaService) SetPreset(preset models.Preset) error {
+	if strings.TrimSpace(preset.Name) == "" {
+		return fmt.Errorf("preset name is required")
+	}
+	if strings.TrimSpace(preset.SystemPrompt) == "" {
+		return fmt.Errorf("preset %s needs a system prompt", preset.Name)
+	}
+
+	s.presetMu.Lock()
+	defer s.presetMu.Unlock()
+
+	if s.presets == nil {
+		s.presets = map[string]models.Preset{}
+	}
+	s.presets[preset.Name] = preset
+	return nil
+}
+
+// DeletePreset removes a preset
+func (s *LlamaService) DeletePreset(name string) error {
+	s.presetMu.Lock()
+	defer s.presetMu.Unlock()
+
+	if _, ok := s.presets[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrPresetNotFound, name)
+	}
+	delete(s.presets, name)
+	return nil
+}
+
+// applyPreset puts the system prompt of the preset named in request ahead of its messages
+func (s *LlamaService) applyPreset(request *models.ChatRequest) error {
+	if request.Preset == "" {
+		return nil
+	}
+
+	preset, err := s.GetPreset(request.Preset)
+	if err != nil {
+		return err
+	}
+
+	messages := make([]models.Message, 0, len(request.Messages)+1)
+	messages = append(messages, models.Message{Role: "system", Content: preset.SystemPrompt})
+	request.Messages = append(messages, request.Messages...)
+	return nil
+}
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPresets(t *testing.T) {
+	service := NewLlamaService()
+
+	assert.NoError(t, service.SetPreset(models.Preset{Name: "code-reviewer", SystemPrompt: "You review Go code."}))
+	assert.NoError(t, service.SetPreset(models.Preset{Name: "encyclopedia-editor", SystemPrompt: "You edit encyclopedia articles."}))
+	assert.Error(t, service.SetPreset(models.Preset{Name: "empty"}))
+	assert.Error(t, service.SetPreset(models.Preset{SystemPrompt: "No name."}))
+
+	presets := service.ListPresets()
+	assert.Len(t, presets, 2)
+	assert.Equal(t, "code-reviewer", presets[0].Name)
+
+	preset, err := service.GetPreset("encyclopedia-editor")
+	assert.NoError(t, err)
+	assert.Equal(t, "You edit encyclopedia articles.", preset.SystemPrompt)
+
+	assert.NoError(t, service.DeletePreset("code-reviewer"))
+	assert.ErrorIs(t, service.DeletePreset("code-reviewer"), ErrPresetNotFound)
+	_, err = service.GetPreset("code-reviewer")
+	assert.ErrorIs(t, err, ErrPresetNotFound)
+}
+
+func TestChat_AppliesPreset(t *testing.T) {
+	var sent []models.Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = body.Messages
+		w.Write([]byte(`{"message":{"role":"assistant","content":"LGTM"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.SetPreset(models.Preset{Name: "code-reviewer", SystemPrompt: "You review Go code."})
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.2",
+		Preset:   "code-reviewer",
+		Messages: []models.Message{{Role: "user", Content: "func main() {}"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []models.Message{
+		{Role: "system", Content: "You review Go code."},
+		{Role: "user", Content: "func main() {}"},
+	}, sent)
+
+	_, err = service.Chat(context.Background(), models.ChatRequest{
+		Preset:   "missing",
+		Messages: []models.Message{{Role: "user", Content: "hi"}},
+	})
+	assert.ErrorIs(t, err, ErrPresetNotFound)
+}
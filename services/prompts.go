@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"agent-ollama-gin/models"
+)
+
+// promptTemplate is a registered prompt together with its parsed template
+type promptTemplate struct {
+	prompt models.PromptTemplate
+	tmpl   *template.Template
+}
+
+// ListPrompts returns the prompt templates sorted by name
+func (s *LlamaService) ListPrompts() []models.PromptTemplate {
+	s.promptMu.RLock()
+	defer s.promptMu.RUnlock()
+
+	prompts := make([]models.PromptTemplate, 0, len(s.prompts))
+	for _, prompt := range s.prompts {
+		prompts = append(prompts, prompt.prompt)
+	}
+	sort.Slice(prompts, func(i, j int) bool {
+		return prompts[i].Name < prompts[j].Name
+	})
+	return prompts
+}
+
+// GetPrompt returns a prompt template by name
+func (s *LlamaService) GetPrompt(name string) (models.PromptTemplate, error) {
+	prompt, err := s.getPrompt(name)
+	if err != nil {
+		return models.PromptTemplate{}, err
+	}
+	return prompt.prompt, nil
+}
+
+// SetPrompt parses a prompt template and stores it, replacing the one with the same name.
+// Templates use Go text/template syntax, e.g. "Summarize {{.text}} in {{.words}} words";
+// referencing a variable the run request does not provide is an error.
+func (s *LlamaService) SetPrompt(prompt models.PromptTemplate) error {
+	if strings.TrimSpace(prompt.Name) == "" {
+		return fmt.Errorf("prompt template name is required")
+	}
+	if strings.TrimSpace(prompt.Template) == "" {
+		return fmt.Errorf("prompt template %s is empty", prompt.Name)
+	}
+
+	tmpl, err := template.New(prompt.Name).Option("missingkey=error").Parse(prompt.Template)
+	if err != nil {
+		return fmt.Errorf("invalid prompt template %s: %w", prompt.Name, err)
+	}
+
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+
+	if s.prompts == nil {
+		s.prompts = map[string]*promptTemplate{}
+	}
+	s.prompts[prompt.Name] = &promptTemplate{prompt: prompt, tmpl: tmpl}
+	return nil
+}
+
+// DeletePrompt removes a prompt template
+func (s *LlamaService) DeletePrompt(name string) error {
+	s.promptMu.Lock()
+	defer s.promptMu.Unlock()
+
+	if _, ok := s.prompts[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	delete(s.prompts, name)
+	return nil
+}
+
+// RunPrompt renders a prompt template with the request variables and sends the result to the model
+func (s *LlamaService) RunPrompt(ctx context.Context, name string, request models.RunPromptRequest) (*models.RunPromptResponse, error) {
+	prompt, err := s.getPrompt(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var rendered strings.Builder
+	variables := request.Variables
+	if variables == nil {
+		variables = map[string]interface{}{}
+	}
+	if err := prompt.tmpl.Execute(&rendered, variables); err != nil {
+		return nil, fmt.Errorf("%w %s: %v", ErrPromptRender, name, err)
+	}
+
+	model := request.Model
+	if model == "" {
+		model = prompt.prompt.Model
+	}
+
+	var messages []models.Message
+	if prompt.prompt.SystemPrompt != "" {
+		messages = append(messages, models.Message{Role: "system", Content: prompt.prompt.SystemPrompt})
+	}
+	messages = append(messages, models.Message{Role: "user", Content: rendered.String()})
+
+	chatResponse, err := s.chat(ctx, EndpointPrompt, models.ChatRequest{
+		Model:       model,
+		Messages:    messages,
+		Temperature: request.Temperature,
+		MaxTokens:   request.MaxTokens,
+		Options:     request.Options,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to run prompt template: %w", err)
+	}
+
+	text := ""
+	if len(chatResponse.Choices) > 0 {
+		text = strings.TrimSpace(chatResponse.Choices[0].Message.Content)
+	}
+
+	return &models.RunPromptResponse{
+		ID:               s.GenerateID(),
+		Object:           "prompt.run",
+		Created:          s.Now().Unix(),
+		Model:            chatResponse.Model,
+		Prompt:           rendered.String(),
+		Text:             text,
+		Usage:            chatResponse.Usage,
+		Backend:          chatResponse.Backend,
+		FallbackAttempts: chatResponse.FallbackAttempts,
+	}, nil
+}
+
+func (s *LlamaService) getPrompt(name string) (*promptTemplate, error) {
+	s.promptMu.RLock()
+	defer s.promptMu.RUnlock()
+
+	prompt, ok := s.prompts[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrPromptNotFound, name)
+	}
+	return prompt, nil
+}
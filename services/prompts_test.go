@@ -0,0 +1,84 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrompts(t *testing.T) {
+	service := NewLlamaService()
+
+	assert.NoError(t, service.SetPrompt(models.PromptTemplate{Name: "summarize", Template: "Summarize {{.text}}"}))
+	assert.Error(t, service.SetPrompt(models.PromptTemplate{Name: "broken", Template: "Summarize {{.text"}))
+	assert.Error(t, service.SetPrompt(models.PromptTemplate{Name: "empty"}))
+	assert.Error(t, service.SetPrompt(models.PromptTemplate{Template: "No name."}))
+
+	prompts := service.ListPrompts()
+	assert.Len(t, prompts, 1)
+	assert.Equal(t, "summarize", prompts[0].Name)
+
+	assert.NoError(t, service.DeletePrompt("summarize"))
+	assert.ErrorIs(t, service.DeletePrompt("summarize"), ErrPromptNotFound)
+	_, err := service.GetPrompt("summarize")
+	assert.ErrorIs(t, err, ErrPromptNotFound)
+}
+
+func TestRunPrompt(t *testing.T) {
+	var sent []models.Message
+	var model string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model    string           `json:"model"`
+			Messages []models.Message `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = body.Messages
+		model = body.Model
+		w.Write([]byte(`{"model":"llama3.2","message":{"role":"assistant","content":" Go is enjoyable. "},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.SetPrompt(models.PromptTemplate{
+		Name:         "summarize",
+		Template:     "Summarize in {{.words}} words: {{.text}}",
+		SystemPrompt: "You write short summaries.",
+		Model:        "llama3.2",
+	})
+
+	response, err := service.RunPrompt(context.Background(), "summarize", models.RunPromptRequest{
+		Variables: map[string]interface{}{"words": 3, "text": "Go is fun."},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "prompt.run", response.Object)
+	assert.Equal(t, "Summarize in 3 words: Go is fun.", response.Prompt)
+	assert.Equal(t, "Go is enjoyable.", response.Text)
+	assert.Equal(t, "llama3.2", model)
+	assert.Equal(t, []models.Message{
+		{Role: "system", Content: "You write short summaries."},
+		{Role: "user", Content: "Summarize in 3 words: Go is fun."},
+	}, sent)
+}
+
+func TestRunPrompt_MissingVariable(t *testing.T) {
+	service := NewLlamaService()
+	service.SetPrompt(models.PromptTemplate{Name: "summarize", Template: "Summarize {{.text}}"})
+
+	_, err := service.RunPrompt(context.Background(), "summarize", models.RunPromptRequest{})
+	assert.ErrorIs(t, err, ErrPromptRender)
+
+	_, err = service.RunPrompt(context.Background(), "missing", models.RunPromptRequest{})
+	assert.ErrorIs(t, err, ErrPromptNotFound)
+}
@@ -0,0 +1,162 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+const (
+	// anthropicVersion is the Messages API version requests are made against
+	anthropicVersion = "2023-06-01"
+	// anthropicMaxTokens is sent when a request sets no limit, which the Messages API requires
+	anthropicMaxTokens = 4096
+)
+
+// AnthropicProvider serves models through the Anthropic Messages API
+type AnthropicProvider struct {
+	client  HTTPClient
+	baseURL string
+	apiKey  string
+}
+
+// NewAnthropicProvider creates a provider for the API at baseURL, e.g. https://api.anthropic.com
+func NewAnthropicProvider(client HTTPClient, baseURL, apiKey string) *AnthropicProvider {
+	return &AnthropicProvider{client: client, baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+type anthropicRequest struct {
+	Model         string           `json:"model"`
+	System        string           `json:"system,omitempty"`
+	Messages      []models.Message `json:"messages"`
+	MaxTokens     int              `json:"max_tokens"`
+	Temperature   *float64         `json:"temperature,omitempty"`
+	TopP          *float64         `json:"top_p,omitempty"`
+	StopSequences []string         `json:"stop_sequences,omitempty"`
+	Stream        bool             `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// Chat implements Provider
+func (p *AnthropicProvider) Chat(ctx context.Context, request ProviderRequest) (*ProviderReply, error) {
+	resp, err := p.post(ctx, p.messagesRequest(request, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var message struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage anthropicUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&message); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	var content strings.Builder
+	for _, block := range message.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	return &ProviderReply{Content: content.String(), Usage: anthropicTotals(message.Usage)}, nil
+}
+
+// StreamChat implements Provider
+func (p *AnthropicProvider) StreamChat(ctx context.Context, request ProviderRequest, onText func(string)) (models.Usage, error) {
+	resp, err := p.post(ctx, p.messagesRequest(request, true))
+	if err != nil {
+		return models.Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	// Input tokens arrive with message_start, output tokens with message_delta
+	var usage anthropicUsage
+	err = readServerSentEvents(resp.Body, func(event, data string) error {
+		var payload struct {
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage anthropicUsage `json:"usage"`
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			return fmt.Errorf("failed to decode stream event: %w", err)
+		}
+
+		switch event {
+		case "message_start":
+			usage.InputTokens = payload.Message.Usage.InputTokens
+		case "content_block_delta":
+			if payload.Delta.Type == "text_delta" && payload.Delta.Text != "" {
+				onText(payload.Delta.Text)
+			}
+		case "message_delta":
+			usage.OutputTokens = payload.Usage.OutputTokens
+		case "error":
+			return fmt.Errorf("anthropic stream failed: %s: %s", payload.Error.Type, payload.Error.Message)
+		}
+		return nil
+	})
+	return anthropicTotals(usage), err
+}
+
+// messagesRequest converts a request. System messages move to the system prompt, which the
+// Messages API takes separately from the conversation.
+func (p *AnthropicProvider) messagesRequest(request ProviderRequest, stream bool) anthropicRequest {
+	body := anthropicRequest{
+		Model:         request.Model,
+		MaxTokens:     request.MaxTokens,
+		Temperature:   request.Temperature,
+		TopP:          request.TopP,
+		StopSequences: request.Stop,
+		Stream:        stream,
+	}
+	if body.MaxTokens <= 0 {
+		body.MaxTokens = anthropicMaxTokens
+	}
+
+	var system []string
+	for _, message := range request.Messages {
+		if message.Role == "system" {
+			system = append(system, message.Content)
+			continue
+		}
+		body.Messages = append(body.Messages, models.Message{Role: message.Role, Content: message.Content})
+	}
+	body.System = strings.Join(system, "\n\n")
+	return body
+}
+
+func (p *AnthropicProvider) post(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	header := http.Header{}
+	header.Set("x-api-key", p.apiKey)
+	header.Set("anthropic-version", anthropicVersion)
+	return postProvider(ctx, p.client, ProviderAnthropic, p.baseURL+"/v1/messages", header, body)
+}
+
+func anthropicTotals(usage anthropicUsage) models.Usage {
+	return models.Usage{
+		PromptTokens:     usage.InputTokens,
+		CompletionTokens: usage.OutputTokens,
+		TotalTokens:      usage.InputTokens + usage.OutputTokens,
+	}
+}
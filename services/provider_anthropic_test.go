@@ -0,0 +1,77 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnthropicProvider_Chat(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/messages", r.URL.Path)
+		assert.Equal(t, "sk-ant-test", r.Header.Get("x-api-key"))
+		assert.Equal(t, anthropicVersion, r.Header.Get("anthropic-version"))
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"content":[{"type":"text","text":"Hel"},{"type":"text","text":"lo"}],"usage":{"input_tokens":9,"output_tokens":2}}`)
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(http.DefaultClient, server.URL, "sk-ant-test")
+	reply, err := provider.Chat(context.Background(), ProviderRequest{
+		Model: "claude-3-5-haiku-latest",
+		Messages: []models.Message{
+			{Role: "system", Content: "Be brief"},
+			{Role: "user", Content: "Hi"},
+		},
+		Stop: []string{"END"},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ProviderReply{Content: "Hello", Usage: models.Usage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}}, reply)
+	assert.Equal(t, "Be brief", body["system"])
+	assert.Equal(t, []interface{}{map[string]interface{}{"role": "user", "content": "Hi"}}, body["messages"])
+	assert.Equal(t, float64(anthropicMaxTokens), body["max_tokens"])
+	assert.Equal(t, []interface{}{"END"}, body["stop_sequences"])
+}
+
+func TestAnthropicProvider_StreamChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"usage\":{\"input_tokens\":9,\"output_tokens\":1}}}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		fmt.Fprint(w, "event: ping\ndata: {\"type\":\"ping\"}\n\n")
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n\n")
+		fmt.Fprint(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"usage\":{\"output_tokens\":2}}\n\n")
+		fmt.Fprint(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer server.Close()
+
+	var text string
+	provider := NewAnthropicProvider(http.DefaultClient, server.URL, "sk-ant-test")
+	usage, err := provider.StreamChat(context.Background(), ProviderRequest{Model: "claude-3-5-haiku-latest"}, func(chunk string) { text += chunk })
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", text)
+	assert.Equal(t, models.Usage{PromptTokens: 9, CompletionTokens: 2, TotalTokens: 11}, usage)
+}
+
+func TestAnthropicProvider_StreamError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\n")
+		fmt.Fprint(w, "event: error\ndata: {\"type\":\"error\",\"error\":{\"type\":\"overloaded_error\",\"message\":\"Overloaded\"}}\n\n")
+	}))
+	defer server.Close()
+
+	provider := NewAnthropicProvider(http.DefaultClient, server.URL, "sk-ant-test")
+	_, err := provider.StreamChat(context.Background(), ProviderRequest{Model: "claude-3-5-haiku-latest"}, func(string) {})
+
+	assert.EqualError(t, err, "anthropic stream failed: overloaded_error: Overloaded")
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// OpenAIProvider serves models through the chat completions API of OpenAI or any compatible
+// server, such as vLLM, LM Studio or OpenRouter
+type OpenAIProvider struct {
+	client  HTTPClient
+	baseURL string
+	apiKey  string
+}
+
+// NewOpenAIProvider creates a provider for the API at baseURL, e.g. https://api.openai.com/v1
+func NewOpenAIProvider(client HTTPClient, baseURL, apiKey string) *OpenAIProvider {
+	return &OpenAIProvider{client: client, baseURL: strings.TrimSuffix(baseURL, "/"), apiKey: apiKey}
+}
+
+type openAIChatRequest struct {
+	Model         string           `json:"model"`
+	Messages      []models.Message `json:"messages"`
+	Temperature   *float64         `json:"temperature,omitempty"`
+	TopP          *float64         `json:"top_p,omitempty"`
+	MaxTokens     int              `json:"max_tokens,omitempty"`
+	Stop          []string         `json:"stop,omitempty"`
+	Stream        bool             `json:"stream,omitempty"`
+	StreamOptions *struct {
+		IncludeUsage bool `json:"include_usage"`
+	} `json:"stream_options,omitempty"`
+}
+
+type openAIUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+func (u *openAIUsage) usage() models.Usage {
+	if u == nil {
+		return models.Usage{}
+	}
+	return models.Usage{PromptTokens: u.PromptTokens, CompletionTokens: u.CompletionTokens, TotalTokens: u.TotalTokens}
+}
+
+// Chat implements Provider
+func (p *OpenAIProvider) Chat(ctx context.Context, request ProviderRequest) (*ProviderReply, error) {
+	resp, err := p.post(ctx, p.chatRequest(request, false))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var completion struct {
+		Choices []struct {
+			Message models.Message `json:"message"`
+		} `json:"choices"`
+		Usage *openAIUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&completion); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	reply := &ProviderReply{Usage: completion.Usage.usage()}
+	if len(completion.Choices) > 0 {
+		reply.Content = completion.Choices[0].Message.Content
+	}
+	return reply, nil
+}
+
+// StreamChat implements Provider. Usage is read from the last chunk, which servers send when
+// asked with stream_options; servers that ignore it report no usage.
+func (p *OpenAIProvider) StreamChat(ctx context.Context, request ProviderRequest, onText func(string)) (models.Usage, error) {
+	resp, err := p.post(ctx, p.chatRequest(request, true))
+	if err != nil {
+		return models.Usage{}, err
+	}
+	defer resp.Body.Close()
+
+	var usage models.Usage
+	err = readServerSentEvents(resp.Body, func(_, data string) error {
+		if data == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content string `json:"content"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *openAIUsage `json:"usage"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to decode stream chunk: %w", err)
+		}
+		if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+			onText(chunk.Choices[0].Delta.Content)
+		}
+		if chunk.Usage != nil {
+			usage = chunk.Usage.usage()
+		}
+		return nil
+	})
+	return usage, err
+}
+
+func (p *OpenAIProvider) chatRequest(request ProviderRequest, stream bool) openAIChatRequest {
+	body := openAIChatRequest{
+		Model:       request.Model,
+		Messages:    request.Messages,
+		Temperature: request.Temperature,
+		TopP:        request.TopP,
+		MaxTokens:   request.MaxTokens,
+		Stop:        request.Stop,
+		Stream:      stream,
+	}
+	if stream {
+		body.StreamOptions = &struct {
+			IncludeUsage bool `json:"include_usage"`
+		}{IncludeUsage: true}
+	}
+	return body
+}
+
+func (p *OpenAIProvider) post(ctx context.Context, body openAIChatRequest) (*http.Response, error) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+p.apiKey)
+	return postProvider(ctx, p.client, ProviderOpenAI, p.baseURL+"/chat/completions", header, body)
+}
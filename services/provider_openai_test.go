@@ -0,0 +1,78 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIProvider_Chat(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/chat/completions", r.URL.Path)
+		assert.Equal(t, "Bearer sk-test", r.Header.Get("Authorization"))
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"Hello"}}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`)
+	}))
+	defer server.Close()
+
+	temperature := 0.2
+	provider := NewOpenAIProvider(http.DefaultClient, server.URL+"/v1/", "sk-test")
+	reply, err := provider.Chat(context.Background(), ProviderRequest{
+		Model:       "gpt-4o-mini",
+		Messages:    []models.Message{{Role: "user", Content: "Hi"}},
+		Temperature: &temperature,
+		MaxTokens:   50,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, &ProviderReply{Content: "Hello", Usage: models.Usage{PromptTokens: 5, CompletionTokens: 1, TotalTokens: 6}}, reply)
+	assert.Equal(t, "gpt-4o-mini", body["model"])
+	assert.Equal(t, 0.2, body["temperature"])
+	assert.Equal(t, float64(50), body["max_tokens"])
+	assert.NotContains(t, body, "stream")
+}
+
+func TestOpenAIProvider_StreamChat(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, true, body["stream"])
+		assert.Equal(t, map[string]interface{}{"include_usage": true}, body["stream_options"])
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"role\":\"assistant\",\"content\":\"Hel\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"lo\"}}]}\n\n")
+		fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":5,\"completion_tokens\":2,\"total_tokens\":7}}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	var text string
+	provider := NewOpenAIProvider(http.DefaultClient, server.URL, "sk-test")
+	usage, err := provider.StreamChat(context.Background(), ProviderRequest{Model: "gpt-4o-mini"}, func(chunk string) { text += chunk })
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Hello", text)
+	assert.Equal(t, models.Usage{PromptTokens: 5, CompletionTokens: 2, TotalTokens: 7}, usage)
+}
+
+func TestOpenAIProvider_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		fmt.Fprint(w, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`)
+	}))
+	defer server.Close()
+
+	_, err := NewOpenAIProvider(http.DefaultClient, server.URL, "sk-wrong").Chat(context.Background(), ProviderRequest{Model: "gpt-4o-mini"})
+
+	assert.Equal(t, &UpstreamError{Backend: ProviderOpenAI, StatusCode: http.StatusUnauthorized, Message: "Incorrect API key provided"}, err)
+	assert.EqualError(t, err, "openai returned status 401: Incorrect API key provided")
+}
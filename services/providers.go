@@ -0,0 +1,248 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+)
+
+// Remote providers enabled by configuration, named by the prefix of the models they serve
+const (
+	ProviderOpenAI    = "openai"
+	ProviderAnthropic = "anthropic"
+)
+
+// Provider generates chat replies with a remote API other than Ollama. It serves the models named
+// with its prefix, so "openai:gpt-4o-mini" goes to the provider registered as "openai" and asks it
+// for "gpt-4o-mini". Failures are returned as UpstreamErrors naming the provider as the backend.
+type Provider interface {
+	// Chat returns the whole reply
+	Chat(ctx context.Context, request ProviderRequest) (*ProviderReply, error)
+	// StreamChat passes the reply's text to onText as it is generated and returns the usage
+	StreamChat(ctx context.Context, request ProviderRequest, onText func(string)) (models.Usage, error)
+}
+
+// ProviderRequest is a chat request in the terms remote providers share
+type ProviderRequest struct {
+	Model       string // Without the provider prefix
+	Messages    []models.Message
+	Temperature *float64
+	TopP        *float64
+	MaxTokens   int // 0 for the provider's default
+	Stop        []string
+}
+
+// ProviderReply is the reply of a remote provider
+type ProviderReply struct {
+	Content string
+	Usage   models.Usage
+}
+
+// registerProviders adds the remote providers whose API key is configured, unless an option
+// already registered one under the same name
+func (s *LlamaService) registerProviders(cfg config.ProvidersConfig) {
+	if s.providers == nil {
+		s.providers = map[string]Provider{}
+	}
+	if _, ok := s.providers[ProviderOpenAI]; !ok && cfg.OpenAIAPIKey != "" {
+		s.providers[ProviderOpenAI] = NewOpenAIProvider(s.httpClient, cfg.OpenAIBaseURL, cfg.OpenAIAPIKey)
+	}
+	if _, ok := s.providers[ProviderAnthropic]; !ok && cfg.AnthropicAPIKey != "" {
+		s.providers[ProviderAnthropic] = NewAnthropicProvider(s.httpClient, cfg.AnthropicBaseURL, cfg.AnthropicAPIKey)
+	}
+}
+
+// provider returns the remote provider serving model and the model's name at that provider.
+// Models without a registered prefix are served by Ollama.
+func (s *LlamaService) provider(model string) (name string, provider Provider, remoteModel string, ok bool) {
+	name, remoteModel, found := strings.Cut(model, ":")
+	if !found {
+		return "", nil, "", false
+	}
+	provider, ok = s.providers[name]
+	return name, provider, remoteModel, ok
+}
+
+// generateRemote sends a chat request for model to a remote provider. The context window is not
+// trimmed: remote models report no context length.
+func (s *LlamaService) generateRemote(ctx context.Context, backend string, provider Provider, model, remoteModel string, request models.ChatRequest) (*models.ChatResponse, error) {
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
+
+	timeout := s.generationTimeout(model)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	reply, err := provider.Chat(ctx, providerRequest(remoteModel, request))
+	if err != nil {
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("failed to make chat request: %w", err)
+	}
+
+	response := &models.ChatResponse{
+		ID:      s.GenerateID(),
+		Object:  "chat.completion",
+		Created: s.Now().Unix(),
+		Model:   model,
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: reply.Content}}},
+		Usage:   reply.Usage,
+		Backend: backend,
+	}
+	s.recordUsage(ctx, backend, model, response.Usage, time.Since(start))
+	return response, nil
+}
+
+// streamRemote streams the reply of a remote provider to responseChan like streamModel does for Ollama
+func (s *LlamaService) streamRemote(ctx context.Context, backend string, provider Provider, model, remoteModel string, request models.ChatRequest, responseChan chan<- string) (*models.ChatResponse, error) {
+	enterStage(ctx, StageQueue)
+	release, err := s.queue.acquire(ctx, model)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	enterStage(ctx, StageGeneration)
+
+	timeout := s.generationTimeout(model)
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	var content strings.Builder
+	usage, err := provider.StreamChat(ctx, providerRequest(remoteModel, request), func(text string) {
+		content.WriteString(text)
+		responseChan <- text
+	})
+	response := &models.ChatResponse{
+		ID:      s.GenerateID(),
+		Object:  "chat.completion",
+		Created: s.Now().Unix(),
+		Model:   model,
+		Choices: []models.Choice{{Message: models.Message{Role: "assistant", Content: content.String()}}},
+		Usage:   usage,
+		Backend: backend,
+	}
+	if err != nil {
+		var sent *models.ChatResponse
+		if content.Len() > 0 {
+			sent = response
+		}
+		if ctxErr := contextError(ctx, model, timeout, ""); ctxErr != nil {
+			return sent, ctxErr
+		}
+		return sent, err
+	}
+	s.recordUsage(ctx, backend, model, usage, time.Since(start))
+	return response, nil
+}
+
+// providerRequest converts a chat request, options taking precedence over the top-level fields
+// as they do for Ollama
+func providerRequest(model string, request models.ChatRequest) ProviderRequest {
+	converted := ProviderRequest{Model: model, Messages: request.Messages, MaxTokens: request.MaxTokens}
+	if request.Temperature > 0 {
+		temperature := request.Temperature
+		converted.Temperature = &temperature
+	}
+	if options := request.Options; options != nil {
+		if options.Temperature != nil {
+			converted.Temperature = options.Temperature
+		}
+		if options.NumPredict != nil && *options.NumPredict > 0 {
+			converted.MaxTokens = *options.NumPredict
+		}
+		converted.TopP = options.TopP
+		converted.Stop = options.Stop
+	}
+	return converted
+}
+
+// postProvider sends a JSON request to a remote provider and returns the response when its
+// status is 2xx, or an UpstreamError carrying the provider's error message
+func postProvider(ctx context.Context, client HTTPClient, backend, url string, header http.Header, body interface{}) (*http.Response, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header = header
+	req.Header.Set("Content-Type", "application/json")
+	forwardRequestID(ctx, req.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &UpstreamError{Backend: backend, Message: err.Error()}
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	// OpenAI and Anthropic both describe failures as {"error": {"message": ...}}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var failure struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(data))
+	if json.Unmarshal(data, &failure) == nil && failure.Error.Message != "" {
+		message = failure.Error.Message
+	}
+	return nil, &UpstreamError{Backend: backend, StatusCode: resp.StatusCode, Message: message}
+}
+
+// readServerSentEvents calls handle with the event name and data of each server-sent event in
+// body until it ends or handle returns an error
+func readServerSentEvents(body io.Reader, handle func(event, data string) error) error {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var event string
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				if err := handle(event, strings.Join(data, "\n")); err != nil {
+					return err
+				}
+			}
+			event, data = "", nil
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(data) > 0 {
+		return handle(event, strings.Join(data, "\n"))
+	}
+	return nil
+}
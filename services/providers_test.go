@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeProvider replies with the model it was asked for and records the request
+type fakeProvider struct {
+	request ProviderRequest
+	err     error
+}
+
+func (p *fakeProvider) Chat(ctx context.Context, request ProviderRequest) (*ProviderReply, error) {
+	p.request = request
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &ProviderReply{Content: "reply from " + request.Model, Usage: models.Usage{PromptTokens: 2, CompletionTokens: 3, TotalTokens: 5}}, nil
+}
+
+func (p *fakeProvider) StreamChat(ctx context.Context, request ProviderRequest, onText func(string)) (models.Usage, error) {
+	p.request = request
+	for _, word := range strings.SplitAfter("reply from "+request.Model, " ") {
+		onText(word)
+	}
+	return models.Usage{TotalTokens: 5}, p.err
+}
+
+func TestProviderRouting_Chat(t *testing.T) {
+	provider := &fakeProvider{}
+	service := NewLlamaService(WithProvider("openai", provider))
+
+	temperature := 0.3
+	numPredict := 40
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:       "openai:gpt-4o-mini",
+		Messages:    []models.Message{{Role: "user", Content: "Hi"}},
+		Temperature: 0.9,
+		Options:     &models.Options{Temperature: &temperature, NumPredict: &numPredict},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "reply from gpt-4o-mini", response.Choices[0].Message.Content)
+	assert.Equal(t, "openai:gpt-4o-mini", response.Model)
+	assert.Equal(t, "openai", response.Backend)
+	assert.Equal(t, &temperature, provider.request.Temperature)
+	assert.Equal(t, 40, provider.request.MaxTokens)
+}
+
+func TestProviderRouting_StreamChat(t *testing.T) {
+	service := NewLlamaService(WithProvider("anthropic", &fakeProvider{}))
+
+	responseChan := make(chan string)
+	go service.StreamChat(context.Background(), models.ChatRequest{
+		Model:    "anthropic:claude-3-5-haiku-latest",
+		Messages: []models.Message{{Role: "user", Content: "Hi"}},
+	}, responseChan)
+
+	var text string
+	for chunk := range responseChan {
+		text += chunk
+	}
+	assert.Equal(t, "reply from claude-3-5-haiku-latest", text)
+}
+
+func TestProviderRouting_Errors(t *testing.T) {
+	service := NewLlamaService(WithProvider("openai", &fakeProvider{err: &UpstreamError{Backend: "openai", StatusCode: 429, Message: "slow down"}}))
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{Model: "openai:gpt-4o", Messages: []models.Message{{Role: "user", Content: "Hi"}}})
+	var upstreamErr *UpstreamError
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, 429, upstreamErr.StatusCode)
+
+	_, err = service.Completion(context.Background(), models.CompletionRequest{Model: "openai:gpt-4o", Prompt: "Once"})
+	assert.ErrorIs(t, err, ErrChatOnly)
+	_, err = service.Embedding(context.Background(), models.EmbeddingRequest{Model: "openai:text-embedding-3-small", Input: "text"})
+	assert.ErrorIs(t, err, ErrChatOnly)
+}
+
+func TestRegisterProviders(t *testing.T) {
+	service := &LlamaService{}
+	service.registerProviders(config.ProvidersConfig{OpenAIBaseURL: "https://api.openai.com/v1", OpenAIAPIKey: "sk-test"})
+
+	_, _, _, ok := service.provider("openai:gpt-4o-mini")
+	assert.True(t, ok)
+	_, _, _, ok = service.provider("anthropic:claude-3-5-haiku-latest")
+	assert.False(t, ok, "providers without an API key stay disabled")
+	_, _, _, ok = service.provider("llama3.2:1b")
+	assert.False(t, ok, "Ollama tags are not provider prefixes")
+}
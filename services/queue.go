@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// requestQueue limits how many generations run at once, server-wide and per model.
+// Requests beyond the limits wait in a bounded queue until a slot frees up or the queue timeout expires.
+// A freed slot goes to the waiting request of the highest priority class that can use it, and among
+// requests of the same class to the one that has waited longest.
+type requestQueue struct {
+	maxConcurrent int // 0 means unlimited
+	perModelLimit int // 0 means unlimited
+	maxQueued     int
+	timeout       time.Duration
+
+	mu       sync.Mutex
+	running  int
+	perModel map[string]int
+	waiters  []*queueWaiter // Ordered by rank, highest first, then by arrival
+}
+
+// queueWaiter is a request waiting for a slot. ready is closed once the slot is granted.
+type queueWaiter struct {
+	model string
+	rank  int
+	ready chan struct{}
+}
+
+func newRequestQueue(maxConcurrent, maxPerModel, maxQueued int, timeout time.Duration) *requestQueue {
+	if maxConcurrent <= 0 && maxPerModel <= 0 {
+		return nil
+	}
+
+	return &requestQueue{
+		maxConcurrent: maxConcurrent,
+		perModelLimit: maxPerModel,
+		maxQueued:     maxQueued,
+		timeout:       timeout,
+		perModel:      map[string]int{},
+	}
+}
+
+// acquire reserves a slot for model and returns the function that releases it. Waiting requests
+// are served by the priority class of ctx.
+// It fails with a QueueError when the queue is full or the wait exceeds the queue timeout.
+func (q *requestQueue) acquire(ctx context.Context, model string) (func(), error) {
+	if q == nil {
+		return func() {}, nil
+	}
+
+	q.mu.Lock()
+	// Free slots are handed to waiters as soon as they are released, so a free slot
+	// means no waiter can use it
+	if q.canRun(model) {
+		q.take(model)
+		q.mu.Unlock()
+		return q.releaser(model), nil
+	}
+	if len(q.waiters) >= q.maxQueued {
+		q.mu.Unlock()
+		return nil, &QueueError{Reason: ErrQueueFull, RetryAfter: q.timeout}
+	}
+	waiter := &queueWaiter{model: model, rank: priorityRank(RequestPriority(ctx)), ready: make(chan struct{})}
+	position, _ := slices.BinarySearchFunc(q.waiters, waiter.rank, func(w *queueWaiter, rank int) int {
+		// Behind every waiter of the same or a higher rank
+		if w.rank >= rank {
+			return -1
+		}
+		return 1
+	})
+	q.waiters = slices.Insert(q.waiters, position, waiter)
+	q.mu.Unlock()
+
+	waitCtx, cancel := context.WithTimeout(ctx, q.timeout)
+	defer cancel()
+
+	select {
+	case <-waiter.ready:
+		return q.releaser(model), nil
+	case <-waitCtx.Done():
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	select {
+	case <-waiter.ready:
+		// Granted while giving up; hand the slot on
+		q.free(model)
+	default:
+		q.waiters = slices.DeleteFunc(q.waiters, func(w *queueWaiter) bool { return w == waiter })
+	}
+	return nil, q.waitError(ctx, model)
+}
+
+// canRun reports whether a generation of model fits the limits. Callers hold q.mu.
+func (q *requestQueue) canRun(model string) bool {
+	return (q.maxConcurrent <= 0 || q.running < q.maxConcurrent) &&
+		(q.perModelLimit <= 0 || q.perModel[model] < q.perModelLimit)
+}
+
+// take counts a generation of model as running. Callers hold q.mu.
+func (q *requestQueue) take(model string) {
+	q.running++
+	q.perModel[model]++
+}
+
+// free ends a generation of model and grants the freed slots to the waiters that can use them.
+// Callers hold q.mu.
+func (q *requestQueue) free(model string) {
+	q.running--
+	if q.perModel[model]--; q.perModel[model] <= 0 {
+		delete(q.perModel, model)
+	}
+
+	remaining := q.waiters[:0]
+	for _, waiter := range q.waiters {
+		if !q.canRun(waiter.model) {
+			remaining = append(remaining, waiter)
+			continue
+		}
+		q.take(waiter.model)
+		close(waiter.ready)
+	}
+	clear(q.waiters[len(remaining):])
+	q.waiters = remaining
+}
+
+// waitError reports a client budget running out as a RequestBudgetError, a caller cancellation
+// as-is and anything else as a queue timeout
+func (q *requestQueue) waitError(ctx context.Context, model string) error {
+	if err := budgetError(ctx, model, ""); err != nil {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return &QueueError{Reason: ErrQueueTimeout, RetryAfter: q.timeout}
+}
+
+func (q *requestQueue) releaser(model string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			defer q.mu.Unlock()
+			q.free(model)
+		})
+	}
+}
+
+// requestPriorityKey is the context key of the class set by WithPriority
+type requestPriorityKey struct{}
+
+// WithPriority sets the priority class of the request of ctx, one of models.Priorities
+func WithPriority(ctx context.Context, priority string) context.Context {
+	return context.WithValue(ctx, requestPriorityKey{}, priority)
+}
+
+// RequestPriority returns the priority class of the request of ctx, models.PriorityNormal if none was set
+func RequestPriority(ctx context.Context) string {
+	if priority, ok := ctx.Value(requestPriorityKey{}).(string); ok {
+		return priority
+	}
+	return models.PriorityNormal
+}
+
+// withDefaultPriority sets the priority class of ctx unless the client chose one
+func withDefaultPriority(ctx context.Context, priority string) context.Context {
+	if _, ok := ctx.Value(requestPriorityKey{}).(string); ok {
+		return ctx
+	}
+	return WithPriority(ctx, priority)
+}
+
+// priorityRank orders priority classes, higher ranks first
+func priorityRank(priority string) int {
+	if i := slices.Index(models.Priorities, priority); i >= 0 {
+		return len(models.Priorities) - i
+	}
+	return priorityRank(models.PriorityNormal)
+}
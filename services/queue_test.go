@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestQueue_Unlimited(t *testing.T) {
+	queue := newRequestQueue(0, 0, 0, time.Second)
+	assert.Nil(t, queue)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	release()
+}
+
+func TestRequestQueue_GlobalLimit(t *testing.T) {
+	queue := newRequestQueue(1, 0, 1, 50*time.Millisecond)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+
+	// A second request waits and then times out
+	_, err = queue.acquire(context.Background(), "phi3")
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+
+	// Releasing the slot lets a waiting request through
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		release()
+	}()
+	release2, err := queue.acquire(context.Background(), "phi3")
+	assert.NoError(t, err)
+	release2()
+}
+
+func TestRequestQueue_QueueFull(t *testing.T) {
+	queue := newRequestQueue(1, 0, 1, time.Second)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	defer release()
+
+	waiting := make(chan error)
+	go func() {
+		_, err := queue.acquire(context.Background(), "llama2")
+		waiting <- err
+	}()
+
+	assert.Eventually(t, func() bool {
+		queue.mu.Lock()
+		defer queue.mu.Unlock()
+		return len(queue.waiters) == 1
+	}, time.Second, 5*time.Millisecond)
+
+	_, err = queue.acquire(context.Background(), "llama2")
+	var queueErr *QueueError
+	assert.ErrorAs(t, err, &queueErr)
+	assert.ErrorIs(t, err, ErrQueueFull)
+	assert.Equal(t, time.Second, queueErr.RetryAfter)
+
+	release()
+	assert.NoError(t, <-waiting)
+}
+
+func TestRequestQueue_PerModelLimit(t *testing.T) {
+	queue := newRequestQueue(0, 1, 0, 50*time.Millisecond)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	defer release()
+
+	// Other models are not affected by the per-model limit
+	releaseOther, err := queue.acquire(context.Background(), "phi3")
+	assert.NoError(t, err)
+	releaseOther()
+
+	_, err = queue.acquire(context.Background(), "llama2")
+	assert.ErrorIs(t, err, ErrQueueFull)
+}
+
+func TestRequestQueue_CallerCancelled(t *testing.T) {
+	queue := newRequestQueue(1, 0, 1, time.Second)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = queue.acquire(ctx, "llama2")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestRequestQueue_Priority(t *testing.T) {
+	queue := newRequestQueue(1, 0, 3, time.Second)
+
+	release, err := queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+
+	// Requests queue in arrival order, behind every request of a higher class
+	granted := make(chan string, 3)
+	for i, priority := range []string{models.PriorityBackground, models.PriorityNormal, models.PriorityInteractive} {
+		go func() {
+			release, err := queue.acquire(WithPriority(context.Background(), priority), "llama2")
+			if assert.NoError(t, err) {
+				granted <- priority
+				release()
+			}
+		}()
+		assert.Eventually(t, func() bool {
+			queue.mu.Lock()
+			defer queue.mu.Unlock()
+			return len(queue.waiters) == i+1
+		}, time.Second, 5*time.Millisecond)
+	}
+
+	release()
+	assert.Equal(t, models.PriorityInteractive, <-granted)
+	assert.Equal(t, models.PriorityNormal, <-granted)
+	assert.Equal(t, models.PriorityBackground, <-granted)
+}
+
+func TestRequestPriority(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, models.PriorityNormal, RequestPriority(ctx))
+
+	// Endpoint defaults do not override the client's choice
+	assert.Equal(t, models.PriorityInteractive, RequestPriority(withDefaultPriority(ctx, models.PriorityInteractive)))
+	ctx = WithPriority(ctx, models.PriorityBackground)
+	assert.Equal(t, models.PriorityBackground, RequestPriority(withDefaultPriority(ctx, models.PriorityInteractive)))
+}
+
+func TestEmbedding_WaitsForSlot(t *testing.T) {
+	service := NewLlamaService()
+	service.queue = newRequestQueue(1, 0, 1, 50*time.Millisecond)
+
+	release, err := service.queue.acquire(context.Background(), "llama2")
+	assert.NoError(t, err)
+	defer release()
+
+	_, err = service.Embedding(context.Background(), models.EmbeddingRequest{Model: "nomic-embed-text", Input: "Hello"})
+	assert.ErrorIs(t, err, ErrQueueTimeout)
+}
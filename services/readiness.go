@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// ReadinessCheck reports why a dependency cannot serve requests, or nil when it can
+type ReadinessCheck func(ctx context.Context) error
+
+// Readiness runs the dependency checks behind the readiness probe
+type Readiness struct {
+	timeout  time.Duration
+	names    []string
+	checks   map[string]ReadinessCheck
+	optional map[string]bool
+}
+
+// NewReadiness creates a probe with no checks. Each check is given timeout to answer.
+func NewReadiness(timeout time.Duration) *Readiness {
+	return &Readiness{timeout: timeout, checks: map[string]ReadinessCheck{}, optional: map[string]bool{}}
+}
+
+// Add registers check under name. Checks are added at startup, before the probe is served.
+func (r *Readiness) Add(name string, check ReadinessCheck) {
+	if _, ok := r.checks[name]; !ok {
+		r.names = append(r.names, name)
+	}
+	r.checks[name] = check
+}
+
+// AddOptional registers a check of a dependency the server can run without. When it fails the
+// server is reported degraded but stays ready.
+func (r *Readiness) AddOptional(name string, check ReadinessCheck) {
+	r.Add(name, check)
+	r.optional[name] = true
+}
+
+// Check runs every check in parallel and reports the server ready when all of them pass, and
+// degraded when only optional ones fail
+func (r *Readiness) Check(ctx context.Context) models.ReadinessReport {
+	results := make([]models.DependencyStatus, len(r.names))
+	var wg sync.WaitGroup
+
+	for i, name := range r.names {
+		check := r.checks[name]
+		// Reported if the check panics before filling in the result
+		results[i] = models.DependencyStatus{Status: models.DependencyFailed, Error: "check did not complete"}
+
+		wg.Add(1)
+		Go("readiness", func(context.Context) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, r.timeout)
+			defer cancel()
+
+			start := time.Now()
+			err := check(checkCtx)
+			result := models.DependencyStatus{
+				Status:    models.DependencyOK,
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				result.Status = models.DependencyFailed
+				result.Error = err.Error()
+			}
+			results[i] = result
+		})
+	}
+	wg.Wait()
+
+	report := models.ReadinessReport{Status: models.StatusReady, Checks: make(map[string]models.DependencyStatus, len(r.names))}
+	for i, name := range r.names {
+		results[i].Optional = r.optional[name]
+		report.Checks[name] = results[i]
+		switch {
+		case results[i].Status == models.DependencyOK:
+		case results[i].Optional:
+			if report.Status == models.StatusReady {
+				report.Status = models.StatusDegraded
+			}
+		default:
+			report.Status = models.StatusNotReady
+		}
+	}
+	return report
+}
+
+// CheckOllama verifies the local Ollama server answers /api/tags. The request is sent once,
+// bypassing retries and the circuit breaker, so the probe reflects the current state.
+func (s *LlamaService) CheckOllama(ctx context.Context) error {
+	_, err := s.localModelNames(ctx)
+	return err
+}
+
+// CheckDefaultModel verifies the default model, after alias resolution, is pulled on the local
+// Ollama server. Cloud models and models of remote providers are not checked.
+func (s *LlamaService) CheckDefaultModel(ctx context.Context) error {
+	model := s.getModel("")
+	if _, _, _, remote := s.provider(model); remote || s.IsCloudModel(model) {
+		return nil
+	}
+
+	names, err := s.localModelNames(ctx)
+	if err != nil {
+		return err
+	}
+	// Ollama lists untagged models with their implicit latest tag
+	if !slices.Contains(names, model) && !slices.Contains(names, model+":latest") {
+		return fmt.Errorf("default model %s is not pulled", model)
+	}
+	return nil
+}
+
+// localModelNames lists the models pulled on the local Ollama server
+func (s *LlamaService) localModelNames(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", s.config.BaseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	forwardRequestID(ctx, req.Header)
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ollama is unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama API returned status %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, fmt.Errorf("failed to decode model list: %w", err)
+	}
+
+	names := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		names = append(names, model.Name)
+	}
+	return names, nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadiness_Check(t *testing.T) {
+	readiness := NewReadiness(50 * time.Millisecond)
+	readiness.Add("ok", func(context.Context) error { return nil })
+	readiness.Add("down", func(context.Context) error { return errors.New("connection refused") })
+	readiness.Add("slow", func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	readiness.Add("panics", func(context.Context) error { panic("boom") })
+
+	report := readiness.Check(context.Background())
+	assert.Equal(t, models.StatusNotReady, report.Status)
+	assert.Equal(t, models.DependencyOK, report.Checks["ok"].Status)
+	assert.Equal(t, "connection refused", report.Checks["down"].Error)
+	assert.Equal(t, context.DeadlineExceeded.Error(), report.Checks["slow"].Error)
+	assert.Equal(t, models.DependencyFailed, report.Checks["panics"].Status)
+}
+
+func TestReadiness_AllPassing(t *testing.T) {
+	readiness := NewReadiness(time.Second)
+	readiness.Add("ok", func(context.Context) error { return nil })
+
+	report := readiness.Check(context.Background())
+	assert.Equal(t, models.StatusReady, report.Status)
+	assert.Len(t, report.Checks, 1)
+}
+
+func TestReadiness_OptionalChecks(t *testing.T) {
+	readiness := NewReadiness(time.Second)
+	readiness.Add("ollama", func(context.Context) error { return nil })
+	readiness.AddOptional("usage_store", func(context.Context) error { return errors.New("connection refused") })
+
+	report := readiness.Check(context.Background())
+	assert.Equal(t, models.StatusDegraded, report.Status)
+	assert.True(t, report.Checks["usage_store"].Optional)
+	assert.False(t, report.Checks["ollama"].Optional)
+
+	readiness.Add("ollama", func(context.Context) error { return errors.New("connection refused") })
+	assert.Equal(t, models.StatusNotReady, readiness.Check(context.Background()).Status)
+}
+
+func TestCheckDefaultModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/tags", r.URL.Path)
+		fmt.Fprint(w, `{"models":[{"name":"llama2:latest"},{"name":"mistral:7b"}]}`)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	for model, pulled := range map[string]bool{
+		"llama2":            true,
+		"mistral:7b":        true,
+		"mistral":           false,
+		"gpt-oss:20b-cloud": true, // Cloud models are not checked
+	} {
+		service.config.DefaultModel = model
+		err := service.CheckDefaultModel(context.Background())
+		if pulled {
+			assert.NoError(t, err, model)
+		} else {
+			assert.EqualError(t, err, "default model mistral is not pulled")
+		}
+	}
+	assert.NoError(t, service.CheckOllama(context.Background()))
+}
+
+func TestCheckOllama_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	assert.EqualError(t, service.CheckOllama(context.Background()), "ollama API returned status 503")
+	assert.Error(t, service.CheckDefaultModel(context.Background()))
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+)
+
+// requestIDKey is the context key of the request ID set by WithRequestID
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log lines and upstream requests carry requestID
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// requestID returns the request ID of ctx, empty when it has none
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// forwardRequestID sets X-Request-ID on an upstream request so Ollama logs can be correlated
+func forwardRequestID(ctx context.Context, header http.Header) {
+	if id := requestID(ctx); id != "" {
+		header.Set("X-Request-ID", id)
+	}
+}
+
+// logger returns the default logger, with the request ID of ctx as the request_id field when it
+// has one
+func logger(ctx context.Context) *slog.Logger {
+	if id := requestID(ctx); id != "" {
+		return slog.With("request_id", id)
+	}
+	return slog.Default()
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID_ForwardedUpstream(t *testing.T) {
+	forwarded := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		forwarded <- r.Header.Get("X-Request-ID")
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	ctx := WithRequestID(context.Background(), "abc-123")
+	_, err := service.Chat(ctx, models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hello"}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "abc-123", <-forwarded)
+}
+
+func TestLogger_RequestIDField(t *testing.T) {
+	var out bytes.Buffer
+	// SetDefault also redirects the log package, which has to be restored separately
+	defer func(logger *slog.Logger, w io.Writer, flags int) {
+		slog.SetDefault(logger)
+		log.SetOutput(w)
+		log.SetFlags(flags)
+	}(slog.Default(), log.Writer(), log.Flags())
+	slog.SetDefault(slog.New(slog.NewJSONHandler(&out, nil)))
+
+	logger(WithRequestID(context.Background(), "abc-123")).Warn("Shadow request failed", "model", "phi3")
+
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(out.Bytes(), &record))
+	assert.Equal(t, "abc-123", record["request_id"])
+	assert.Equal(t, "phi3", record["model"])
+}
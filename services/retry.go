@@ -0,0 +1,33 @@
+package services
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// maxRetryBackoff caps the delay between two attempts
+const maxRetryBackoff = 10 * time.Second
+
+// shouldRetry reports whether an upstream attempt failed transiently, such as while Ollama restarts
+func (s *LlamaService) shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return errors.Is(err, syscall.ECONNREFUSED) ||
+			errors.Is(err, syscall.ECONNRESET) ||
+			errors.Is(err, io.EOF) ||
+			errors.Is(err, io.ErrUnexpectedEOF)
+	}
+	return slices.Contains(s.config.RetryStatusCodes, resp.StatusCode)
+}
+
+// retryDelay returns the backoff before the attempt following attempt, doubling each time
+func (s *LlamaService) retryDelay(attempt int) time.Duration {
+	delay := time.Duration(s.config.RetryBackoff) * time.Millisecond
+	for i := 1; i < attempt && delay < maxRetryBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxRetryBackoff)
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetryDelay(t *testing.T) {
+	service := NewLlamaService()
+	service.config.RetryBackoff = 250
+
+	assert.Equal(t, 250*time.Millisecond, service.retryDelay(1))
+	assert.Equal(t, 500*time.Millisecond, service.retryDelay(2))
+	assert.Equal(t, time.Second, service.retryDelay(3))
+	assert.Equal(t, maxRetryBackoff, service.retryDelay(20))
+}
+
+func TestMakeRequest_RetriesTransientStatus(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		assert.Equal(t, "Hello", body["prompt"])
+
+		if attempts.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"response": "Hi", "done": true})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryBackoff = 1
+
+	response, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), attempts.Load())
+	assert.Equal(t, "Hi", response.Choices[0].Message.Content)
+}
+
+func TestMakeRequest_GivesUpAfterMaxAttempts(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.RetryBackoff = 1
+	service.config.RetryMaxAttempts = 2
+
+	resp, err := service.makeRequest(context.Background(), "GET", "/api/tags", nil, server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, resp.StatusCode)
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestMakeRequest_DoesNotRetryClientErrors(t *testing.T) {
+	var attempts atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.RetryBackoff = 1
+
+	resp, err := service.makeRequest(context.Background(), "GET", "/api/tags", nil, server.URL)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, int32(1), attempts.Load())
+}
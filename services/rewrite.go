@@ -0,0 +1,132 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"agent-ollama-gin/models"
+)
+
+// citationPattern matches URLs and numeric reference markers such as [1] or [2, 3]
+var citationPattern = regexp.MustCompile(`https?://[^\s<>"')\]]+|\[\d+(?:\s*[,\-–]\s*\d+)*\]`)
+
+// Rewrite rewrites existing text in the requested style, tone and length
+func (s *LlamaService) Rewrite(ctx context.Context, request models.RewriteRequest) (*models.RewriteResponse, error) {
+	text := request.Text
+	var citations []string
+	if request.PreserveCitations {
+		text, citations = protectCitations(text)
+	}
+
+	chatRequest := models.ChatRequest{
+		Model:       request.Model,
+		Temperature: request.Temperature,
+		Messages: []models.Message{
+			{Role: "system", Content: buildRewritePrompt(request, len(citations) > 0)},
+			{Role: "user", Content: text},
+		},
+	}
+
+	chatResponse, err := s.chat(ctx, EndpointRewrite, chatRequest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to rewrite text: %w", err)
+	}
+
+	rewritten := ""
+	if len(chatResponse.Choices) > 0 {
+		rewritten = strings.TrimSpace(chatResponse.Choices[0].Message.Content)
+	}
+	var missing []string
+	if len(citations) > 0 {
+		rewritten, missing = restoreCitations(rewritten, citations)
+	}
+	if len(missing) > 0 {
+		logger(ctx).Warn("Rewrite dropped citations", "model", chatResponse.Model, "missing", len(missing), "citations", len(citations))
+	}
+
+	return &models.RewriteResponse{
+		ID:               s.GenerateID(),
+		Object:           "text.rewrite",
+		Created:          s.Now().Unix(),
+		Model:            chatResponse.Model,
+		Text:             rewritten,
+		Usage:            chatResponse.Usage,
+		FallbackAttempts: chatResponse.FallbackAttempts,
+		MissingCitations: missing,
+	}, nil
+}
+
+// buildRewritePrompt builds the system prompt describing the target style
+func buildRewritePrompt(request models.RewriteRequest, hasCitations bool) string {
+	var b strings.Builder
+	b.WriteString("You are an editor. Rewrite the text provided by the user. ")
+	b.WriteString("Keep the original meaning and facts. Reply with the rewritten text only, without commentary.")
+
+	if request.Style != "" {
+		fmt.Fprintf(&b, "\nStyle: %s.", request.Style)
+	}
+	if request.Tone != "" {
+		fmt.Fprintf(&b, "\nTone: %s.", request.Tone)
+	}
+	if request.Length != "" {
+		fmt.Fprintf(&b, "\nLength: %s.", request.Length)
+	}
+	if request.Language != "" {
+		fmt.Fprintf(&b, "\nWrite the result in this language: %s.", request.Language)
+	}
+	if hasCitations {
+		b.WriteString("\nThe text contains placeholders like {{CITE_0}}. Keep every placeholder exactly as written, next to the statement it supports.")
+	}
+
+	return b.String()
+}
+
+// protectCitations replaces citations and URLs with placeholders so the model cannot alter them.
+// Text that already looks like a placeholder is protected the same way, so it is not mistaken for
+// a citation on the way back.
+func protectCitations(text string) (string, []string) {
+	var citations []string
+	protected := protectedPattern.ReplaceAllStringFunc(text, func(match string) string {
+		placeholder := citationPlaceholder(len(citations))
+		citations = append(citations, match)
+		return placeholder
+	})
+	return protected, citations
+}
+
+// citationPlaceholderPattern matches the placeholders written by citationPlaceholder
+var citationPlaceholderPattern = regexp.MustCompile(`\{\{CITE_(\d+)\}\}`)
+
+// protectedPattern matches the text protectCitations replaces: placeholders and citations
+var protectedPattern = regexp.MustCompile(citationPlaceholderPattern.String() + `|` + citationPattern.String())
+
+// restoreCitations puts the original citations back in place of their placeholders in a single
+// pass, so placeholders appearing in a restored citation are left as written. Placeholders
+// the model made up are kept. It also returns the citations whose placeholder the model dropped
+// or altered, in their original order.
+func restoreCitations(text string, citations []string) (string, []string) {
+	used := make([]bool, len(citations))
+	restored := citationPlaceholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		index, err := strconv.Atoi(citationPlaceholderPattern.FindStringSubmatch(placeholder)[1])
+		if err != nil || index >= len(citations) {
+			return placeholder
+		}
+		used[index] = true
+		return citations[index]
+	})
+
+	var missing []string
+	for index, citation := range citations {
+		if !used[index] {
+			missing = append(missing, citation)
+		}
+	}
+	return restored, missing
+}
+
+func citationPlaceholder(index int) string {
+	return fmt.Sprintf("{{CITE_%d}}", index)
+}
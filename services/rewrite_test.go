@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtectAndRestoreCitations(t *testing.T) {
+	text := "Paris is the capital of France [1] (see https://en.wikipedia.org/wiki/Paris) and has 2M residents [2, 3]."
+
+	protected, citations := protectCitations(text)
+
+	assert.Equal(t, []string{"[1]", "https://en.wikipedia.org/wiki/Paris", "[2, 3]"}, citations)
+	assert.NotContains(t, protected, "https://")
+	assert.Contains(t, protected, "{{CITE_0}}")
+	restored, missing := restoreCitations(protected, citations)
+	assert.Equal(t, text, restored)
+	assert.Empty(t, missing)
+}
+
+func TestRestoreCitations_SinglePass(t *testing.T) {
+	// A citation that looks like a placeholder is restored as written
+	text := "See https://example.com/{{CITE_1}} and [2]."
+	protected, citations := protectCitations(text)
+
+	assert.Equal(t, "See {{CITE_0}} and {{CITE_1}}.", protected)
+	restored, _ := restoreCitations(protected, citations)
+	assert.Equal(t, text, restored)
+
+	// Placeholders without a citation are kept
+	restored, _ = restoreCitations("{{CITE_1}} {{CITE_7}}", citations)
+	assert.Equal(t, "[2] {{CITE_7}}", restored)
+}
+
+func TestProtectCitations_LiteralPlaceholder(t *testing.T) {
+	// Placeholder text written by the user is not turned into a citation
+	text := "Templates use {{CITE_0}} markers [1]."
+	protected, citations := protectCitations(text)
+
+	assert.Equal(t, "Templates use {{CITE_0}} markers {{CITE_1}}.", protected)
+	assert.Equal(t, []string{"{{CITE_0}}", "[1]"}, citations)
+	restored, missing := restoreCitations(protected, citations)
+	assert.Equal(t, text, restored)
+	assert.Empty(t, missing)
+}
+
+func TestRestoreCitations_Missing(t *testing.T) {
+	citations := []string{"[1]", "https://example.com", "[2]"}
+
+	restored, missing := restoreCitations("Shorter {{CITE_2}}, mangled {{ CITE_1 }}.", citations)
+
+	assert.Equal(t, "Shorter [2], mangled {{ CITE_1 }}.", restored)
+	assert.Equal(t, []string{"[1]", "https://example.com"}, missing)
+}
+
+func TestBuildRewritePrompt(t *testing.T) {
+	prompt := buildRewritePrompt(models.RewriteRequest{
+		Style:    "encyclopedic",
+		Tone:     "neutral",
+		Length:   "shorter",
+		Language: "German",
+	}, true)
+
+	assert.Contains(t, prompt, "Style: encyclopedic.")
+	assert.Contains(t, prompt, "Tone: neutral.")
+	assert.Contains(t, prompt, "Length: shorter.")
+	assert.Contains(t, prompt, "Write the result in this language: German.")
+	assert.Contains(t, prompt, "{{CITE_0}}")
+}
+
+func TestRewrite_PreservesCitations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		// Echo the user message back so the placeholders survive the round trip
+		messages := body["messages"].([]interface{})
+		content := messages[1].(map[string]interface{})["content"].(string)
+		assert.NotContains(t, content, "[1]")
+
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": map[string]interface{}{"role": "assistant", "content": "Rewritten: " + content},
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Rewrite(context.Background(), models.RewriteRequest{
+		Text:              "Paris is the capital of France [1].",
+		Model:             "llama2",
+		PreserveCitations: true,
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "text.rewrite", response.Object)
+	assert.Equal(t, "Rewritten: Paris is the capital of France [1].", response.Text)
+	assert.Empty(t, response.MissingCitations)
+}
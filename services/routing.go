@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// backendFor returns the base URL and backend name used for model.
+// Cloud models go to the Ollama Cloud API, everything else to the local daemon.
+func (s *LlamaService) backendFor(model string) (string, string) {
+	if s.IsCloudModel(model) && s.config.CloudEnabled {
+		return s.config.CloudAPIURL, BackendCloud
+	}
+	return s.config.BaseURL, BackendLocal
+}
+
+// sendGeneration posts a generation request for model to its backend and, when FAILOVER_TO_CLOUD is
+// enabled, retries it against Ollama Cloud if the local instance is unreachable or does not have the model.
+// It returns the response together with the backend that served it. Connection failures and error
+// statuses are reported as an UpstreamError naming the backend.
+func (s *LlamaService) sendGeneration(ctx context.Context, path string, body map[string]interface{}, model string) (*http.Response, string, error) {
+	baseURL, backend := s.backendFor(model)
+
+	resp, err := s.sendThroughBreaker(ctx, backend, baseURL, path, body)
+	if backend == BackendLocal && s.shouldFailover(ctx, resp, err) {
+		if resp != nil {
+			resp.Body.Close()
+		}
+		logger(ctx).Warn("Local Ollama failed, failing over to cloud", "model", model)
+		resp, err = s.sendThroughBreaker(ctx, BackendCloud, s.config.CloudAPIURL, path, body)
+		backend = BackendCloud
+	}
+
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, backend, err
+		}
+		return nil, backend, &UpstreamError{Backend: backend, Message: err.Error()}
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, backend, &UpstreamError{
+			Backend:    backend,
+			StatusCode: resp.StatusCode,
+			Message:    upstreamMessage(resp.Body),
+		}
+	}
+
+	return resp, backend, nil
+}
+
+// sendThroughBreaker posts to a backend unless its circuit is open, recording the outcome.
+// Connection errors and 5xx responses count as failures; client errors such as an unknown model do not.
+func (s *LlamaService) sendThroughBreaker(ctx context.Context, backend, baseURL, path string, body map[string]interface{}) (*http.Response, error) {
+	breaker := s.breakers[backend]
+	if !breaker.allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	start := time.Now()
+	resp, err := s.makeRequest(ctx, "POST", path, body, baseURL)
+	switch {
+	case ctx.Err() != nil:
+		breaker.abandon()
+	case err != nil:
+		breaker.record(time.Since(start), err.Error())
+	case resp.StatusCode >= http.StatusInternalServerError:
+		breaker.record(time.Since(start), fmt.Sprintf("status %d", resp.StatusCode))
+	default:
+		breaker.record(time.Since(start), "")
+	}
+	return resp, err
+}
+
+// upstreamMessage extracts the error message from an Ollama error response body
+func upstreamMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 4096))
+
+	var ollamaErr struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &ollamaErr) == nil && ollamaErr.Error != "" {
+		return ollamaErr.Error
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/config"
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackendFor(t *testing.T) {
+	service := NewLlamaService()
+	service.config.BaseURL = "http://local"
+	service.config.CloudAPIURL = "http://cloud"
+	service.config.CloudEnabled = true
+
+	baseURL, backend := service.backendFor("llama3.2")
+	assert.Equal(t, "http://local", baseURL)
+	assert.Equal(t, BackendLocal, backend)
+
+	baseURL, backend = service.backendFor("gpt-oss:120b-cloud")
+	assert.Equal(t, "http://cloud", baseURL)
+	assert.Equal(t, BackendCloud, backend)
+}
+
+func TestGenerationTimeout_CloudModels(t *testing.T) {
+	service := NewLlamaService()
+	service.config.Timeout = 60
+	service.config.CloudTimeout = 300
+	service.config.ModelTimeouts = []config.ModelTimeout{{Pattern: "120b", Seconds: 900}}
+
+	assert.Equal(t, 60*time.Second, service.generationTimeout("llama3.2"))
+	assert.Equal(t, 300*time.Second, service.generationTimeout("gpt-oss:20b-cloud"))
+	assert.Equal(t, 900*time.Second, service.generationTimeout("gpt-oss:120b-cloud"))
+}
+
+func TestChat_UpstreamErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"error":"model 'missing' not found"}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "missing",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+
+	var upstreamErr *UpstreamError
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, BackendLocal, upstreamErr.Backend)
+	assert.Equal(t, http.StatusNotFound, upstreamErr.StatusCode)
+	assert.Equal(t, "model 'missing' not found", upstreamErr.Message)
+}
+
+func TestCompletion_UpstreamUnreachable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+
+	_, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Hello"})
+
+	var upstreamErr *UpstreamError
+	assert.True(t, errors.As(err, &upstreamErr))
+	assert.Equal(t, BackendLocal, upstreamErr.Backend)
+	assert.Equal(t, 0, upstreamErr.StatusCode)
+}
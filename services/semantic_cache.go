@@ -0,0 +1,172 @@
+package services
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// semanticCache keeps recent chat answers next to an embedding of the prompt that produced them,
+// so a prompt phrased differently but meaning the same can be answered without generating again.
+// Only the last user message is compared; the rest of the request must match exactly.
+type semanticCache struct {
+	model      string // Embedding model, empty when the cache is disabled
+	similarity float64
+	limit      int
+	ttl        time.Duration
+
+	mu      sync.Mutex
+	entries []semanticCacheEntry // Oldest first, at most limit entries
+	dirty   bool                 // Changed since the last snapshot
+}
+
+type semanticCacheEntry struct {
+	scope    string    // Key of the request without its last user message
+	vector   []float64 // Normalized embedding of the last user message
+	response models.ChatResponse
+	expires  time.Time
+}
+
+// semanticKey locates a request in the cache
+type semanticKey struct {
+	scope  string
+	vector []float64
+}
+
+// newSemanticCache creates a cache embedding prompts with model. similarity is the minimum
+// cosine similarity in percent for a cached answer to be reused.
+func newSemanticCache(model string, similarity, limit int, ttl time.Duration) *semanticCache {
+	return &semanticCache{
+		model:      model,
+		similarity: float64(min(max(similarity, 0), 100)) / 100,
+		limit:      max(limit, 1),
+		ttl:        ttl,
+	}
+}
+
+func (c *semanticCache) enabled() bool {
+	return c != nil && c.model != ""
+}
+
+// lookup returns a copy of the most similar cached answer, if one is similar enough
+func (c *semanticCache) lookup(key *semanticKey, now time.Time) (*models.ChatResponse, float64) {
+	if key == nil {
+		return nil, 0
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var best *semanticCacheEntry
+	bestSimilarity := c.similarity
+	for i := range c.entries {
+		entry := &c.entries[i]
+		if entry.scope != key.scope || now.After(entry.expires) || len(entry.vector) != len(key.vector) {
+			continue
+		}
+		if similarity := dot(entry.vector, key.vector); similarity >= bestSimilarity {
+			best, bestSimilarity = entry, similarity
+		}
+	}
+	if best == nil {
+		return nil, 0
+	}
+	return copyChatResponse(&best.response), bestSimilarity
+}
+
+// store caches response under key, evicting expired entries and then the oldest ones
+func (c *semanticCache) store(key *semanticKey, response *models.ChatResponse, now time.Time) {
+	if key == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = slices.DeleteFunc(c.entries, func(entry semanticCacheEntry) bool {
+		return now.After(entry.expires)
+	})
+	c.entries = append(c.entries, semanticCacheEntry{
+		scope:    key.scope,
+		vector:   key.vector,
+		response: *copyChatResponse(response),
+		expires:  now.Add(c.ttl),
+	})
+	if len(c.entries) > c.limit {
+		c.entries = slices.Delete(c.entries, 0, len(c.entries)-c.limit)
+	}
+	c.dirty = true
+}
+
+// semanticKey embeds the last user message of a chat request. It returns nil when the request
+// cannot be cached: the cache is disabled, the request is not a plain chat or embedding fails.
+func (s *LlamaService) semanticKey(ctx context.Context, endpoint, model string, request models.ChatRequest) *semanticKey {
+	if !s.cache.enabled() || endpoint != EndpointChat {
+		return nil
+	}
+
+	last := -1
+	for i, message := range request.Messages {
+		if message.Role == "user" {
+			last = i
+		}
+	}
+	if last < 0 || request.Messages[last].Content == "" {
+		return nil
+	}
+	prompt := request.Messages[last].Content
+
+	// The scope covers everything but the prompt itself, so history, options and format must match
+	request.Messages = slices.Clone(request.Messages)
+	request.Messages[last].Content = ""
+	scope, err := requestKey(endpoint, model, request)
+	if err != nil {
+		return nil
+	}
+
+	enterStage(ctx, StageSemanticCache)
+	embedding, err := s.Embedding(ctx, models.EmbeddingRequest{Model: s.cache.model, Input: prompt, Normalize: true})
+	if err != nil || len(embedding.Data) == 0 {
+		logger(ctx).Warn("Semantic cache skipped, embedding the prompt failed", "model", s.cache.model, "error", err)
+		return nil
+	}
+	return &semanticKey{scope: scope, vector: embedding.Data[0].Embedding}
+}
+
+// cachedGenerate answers from the semantic cache when a similar prompt was answered before,
+// and caches what generate returns otherwise
+func (s *LlamaService) cachedGenerate(ctx context.Context, endpoint, model string, request models.ChatRequest, generate func(context.Context) (*models.ChatResponse, error)) (*models.ChatResponse, error) {
+	key := s.semanticKey(ctx, endpoint, model, request)
+	if response, similarity := s.cache.lookup(key, s.Now()); response != nil {
+		response.ID = s.GenerateID()
+		response.Created = s.Now().Unix()
+		response.CacheHit = true
+		response.CacheSimilarity = similarity
+		return response, nil
+	}
+
+	response, err := generate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	s.cache.store(key, response, s.Now())
+	return response, nil
+}
+
+// copyChatResponse copies a response deeply enough for hooks to edit the copy
+func copyChatResponse(response *models.ChatResponse) *models.ChatResponse {
+	copied := *response
+	copied.Choices = slices.Clone(response.Choices)
+	return &copied
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
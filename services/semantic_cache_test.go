@@ -0,0 +1,120 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSemanticCache_LookupAndEviction(t *testing.T) {
+	cache := newSemanticCache("nomic-embed-text", 90, 2, time.Minute)
+	now := time.Now()
+
+	key := &semanticKey{scope: "a", vector: []float64{1, 0}}
+	cache.store(key, &models.ChatResponse{Choices: []models.Choice{{Message: models.Message{Content: "Paris"}}}}, now)
+
+	response, similarity := cache.lookup(&semanticKey{scope: "a", vector: []float64{0.95, 0.31}}, now)
+	assert.NotNil(t, response)
+	assert.InDelta(t, 0.95, similarity, 0.001)
+
+	response, _ = cache.lookup(&semanticKey{scope: "a", vector: []float64{0, 1}}, now)
+	assert.Nil(t, response, "dissimilar prompt")
+	response, _ = cache.lookup(&semanticKey{scope: "b", vector: []float64{1, 0}}, now)
+	assert.Nil(t, response, "other scope")
+	response, _ = cache.lookup(key, now.Add(2*time.Minute))
+	assert.Nil(t, response, "expired")
+
+	cache.store(&semanticKey{scope: "b", vector: []float64{1, 0}}, &models.ChatResponse{}, now)
+	cache.store(&semanticKey{scope: "c", vector: []float64{1, 0}}, &models.ChatResponse{}, now)
+	response, _ = cache.lookup(key, now)
+	assert.Nil(t, response, "oldest entry evicted")
+}
+
+func TestChat_SemanticCache(t *testing.T) {
+	// Rephrasings of the same question get nearly the same embedding
+	vectors := map[string][]float64{
+		"What is the capital of France?":  {1, 0, 0},
+		"what's the capital of france":    {0.98, 0.2, 0},
+		"How tall is the Eiffel Tower?":   {0, 0, 1},
+		"Tell me the capital of Germany.": {0.5, 0, 0.87},
+	}
+	chatCalls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Prompt string `json:"prompt"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		switch r.URL.Path {
+		case "/api/embeddings":
+			json.NewEncoder(w).Encode(map[string]interface{}{"embedding": vectors[body.Prompt]})
+		case "/api/chat":
+			chatCalls++
+			w.Write([]byte(`{"message":{"role":"assistant","content":"Paris"},"done":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.cache = newSemanticCache("nomic-embed-text", 95, 10, time.Hour)
+
+	ask := func(system, prompt string) *models.ChatResponse {
+		messages := []models.Message{{Role: "user", Content: prompt}}
+		if system != "" {
+			messages = append([]models.Message{{Role: "system", Content: system}}, messages...)
+		}
+		response, err := service.Chat(context.Background(), models.ChatRequest{Model: "llama2", Messages: messages})
+		assert.NoError(t, err)
+		return response
+	}
+
+	first := ask("", "What is the capital of France?")
+	assert.False(t, first.CacheHit)
+	assert.Equal(t, 1, chatCalls)
+
+	hit := ask("", "what's the capital of france")
+	assert.True(t, hit.CacheHit)
+	assert.Greater(t, hit.CacheSimilarity, 0.95)
+	assert.Equal(t, "Paris", hit.Choices[0].Message.Content)
+	assert.NotEqual(t, first.ID, hit.ID)
+	assert.Equal(t, 1, chatCalls)
+
+	assert.False(t, ask("", "How tall is the Eiffel Tower?").CacheHit)
+	assert.False(t, ask("", "Tell me the capital of Germany.").CacheHit)
+	assert.False(t, ask("Answer in French.", "What is the capital of France?").CacheHit, "different system prompt")
+	assert.Equal(t, 4, chatCalls)
+}
+
+func TestChat_SemanticCacheSkippedWhenEmbeddingFails(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Paris"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.cache = newSemanticCache("nomic-embed-text", 95, 10, time.Hour)
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama2",
+		Messages: []models.Message{{Role: "user", Content: "What is the capital of France?"}},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "Paris", response.Choices[0].Message.Content)
+	assert.Empty(t, service.cache.entries)
+}
@@ -0,0 +1,142 @@
+package services
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// shadowInFlight bounds the shadow requests running at once so mirrored traffic cannot pile up
+const shadowInFlight = 2
+
+// shadowMirror sends a sample of production chat requests to a candidate model in the background.
+// Its answers are never returned to clients; they are kept next to the production answers for comparison.
+type shadowMirror struct {
+	model   string
+	percent int
+	limit   int
+	slots   chan struct{}
+
+	mirrored atomic.Int64
+	dropped  atomic.Int64
+	failed   atomic.Int64
+
+	mu      sync.Mutex
+	results []models.ShadowResult // Newest last, at most limit entries
+}
+
+func newShadowMirror(model string, percent, limit int) *shadowMirror {
+	return &shadowMirror{
+		model:   model,
+		percent: min(max(percent, 0), 100),
+		limit:   max(limit, 1),
+		slots:   make(chan struct{}, shadowInFlight),
+	}
+}
+
+// sample reports whether a request for model should be mirrored
+func (m *shadowMirror) sample(model string) bool {
+	if m == nil || m.model == "" || m.percent == 0 || model == m.model {
+		return false
+	}
+	return rand.IntN(100) < m.percent
+}
+
+func (m *shadowMirror) store(result models.ShadowResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.results = append(m.results, result)
+	if len(m.results) > m.limit {
+		m.results = m.results[len(m.results)-m.limit:]
+	}
+}
+
+// mirrorChat sends a sampled chat request to the shadow model without waiting for it.
+// The production request is never slowed down: when the shadow slots are taken the sample is dropped.
+// The shadow request carries the request ID of ctx but is not cancelled with it, and waits for a
+// generation slot in the background class so it never delays client requests.
+func (s *LlamaService) mirrorChat(ctx context.Context, request models.ChatRequest, response *models.ChatResponse, latency time.Duration) {
+	shadow := s.shadow
+	if !shadow.sample(response.Model) {
+		return
+	}
+
+	select {
+	case shadow.slots <- struct{}{}:
+	default:
+		shadow.dropped.Add(1)
+		return
+	}
+	shadow.mirrored.Add(1)
+
+	result := models.ShadowResult{
+		ID:          s.GenerateID(),
+		CreatedAt:   s.Now(),
+		Prompt:      lastUserMessage(request.Messages),
+		Model:       response.Model,
+		LatencyMs:   latency.Milliseconds(),
+		ShadowModel: shadow.model,
+	}
+	if len(response.Choices) > 0 {
+		result.Output = response.Choices[0].Message.Content
+	}
+
+	id := requestID(ctx)
+	Go("shadow", func(ctx context.Context) {
+		defer func() { <-shadow.slots }()
+		ctx = WithPriority(WithRequestID(ctx, id), models.PriorityBackground)
+
+		start := time.Now()
+		shadowResponse, err := s.generateChat(ctx, shadow.model, request)
+		result.ShadowLatencyMs = time.Since(start).Milliseconds()
+		if err != nil {
+			logger(ctx).Warn("Shadow request failed", "model", shadow.model, "error", err)
+			shadow.failed.Add(1)
+			result.Error = err.Error()
+		} else {
+			if len(shadowResponse.Choices) > 0 {
+				result.ShadowOutput = shadowResponse.Choices[0].Message.Content
+			}
+			result.ShadowUsage = shadowResponse.Usage
+		}
+		shadow.store(result)
+	})
+}
+
+// ShadowReport returns the shadow mode settings, counters and the most recent results
+func (s *LlamaService) ShadowReport() models.ShadowReport {
+	shadow := s.shadow
+	if shadow == nil {
+		return models.ShadowReport{Results: []models.ShadowResult{}}
+	}
+
+	shadow.mu.Lock()
+	results := make([]models.ShadowResult, 0, len(shadow.results))
+	for i := len(shadow.results) - 1; i >= 0; i-- {
+		results = append(results, shadow.results[i])
+	}
+	shadow.mu.Unlock()
+
+	return models.ShadowReport{
+		Model:    shadow.model,
+		Percent:  shadow.percent,
+		Mirrored: shadow.mirrored.Load(),
+		Dropped:  shadow.dropped.Load(),
+		Failed:   shadow.failed.Load(),
+		Results:  results,
+	}
+}
+
+func lastUserMessage(messages []models.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role == "user" {
+			return messages[i].Content
+		}
+	}
+	return ""
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChat_MirrorsToShadowModel(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprintf(w, `{"model":%q,"message":{"role":"assistant","content":"answer from %s"},"done":true}`, body.Model, body.Model)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.shadow = newShadowMirror("phi3:mini", 100, 10)
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "answer from llama3.1:8b", response.Choices[0].Message.Content)
+
+	assert.Eventually(t, func() bool {
+		return len(service.ShadowReport().Results) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	report := service.ShadowReport()
+	assert.Equal(t, int64(1), report.Mirrored)
+	assert.Equal(t, "Hello", report.Results[0].Prompt)
+	assert.Equal(t, "answer from llama3.1:8b", report.Results[0].Output)
+	assert.Equal(t, "answer from phi3:mini", report.Results[0].ShadowOutput)
+	assert.Empty(t, report.Results[0].Error)
+}
+
+func TestShadowMirror(t *testing.T) {
+	var disabled *shadowMirror
+	assert.False(t, disabled.sample("llama3.1:8b"))
+	assert.False(t, newShadowMirror("phi3:mini", 0, 10).sample("llama3.1:8b"))
+	assert.False(t, newShadowMirror("phi3:mini", 100, 10).sample("phi3:mini"))
+	assert.True(t, newShadowMirror("phi3:mini", 100, 10).sample("llama3.1:8b"))
+
+	shadow := newShadowMirror("phi3:mini", 100, 2)
+	for i := 0; i < 3; i++ {
+		shadow.store(models.ShadowResult{ID: fmt.Sprint(i)})
+	}
+	assert.Equal(t, []models.ShadowResult{{ID: "1"}, {ID: "2"}}, shadow.results)
+}
+
+func TestChat_ShadowWaitsAsBackground(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.shadow = newShadowMirror("phi3:mini", 100, 10)
+	service.queue = newRequestQueue(0, 1, 4, time.Second)
+
+	// Keep the shadow model busy so the mirrored request has to wait
+	release, err := service.queue.acquire(context.Background(), "phi3:mini")
+	assert.NoError(t, err)
+
+	_, err = service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.1:8b",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		service.queue.mu.Lock()
+		defer service.queue.mu.Unlock()
+		return len(service.queue.waiters) == 1
+	}, time.Second, 5*time.Millisecond)
+	service.queue.mu.Lock()
+	assert.Equal(t, priorityRank(models.PriorityBackground), service.queue.waiters[0].rank)
+	service.queue.mu.Unlock()
+
+	release()
+	assert.Eventually(t, func() bool {
+		return len(service.ShadowReport().Results) == 1
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,143 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"agent-ollama-gin/models"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// schemaErrorPrinter renders schema validation errors, which are fed back to the model in English
+var schemaErrorPrinter = message.NewPrinter(language.English)
+
+// outputFormat is the structured output a chat request asked for: any JSON value when schema is
+// nil ("format": "json"), otherwise a value matching the JSON schema
+type outputFormat struct {
+	schema *jsonschema.Schema
+}
+
+// parseFormat compiles the format of a chat request. It returns nil when no format was requested.
+func parseFormat(format json.RawMessage) (*outputFormat, error) {
+	format = bytes.TrimSpace(format)
+	if len(format) == 0 || string(format) == "null" || string(format) == `""` {
+		return nil, nil
+	}
+	if string(format) == `"json"` {
+		return &outputFormat{}, nil
+	}
+
+	document, err := jsonschema.UnmarshalJSON(bytes.NewReader(format))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	if _, ok := document.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf(`%w: format must be "json" or a JSON schema object`, ErrInvalidFormat)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource("format.json", document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	schema, err := compiler.Compile("format.json")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	return &outputFormat{schema: schema}, nil
+}
+
+// validate returns what is wrong with output, or nothing when it matches the format
+func (f *outputFormat) validate(output string) []string {
+	value, err := jsonschema.UnmarshalJSON(strings.NewReader(output))
+	if err != nil {
+		return []string{fmt.Sprintf("output is not valid JSON: %v", err)}
+	}
+	if f.schema == nil {
+		return nil
+	}
+
+	err = f.schema.Validate(value)
+	validationErr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		if err != nil {
+			return []string{err.Error()}
+		}
+		return nil
+	}
+
+	var problems []string
+	var collect func(*jsonschema.ValidationError)
+	collect = func(e *jsonschema.ValidationError) {
+		if len(e.Causes) == 0 {
+			problems = append(problems, fmt.Sprintf("at /%s: %s", strings.Join(e.InstanceLocation, "/"), e.ErrorKind.LocalizedString(schemaErrorPrinter)))
+			return
+		}
+		for _, cause := range e.Causes {
+			collect(cause)
+		}
+	}
+	collect(validationErr)
+	return problems
+}
+
+// generateStructured sends a chat request to model and, when the request asked for structured
+// output, validates the answer. An invalid answer is sent back to the model together with the
+// validation errors, up to the configured number of repair attempts.
+func (s *LlamaService) generateStructured(ctx context.Context, model string, request models.ChatRequest, format *outputFormat) (*models.ChatResponse, error) {
+	response, err := s.generateChat(ctx, model, request)
+	if err != nil || format == nil {
+		return response, err
+	}
+
+	for repairs := 0; ; repairs++ {
+		output := ""
+		if len(response.Choices) > 0 {
+			output = response.Choices[0].Message.Content
+		}
+
+		problems := format.validate(output)
+		if len(problems) == 0 {
+			response.RepairAttempts = repairs
+			return response, nil
+		}
+		if repairs >= s.config.SchemaRepairAttempts {
+			return nil, &SchemaValidationError{Model: model, Errors: problems, Output: output, Attempts: repairs + 1}
+		}
+
+		request.Messages = append(slices.Clip(request.Messages),
+			models.Message{Role: "assistant", Content: output},
+			models.Message{Role: "user", Content: repairPrompt(problems)},
+		)
+		repaired, err := s.generateChat(ctx, model, request)
+		if err != nil {
+			return nil, err
+		}
+		repaired.Usage = addUsage(response.Usage, repaired.Usage)
+		response = repaired
+	}
+}
+
+func repairPrompt(problems []string) string {
+	var b strings.Builder
+	b.WriteString("Your previous answer does not match the required JSON format:\n")
+	for _, problem := range problems {
+		fmt.Fprintf(&b, "- %s\n", problem)
+	}
+	b.WriteString("Reply again with only the corrected JSON, without explanations or code fences.")
+	return b.String()
+}
+
+func addUsage(a, b models.Usage) models.Usage {
+	return models.Usage{
+		PromptTokens:     a.PromptTokens + b.PromptTokens,
+		CompletionTokens: a.CompletionTokens + b.CompletionTokens,
+		TotalTokens:      a.TotalTokens + b.TotalTokens,
+	}
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const personSchema = `{
+	"type": "object",
+	"properties": {"name": {"type": "string"}, "age": {"type": "integer"}},
+	"required": ["name", "age"]
+}`
+
+func TestParseFormat(t *testing.T) {
+	format, err := parseFormat(nil)
+	assert.NoError(t, err)
+	assert.Nil(t, format)
+
+	format, err = parseFormat(json.RawMessage(`"json"`))
+	assert.NoError(t, err)
+	assert.Empty(t, format.validate(`{"any": "value"}`))
+	assert.Len(t, format.validate(`not json`), 1)
+
+	format, err = parseFormat(json.RawMessage(personSchema))
+	assert.NoError(t, err)
+	assert.Empty(t, format.validate(`{"name": "Ada", "age": 36}`))
+	assert.Equal(t, []string{"at /age: got string, want integer"}, format.validate(`{"name": "Ada", "age": "36"}`))
+
+	_, err = parseFormat(json.RawMessage(`"yaml"`))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+	_, err = parseFormat(json.RawMessage(`{"type": "nonsense"}`))
+	assert.ErrorIs(t, err, ErrInvalidFormat)
+}
+
+func TestChat_RepairsInvalidStructuredOutput(t *testing.T) {
+	answers := []string{`{"name": "Ada"}`, `{"name": "Ada", "age": 36}`}
+	var requests []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		answer, _ := json.Marshal(answers[len(requests)])
+		requests = append(requests, body)
+		fmt.Fprintf(w, `{"message":{"role":"assistant","content":%s},"done":true,"prompt_eval_count":10,"eval_count":5}`, answer)
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	response, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.2",
+		Messages: []models.Message{{Role: "user", Content: "Describe Ada Lovelace"}},
+		Format:   json.RawMessage(personSchema),
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name": "Ada", "age": 36}`, response.Choices[0].Message.Content)
+	assert.Equal(t, 1, response.RepairAttempts)
+	assert.Equal(t, 30, response.Usage.TotalTokens)
+	assert.Len(t, requests, 2)
+	assert.NotNil(t, requests[0]["format"])
+
+	repair := requests[1]["messages"].([]interface{})
+	assert.Len(t, repair, 3)
+	assert.Contains(t, repair[2].(map[string]interface{})["content"], "missing property 'age'")
+}
+
+func TestChat_StructuredOutputRepairsExhausted(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		calls++
+		w.Write([]byte(`{"message":{"role":"assistant","content":"I cannot do that"},"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.SchemaRepairAttempts = 1
+
+	_, err := service.Chat(context.Background(), models.ChatRequest{
+		Model:    "llama3.2",
+		Messages: []models.Message{{Role: "user", Content: "Describe Ada Lovelace"}},
+		Format:   json.RawMessage(`"json"`),
+	})
+
+	var validationErr *SchemaValidationError
+	assert.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, 2, validationErr.Attempts)
+	assert.Equal(t, "I cannot do that", validationErr.Output)
+	assert.Equal(t, 2, calls)
+}
@@ -0,0 +1,238 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// Swap step statuses
+const (
+	swapStepOK      = "ok"
+	swapStepFailed  = "failed"
+	swapStepSkipped = "skipped"
+)
+
+// SwapModel moves an alias, or the default model when no alias is given, to a new model without
+// interrupting traffic. The new model is pulled and warmed while the old one keeps serving, the switch
+// itself is a single update under the alias lock, and the previous model is unloaded afterwards.
+// If the new model cannot be pulled or warmed within the swap timeout, the old model stays in place and
+// a model that failed to warm is unloaded again.
+func (s *LlamaService) SwapModel(ctx context.Context, request models.SwapModelRequest) (*models.SwapModelResponse, error) {
+	if request.Alias != "" && request.Alias == request.Model {
+		return nil, fmt.Errorf("alias %s cannot point to itself", request.Alias)
+	}
+	if s.IsCloudModel(request.Model) && !s.isSignedIn.Load() {
+		return nil, fmt.Errorf("must be signed in to use cloud models")
+	}
+	if !s.swapMu.TryLock() {
+		return nil, ErrSwapInProgress
+	}
+	defer s.swapMu.Unlock()
+
+	// The swap outlives the admin request: a client disconnect must not leave it half done.
+	// Pulling and warming the new model must finish by the swap deadline.
+	ctx = context.WithoutCancel(ctx)
+	timeout := time.Duration(s.config.SwapTimeout) * time.Second
+	prepareCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	response := &models.SwapModelResponse{
+		Alias:   request.Alias,
+		Current: request.Model,
+	}
+	if request.Alias != "" {
+		response.Previous = s.resolveAlias(request.Alias)
+	} else {
+		response.Previous = s.getModel("")
+	}
+
+	if response.Previous == request.Model {
+		response.Success = true
+		return response, nil
+	}
+
+	// Cloud models run remotely, so there is nothing to download or load
+	if s.IsCloudModel(request.Model) {
+		addSwapStep(response, "pull", swapStepSkipped, nil)
+	} else if err := s.pullModel(prepareCtx, request.Model); err != nil {
+		// Nothing was loaded yet, the previous model keeps serving untouched
+		addSwapStep(response, "pull", swapStepFailed, swapDeadlineError(prepareCtx, timeout, err))
+		return response, nil
+	} else {
+		addSwapStep(response, "pull", swapStepOK, nil)
+	}
+
+	if err := s.preloadModel(prepareCtx, request.Model); err != nil {
+		addSwapStep(response, "warm", swapStepFailed, swapDeadlineError(prepareCtx, timeout, err))
+		return s.rollbackSwap(ctx, response), nil
+	}
+	addSwapStep(response, "warm", swapStepOK, nil)
+
+	s.aliasMu.Lock()
+	if request.Alias != "" {
+		if s.aliases == nil {
+			s.aliases = map[string]string{}
+		}
+		s.aliases[request.Alias] = request.Model
+	} else {
+		s.config.DefaultModel = request.Model
+	}
+	s.aliasMu.Unlock()
+	addSwapStep(response, "switch", swapStepOK, nil)
+	logger(ctx).Info("Swapped model", "target", swapTarget(request.Alias), "previous", response.Previous, "current", request.Model)
+
+	// The previous model may still be served under another name; leave it loaded in that case
+	if s.IsCloudModel(response.Previous) || s.modelInUse(response.Previous) {
+		addSwapStep(response, "unload", swapStepSkipped, nil)
+	} else if err := s.unloadModel(ctx, response.Previous); err != nil {
+		// The switch already succeeded, Ollama evicts the old model once its keep_alive expires
+		logger(ctx).Warn("Failed to unload model after swap", "model", response.Previous, "error", err)
+		addSwapStep(response, "unload", swapStepFailed, err)
+	} else {
+		addSwapStep(response, "unload", swapStepOK, nil)
+	}
+
+	response.Success = true
+	return response, nil
+}
+
+// rollbackSwap unloads a model that failed to come up, leaving the previous model serving
+func (s *LlamaService) rollbackSwap(ctx context.Context, response *models.SwapModelResponse) *models.SwapModelResponse {
+	response.RolledBack = true
+	if s.IsCloudModel(response.Current) || s.modelInUse(response.Current) {
+		addSwapStep(response, "rollback", swapStepSkipped, nil)
+		return response
+	}
+	if err := s.unloadModel(ctx, response.Current); err != nil {
+		logger(ctx).Warn("Failed to unload model during rollback", "model", response.Current, "error", err)
+		addSwapStep(response, "rollback", swapStepFailed, err)
+		return response
+	}
+	addSwapStep(response, "rollback", swapStepOK, nil)
+	return response
+}
+
+// modelInUse reports whether the default model or any alias points at model
+func (s *LlamaService) modelInUse(model string) bool {
+	s.aliasMu.RLock()
+	defer s.aliasMu.RUnlock()
+
+	defaultModel := s.config.DefaultModel
+	if target, ok := s.aliases[defaultModel]; ok {
+		defaultModel = target
+	}
+	if defaultModel == model {
+		return true
+	}
+	for _, target := range s.aliases {
+		if target == model {
+			return true
+		}
+	}
+	return false
+}
+
+// swapDeadlineError names the swap timeout as the cause of err once it has run out
+func swapDeadlineError(ctx context.Context, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("swap did not complete within %s: %w", timeout, err)
+	}
+	return err
+}
+
+// pullModel downloads a model and waits for the pull to finish. The pull is streamed, so Ollama
+// answers right away and reports its progress until the download is complete.
+func (s *LlamaService) pullModel(ctx context.Context, model string) error {
+	pullRequest := map[string]interface{}{
+		"model":  model,
+		"stream": true,
+	}
+
+	resp, err := s.makeLoadRequest(ctx, "POST", "/api/pull", pullRequest, s.config.BaseURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return readProgress(resp.Body, func(chunk map[string]interface{}) bool {
+		return chunk["status"] == "success"
+	})
+}
+
+// readProgress reads the NDJSON progress of a streamed pull or load until a line is complete.
+// An error line or a stream ending before that fails it.
+func readProgress(body io.Reader, complete func(chunk map[string]interface{}) bool) error {
+	decoder := json.NewDecoder(body)
+	for {
+		var chunk map[string]interface{}
+		if err := decoder.Decode(&chunk); err != nil {
+			if err == io.EOF {
+				return errors.New("ollama ended the stream before completing")
+			}
+			return fmt.Errorf("failed to read progress: %w", err)
+		}
+		if message, ok := chunk["error"].(string); ok {
+			return errors.New(message)
+		}
+		if complete(chunk) {
+			return nil
+		}
+	}
+}
+
+// unloadModel evicts a model from memory by issuing an empty generate call with keep_alive 0
+func (s *LlamaService) unloadModel(ctx context.Context, model string) error {
+	unloadRequest := map[string]interface{}{
+		"model":      model,
+		"keep_alive": 0,
+		"stream":     false,
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(s.config.Timeout)*time.Second)
+	defer cancel()
+
+	resp, err := s.makeRequest(ctx, "POST", "/api/generate", unloadRequest, s.config.BaseURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ollama API returned status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
+
+// DefaultModel returns the model requests without one are sent to, as set by the last model swap
+func (s *LlamaService) DefaultModel() string {
+	s.aliasMu.RLock()
+	defer s.aliasMu.RUnlock()
+	return s.config.DefaultModel
+}
+
+func addSwapStep(response *models.SwapModelResponse, name, status string, err error) {
+	step := models.SwapStep{Name: name, Status: status}
+	if err != nil {
+		step.Error = err.Error()
+	}
+	response.Steps = append(response.Steps, step)
+}
+
+func swapTarget(alias string) string {
+	if alias == "" {
+		return "default model"
+	}
+	return "alias " + alias
+}
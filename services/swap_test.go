@@ -0,0 +1,179 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSwapServer records every call as "path model keep_alive", streams pulls, which fail for the
+// "missing" model, and fails warm-up for failModel
+func newSwapServer(failModel string) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var calls []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		call := r.URL.Path + " " + body["model"].(string)
+		if keepAlive, ok := body["keep_alive"]; ok {
+			call += " " + jsonString(keepAlive)
+		}
+		mu.Lock()
+		calls = append(calls, call)
+		mu.Unlock()
+
+		if r.URL.Path == "/api/generate" && body["model"] == failModel && body["keep_alive"] != float64(0) {
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"error":"out of memory"}`))
+			return
+		}
+		switch {
+		case r.URL.Path == "/api/pull" && body["model"] == "missing":
+			w.Write([]byte("{\"status\":\"pulling manifest\"}\n{\"error\":\"pull model manifest: file does not exist\"}\n"))
+		case r.URL.Path == "/api/pull":
+			w.Write([]byte("{\"status\":\"pulling manifest\"}\n{\"status\":\"success\"}\n"))
+		default:
+			w.Write([]byte(`{"done":true}`))
+		}
+	}))
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), calls...)
+	}
+}
+
+func jsonString(value interface{}) string {
+	encoded, _ := json.Marshal(value)
+	return string(encoded)
+}
+
+func TestSwapModel_Alias(t *testing.T) {
+	server, calls := newSwapServer("")
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.PreloadKeepAlive = "1h"
+	service.config.RetryMaxAttempts = 1
+	service.SetAlias("chat", "llama3.2")
+
+	response, err := service.SwapModel(context.Background(), models.SwapModelRequest{Alias: "chat", Model: "llama3.3"})
+
+	assert.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.False(t, response.RolledBack)
+	assert.Equal(t, "llama3.2", response.Previous)
+	assert.Equal(t, "llama3.3", service.resolveAlias("chat"))
+	assert.Equal(t, []string{
+		"/api/pull llama3.3",
+		`/api/generate llama3.3 "1h"`,
+		"/api/generate llama3.2 0",
+	}, calls())
+}
+
+func TestSwapModel_DefaultModel(t *testing.T) {
+	server, calls := newSwapServer("")
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.DefaultModel = "llama3.2"
+	service.config.RetryMaxAttempts = 1
+	// The old model is still served under an alias, so it must stay loaded
+	service.SetAlias("legacy", "llama3.2")
+
+	response, err := service.SwapModel(context.Background(), models.SwapModelRequest{Model: "llama3.3"})
+
+	assert.NoError(t, err)
+	assert.True(t, response.Success)
+	assert.Equal(t, "llama3.3", service.getModel(""))
+	assert.Len(t, calls(), 2)
+	assert.Equal(t, models.SwapStep{Name: "unload", Status: "skipped"}, response.Steps[len(response.Steps)-1])
+}
+
+func TestSwapModel_WarmFailureRollsBack(t *testing.T) {
+	server, calls := newSwapServer("llama3.3")
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.PreloadKeepAlive = "1h"
+	service.config.RetryMaxAttempts = 1
+	service.SetAlias("chat", "llama3.2")
+
+	response, err := service.SwapModel(context.Background(), models.SwapModelRequest{Alias: "chat", Model: "llama3.3"})
+
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.True(t, response.RolledBack)
+	assert.Equal(t, "llama3.2", service.resolveAlias("chat"))
+	assert.Equal(t, "/api/generate llama3.3 0", calls()[len(calls())-1])
+	assert.Contains(t, response.Steps[1].Error, "out of memory")
+}
+
+func TestSwapModel_PullFailure(t *testing.T) {
+	server, calls := newSwapServer("")
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.SetAlias("chat", "llama3.2")
+
+	response, err := service.SwapModel(context.Background(), models.SwapModelRequest{Alias: "chat", Model: "missing"})
+
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, "llama3.2", service.resolveAlias("chat"))
+	assert.Equal(t, []models.SwapStep{{Name: "pull", Status: "failed", Error: "pull model manifest: file does not exist"}}, response.Steps)
+	assert.Len(t, calls(), 1)
+}
+
+func TestSwapModel_Deadline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// A pull that keeps reporting progress but never completes
+		for {
+			w.Write([]byte(`{"status":"pulling","completed":1}` + "\n"))
+			w.(http.Flusher).Flush()
+			select {
+			case <-r.Context().Done():
+				return
+			case <-time.After(100 * time.Millisecond):
+			}
+		}
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.RetryMaxAttempts = 1
+	service.config.SwapTimeout = 1
+	service.SetAlias("chat", "llama3.2")
+
+	response, err := service.SwapModel(context.Background(), models.SwapModelRequest{Alias: "chat", Model: "llama3.3"})
+
+	assert.NoError(t, err)
+	assert.False(t, response.Success)
+	assert.Equal(t, "llama3.2", service.resolveAlias("chat"))
+	assert.Contains(t, response.Steps[0].Error, "swap did not complete within 1s")
+}
+
+func TestSwapModel_InProgress(t *testing.T) {
+	service := NewLlamaService()
+	service.swapMu.Lock()
+	defer service.swapMu.Unlock()
+
+	_, err := service.SwapModel(context.Background(), models.SwapModelRequest{Model: "llama3.3"})
+
+	assert.ErrorIs(t, err, ErrSwapInProgress)
+}
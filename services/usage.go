@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+)
+
+// cloudQuotaNotice explains why account-level quota is missing from usage reports
+const cloudQuotaNotice = "Ollama Cloud does not expose account usage or quota through its API; see https://ollama.com/settings for plan limits"
+
+// usageTracker accumulates token usage per backend since the service started
+type usageTracker struct {
+	mu       sync.Mutex
+	since    time.Time
+	backends map[string]*models.BackendUsage
+}
+
+func newUsageTracker() *usageTracker {
+	return &usageTracker{
+		since: time.Now(),
+		backends: map[string]*models.BackendUsage{
+			BackendLocal: {},
+			BackendCloud: {},
+		},
+	}
+}
+
+func (t *usageTracker) record(backend string, usage models.Usage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	totals, ok := t.backends[backend]
+	if !ok {
+		return
+	}
+	totals.Requests++
+	totals.PromptTokens += int64(usage.PromptTokens)
+	totals.CompletionTokens += int64(usage.CompletionTokens)
+	totals.TotalTokens += int64(usage.TotalTokens)
+}
+
+// tokenRecorderKey is the context key of the callback set by WithTokenRecorder
+type tokenRecorderKey struct{}
+
+// WithTokenRecorder returns a context whose generations report the tokens they consume to record.
+// It lets callers such as quota middleware attribute usage to the client that made the request.
+func WithTokenRecorder(ctx context.Context, record func(tokens int)) context.Context {
+	return context.WithValue(ctx, tokenRecorderKey{}, record)
+}
+
+// callerKey is the context key of the caller set by WithCaller
+type callerKey struct{}
+
+// WithCaller returns a context whose generations are attributed to caller in usage records
+func WithCaller(ctx context.Context, caller string) context.Context {
+	return context.WithValue(ctx, callerKey{}, caller)
+}
+
+// recordUsage adds usage to the server totals for backend and charges it to the caller of ctx.
+// Coalesced generations are charged to each of their callers by coalesce instead.
+func (s *LlamaService) recordUsage(ctx context.Context, backend, model string, usage models.Usage, latency time.Duration) {
+	s.usage.record(backend, usage)
+	if inFlight(ctx) {
+		return
+	}
+	s.chargeUsage(ctx, backend, model, usage, latency)
+}
+
+// chargeUsage adds usage to the request's token recorder and stores a usage record of the
+// generation with its model, latency and caller
+func (s *LlamaService) chargeUsage(ctx context.Context, backend, model string, usage models.Usage, latency time.Duration) {
+	if record, ok := ctx.Value(tokenRecorderKey{}).(func(int)); ok {
+		record(usage.TotalTokens)
+	}
+
+	caller, _ := ctx.Value(callerKey{}).(string)
+	record := models.UsageRecord{
+		Time:             s.Now().Unix(),
+		Caller:           caller,
+		Model:            model,
+		Backend:          backend,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		TotalTokens:      usage.TotalTokens,
+		LatencyMs:        latency.Milliseconds(),
+	}
+	if err := s.usageStore.Append(context.WithoutCancel(ctx), record); err != nil {
+		logger(ctx).Error("Failed to store usage record", "model", model, "error", err)
+	}
+}
+
+// SetUsageStore replaces the store usage records are kept in
+func (s *LlamaService) SetUsageStore(store UsageStore) {
+	s.usageStore = store
+}
+
+// UsageRecords returns the usage records matching query, newest first and at most query.Limit of
+// them, with totals over every match
+func (s *LlamaService) UsageRecords(ctx context.Context, query models.UsageQuery) (*models.UsageRecordList, error) {
+	records, err := s.usageStore.Records(ctx, query.Day)
+	if err != nil {
+		return nil, err
+	}
+
+	var total usageSum
+	byModel, byCaller := map[string]*usageSum{}, map[string]*usageSum{}
+	data := []models.UsageRecord{}
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if (query.Model != "" && record.Model != query.Model) || (query.Caller != "" && record.Caller != query.Caller) {
+			continue
+		}
+		if query.Limit == 0 || len(data) < query.Limit {
+			data = append(data, record)
+		}
+
+		total.add(record)
+		if byModel[record.Model] == nil {
+			byModel[record.Model] = &usageSum{}
+		}
+		byModel[record.Model].add(record)
+		if byCaller[record.Caller] == nil {
+			byCaller[record.Caller] = &usageSum{}
+		}
+		byCaller[record.Caller].add(record)
+	}
+
+	list := &models.UsageRecordList{
+		Object:   "list",
+		Totals:   total.totals(),
+		ByModel:  map[string]models.UsageTotals{},
+		ByCaller: map[string]models.UsageTotals{},
+		Data:     data,
+	}
+	for model, sum := range byModel {
+		list.ByModel[model] = sum.totals()
+	}
+	for caller, sum := range byCaller {
+		list.ByCaller[caller] = sum.totals()
+	}
+	return list, nil
+}
+
+// usageSum accumulates usage records into totals
+type usageSum struct {
+	requests, promptTokens, completionTokens, totalTokens, latencyMs int64
+}
+
+func (u *usageSum) add(record models.UsageRecord) {
+	u.requests++
+	u.promptTokens += int64(record.PromptTokens)
+	u.completionTokens += int64(record.CompletionTokens)
+	u.totalTokens += int64(record.TotalTokens)
+	u.latencyMs += record.LatencyMs
+}
+
+func (u *usageSum) totals() models.UsageTotals {
+	totals := models.UsageTotals{
+		Requests:         u.requests,
+		PromptTokens:     u.promptTokens,
+		CompletionTokens: u.completionTokens,
+		TotalTokens:      u.totalTokens,
+	}
+	if u.requests > 0 {
+		totals.AverageLatencyMs = u.latencyMs / u.requests
+	}
+	return totals
+}
+
+// Usage reports the tokens consumed through this server per backend since it started
+func (s *LlamaService) Usage() *models.UsageReport {
+	s.usage.mu.Lock()
+	defer s.usage.mu.Unlock()
+
+	return &models.UsageReport{
+		Object:    "usage",
+		Since:     s.usage.since.Unix(),
+		Local:     *s.usage.backends[BackendLocal],
+		Cloud:     *s.usage.backends[BackendCloud],
+		SignedIn:  s.isSignedIn.Load(),
+		QuotaNote: cloudQuotaNotice,
+	}
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// UsageStore persists usage records by UTC day
+type UsageStore interface {
+	// Append records one generation
+	Append(ctx context.Context, record models.UsageRecord) error
+	// Records returns the records of day (YYYY-MM-DD), or of every retained day when day is empty,
+	// oldest first
+	Records(ctx context.Context, day string) ([]models.UsageRecord, error)
+}
+
+// usageDay is the UTC day a record falls on
+func usageDay(t time.Time) string {
+	return t.UTC().Format(time.DateOnly)
+}
+
+// retainedDays lists the days kept by a store with the given retention, oldest first
+func retainedDays(now time.Time, retention int) []string {
+	days := make([]string, 0, retention)
+	for i := retention - 1; i >= 0; i-- {
+		days = append(days, usageDay(now.AddDate(0, 0, -i)))
+	}
+	return days
+}
+
+// MemoryUsageStore keeps usage records in process memory. Records are lost on restart and are not
+// shared between replicas.
+type MemoryUsageStore struct {
+	mu        sync.Mutex
+	retention int // Days
+	days      map[string][]models.UsageRecord
+	now       func() time.Time
+}
+
+// NewMemoryUsageStore creates a store that forgets records retention days after the day they fall on
+func NewMemoryUsageStore(retention int) *MemoryUsageStore {
+	return &MemoryUsageStore{
+		retention: retention,
+		days:      map[string][]models.UsageRecord{},
+		now:       time.Now,
+	}
+}
+
+func (s *MemoryUsageStore) Append(ctx context.Context, record models.UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Drop days past the retention so old records do not accumulate
+	retained := map[string]bool{}
+	for _, day := range retainedDays(s.now(), s.retention) {
+		retained[day] = true
+	}
+	for day := range s.days {
+		if !retained[day] {
+			delete(s.days, day)
+		}
+	}
+
+	day := usageDay(time.Unix(record.Time, 0))
+	if retained[day] {
+		s.days[day] = append(s.days[day], record)
+	}
+	return nil
+}
+
+func (s *MemoryUsageStore) Records(ctx context.Context, day string) ([]models.UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	days := []string{day}
+	if day == "" {
+		days = retainedDays(s.now(), s.retention)
+	}
+
+	var records []models.UsageRecord
+	for _, day := range days {
+		records = append(records, s.days[day]...)
+	}
+	return records, nil
+}
+
+// RedisUsageStore keeps each day's usage records in a Redis list of JSON documents, so every replica
+// connected to the same Redis reports the same usage and it survives restarts.
+type RedisUsageStore struct {
+	client    redis.Cmdable
+	retention int // Days
+	prefix    string
+	now       func() time.Time
+}
+
+// NewRedisUsageStore creates a store whose daily lists expire retention days after the day they cover
+func NewRedisUsageStore(client redis.Cmdable, retention int) *RedisUsageStore {
+	return &RedisUsageStore{
+		client:    client,
+		retention: retention,
+		prefix:    "usage:",
+		now:       time.Now,
+	}
+}
+
+func (s *RedisUsageStore) Append(ctx context.Context, record models.UsageRecord) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to encode usage record: %w", err)
+	}
+
+	finished := time.Unix(record.Time, 0).UTC()
+	key := s.prefix + usageDay(finished)
+	dayEnd := time.Date(finished.Year(), finished.Month(), finished.Day()+1, 0, 0, 0, 0, time.UTC)
+
+	if err := s.client.RPush(ctx, key, data).Err(); err != nil {
+		return fmt.Errorf("failed to save usage record: %w", err)
+	}
+	if err := s.client.ExpireAt(ctx, key, dayEnd.AddDate(0, 0, s.retention-1)).Err(); err != nil {
+		return fmt.Errorf("failed to set usage record expiry: %w", err)
+	}
+	return nil
+}
+
+func (s *RedisUsageStore) Records(ctx context.Context, day string) ([]models.UsageRecord, error) {
+	days := []string{day}
+	if day == "" {
+		days = retainedDays(s.now(), s.retention)
+	}
+
+	var records []models.UsageRecord
+	for _, day := range days {
+		values, err := s.client.LRange(ctx, s.prefix+day, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load usage records: %w", err)
+		}
+		for _, value := range values {
+			var record models.UsageRecord
+			if err := json.Unmarshal([]byte(value), &record); err != nil {
+				return nil, fmt.Errorf("failed to decode usage record: %w", err)
+			}
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"agent-ollama-gin/models"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeRedisLists implements the list commands used by RedisUsageStore
+type fakeRedisLists struct {
+	redis.Cmdable
+	lists   map[string][]string
+	expires map[string]time.Time
+	err     error
+}
+
+func newFakeRedisLists() *fakeRedisLists {
+	return &fakeRedisLists{lists: map[string][]string{}, expires: map[string]time.Time{}}
+}
+
+func (f *fakeRedisLists) RPush(ctx context.Context, key string, values ...interface{}) *redis.IntCmd {
+	for _, value := range values {
+		f.lists[key] = append(f.lists[key], string(value.([]byte)))
+	}
+	return redis.NewIntResult(int64(len(f.lists[key])), nil)
+}
+
+func (f *fakeRedisLists) ExpireAt(ctx context.Context, key string, tm time.Time) *redis.BoolCmd {
+	f.expires[key] = tm
+	return redis.NewBoolResult(true, nil)
+}
+
+func (f *fakeRedisLists) LRange(ctx context.Context, key string, start, stop int64) *redis.StringSliceCmd {
+	return redis.NewStringSliceResult(f.lists[key], f.err)
+}
+
+var usageNow = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func testUsageStore(t *testing.T, store UsageStore) {
+	ctx := context.Background()
+	yesterday := models.UsageRecord{Time: usageNow.AddDate(0, 0, -1).Unix(), Caller: "user:alice", Model: "llama3.2", TotalTokens: 10}
+	today := models.UsageRecord{Time: usageNow.Unix(), Caller: "ip:1.2.3.4", Model: "mistral", TotalTokens: 20}
+
+	assert.NoError(t, store.Append(ctx, yesterday))
+	assert.NoError(t, store.Append(ctx, today))
+
+	records, err := store.Records(ctx, "2025-03-10")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UsageRecord{today}, records)
+
+	records, err = store.Records(ctx, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []models.UsageRecord{yesterday, today}, records)
+}
+
+func TestMemoryUsageStore(t *testing.T) {
+	store := NewMemoryUsageStore(7)
+	store.now = func() time.Time { return usageNow }
+	testUsageStore(t, store)
+}
+
+func TestMemoryUsageStore_Retention(t *testing.T) {
+	store := NewMemoryUsageStore(2)
+	store.now = func() time.Time { return usageNow }
+	ctx := context.Background()
+
+	assert.NoError(t, store.Append(ctx, models.UsageRecord{Time: usageNow.AddDate(0, 0, -1).Unix()}))
+	assert.NoError(t, store.Append(ctx, models.UsageRecord{Time: usageNow.AddDate(0, 0, -2).Unix()}))
+
+	// A day later, the oldest retained day is dropped
+	store.now = func() time.Time { return usageNow.AddDate(0, 0, 1) }
+	assert.NoError(t, store.Append(ctx, models.UsageRecord{Time: usageNow.AddDate(0, 0, 1).Unix()}))
+
+	records, err := store.Records(ctx, "")
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Len(t, store.days, 1)
+}
+
+func TestRedisUsageStore(t *testing.T) {
+	client := newFakeRedisLists()
+	store := NewRedisUsageStore(client, 7)
+	store.now = func() time.Time { return usageNow }
+	testUsageStore(t, store)
+
+	// Each day's list expires once the retention has passed
+	assert.Equal(t, time.Date(2025, 3, 17, 0, 0, 0, 0, time.UTC), client.expires["usage:2025-03-10"])
+
+	client.err = errors.New("connection refused")
+	_, err := store.Records(context.Background(), "2025-03-10")
+	assert.Error(t, err)
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"agent-ollama-gin/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUsage_TracksPerBackend(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"response":          "Hi",
+			"message":           map[string]interface{}{"role": "assistant", "content": "Hi"},
+			"prompt_eval_count": 10,
+			"eval_count":        5,
+			"done":              true,
+		})
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.CloudAPIURL = server.URL
+	service.config.CloudEnabled = true
+	service.isSignedIn.Store(true)
+
+	_, err := service.Completion(context.Background(), models.CompletionRequest{Prompt: "Hello"})
+	assert.NoError(t, err)
+	_, err = service.Chat(context.Background(), models.ChatRequest{
+		Model:    "gpt-oss:20b-cloud",
+		Messages: []models.Message{{Role: "user", Content: "Hello"}},
+	})
+	assert.NoError(t, err)
+
+	report := service.Usage()
+	assert.Equal(t, models.BackendUsage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, report.Local)
+	assert.Equal(t, models.BackendUsage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, report.Cloud)
+	assert.NotEmpty(t, report.QuotaNote)
+}
+
+func TestWithTokenRecorder(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"prompt_eval_count":10,"eval_count":5,"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	recorded := 0
+	ctx := WithTokenRecorder(context.Background(), func(tokens int) { recorded += tokens })
+	_, err := service.Chat(ctx, models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hello"}}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 15, recorded)
+}
+
+func TestWithTokenRecorder_Stream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"H"},"done":false}` + "\n"))
+		w.Write([]byte(`{"message":{"role":"assistant","content":"i"},"prompt_eval_count":10,"eval_count":5,"done":true}` + "\n"))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+
+	recorded := 0
+	ctx := WithTokenRecorder(context.Background(), func(tokens int) { recorded += tokens })
+	responseChan := make(chan string)
+	go service.StreamChat(ctx, models.ChatRequest{Messages: []models.Message{{Role: "user", Content: "Hello"}}}, responseChan)
+
+	var content string
+	for chunk := range responseChan {
+		content += chunk
+	}
+	assert.Equal(t, "Hi", content)
+	assert.Equal(t, 15, recorded)
+	assert.Equal(t, models.BackendUsage{Requests: 1, PromptTokens: 10, CompletionTokens: 5, TotalTokens: 15}, service.Usage().Local)
+}
+
+func TestUsageRecords(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"message":{"role":"assistant","content":"Hi"},"prompt_eval_count":10,"eval_count":5,"done":true}`))
+	}))
+	defer server.Close()
+
+	service := NewLlamaService()
+	service.config.BaseURL = server.URL
+	service.config.CoalesceRequests = false
+
+	for _, call := range []struct{ caller, model string }{
+		{"user:alice", "llama3.2"},
+		{"user:alice", "mistral"},
+		{"ip:1.2.3.4", "llama3.2"},
+	} {
+		_, err := service.Chat(WithCaller(context.Background(), call.caller), models.ChatRequest{
+			Model:    call.model,
+			Messages: []models.Message{{Role: "user", Content: "Hello"}},
+		})
+		assert.NoError(t, err)
+	}
+
+	list, err := service.UsageRecords(context.Background(), models.UsageQuery{})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), list.Totals.Requests)
+	assert.Equal(t, int64(45), list.Totals.TotalTokens)
+	assert.Equal(t, int64(2), list.ByModel["llama3.2"].Requests)
+	assert.Equal(t, int64(30), list.ByCaller["user:alice"].TotalTokens)
+	assert.Equal(t, "ip:1.2.3.4", list.Data[0].Caller, "newest first")
+	assert.Equal(t, "local", list.Data[0].Backend)
+
+	list, err = service.UsageRecords(context.Background(), models.UsageQuery{Caller: "user:alice", Model: "llama3.2"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), list.Totals.Requests)
+	assert.Len(t, list.Data, 1)
+
+	// The limit caps the records listed, not the totals
+	list, err = service.UsageRecords(context.Background(), models.UsageQuery{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), list.Totals.Requests)
+	assert.Len(t, list.Data, 1)
+
+	list, err = service.UsageRecords(context.Background(), models.UsageQuery{Day: "2000-01-01"})
+	assert.NoError(t, err)
+	assert.Zero(t, list.Totals.Requests)
+	assert.Empty(t, list.Data)
+}
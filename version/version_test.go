@@ -0,0 +1,22 @@
+package version
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet_Defaults(t *testing.T) {
+	info := Get()
+
+	assert.Equal(t, "dev", info.Version)
+	assert.Equal(t, "unknown", info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+func TestInfo_String(t *testing.T) {
+	info := Info{Version: "v2.1.0", Commit: "abc1234", BuildDate: "2025-10-16T12:00:00Z", GoVersion: "go1.23.0"}
+
+	assert.Equal(t, "v2.1.0 (commit abc1234, built 2025-10-16T12:00:00Z, go1.23.0)", info.String())
+}